@@ -1,20 +1,36 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+	_ "time/tzdata" // Zona waktu karyawan tetap dapat dimuat pada image tanpa tzdata
 
 	"github.com/dzakaeryan20/dealls-hris/internal/api"
 	"github.com/dzakaeryan20/dealls-hris/internal/config"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/attendance"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/auth"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/bpjs"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/holiday"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/leave"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/overtime"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/paycomponent"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/payroll"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/reimbursement"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/shift"
 	"github.com/dzakaeryan20/dealls-hris/internal/platform/database"
 	"github.com/dzakaeryan20/dealls-hris/internal/platform/seeder"
+	"github.com/dzakaeryan20/dealls-hris/internal/platform/storage"
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -31,18 +47,82 @@ func main() {
 	}
 	log.Println("Database connection successful.")
 
+	// Lembur yang diajukan sebelum ada alur persetujuan sudah dibayar tanpa
+	// syarat, sehingga dianggap approved.
+	legacyOvertime := db.Migrator().HasTable(&overtime.Overtime{}) && !db.Migrator().HasColumn(&overtime.Overtime{}, "status")
+	// Lembur yang sudah masuk payslip aktif dihubungkan ke payslip tersebut
+	// agar tidak dibayar lagi.
+	unlinkedOvertime := db.Migrator().HasTable(&overtime.Overtime{}) && !db.Migrator().HasColumn(&overtime.Overtime{}, "payslip_id")
+	// Begitu pula reimbursement lama; yang sudah masuk payslip aktif dianggap
+	// paid oleh payslip tersebut.
+	legacyReimbursement := db.Migrator().HasTable(&reimbursement.Reimbursement{}) && !db.Migrator().HasColumn(&reimbursement.Reimbursement{}, "status")
+
 	// Auto-migrate the schema
 	err = db.AutoMigrate(
 		&employee.Employee{},
 		&payroll.PayrollPeriod{},
 		&attendance.Attendance{},
+		&attendance.Event{},
+		&attendance.Correction{},
 		&overtime.Overtime{},
+		&overtime.Revision{},
 		&reimbursement.Reimbursement{},
+		&reimbursement.StatusHistory{},
+		&reimbursement.Attachment{},
+		&reimbursement.Category{},
+		&reimbursement.CategoryLimit{},
+		&reimbursement.Revision{},
 		&payroll.Payslip{},
+		&payroll.PayslipLine{},
+		&paycomponent.Component{},
+		&paycomponent.Assignment{},
+		&payroll.PayrollJob{},
+		&payroll.PayrollJobItem{},
+		&holiday.Holiday{},
+		&shift.Shift{},
+		&shift.RosterEntry{},
+		&leave.Type{},
+		&leave.Entitlement{},
+		&leave.Request{},
 	)
 	if err != nil {
 		log.Fatalf("could not migrate database: %v", err)
 	}
+	// Payslip kini berversi; unique index lama (user_id, payroll_period_id)
+	// digantikan idx_payslips_active_user_period yang mengabaikan payslip void.
+	if db.Migrator().HasIndex(&payroll.Payslip{}, "idx_payslips_user_period") {
+		if err := db.Migrator().DropIndex(&payroll.Payslip{}, "idx_payslips_user_period"); err != nil {
+			log.Fatalf("could not migrate database: %v", err)
+		}
+	}
+	if legacyOvertime {
+		if err := db.Model(&overtime.Overtime{}).Where("1 = 1").Update("status", approval.StatusApproved).Error; err != nil {
+			log.Fatalf("could not migrate database: %v", err)
+		}
+	}
+	if unlinkedOvertime {
+		err := db.Exec(`UPDATE overtimes o SET payslip_id = p.id
+			FROM payslips p JOIN payroll_periods pp ON pp.id = p.payroll_period_id
+			WHERE p.user_id = o.user_id AND p.voided_at IS NULL AND o.status = ? AND o.date BETWEEN pp.start_date AND pp.end_date`, approval.StatusApproved).Error
+		if err == nil {
+			err = db.Model(&overtime.Overtime{}).Where("payslip_id IS NULL").Update("payslip_id", "").Error
+		}
+		if err != nil {
+			log.Fatalf("could not migrate database: %v", err)
+		}
+	}
+	if legacyReimbursement {
+		if err := migrateLegacyReimbursements(db); err != nil {
+			log.Fatalf("could not migrate database: %v", err)
+		}
+	}
+	// Status periode lama dipetakan ke siklus hidup baru.
+	if err := db.Model(&payroll.PayrollPeriod{}).Where("status IN ?", []string{"pending", "processing"}).Update("status", payroll.PeriodOpen).Error; err != nil {
+		log.Fatalf("could not migrate database: %v", err)
+	}
+	if err := db.Model(&payroll.PayrollPeriod{}).Where("status = ?", "completed").Update("status", payroll.PeriodCalculated).Error; err != nil {
+		log.Fatalf("could not migrate database: %v", err)
+	}
 
 	// 3. Run Seeder (optional)
 	if cfg.RunSeeder {
@@ -59,14 +139,85 @@ func main() {
 	overtimeRepo := overtime.NewRepository(db)
 	reimbursementRepo := reimbursement.NewRepository(db)
 	payrollRepo := payroll.NewRepository(db)
+	payComponentRepo := paycomponent.NewRepository(db)
+	holidayRepo := holiday.NewRepository(db)
+	shiftRepo := shift.NewRepository(db)
+	leaveRepo := leave.NewRepository(db)
 
 	// 5. Initialize Services
+	roundingMode, err := money.ParseRoundingMode(cfg.PayrollRoundingMode)
+	if err != nil {
+		log.Fatalf("invalid payroll rounding config: %v", err)
+	}
+	payrollRounding := money.Rounding{Mode: roundingMode, Scale: cfg.PayrollRoundingScale}
+
+	bpjsConfig, err := newBPJSConfig(cfg)
+	if err != nil {
+		log.Fatalf("invalid BPJS config: %v", err)
+	}
+	proration, groupProration, err := newProration(cfg)
+	if err != nil {
+		log.Fatalf("invalid payroll proration config: %v", err)
+	}
+	overtimeMethod, err := payroll.ParseOvertimeMethod(cfg.PayrollOvertimeMethod)
+	if err != nil {
+		log.Fatalf("invalid payroll overtime config: %v", err)
+	}
+	receiptStorage, err := newReceiptStorage(cfg)
+	if err != nil {
+		log.Fatalf("invalid receipt storage config: %v", err)
+	}
+
 	authService := auth.NewService(employeeRepo, cfg.JWTSecret)
-	employeeService := employee.NewService(employeeRepo)
-	attendanceService := attendance.NewService(attendanceRepo)
-	overtimeService := overtime.NewService(overtimeRepo)
-	reimbursementService := reimbursement.NewService(reimbursementRepo)
-	payrollService := payroll.NewService(payrollRepo, employeeRepo)
+	defaultZone, err := employee.LoadTimeZone(cfg.DefaultTimeZone)
+	if err != nil {
+		log.Fatalf("invalid DEFAULT_TIME_ZONE %q: %v", cfg.DefaultTimeZone, err)
+	}
+	employeeService := employee.NewService(employeeRepo, employee.WithDefaultTimeZone(defaultZone))
+	holidayService := holiday.NewService(holidayRepo, employeeRepo)
+	shiftService := shift.NewService(shiftRepo, employeeRepo, shift.WithPeriodLock(payrollRepo))
+	// Pengajuan bertanggal di dalam periode payroll yang sudah ditutup ditolak.
+	attendanceService := attendance.NewService(attendanceRepo, employeeRepo,
+		attendance.WithPeriodLock(payrollRepo),
+		attendance.WithHolidayCalendar(holidayService),
+		attendance.WithTimeZones(employeeService),
+		attendance.WithSchedules(shiftService),
+	)
+	overtimeService := overtime.NewService(overtimeRepo, employeeRepo,
+		overtime.WithPeriodLock(payrollRepo),
+		overtime.WithTimeZones(employeeService),
+		overtime.WithSchedules(shiftService),
+	)
+	leaveService := leave.NewService(leaveRepo, employeeRepo,
+		leave.WithPeriodLock(payrollRepo),
+		leave.WithHolidayCalendar(holidayService),
+		leave.WithSchedules(shiftService),
+	)
+	reimbursementService := reimbursement.NewService(reimbursementRepo, employeeRepo,
+		reimbursement.WithPeriodLock(payrollRepo),
+		reimbursement.WithReceiptStorage(receiptStorage),
+	)
+	payComponentService := paycomponent.NewService(payComponentRepo)
+	payrollService := payroll.NewService(payrollRepo, employeeRepo,
+		payroll.WithRounding(payrollRounding),
+		payroll.WithBPJSService(bpjs.NewService(bpjsConfig)),
+		payroll.WithPayComponents(payComponentRepo),
+		payroll.WithConcurrency(cfg.PayrollWorkers),
+		payroll.WithHolidayCalendar(holidayService),
+		payroll.WithProration(proration, groupProration),
+		payroll.WithOvertimeMethod(overtimeMethod),
+		payroll.WithRoster(shiftRepo),
+		payroll.WithLeaves(leaveRepo),
+	)
+
+	// Worker payroll berhenti bersama server saat menerima SIGINT/SIGTERM.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	workerConfig := payroll.DefaultWorkerConfig()
+	workerConfig.PollInterval = cfg.PayrollJobPollInterval
+	workerConfig.LeaseDuration = cfg.PayrollJobLease
+	go payroll.NewWorker(payrollRepo, payrollService, workerConfig).Run(ctx)
 
 	// 6. Initialize Router
 	router := api.NewRouter(authService,
@@ -74,12 +225,144 @@ func main() {
 		attendanceService,
 		overtimeService,
 		reimbursementService,
-		payrollService, cfg.JWTSecret)
+		payrollService,
+		payComponentService,
+		holidayService,
+		shiftService,
+		leaveService,
+		cfg.JWTSecret)
 
 	// 7. Start Server
-	serverAddr := fmt.Sprintf(":%s", cfg.AppPort)
-	log.Printf("Server starting on %s", serverAddr)
-	if err := http.ListenAndServe(serverAddr, router); err != nil {
+	server := &http.Server{Addr: fmt.Sprintf(":%s", cfg.AppPort), Handler: router}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Server starting on %s", server.Addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatalf("could not start server: %s\n", err)
 	}
 }
+
+// migrateLegacyReimbursements memberi status pada reimbursement yang dibuat
+// sebelum ada alur persetujuan: paid jika sudah masuk payslip aktif, selain
+// itu approved. Setiap reimbursement mendapat satu baris riwayat status
+// tanpa ChangedBy agar asal statusnya tetap terlacak.
+func migrateLegacyReimbursements(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`UPDATE reimbursements r SET status = ?, payslip_id = p.id, paid_at = p.created_at
+			FROM payslips p JOIN payroll_periods pp ON pp.id = p.payroll_period_id
+			WHERE p.user_id = r.user_id AND p.voided_at IS NULL AND r.date BETWEEN pp.start_date AND pp.end_date`, reimbursement.StatusPaid).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&reimbursement.Reimbursement{}).Where("status = ?", reimbursement.StatusSubmitted).Update("status", reimbursement.StatusApproved).Error; err != nil {
+			return err
+		}
+
+		var items []reimbursement.Reimbursement
+		if err := tx.Select("id", "status", "payslip_id", "paid_at").Find(&items).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		now := time.Now()
+		histories := make([]reimbursement.StatusHistory, len(items))
+		for i, item := range items {
+			histories[i] = reimbursement.StatusHistory{
+				ReimbursementID: item.ID,
+				ToStatus:        item.Status,
+				Reason:          "migrated from data recorded before the approval workflow",
+				PayslipID:       item.PayslipID,
+				ChangedAt:       now,
+			}
+			if item.PaidAt != nil {
+				histories[i].ChangedAt = *item.PaidAt
+			}
+		}
+		return tx.CreateInBatches(histories, 500).Error
+	})
+}
+
+// newBPJSConfig menerapkan parameter BPJS dari konfigurasi ke tarif default.
+func newBPJSConfig(cfg *config.Config) (bpjs.Config, error) {
+	bpjsConfig := bpjs.DefaultConfig()
+
+	jknCap, err := money.Parse(cfg.BPJSJKNWageCap, money.IDR)
+	if err != nil {
+		return bpjs.Config{}, err
+	}
+	jpCap, err := money.Parse(cfg.BPJSJPWageCap, money.IDR)
+	if err != nil {
+		return bpjs.Config{}, err
+	}
+
+	jkn := bpjsConfig.Rates[bpjs.ProgramJKN]
+	jkn.WageCap = jknCap
+	bpjsConfig.Rates[bpjs.ProgramJKN] = jkn
+
+	jp := bpjsConfig.Rates[bpjs.ProgramJP]
+	jp.WageCap = jpCap
+	bpjsConfig.Rates[bpjs.ProgramJP] = jp
+
+	jkk := bpjsConfig.Rates[bpjs.ProgramJKK]
+	jkk.EmployerBps = int64(cfg.BPJSJKKRateBps)
+	bpjsConfig.Rates[bpjs.ProgramJKK] = jkk
+
+	return bpjsConfig, nil
+}
+
+// newProration membaca kebijakan proration perusahaan dan daftar kebijakan
+// per kelompok penggajian dengan format "kelompok=kebijakan,...".
+func newProration(cfg *config.Config) (payroll.ProrationPolicy, map[string]payroll.ProrationPolicy, error) {
+	company, err := payroll.ParseProrationPolicy(cfg.PayrollProration)
+	if err != nil {
+		return payroll.ProrationPolicy{}, nil, err
+	}
+
+	groups := make(map[string]payroll.ProrationPolicy)
+	for _, entry := range strings.Split(cfg.PayrollProrationGroups, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		group, policy, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(group) == "" {
+			return payroll.ProrationPolicy{}, nil, fmt.Errorf("invalid pay group entry %q", entry)
+		}
+		p, err := payroll.ParseProrationPolicy(policy)
+		if err != nil {
+			return payroll.ProrationPolicy{}, nil, fmt.Errorf("pay group %q: %w", strings.TrimSpace(group), err)
+		}
+		groups[strings.TrimSpace(group)] = p
+	}
+	return company, groups, nil
+}
+
+// newReceiptStorage memilih penyimpanan berkas bukti reimbursement.
+func newReceiptStorage(cfg *config.Config) (storage.Storage, error) {
+	switch cfg.ReceiptStorage {
+	case "local":
+		local, err := storage.NewLocal(cfg.ReceiptStorageDir)
+		if err != nil {
+			return nil, err
+		}
+		return local, nil
+	case "s3":
+		s3, err := storage.NewS3(storage.S3Config{
+			Endpoint:        cfg.ReceiptS3Endpoint,
+			Region:          cfg.ReceiptS3Region,
+			Bucket:          cfg.ReceiptS3Bucket,
+			AccessKeyID:     cfg.ReceiptS3AccessKeyID,
+			SecretAccessKey: cfg.ReceiptS3SecretAccessKey,
+		}, &http.Client{Timeout: time.Minute})
+		if err != nil {
+			return nil, err
+		}
+		return s3, nil
+	}
+	return nil, fmt.Errorf("unknown receipt storage %q", cfg.ReceiptStorage)
+}
@@ -2,10 +2,16 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/dzakaeryan20/dealls-hris/internal/api/middleware"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/attendance"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/periodlock"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
 )
 
 type AttendanceHandler struct {
@@ -20,7 +26,12 @@ func (h *AttendanceHandler) SubmitAttendance(w http.ResponseWriter, r *http.Requ
 	userID := r.Context().Value(middleware.UserIDKey).(string)
 
 	// Teruskan context dari request
-	if err := h.service.SubmitAttendance(r.Context(), userID); err != nil {
+	err := h.service.SubmitAttendance(r.Context(), userID)
+	if errors.Is(err, periodlock.ErrLocked) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -28,3 +39,202 @@ func (h *AttendanceHandler) SubmitAttendance(w http.ResponseWriter, r *http.Requ
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Attendance submitted successfully for today"})
 }
+
+// ClockIn adalah handler untuk endpoint POST /api/v1/attendance/clock-in.
+func (h *AttendanceHandler) ClockIn(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	item, err := h.service.ClockIn(r.Context(), userID)
+	if err != nil {
+		writeClockError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// ClockOut adalah handler untuk endpoint POST /api/v1/attendance/clock-out.
+func (h *AttendanceHandler) ClockOut(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	item, err := h.service.ClockOut(r.Context(), userID)
+	if err != nil {
+		writeClockError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+func writeClockError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, periodlock.ErrLocked), errors.Is(err, attendance.ErrAlreadyClockedIn), errors.Is(err, attendance.ErrNotClockedIn):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+type correctionRequest struct {
+	Date   string `json:"date"` // "YYYY-MM-DD"
+	Reason string `json:"reason"`
+}
+
+// RequestCorrection adalah handler untuk endpoint POST /api/v1/attendance/corrections.
+func (h *AttendanceHandler) RequestCorrection(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req correctionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.service.RequestCorrection(r.Context(), userID, date, req.Reason)
+	switch {
+	case errors.Is(err, periodlock.ErrLocked), errors.Is(err, attendance.ErrAlreadyRecorded), errors.Is(err, attendance.ErrCorrectionPending):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(item)
+}
+
+// ListMyCorrections adalah handler untuk endpoint GET /api/v1/attendance/corrections.
+// Query opsional: status, start_date, end_date, page, page_size.
+func (h *AttendanceHandler) ListMyCorrections(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	query := r.URL.Query()
+
+	filter, ok := correctionFilterFrom(w, r)
+	if !ok {
+		return
+	}
+	page, pageSize, err := parsePage(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Limit, filter.Offset = pageSize, (page-1)*pageSize
+
+	items, total, err := h.service.ListMyCorrections(r.Context(), userID, filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writePage(w, items, page, pageSize, total)
+}
+
+// CancelCorrection adalah handler untuk endpoint POST /api/v1/attendance/corrections/{correction_id}/cancel.
+// Karyawan hanya dapat membatalkan koreksi miliknya yang masih pending.
+func (h *AttendanceHandler) CancelCorrection(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	item, err := h.service.CancelCorrection(r.Context(), chi.URLParam(r, "correction_id"), userID)
+	if err != nil {
+		writeCorrectionError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// ListCorrections adalah handler untuk endpoint GET /api/v1/admin/attendance-corrections.
+// Query opsional ?status=, ?user_id=, ?start_date= dan ?end_date= (YYYY-MM-DD).
+// Manager hanya melihat koreksi bawahan langsungnya.
+func (h *AttendanceHandler) ListCorrections(w http.ResponseWriter, r *http.Request) {
+	filter, ok := correctionFilterFrom(w, r)
+	if !ok {
+		return
+	}
+	filter.UserID = r.URL.Query().Get("user_id")
+
+	items, err := h.service.ListCorrections(r.Context(), reviewerFrom(r), filter)
+	if err != nil {
+		writeCorrectionError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// ApproveCorrection adalah handler untuk endpoint POST /api/v1/admin/attendance-corrections/{correction_id}/approve.
+func (h *AttendanceHandler) ApproveCorrection(w http.ResponseWriter, r *http.Request) {
+	item, err := h.service.ApproveCorrection(r.Context(), chi.URLParam(r, "correction_id"), reviewerFrom(r))
+	if err != nil {
+		writeCorrectionError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// RejectCorrection adalah handler untuk endpoint POST /api/v1/admin/attendance-corrections/{correction_id}/reject.
+func (h *AttendanceHandler) RejectCorrection(w http.ResponseWriter, r *http.Request) {
+	var req rejectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.service.RejectCorrection(r.Context(), chi.URLParam(r, "correction_id"), req.Reason, reviewerFrom(r))
+	if err != nil {
+		writeCorrectionError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// correctionFilterFrom membaca query status, start_date, dan end_date. Nilai
+// false berarti response 400 sudah ditulis.
+func correctionFilterFrom(w http.ResponseWriter, r *http.Request) (attendance.CorrectionFilter, bool) {
+	query := r.URL.Query()
+
+	var filter attendance.CorrectionFilter
+	if v := query.Get("status"); v != "" {
+		status, err := approval.ParseStatus(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return filter, false
+		}
+		filter.Status = status
+	}
+	if err := parseDateRange(query, &filter.StartDate, &filter.EndDate); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return filter, false
+	}
+	return filter, true
+}
+
+func writeCorrectionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Attendance correction not found", http.StatusNotFound)
+	case errors.Is(err, approval.ErrNotReviewer):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, approval.ErrReasonRequired):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, approval.ErrInvalidStatusTransition), errors.Is(err, approval.ErrModified),
+		errors.Is(err, periodlock.ErrLocked), errors.Is(err, periodlock.ErrCalculated), errors.Is(err, attendance.ErrAlreadyRecorded):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/api/middleware"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// EmployeeHandler menangani pengelolaan data karyawan oleh admin.
+type EmployeeHandler struct {
+	service employee.Service
+}
+
+// NewEmployeeHandler membuat instance baru dari EmployeeHandler.
+func NewEmployeeHandler(s employee.Service) *EmployeeHandler {
+	return &EmployeeHandler{service: s}
+}
+
+type updateLocationRequest struct {
+	Location string `json:"location"`
+}
+
+// UpdateLocation adalah handler untuk endpoint PUT /api/v1/admin/employees/{user_id}/location.
+// Lokasi menentukan hari libur lokal yang berlaku bagi karyawan.
+func (h *EmployeeHandler) UpdateLocation(w http.ResponseWriter, r *http.Request) {
+	var req updateLocationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	err := h.service.UpdateLocation(r.Context(), chi.URLParam(r, "user_id"), req.Location, adminID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Employee not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Employee location updated successfully"})
+}
+
+type updateGradeRequest struct {
+	Grade string `json:"grade"`
+}
+
+// UpdateGrade adalah handler untuk endpoint PUT /api/v1/admin/employees/{user_id}/grade.
+// Golongan menentukan batas reimbursement karyawan per kategori.
+func (h *EmployeeHandler) UpdateGrade(w http.ResponseWriter, r *http.Request) {
+	var req updateGradeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	err := h.service.UpdateGrade(r.Context(), chi.URLParam(r, "user_id"), req.Grade, adminID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Employee not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Employee grade updated successfully"})
+}
+
+type updatePayGroupRequest struct {
+	PayGroup string `json:"pay_group"`
+}
+
+// UpdatePayGroup adalah handler untuk endpoint PUT /api/v1/admin/employees/{user_id}/pay-group.
+// Kelompok penggajian menentukan kebijakan proration gaji karyawan.
+func (h *EmployeeHandler) UpdatePayGroup(w http.ResponseWriter, r *http.Request) {
+	var req updatePayGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	err := h.service.UpdatePayGroup(r.Context(), chi.URLParam(r, "user_id"), req.PayGroup, adminID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Employee not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Employee pay group updated successfully"})
+}
+
+type updateManagerRequest struct {
+	ManagerID string `json:"manager_id"`
+}
+
+// UpdateManager adalah handler untuk endpoint PUT /api/v1/admin/employees/{user_id}/manager.
+// Manager menyetujui pengajuan lembur bawahan langsungnya.
+func (h *EmployeeHandler) UpdateManager(w http.ResponseWriter, r *http.Request) {
+	var req updateManagerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	err := h.service.UpdateManager(r.Context(), chi.URLParam(r, "user_id"), req.ManagerID, adminID)
+	if errors.Is(err, employee.ErrInvalidManager) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Employee not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Employee manager updated successfully"})
+}
+
+type updateTaxStatusRequest struct {
+	TaxStatus string `json:"tax_status"`
+}
+
+// UpdateTaxStatus adalah handler untuk endpoint PUT /api/v1/admin/employees/{user_id}/tax-status.
+// Status PTKP menentukan PTKP dan kategori TER pemotongan PPh 21 karyawan.
+func (h *EmployeeHandler) UpdateTaxStatus(w http.ResponseWriter, r *http.Request) {
+	var req updateTaxStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	err := h.service.UpdateTaxStatus(r.Context(), chi.URLParam(r, "user_id"), req.TaxStatus, adminID)
+	if errors.Is(err, employee.ErrInvalidTaxStatus) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Employee not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Employee tax status updated successfully"})
+}
+
+type updateTimeZoneRequest struct {
+	TimeZone string `json:"time_zone"`
+}
+
+// UpdateTimeZone adalah handler untuk endpoint PUT /api/v1/admin/employees/{user_id}/timezone.
+// Zona waktu menentukan tanggal absensi dan batas pukul 17.00 untuk lembur.
+func (h *EmployeeHandler) UpdateTimeZone(w http.ResponseWriter, r *http.Request) {
+	var req updateTimeZoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	err := h.service.UpdateTimeZone(r.Context(), chi.URLParam(r, "user_id"), req.TimeZone, adminID)
+	if errors.Is(err, employee.ErrInvalidTimeZone) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Employee not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Employee time zone updated successfully"})
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/api/middleware"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/holiday"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// HolidayHandler menangani pengelolaan kalender hari libur oleh admin.
+type HolidayHandler struct {
+	service holiday.Service
+}
+
+// NewHolidayHandler membuat instance baru dari HolidayHandler.
+func NewHolidayHandler(s holiday.Service) *HolidayHandler {
+	return &HolidayHandler{service: s}
+}
+
+type holidayRequest struct {
+	Date     string       `json:"date"` // "YYYY-MM-DD"
+	Name     string       `json:"name"`
+	Type     holiday.Type `json:"type"`
+	Location string       `json:"location"` // Opsional; kosong = semua lokasi
+}
+
+type importHolidaysRequest struct {
+	Year     int              `json:"year"`
+	Location string           `json:"location"` // Opsional; kosong = semua lokasi
+	Holidays []holidayRequest `json:"holidays"`
+}
+
+// CreateHoliday adalah handler untuk endpoint POST /api/v1/admin/holidays.
+func (h *HolidayHandler) CreateHoliday(w http.ResponseWriter, r *http.Request) {
+	var req holidayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	item := &holiday.Holiday{Date: date, Name: req.Name, Type: req.Type, Location: req.Location}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	if err := h.service.CreateHoliday(r.Context(), item, adminID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(item)
+}
+
+// ListHolidays adalah handler untuk endpoint GET /api/v1/admin/holidays.
+// Query ?year= wajib; ?location= opsional untuk memfilter libur lokal.
+func (h *HolidayHandler) ListHolidays(w http.ResponseWriter, r *http.Request) {
+	year, err := strconv.Atoi(r.URL.Query().Get("year"))
+	if err != nil {
+		http.Error(w, "Invalid year", http.StatusBadRequest)
+		return
+	}
+
+	holidays, err := h.service.ListHolidays(r.Context(), year, r.URL.Query().Get("location"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(holidays)
+}
+
+// UpdateHoliday adalah handler untuk endpoint PUT /api/v1/admin/holidays/{holiday_id}.
+func (h *HolidayHandler) UpdateHoliday(w http.ResponseWriter, r *http.Request) {
+	var req holiday.HolidayUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	item, err := h.service.UpdateHoliday(r.Context(), chi.URLParam(r, "holiday_id"), req, adminID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Holiday not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// DeleteHoliday adalah handler untuk endpoint DELETE /api/v1/admin/holidays/{holiday_id}.
+func (h *HolidayHandler) DeleteHoliday(w http.ResponseWriter, r *http.Request) {
+	err := h.service.DeleteHoliday(r.Context(), chi.URLParam(r, "holiday_id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Holiday not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ImportHolidays adalah handler untuk endpoint POST /api/v1/admin/holidays/import.
+// Mengganti seluruh hari libur satu tahun untuk lokasi yang diberikan.
+func (h *HolidayHandler) ImportHolidays(w http.ResponseWriter, r *http.Request) {
+	var req importHolidaysRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	holidays := make([]holiday.Holiday, 0, len(req.Holidays))
+	for _, item := range req.Holidays {
+		date, err := time.Parse("2006-01-02", item.Date)
+		if err != nil {
+			http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		holidays = append(holidays, holiday.Holiday{Date: date, Name: item.Name, Type: item.Type})
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	imported, err := h.service.ImportHolidays(r.Context(), req.Year, req.Location, holidays, adminID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(imported)
+}
@@ -0,0 +1,299 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/api/middleware"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/leave"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/periodlock"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// LeaveHandler menangani jenis cuti, jatah tahunan, dan pengajuan cuti.
+type LeaveHandler struct {
+	service leave.Service
+}
+
+// NewLeaveHandler membuat instance baru dari LeaveHandler.
+func NewLeaveHandler(s leave.Service) *LeaveHandler {
+	return &LeaveHandler{service: s}
+}
+
+type leaveRequest struct {
+	TypeID    string `json:"type_id"`
+	StartDate string `json:"start_date"` // "YYYY-MM-DD"
+	EndDate   string `json:"end_date"`   // "YYYY-MM-DD"
+	Reason    string `json:"reason"`
+}
+
+type grantEntitlementsRequest struct {
+	Year int `json:"year"`
+}
+
+// SubmitLeave adalah handler untuk endpoint POST /api/v1/leave.
+func (h *LeaveHandler) SubmitLeave(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req leaveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	end, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.service.SubmitRequest(r.Context(), userID, req.TypeID, start, end, req.Reason)
+	switch {
+	case errors.Is(err, periodlock.ErrLocked), errors.Is(err, leave.ErrOverlap):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, leave.ErrInsufficientBalance):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(item)
+}
+
+// ListMyLeave adalah handler untuk endpoint GET /api/v1/leave.
+// Query opsional: status, start_date, end_date, page, page_size.
+func (h *LeaveHandler) ListMyLeave(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	query := r.URL.Query()
+
+	var filter leave.ListFilter
+	if v := query.Get("status"); v != "" {
+		status, err := approval.ParseStatus(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.Status = status
+	}
+	if err := parseDateRange(query, &filter.StartDate, &filter.EndDate); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, pageSize, err := parsePage(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Limit, filter.Offset = pageSize, (page-1)*pageSize
+
+	items, total, err := h.service.ListMyRequests(r.Context(), userID, filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writePage(w, items, page, pageSize, total)
+}
+
+// GetBalances adalah handler untuk endpoint GET /api/v1/leave/balances.
+// Query opsional ?date= (YYYY-MM-DD, default hari ini) menentukan tahun dan
+// batas akrual saldo.
+func (h *LeaveHandler) GetBalances(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	date := time.Now()
+	if v := r.URL.Query().Get("date"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		date = parsed
+	}
+
+	balances, err := h.service.GetBalances(r.Context(), userID, date)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(balances)
+}
+
+// CancelLeave adalah handler untuk endpoint POST /api/v1/leave/{leave_id}/cancel.
+// Karyawan hanya dapat membatalkan cuti miliknya yang masih pending.
+func (h *LeaveHandler) CancelLeave(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	item, err := h.service.CancelRequest(r.Context(), chi.URLParam(r, "leave_id"), userID)
+	if err != nil {
+		writeLeaveError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// ListLeave adalah handler untuk endpoint GET /api/v1/admin/leave.
+// Query opsional ?status=, ?user_id=, ?start_date= dan ?end_date= (YYYY-MM-DD).
+// Manager hanya melihat cuti bawahan langsungnya.
+func (h *LeaveHandler) ListLeave(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := leave.ListFilter{UserID: query.Get("user_id")}
+	if v := query.Get("status"); v != "" {
+		status, err := approval.ParseStatus(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.Status = status
+	}
+	if err := parseDateRange(query, &filter.StartDate, &filter.EndDate); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	items, err := h.service.ListRequests(r.Context(), reviewerFrom(r), filter)
+	if err != nil {
+		writeLeaveError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// ApproveLeave adalah handler untuk endpoint POST /api/v1/admin/leave/{leave_id}/approve.
+func (h *LeaveHandler) ApproveLeave(w http.ResponseWriter, r *http.Request) {
+	item, err := h.service.ApproveRequest(r.Context(), chi.URLParam(r, "leave_id"), reviewerFrom(r))
+	if err != nil {
+		writeLeaveError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// RejectLeave adalah handler untuk endpoint POST /api/v1/admin/leave/{leave_id}/reject.
+func (h *LeaveHandler) RejectLeave(w http.ResponseWriter, r *http.Request) {
+	var req rejectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.service.RejectRequest(r.Context(), chi.URLParam(r, "leave_id"), req.Reason, reviewerFrom(r))
+	if err != nil {
+		writeLeaveError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// CreateType adalah handler untuk endpoint POST /api/v1/admin/leave-types.
+func (h *LeaveHandler) CreateType(w http.ResponseWriter, r *http.Request) {
+	var t leave.Type
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	if err := h.service.CreateType(r.Context(), &t, adminID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(t)
+}
+
+// ListTypes adalah handler untuk endpoint GET /api/v1/admin/leave-types dan
+// GET /api/v1/leave/types.
+func (h *LeaveHandler) ListTypes(w http.ResponseWriter, r *http.Request) {
+	types, err := h.service.ListTypes(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types)
+}
+
+// UpdateType adalah handler untuk endpoint PUT /api/v1/admin/leave-types/{type_id}.
+func (h *LeaveHandler) UpdateType(w http.ResponseWriter, r *http.Request) {
+	var req leave.TypeUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	t, err := h.service.UpdateType(r.Context(), chi.URLParam(r, "type_id"), req, adminID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Leave type not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t)
+}
+
+// GrantEntitlements adalah handler untuk endpoint POST /api/v1/admin/leave-entitlements.
+func (h *LeaveHandler) GrantEntitlements(w http.ResponseWriter, r *http.Request) {
+	var req grantEntitlementsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	entitlements, err := h.service.GrantEntitlements(r.Context(), req.Year, adminID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entitlements)
+}
+
+func writeLeaveError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Leave not found", http.StatusNotFound)
+	case errors.Is(err, approval.ErrNotReviewer):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, approval.ErrReasonRequired):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, approval.ErrInvalidStatusTransition), errors.Is(err, approval.ErrModified),
+		errors.Is(err, periodlock.ErrLocked), errors.Is(err, periodlock.ErrCalculated):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
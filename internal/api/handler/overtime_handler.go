@@ -2,11 +2,18 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/dzakaeryan20/dealls-hris/internal/api/middleware"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/overtime"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/periodlock"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
 )
 
 type OvertimeHandler struct {
@@ -37,7 +44,12 @@ func (h *OvertimeHandler) SubmitOvertime(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	// Teruskan context dari request
-	if err := h.service.SubmitOvertime(r.Context(), userID, date, req.Hours); err != nil {
+	err = h.service.SubmitOvertime(r.Context(), userID, date, req.Hours)
+	if errors.Is(err, periodlock.ErrLocked) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -45,3 +57,186 @@ func (h *OvertimeHandler) SubmitOvertime(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Overtime submitted successfully"})
 }
+
+// CancelOvertime adalah handler untuk endpoint POST /api/v1/overtime/{overtime_id}/cancel.
+// Karyawan hanya dapat membatalkan lembur miliknya yang masih pending.
+func (h *OvertimeHandler) CancelOvertime(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	item, err := h.service.CancelOvertime(r.Context(), chi.URLParam(r, "overtime_id"), userID)
+	if err != nil {
+		writeOvertimeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// UpdateOvertime adalah handler untuk endpoint PUT /api/v1/overtime/{overtime_id}.
+// Karyawan hanya dapat mengubah lembur miliknya yang masih pending; nilai
+// sebelumnya disimpan sebagai revisi.
+func (h *OvertimeHandler) UpdateOvertime(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req overtimeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.service.UpdateOvertime(r.Context(), chi.URLParam(r, "overtime_id"), userID, date, req.Hours)
+	switch {
+	case err == nil:
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, approval.ErrInvalidStatusTransition),
+		errors.Is(err, approval.ErrModified), errors.Is(err, periodlock.ErrLocked):
+		writeOvertimeError(w, err)
+		return
+	default:
+		// Sisanya adalah kesalahan validasi seperti pada pengajuan baru.
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// GetMyRevisions adalah handler untuk endpoint GET /api/v1/overtime/{overtime_id}/revisions.
+func (h *OvertimeHandler) GetMyRevisions(w http.ResponseWriter, r *http.Request) {
+	revisions, err := h.service.GetRevisions(r.Context(), chi.URLParam(r, "overtime_id"), reviewerFrom(r))
+	// Lembur milik karyawan lain diperlakukan sebagai tidak ditemukan.
+	if errors.Is(err, approval.ErrNotReviewer) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		writeOvertimeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
+
+// GetRevisions adalah handler untuk endpoint GET /api/v1/admin/overtime/{overtime_id}/revisions.
+// Manager hanya dapat melihat revisi lembur bawahan langsungnya.
+func (h *OvertimeHandler) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	revisions, err := h.service.GetRevisions(r.Context(), chi.URLParam(r, "overtime_id"), reviewerFrom(r))
+	if err != nil {
+		writeOvertimeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
+
+// ListOvertimes adalah handler untuk endpoint GET /api/v1/admin/overtime.
+// Query opsional ?status=, ?user_id=, ?start_date= dan ?end_date= (YYYY-MM-DD).
+// Manager hanya melihat lembur bawahan langsungnya.
+func (h *OvertimeHandler) ListOvertimes(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := overtime.ListFilter{UserID: query.Get("user_id")}
+	if v := query.Get("status"); v != "" {
+		status, err := approval.ParseStatus(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.Status = status
+	}
+	if err := parseDateRange(query, &filter.StartDate, &filter.EndDate); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	items, err := h.service.ListOvertimes(r.Context(), reviewerFrom(r), filter)
+	if err != nil {
+		writeOvertimeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// ApproveOvertime adalah handler untuk endpoint POST /api/v1/admin/overtime/{overtime_id}/approve.
+func (h *OvertimeHandler) ApproveOvertime(w http.ResponseWriter, r *http.Request) {
+	item, err := h.service.ApproveOvertime(r.Context(), chi.URLParam(r, "overtime_id"), reviewerFrom(r))
+	if err != nil {
+		writeOvertimeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+type rejectRequest struct {
+	Reason string `json:"reason"`
+}
+
+// RejectOvertime adalah handler untuk endpoint POST /api/v1/admin/overtime/{overtime_id}/reject.
+func (h *OvertimeHandler) RejectOvertime(w http.ResponseWriter, r *http.Request) {
+	var req rejectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.service.RejectOvertime(r.Context(), chi.URLParam(r, "overtime_id"), req.Reason, reviewerFrom(r))
+	if err != nil {
+		writeOvertimeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// parseDateRange membaca parameter query start_date dan end_date (opsional,
+// format YYYY-MM-DD) ke start dan end.
+func parseDateRange(query url.Values, start, end **time.Time) error {
+	for _, param := range []struct {
+		name string
+		dest **time.Time
+	}{{"start_date", start}, {"end_date", end}} {
+		if v := query.Get(param.name); v != "" {
+			date, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				return errors.New("Invalid " + param.name + " format. Use YYYY-MM-DD")
+			}
+			*param.dest = &date
+		}
+	}
+	return nil
+}
+
+// reviewerFrom mengambil identitas reviewer dari context request.
+func reviewerFrom(r *http.Request) employee.Reviewer {
+	return employee.Reviewer{
+		ID:   r.Context().Value(middleware.UserIDKey).(string),
+		Role: r.Context().Value(middleware.UserRoleKey).(string),
+	}
+}
+
+func writeOvertimeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Overtime not found", http.StatusNotFound)
+	case errors.Is(err, approval.ErrNotReviewer):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, approval.ErrReasonRequired):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, approval.ErrInvalidStatusTransition), errors.Is(err, approval.ErrModified), errors.Is(err, periodlock.ErrLocked):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/api/middleware"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/paycomponent"
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
+	"github.com/go-chi/chi/v5"
+)
+
+// PayComponentHandler menangani pengelolaan komponen gaji oleh admin.
+type PayComponentHandler struct {
+	service paycomponent.Service
+}
+
+// NewPayComponentHandler membuat instance baru dari PayComponentHandler.
+func NewPayComponentHandler(s paycomponent.Service) *PayComponentHandler {
+	return &PayComponentHandler{service: s}
+}
+
+type createComponentRequest struct {
+	Code            string                       `json:"code"`
+	Name            string                       `json:"name"`
+	Kind            paycomponent.Kind            `json:"kind"`
+	CalculationType paycomponent.CalculationType `json:"calculation_type"`
+	Amount          money.Money                  `json:"amount"`
+	PercentageBps   int64                        `json:"percentage_bps"`
+	Taxable         bool                         `json:"taxable"`
+}
+
+type assignComponentRequest struct {
+	ComponentID   string       `json:"component_id"`
+	Amount        *money.Money `json:"amount"`
+	PercentageBps *int64       `json:"percentage_bps"`
+	EffectiveFrom string       `json:"effective_from"` // "YYYY-MM-DD"
+	EffectiveTo   string       `json:"effective_to"`   // "YYYY-MM-DD", opsional
+}
+
+type endAssignmentRequest struct {
+	EffectiveTo string `json:"effective_to"` // "YYYY-MM-DD"
+}
+
+// CreateComponent adalah handler untuk endpoint POST /api/v1/admin/pay-components.
+func (h *PayComponentHandler) CreateComponent(w http.ResponseWriter, r *http.Request) {
+	var req createComponentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	component := &paycomponent.Component{
+		Code:            req.Code,
+		Name:            req.Name,
+		Kind:            req.Kind,
+		CalculationType: req.CalculationType,
+		Amount:          req.Amount,
+		PercentageBps:   req.PercentageBps,
+		Taxable:         req.Taxable,
+	}
+	if err := h.service.CreateComponent(r.Context(), component, adminID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(component)
+}
+
+// ListComponents adalah handler untuk endpoint GET /api/v1/admin/pay-components.
+func (h *PayComponentHandler) ListComponents(w http.ResponseWriter, r *http.Request) {
+	components, err := h.service.ListComponents(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(components)
+}
+
+// UpdateComponent adalah handler untuk endpoint PUT /api/v1/admin/pay-components/{component_id}.
+func (h *PayComponentHandler) UpdateComponent(w http.ResponseWriter, r *http.Request) {
+	var req paycomponent.ComponentUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	component, err := h.service.UpdateComponent(r.Context(), chi.URLParam(r, "component_id"), req, adminID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(component)
+}
+
+// AssignComponent adalah handler untuk endpoint POST /api/v1/admin/employees/{user_id}/pay-components.
+func (h *PayComponentHandler) AssignComponent(w http.ResponseWriter, r *http.Request) {
+	var req assignComponentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	effectiveFrom, err := time.Parse("2006-01-02", req.EffectiveFrom)
+	if err != nil {
+		http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	assignment := &paycomponent.Assignment{
+		UserID:        chi.URLParam(r, "user_id"),
+		ComponentID:   req.ComponentID,
+		Amount:        req.Amount,
+		PercentageBps: req.PercentageBps,
+		EffectiveFrom: effectiveFrom,
+	}
+	if req.EffectiveTo != "" {
+		effectiveTo, err := time.Parse("2006-01-02", req.EffectiveTo)
+		if err != nil {
+			http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		assignment.EffectiveTo = &effectiveTo
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	if err := h.service.AssignComponent(r.Context(), assignment, adminID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(assignment)
+}
+
+// ListAssignments adalah handler untuk endpoint GET /api/v1/admin/employees/{user_id}/pay-components.
+func (h *PayComponentHandler) ListAssignments(w http.ResponseWriter, r *http.Request) {
+	assignments, err := h.service.ListAssignments(r.Context(), chi.URLParam(r, "user_id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(assignments)
+}
+
+// EndAssignment adalah handler untuk endpoint POST /api/v1/admin/pay-component-assignments/{assignment_id}/end.
+func (h *PayComponentHandler) EndAssignment(w http.ResponseWriter, r *http.Request) {
+	var req endAssignmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	effectiveTo, err := time.Parse("2006-01-02", req.EffectiveTo)
+	if err != nil {
+		http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	assignment, err := h.service.EndAssignment(r.Context(), chi.URLParam(r, "assignment_id"), effectiveTo, adminID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(assignment)
+}
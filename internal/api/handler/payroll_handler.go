@@ -2,8 +2,12 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/dzakaeryan20/dealls-hris/internal/api/middleware"
@@ -23,8 +27,30 @@ func NewPayrollHandler(s payroll.Service) *PayrollHandler {
 
 // createPeriodRequest adalah struct untuk menampung data JSON saat admin membuat periode payroll.
 type createPeriodRequest struct {
-	StartDate string `json:"start_date" validate:"required"` // Format: "YYYY-MM-DD"
-	EndDate   string `json:"end_date" validate:"required"`   // Format: "YYYY-MM-DD"
+	Type      payroll.PeriodType `json:"type"`                           // Opsional; default "monthly"
+	StartDate string             `json:"start_date" validate:"required"` // Format: "YYYY-MM-DD"
+	EndDate   string             `json:"end_date" validate:"required"`   // Format: "YYYY-MM-DD"
+}
+
+// createPeriodResponse adalah periode yang dibuat beserta peringatan celah
+// tanggal dengan periode di sekitarnya.
+type createPeriodResponse struct {
+	*payroll.PayrollPeriod
+	Warnings []payroll.PeriodWarning `json:"warnings,omitempty"`
+}
+
+type generatePeriodsRequest struct {
+	Year           int                `json:"year"`
+	Type           payroll.PeriodType `json:"type"`             // monthly, semi_monthly, atau weekly
+	StartDay       int                `json:"start_day"`        // Opsional, untuk monthly
+	SplitDay       int                `json:"split_day"`        // Opsional, untuk semi_monthly
+	FirstStartDate string             `json:"first_start_date"` // Opsional, untuk weekly; "YYYY-MM-DD"
+}
+
+// generatePeriodsResponse adalah hasil pembentukan periode dari kalender penggajian.
+type generatePeriodsResponse struct {
+	Periods  []*payroll.PayrollPeriod `json:"periods"`
+	Warnings []payroll.PeriodWarning  `json:"warnings,omitempty"`
 }
 
 // CreatePayrollPeriod adalah handler untuk endpoint POST /api/v1/admin/payroll-period.
@@ -47,16 +73,63 @@ func (h *PayrollHandler) CreatePayrollPeriod(w http.ResponseWriter, r *http.Requ
 	// Mengambil ID admin dari context untuk melacak siapa yang membuat periode ini.
 	adminID := r.Context().Value(middleware.UserIDKey).(string)
 
+	if req.Type == "" {
+		req.Type = payroll.PeriodMonthly
+	}
+
 	// Memanggil service untuk membuat periode payroll.
-	period, err := h.service.CreatePayrollPeriod(r.Context(), startDate, endDate, adminID)
+	period, warnings, err := h.service.CreatePayrollPeriod(r.Context(), req.Type, startDate, endDate, adminID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writePeriodError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(period)
+	json.NewEncoder(w).Encode(createPeriodResponse{PayrollPeriod: period, Warnings: warnings})
+}
+
+// GeneratePayrollPeriods adalah handler untuk endpoint POST /api/v1/admin/payroll-period/generate.
+// Membuat seluruh periode satu tahun dari definisi kalender penggajian.
+func (h *PayrollHandler) GeneratePayrollPeriods(w http.ResponseWriter, r *http.Request) {
+	var req generatePeriodsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	calendar := payroll.PayCalendar{Year: req.Year, Type: req.Type, StartDay: req.StartDay, SplitDay: req.SplitDay}
+	if req.FirstStartDate != "" {
+		firstStart, err := time.Parse("2006-01-02", req.FirstStartDate)
+		if err != nil {
+			http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		calendar.FirstStartDate = &firstStart
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	periods, warnings, err := h.service.GeneratePayrollPeriods(r.Context(), calendar, adminID)
+	if err != nil {
+		writePeriodError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(generatePeriodsResponse{Periods: periods, Warnings: warnings})
+}
+
+// writePeriodError memetakan error pembuatan periode ke status HTTP.
+func writePeriodError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, payroll.ErrInvalidPeriod):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, payroll.ErrPeriodOverlap):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
 }
 
 // RunPayroll adalah handler untuk endpoint POST /api/v1/admin/payroll/{period_id}/run.
@@ -72,15 +145,204 @@ func (h *PayrollHandler) RunPayroll(w http.ResponseWriter, r *http.Request) {
 	// Mengambil ID admin dari context untuk melacak siapa yang menjalankan payroll.
 	adminID := r.Context().Value(middleware.UserIDKey).(string)
 
-	// Memanggil service untuk menjalankan proses kalkulasi payroll.
-	err := h.service.RunPayroll(r.Context(), periodID, adminID)
+	// Memasukkan eksekusi payroll ke antrean; kalkulasi dijalankan worker.
+	job, err := h.service.RunPayroll(r.Context(), periodID, adminID)
+	if errors.Is(err, payroll.ErrPayrollAlreadyRun) || errors.Is(err, payroll.ErrPeriodNotOpen) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// GetPayrollPeriod adalah handler untuk endpoint GET /api/v1/admin/payroll-period/{period_id}.
+func (h *PayrollHandler) GetPayrollPeriod(w http.ResponseWriter, r *http.Request) {
+	period, err := h.service.GetPayrollPeriod(r.Context(), chi.URLParam(r, "period_id"))
+	if err != nil {
+		http.Error(w, "Payroll period not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(period)
+}
+
+// OpenPayrollPeriod adalah handler untuk endpoint POST /api/v1/admin/payroll-period/{period_id}/open.
+// Periode draft dibuka agar payroll dapat dijalankan.
+func (h *PayrollHandler) OpenPayrollPeriod(w http.ResponseWriter, r *http.Request) {
+	h.transitionPeriod(w, r, h.service.OpenPayrollPeriod)
+}
+
+// ApprovePayroll adalah handler untuk endpoint POST /api/v1/approver/payroll/{period_id}/approve.
+// Fungsi ini hanya bisa diakses oleh approver; setelah disetujui payslip dapat dilihat karyawan.
+func (h *PayrollHandler) ApprovePayroll(w http.ResponseWriter, r *http.Request) {
+	h.transitionPeriod(w, r, h.service.ApprovePayroll)
+}
+
+// MarkPayrollPaid adalah handler untuk endpoint POST /api/v1/admin/payroll/{period_id}/paid.
+func (h *PayrollHandler) MarkPayrollPaid(w http.ResponseWriter, r *http.Request) {
+	h.transitionPeriod(w, r, h.service.MarkPayrollPaid)
+}
+
+// ClosePayrollPeriod adalah handler untuk endpoint POST /api/v1/admin/payroll-period/{period_id}/close.
+// Setelah ditutup, pengajuan bertanggal di dalam periode akan ditolak.
+func (h *PayrollHandler) ClosePayrollPeriod(w http.ResponseWriter, r *http.Request) {
+	h.transitionPeriod(w, r, h.service.ClosePayrollPeriod)
+}
+
+func (h *PayrollHandler) transitionPeriod(w http.ResponseWriter, r *http.Request, transition func(ctx context.Context, periodID, actorID string) (*payroll.PayrollPeriod, error)) {
+	actorID := r.Context().Value(middleware.UserIDKey).(string)
+	period, err := transition(r.Context(), chi.URLParam(r, "period_id"), actorID)
+	if isPeriodConflict(err) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(period)
+}
+
+// isPeriodConflict melaporkan apakah err berasal dari status periode yang
+// tidak mengizinkan aksi yang diminta.
+func isPeriodConflict(err error) bool {
+	return errors.Is(err, payroll.ErrInvalidPeriodTransition) || errors.Is(err, payroll.ErrPeriodModified)
+}
+
+type previewPayrollRequest struct {
+	UserIDs []string `json:"user_ids"` // Opsional; kosong = semua karyawan
+}
+
+// PreviewPayroll adalah handler untuk endpoint POST /api/v1/admin/payroll/{period_id}/preview.
+// Menjalankan kalkulasi payroll tanpa menyimpan payslip maupun mengunci periode.
+func (h *PayrollHandler) PreviewPayroll(w http.ResponseWriter, r *http.Request) {
+	var req previewPayrollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	preview, err := h.service.PreviewPayroll(r.Context(), chi.URLParam(r, "period_id"), req.UserIDs)
+	if errors.Is(err, payroll.ErrPayrollAlreadyRun) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, payroll.ErrUnknownEmployees) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
+
+type reversePayrollRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ReversePayroll adalah handler untuk endpoint POST /api/v1/admin/payroll/{period_id}/reverse.
+// Payslip periode ditandai void dan periode kembali open untuk dijalankan ulang.
+func (h *PayrollHandler) ReversePayroll(w http.ResponseWriter, r *http.Request) {
+	var req reversePayrollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	err := h.service.ReversePayroll(r.Context(), chi.URLParam(r, "period_id"), req.Reason, adminID)
+	if isPeriodConflict(err) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Payroll reversed successfully"})
+}
+
+// GetPayslipVersions adalah handler untuk endpoint GET /api/v1/admin/payroll/{period_id}/employees/{user_id}/payslips.
+// Mengembalikan semua versi payslip karyawan, termasuk yang sudah void.
+func (h *PayrollHandler) GetPayslipVersions(w http.ResponseWriter, r *http.Request) {
+	payslips, err := h.service.GetPayslipVersions(r.Context(), chi.URLParam(r, "user_id"), chi.URLParam(r, "period_id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payslips)
+}
+
+// DiffPayslips adalah handler untuk endpoint GET /api/v1/admin/payroll/{period_id}/employees/{user_id}/payslip-diff.
+// Query opsional ?from=1&to=2; default membandingkan dua versi terakhir.
+func (h *PayrollHandler) DiffPayslips(w http.ResponseWriter, r *http.Request) {
+	var versions [2]int
+	for i, name := range []string{"from", "to"} {
+		if v := r.URL.Query().Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				http.Error(w, "Invalid version "+name, http.StatusBadRequest)
+				return
+			}
+			versions[i] = n
+		}
+	}
+
+	diff, err := h.service.DiffPayslips(r.Context(), chi.URLParam(r, "user_id"), chi.URLParam(r, "period_id"), versions[0], versions[1])
+	if errors.Is(err, payroll.ErrPayslipNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
+
+// GetPayrollJob adalah handler untuk endpoint GET /api/v1/admin/payroll/jobs/{job_id}.
+// Mengembalikan status dan progres job payroll.
+func (h *PayrollHandler) GetPayrollJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.service.GetPayrollJob(r.Context(), chi.URLParam(r, "job_id"))
+	if err != nil {
+		http.Error(w, "Payroll job not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// GetPayrollJobItems adalah handler untuk endpoint GET /api/v1/admin/payroll/jobs/{job_id}/items.
+// Query opsional ?status=pending|succeeded|failed untuk memfilter item.
+func (h *PayrollHandler) GetPayrollJobItems(w http.ResponseWriter, r *http.Request) {
+	items, err := h.service.GetPayrollJobItems(r.Context(), chi.URLParam(r, "job_id"), r.URL.Query().Get("status"))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Payroll run successfully"})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
 }
 
 // GetMyPayslip adalah handler untuk endpoint GET /api/v1/payslip/{period_id}.
@@ -103,7 +365,7 @@ func (h *PayrollHandler) GetMyPayslip(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetPayrollSummary adalah handler untuk endpoint GET /api/v1/admin/payroll/{period_id}/summary.
-// Fungsi ini hanya bisa diakses oleh admin.
+// Fungsi ini bisa diakses oleh admin dan approver.
 func (h *PayrollHandler) GetPayrollSummary(w http.ResponseWriter, r *http.Request) {
 	// Mengambil ID periode dari parameter URL.
 	periodID := chi.URLParam(r, "period_id")
@@ -2,11 +2,22 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/dzakaeryan20/dealls-hris/internal/api/middleware"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/periodlock"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/reimbursement"
+	"github.com/dzakaeryan20/dealls-hris/internal/platform/storage"
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
 )
 
 type ReimbursementHandler struct {
@@ -18,9 +29,10 @@ func NewReimbursementHandler(s reimbursement.Service) *ReimbursementHandler {
 }
 
 type reimbursementRequest struct {
-	Date        string  `json:"date"` // "YYYY-MM-DD"
-	Description string  `json:"description"`
-	Amount      float64 `json:"amount"`
+	CategoryID  string      `json:"category_id"`
+	Date        string      `json:"date"` // "YYYY-MM-DD"
+	Description string      `json:"description"`
+	Amount      money.Money `json:"amount"`
 }
 
 func (h *ReimbursementHandler) SubmitReimbursement(w http.ResponseWriter, r *http.Request) {
@@ -38,11 +50,331 @@ func (h *ReimbursementHandler) SubmitReimbursement(w http.ResponseWriter, r *htt
 		return
 	}
 	// Teruskan context dari request
-	if err := h.service.SubmitReimbursement(r.Context(), userID, date, req.Description, req.Amount); err != nil {
+	item, err := h.service.SubmitReimbursement(r.Context(), userID, req.CategoryID, date, req.Description, req.Amount)
+	if errors.Is(err, periodlock.ErrLocked) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, reimbursement.ErrLimitExceeded) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Reimbursement submitted successfully", "id": item.ID})
+}
+
+// UpdateReimbursement adalah handler untuk endpoint PUT /api/v1/reimbursement/{reimbursement_id}.
+// Karyawan hanya dapat mengubah reimbursement miliknya yang masih submitted;
+// nilai sebelumnya disimpan sebagai revisi.
+func (h *ReimbursementHandler) UpdateReimbursement(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req reimbursementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.service.UpdateReimbursement(r.Context(), chi.URLParam(r, "reimbursement_id"), userID, req.CategoryID, date, req.Description, req.Amount)
+	switch {
+	case err == nil:
+	case errors.Is(err, reimbursement.ErrLimitExceeded):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, reimbursement.ErrNotEditable),
+		errors.Is(err, approval.ErrModified), errors.Is(err, periodlock.ErrLocked):
+		writeReimbursementError(w, err)
+		return
+	default:
+		// Sisanya adalah kesalahan validasi seperti pada pengajuan baru.
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// CancelReimbursement adalah handler untuk endpoint POST /api/v1/reimbursement/{reimbursement_id}/cancel.
+func (h *ReimbursementHandler) CancelReimbursement(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	item, err := h.service.CancelReimbursement(r.Context(), chi.URLParam(r, "reimbursement_id"), userID)
+	if err != nil {
+		writeReimbursementError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// GetMyRevisions adalah handler untuk endpoint GET /api/v1/reimbursement/{reimbursement_id}/revisions.
+func (h *ReimbursementHandler) GetMyRevisions(w http.ResponseWriter, r *http.Request) {
+	h.getRevisions(w, r, r.Context().Value(middleware.UserIDKey).(string))
+}
+
+// GetRevisions adalah handler untuk endpoint GET /api/v1/admin/reimbursements/{reimbursement_id}/revisions.
+func (h *ReimbursementHandler) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	h.getRevisions(w, r, "")
+}
+
+func (h *ReimbursementHandler) getRevisions(w http.ResponseWriter, r *http.Request, ownerID string) {
+	revisions, err := h.service.GetRevisions(r.Context(), chi.URLParam(r, "reimbursement_id"), ownerID)
+	if err != nil {
+		writeReimbursementError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
+
+// GetBalances adalah handler untuk endpoint GET /api/v1/reimbursement/balances.
+// Query opsional date (YYYY-MM-DD, default hari ini) menentukan bulan dan
+// tahun yang dihitung.
+func (h *ReimbursementHandler) GetBalances(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	date := time.Now()
+	if v := r.URL.Query().Get("date"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		date = parsed
+	}
+
+	balances, err := h.service.GetBalances(r.Context(), userID, date)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(balances)
+}
+
+// CreateCategory adalah handler untuk endpoint POST /api/v1/admin/reimbursement-categories.
+func (h *ReimbursementHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var category reimbursement.Category
+	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	if err := h.service.CreateCategory(r.Context(), &category, adminID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Reimbursement submitted successfully"})
+	json.NewEncoder(w).Encode(category)
+}
+
+// ListCategories adalah handler untuk endpoint GET /api/v1/admin/reimbursement-categories.
+func (h *ReimbursementHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.service.ListCategories(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(categories)
+}
+
+// UpdateCategory adalah handler untuk endpoint PUT /api/v1/admin/reimbursement-categories/{category_id}.
+func (h *ReimbursementHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	var req reimbursement.CategoryUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	category, err := h.service.UpdateCategory(r.Context(), chi.URLParam(r, "category_id"), req, adminID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Category not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
+
+// ListReimbursements adalah handler untuk endpoint GET /api/v1/admin/reimbursements.
+// Query opsional: status, user_id, start_date, end_date.
+func (h *ReimbursementHandler) ListReimbursements(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := reimbursement.ListFilter{UserID: query.Get("user_id")}
+	if v := query.Get("status"); v != "" {
+		status, err := reimbursement.ParseStatus(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.Status = status
+	}
+	if err := parseDateRange(query, &filter.StartDate, &filter.EndDate); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	items, err := h.service.ListReimbursements(r.Context(), filter)
+	if err != nil {
+		writeReimbursementError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// GetStatusHistory adalah handler untuk endpoint GET /api/v1/admin/reimbursements/{reimbursement_id}/history.
+func (h *ReimbursementHandler) GetStatusHistory(w http.ResponseWriter, r *http.Request) {
+	history, err := h.service.GetStatusHistory(r.Context(), chi.URLParam(r, "reimbursement_id"))
+	if err != nil {
+		writeReimbursementError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// ApproveReimbursement adalah handler untuk endpoint POST /api/v1/approver/reimbursements/{reimbursement_id}/approve.
+func (h *ReimbursementHandler) ApproveReimbursement(w http.ResponseWriter, r *http.Request) {
+	approverID := r.Context().Value(middleware.UserIDKey).(string)
+
+	item, err := h.service.ApproveReimbursement(r.Context(), chi.URLParam(r, "reimbursement_id"), approverID)
+	if err != nil {
+		writeReimbursementError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// RejectReimbursement adalah handler untuk endpoint POST /api/v1/approver/reimbursements/{reimbursement_id}/reject.
+func (h *ReimbursementHandler) RejectReimbursement(w http.ResponseWriter, r *http.Request) {
+	approverID := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req rejectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.service.RejectReimbursement(r.Context(), chi.URLParam(r, "reimbursement_id"), req.Reason, approverID)
+	if err != nil {
+		writeReimbursementError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// maxReceiptRequestSize memberi ruang untuk overhead multipart di atas
+// ukuran berkas maksimum.
+const maxReceiptRequestSize = reimbursement.MaxReceiptSize + 1<<20
+
+// UploadAttachment adalah handler untuk endpoint POST /api/v1/reimbursement/{reimbursement_id}/attachments.
+// Berkas dikirim sebagai multipart/form-data pada field "file".
+func (h *ReimbursementHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxReceiptRequestSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, reimbursement.ErrReceiptTooLarge.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "A receipt file is required in the \"file\" form field", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.service.AddAttachment(r.Context(), chi.URLParam(r, "reimbursement_id"), userID, header.Filename, header.Size, file)
+	if err != nil {
+		writeReimbursementError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(attachment)
+}
+
+// DownloadMyAttachment adalah handler untuk endpoint GET /api/v1/reimbursement/{reimbursement_id}/attachments/{attachment_id}.
+// Karyawan hanya dapat mengunduh bukti reimbursement miliknya.
+func (h *ReimbursementHandler) DownloadMyAttachment(w http.ResponseWriter, r *http.Request) {
+	h.downloadAttachment(w, r, r.Context().Value(middleware.UserIDKey).(string))
+}
+
+// DownloadAttachment adalah handler untuk endpoint GET /api/v1/admin/reimbursements/{reimbursement_id}/attachments/{attachment_id}.
+func (h *ReimbursementHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	h.downloadAttachment(w, r, "")
+}
+
+func (h *ReimbursementHandler) downloadAttachment(w http.ResponseWriter, r *http.Request, ownerID string) {
+	attachment, content, err := h.service.OpenAttachment(r.Context(), chi.URLParam(r, "reimbursement_id"), chi.URLParam(r, "attachment_id"), ownerID)
+	if err != nil {
+		writeReimbursementError(w, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, content); err != nil {
+		log.Printf("Failed to send receipt %s: %v", attachment.ID, err)
+	}
+}
+
+func writeReimbursementError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Reimbursement not found", http.StatusNotFound)
+	case errors.Is(err, storage.ErrNotFound):
+		// Data bukti ada tetapi berkasnya tidak ditemukan di penyimpanan.
+		http.Error(w, "Attachment file not found", http.StatusNotFound)
+	case errors.Is(err, reimbursement.ErrSelfReview):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, approval.ErrReasonRequired):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, reimbursement.ErrUnsupportedReceipt):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	case errors.Is(err, reimbursement.ErrReceiptTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, reimbursement.ErrInvalidStatusTransition), errors.Is(err, approval.ErrModified), errors.Is(err, periodlock.ErrLocked),
+		errors.Is(err, reimbursement.ErrReceiptRequired), errors.Is(err, reimbursement.ErrNotEditable):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
 }
@@ -0,0 +1,264 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/api/middleware"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/attendance"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/overtime"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/payroll"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/reimbursement"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// SelfServiceHandler menampilkan pengajuan karyawan yang sedang login beserta
+// periode payroll dan payslip yang membayarnya.
+type SelfServiceHandler struct {
+	attendance    attendance.Service
+	overtime      overtime.Service
+	reimbursement reimbursement.Service
+	payroll       payroll.Service
+}
+
+func NewSelfServiceHandler(a attendance.Service, o overtime.Service, r reimbursement.Service, p payroll.Service) *SelfServiceHandler {
+	return &SelfServiceHandler{attendance: a, overtime: o, reimbursement: r, payroll: p}
+}
+
+// pageResponse adalah satu halaman hasil daftar.
+type pageResponse struct {
+	Items    interface{} `json:"items"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+	Total    int64       `json:"total"`
+}
+
+type myAttendance struct {
+	attendance.Attendance
+	Settlement *payroll.Settlement `json:"settlement,omitempty"`
+}
+
+type myOvertime struct {
+	overtime.Overtime
+	Settlement *payroll.Settlement `json:"settlement,omitempty"`
+}
+
+type myReimbursement struct {
+	reimbursement.Reimbursement
+	Settlement *payroll.Settlement `json:"settlement,omitempty"`
+}
+
+// ListMyAttendance adalah handler untuk endpoint GET /api/v1/attendance.
+// Query opsional: start_date, end_date, page, page_size.
+func (h *SelfServiceHandler) ListMyAttendance(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	query := r.URL.Query()
+
+	var filter attendance.ListFilter
+	if err := parseDateRange(query, &filter.StartDate, &filter.EndDate); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, pageSize, err := parsePage(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Limit, filter.Offset = pageSize, (page-1)*pageSize
+
+	attendances, total, err := h.attendance.ListMyAttendance(r.Context(), userID, filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	dates := make([]time.Time, len(attendances))
+	for i, a := range attendances {
+		dates[i] = a.Date
+	}
+	settlements, err := h.settlements(r, userID, dates)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	items := make([]myAttendance, len(attendances))
+	for i, a := range attendances {
+		items[i] = myAttendance{Attendance: a, Settlement: payroll.FindSettlement(settlements, a.Date)}
+	}
+	writePage(w, items, page, pageSize, total)
+}
+
+// ListMyOvertimes adalah handler untuk endpoint GET /api/v1/overtime.
+// Query opsional: status, start_date, end_date, page, page_size. Hanya lembur
+// yang sudah dibayar yang memiliki settlement.
+func (h *SelfServiceHandler) ListMyOvertimes(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	query := r.URL.Query()
+
+	var filter overtime.ListFilter
+	if v := query.Get("status"); v != "" {
+		status, err := approval.ParseStatus(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.Status = status
+	}
+	if err := parseDateRange(query, &filter.StartDate, &filter.EndDate); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, pageSize, err := parsePage(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Limit, filter.Offset = pageSize, (page-1)*pageSize
+
+	overtimes, total, err := h.overtime.ListMyOvertimes(r.Context(), userID, filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	payslipIDs := make([]string, 0, len(overtimes))
+	for _, o := range overtimes {
+		payslipIDs = append(payslipIDs, o.PayslipID)
+	}
+	settlements, err := h.payslipSettlements(r, userID, payslipIDs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	items := make([]myOvertime, len(overtimes))
+	for i, o := range overtimes {
+		items[i] = myOvertime{Overtime: o, Settlement: settlements[o.PayslipID]}
+	}
+	writePage(w, items, page, pageSize, total)
+}
+
+// ListMyReimbursements adalah handler untuk endpoint GET /api/v1/reimbursement.
+// Query opsional: status, start_date, end_date, page, page_size. Hanya
+// reimbursement paid yang memiliki settlement.
+func (h *SelfServiceHandler) ListMyReimbursements(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	query := r.URL.Query()
+
+	var filter reimbursement.ListFilter
+	if v := query.Get("status"); v != "" {
+		status, err := reimbursement.ParseStatus(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.Status = status
+	}
+	if err := parseDateRange(query, &filter.StartDate, &filter.EndDate); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, pageSize, err := parsePage(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Limit, filter.Offset = pageSize, (page-1)*pageSize
+
+	reimbursements, total, err := h.reimbursement.ListMyReimbursements(r.Context(), userID, filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	payslipIDs := make([]string, 0, len(reimbursements))
+	for _, rb := range reimbursements {
+		if rb.Status == reimbursement.StatusPaid {
+			payslipIDs = append(payslipIDs, rb.PayslipID)
+		}
+	}
+	settlements, err := h.payslipSettlements(r, userID, payslipIDs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	items := make([]myReimbursement, len(reimbursements))
+	for i, rb := range reimbursements {
+		items[i] = myReimbursement{Reimbursement: rb}
+		if rb.Status == reimbursement.StatusPaid {
+			items[i].Settlement = settlements[rb.PayslipID]
+		}
+	}
+	writePage(w, items, page, pageSize, total)
+}
+
+// settlements mengambil payslip karyawan yang dapat dilihatnya pada periode
+// yang mencakup tanggal-tanggal tersebut.
+func (h *SelfServiceHandler) settlements(r *http.Request, userID string, dates []time.Time) ([]payroll.Settlement, error) {
+	if len(dates) == 0 {
+		return nil, nil
+	}
+	start, end := dates[0], dates[0]
+	for _, d := range dates[1:] {
+		if d.Before(start) {
+			start = d
+		}
+		if d.After(end) {
+			end = d
+		}
+	}
+	return h.payroll.GetSettlements(r.Context(), userID, start, end)
+}
+
+// payslipSettlements mengambil payslip yang dapat dilihat karyawan dari
+// payslipIDs, dikelompokkan per ID payslip. ID kosong (belum dibayar)
+// diabaikan.
+func (h *SelfServiceHandler) payslipSettlements(r *http.Request, userID string, payslipIDs []string) (map[string]*payroll.Settlement, error) {
+	ids := make([]string, 0, len(payslipIDs))
+	seen := make(map[string]bool, len(payslipIDs))
+	for _, id := range payslipIDs {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	settlements, err := h.payroll.GetSettlementsByPayslips(r.Context(), userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	byPayslip := make(map[string]*payroll.Settlement, len(settlements))
+	for i := range settlements {
+		byPayslip[settlements[i].PayslipID] = &settlements[i]
+	}
+	return byPayslip, nil
+}
+
+// parsePage membaca parameter query page (default 1) dan page_size (default
+// 20, maksimal 100).
+func parsePage(query url.Values) (page, pageSize int, err error) {
+	page, pageSize = 1, defaultPageSize
+	if v := query.Get("page"); v != "" {
+		if page, err = strconv.Atoi(v); err != nil || page < 1 {
+			return 0, 0, errors.New("page must be a positive integer")
+		}
+	}
+	if v := query.Get("page_size"); v != "" {
+		if pageSize, err = strconv.Atoi(v); err != nil || pageSize < 1 || pageSize > maxPageSize {
+			return 0, 0, errors.New("page_size must be between 1 and 100")
+		}
+	}
+	return page, pageSize, nil
+}
+
+func writePage(w http.ResponseWriter, items interface{}, page, pageSize int, total int64) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pageResponse{Items: items, Page: page, PageSize: pageSize, Total: total})
+}
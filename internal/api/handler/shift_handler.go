@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/api/middleware"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/periodlock"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/shift"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// ShiftHandler menangani definisi shift dan roster karyawan.
+type ShiftHandler struct {
+	service shift.Service
+}
+
+// NewShiftHandler membuat instance baru dari ShiftHandler.
+func NewShiftHandler(s shift.Service) *ShiftHandler {
+	return &ShiftHandler{service: s}
+}
+
+type createShiftRequest struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	StartTime string `json:"start_time"` // "HH:MM"
+	EndTime   string `json:"end_time"`   // "HH:MM"
+}
+
+type rosterEntryRequest struct {
+	Date    string `json:"date"`     // "YYYY-MM-DD"
+	ShiftID string `json:"shift_id"` // kosong berarti libur
+}
+
+type assignRosterRequest struct {
+	Entries []rosterEntryRequest `json:"entries"`
+}
+
+// CreateShift adalah handler untuk endpoint POST /api/v1/admin/shifts.
+func (h *ShiftHandler) CreateShift(w http.ResponseWriter, r *http.Request) {
+	var req createShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	s := &shift.Shift{Code: req.Code, Name: req.Name, StartTime: req.StartTime, EndTime: req.EndTime}
+	if err := h.service.CreateShift(r.Context(), s, adminID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(s)
+}
+
+// ListShifts adalah handler untuk endpoint GET /api/v1/admin/shifts.
+func (h *ShiftHandler) ListShifts(w http.ResponseWriter, r *http.Request) {
+	shifts, err := h.service.ListShifts(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shifts)
+}
+
+// UpdateShift adalah handler untuk endpoint PUT /api/v1/admin/shifts/{shift_id}.
+func (h *ShiftHandler) UpdateShift(w http.ResponseWriter, r *http.Request) {
+	var req shift.ShiftUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	s, err := h.service.UpdateShift(r.Context(), chi.URLParam(r, "shift_id"), req, adminID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Shift not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s)
+}
+
+// AssignRoster adalah handler untuk endpoint PUT /api/v1/admin/employees/{user_id}/roster.
+// Jadwal yang sudah ada pada tanggal yang sama ditimpa.
+func (h *ShiftHandler) AssignRoster(w http.ResponseWriter, r *http.Request) {
+	var req assignRosterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	assignments := make([]shift.Assignment, len(req.Entries))
+	for i, e := range req.Entries {
+		date, err := time.Parse("2006-01-02", e.Date)
+		if err != nil {
+			http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		assignments[i] = shift.Assignment{Date: date, ShiftID: e.ShiftID}
+	}
+
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	entries, err := h.service.AssignRoster(r.Context(), chi.URLParam(r, "user_id"), assignments, adminID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Employee not found", http.StatusNotFound)
+		return
+	case errors.Is(err, periodlock.ErrLocked):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// GetRoster adalah handler untuk endpoint GET /api/v1/admin/employees/{user_id}/roster.
+// Query wajib: start_date, end_date.
+func (h *ShiftHandler) GetRoster(w http.ResponseWriter, r *http.Request) {
+	h.getRoster(w, r, chi.URLParam(r, "user_id"))
+}
+
+// GetMyRoster adalah handler untuk endpoint GET /api/v1/roster.
+// Query wajib: start_date, end_date.
+func (h *ShiftHandler) GetMyRoster(w http.ResponseWriter, r *http.Request) {
+	h.getRoster(w, r, r.Context().Value(middleware.UserIDKey).(string))
+}
+
+func (h *ShiftHandler) getRoster(w http.ResponseWriter, r *http.Request, userID string) {
+	var start, end *time.Time
+	if err := parseDateRange(r.URL.Query(), &start, &end); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if start == nil || end == nil || end.Before(*start) {
+		http.Error(w, "start_date and end_date are required and end_date must not be before start_date", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.service.GetRoster(r.Context(), userID, *start, *end)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
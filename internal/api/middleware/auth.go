@@ -4,6 +4,7 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/auth"
@@ -57,15 +58,15 @@ func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
 
 // RoleMiddleware adalah lapisan keamanan kedua setelah AuthMiddleware.
 // Middleware ini memeriksa apakah role pengguna yang ada di dalam context
-// cocok dengan role yang dibutuhkan untuk mengakses endpoint tertentu.
-func RoleMiddleware(requiredRole string) func(http.Handler) http.Handler {
+// cocok dengan salah satu role yang diizinkan untuk mengakses endpoint tertentu.
+func RoleMiddleware(allowedRoles ...string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// 1. Ambil role dari context (yang sudah dimasukkan oleh AuthMiddleware)
 			role, ok := r.Context().Value(UserRoleKey).(string)
 
 			// 2. Jika role tidak ada atau tidak cocok, tolak akses
-			if !ok || role != requiredRole {
+			if !ok || !slices.Contains(allowedRoles, role) {
 				http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
 				return
 			}
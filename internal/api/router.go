@@ -8,9 +8,13 @@ import (
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/attendance"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/auth"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/holiday"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/leave"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/overtime"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/paycomponent"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/payroll"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/reimbursement"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/shift"
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 )
@@ -22,6 +26,10 @@ func NewRouter(
 	overtimeService overtime.Service,
 	reimbursementService reimbursement.Service,
 	payrollService payroll.Service,
+	payComponentService paycomponent.Service,
+	holidayService holiday.Service,
+	shiftService shift.Service,
+	leaveService leave.Service,
 	jwtSecret string,
 ) http.Handler {
 	r := chi.NewRouter()
@@ -38,6 +46,12 @@ func NewRouter(
 	overtimeHandler := handler.NewOvertimeHandler(overtimeService)
 	reimbursementHandler := handler.NewReimbursementHandler(reimbursementService)
 	payrollHandler := handler.NewPayrollHandler(payrollService)
+	payComponentHandler := handler.NewPayComponentHandler(payComponentService)
+	holidayHandler := handler.NewHolidayHandler(holidayService)
+	employeeHandler := handler.NewEmployeeHandler(employeeService)
+	shiftHandler := handler.NewShiftHandler(shiftService)
+	leaveHandler := handler.NewLeaveHandler(leaveService)
+	selfServiceHandler := handler.NewSelfServiceHandler(attendanceService, overtimeService, reimbursementService, payrollService)
 
 	// Public routes
 	r.Post("/api/v1/auth/login", authHandler.Login)
@@ -52,8 +66,37 @@ func NewRouter(
 
 			// Submissions
 			r.Post("/api/v1/attendance", attendanceHandler.SubmitAttendance)
+			r.Post("/api/v1/attendance/clock-in", attendanceHandler.ClockIn)
+			r.Post("/api/v1/attendance/clock-out", attendanceHandler.ClockOut)
+			r.Post("/api/v1/attendance/corrections", attendanceHandler.RequestCorrection)
+			r.Get("/api/v1/attendance/corrections", attendanceHandler.ListMyCorrections)
+			r.Post("/api/v1/attendance/corrections/{correction_id}/cancel", attendanceHandler.CancelCorrection)
 			r.Post("/api/v1/overtime", overtimeHandler.SubmitOvertime)
+			r.Put("/api/v1/overtime/{overtime_id}", overtimeHandler.UpdateOvertime)
+			r.Post("/api/v1/overtime/{overtime_id}/cancel", overtimeHandler.CancelOvertime)
+			r.Get("/api/v1/overtime/{overtime_id}/revisions", overtimeHandler.GetMyRevisions)
 			r.Post("/api/v1/reimbursement", reimbursementHandler.SubmitReimbursement)
+			r.Get("/api/v1/reimbursement/balances", reimbursementHandler.GetBalances)
+			r.Put("/api/v1/reimbursement/{reimbursement_id}", reimbursementHandler.UpdateReimbursement)
+			r.Post("/api/v1/reimbursement/{reimbursement_id}/cancel", reimbursementHandler.CancelReimbursement)
+			r.Get("/api/v1/reimbursement/{reimbursement_id}/revisions", reimbursementHandler.GetMyRevisions)
+			r.Post("/api/v1/reimbursement/{reimbursement_id}/attachments", reimbursementHandler.UploadAttachment)
+			r.Get("/api/v1/reimbursement/{reimbursement_id}/attachments/{attachment_id}", reimbursementHandler.DownloadMyAttachment)
+
+			// Own submissions
+			r.Get("/api/v1/attendance", selfServiceHandler.ListMyAttendance)
+			r.Get("/api/v1/overtime", selfServiceHandler.ListMyOvertimes)
+			r.Get("/api/v1/reimbursement", selfServiceHandler.ListMyReimbursements)
+
+			// Roster
+			r.Get("/api/v1/roster", shiftHandler.GetMyRoster)
+
+			// Leave
+			r.Post("/api/v1/leave", leaveHandler.SubmitLeave)
+			r.Get("/api/v1/leave", leaveHandler.ListMyLeave)
+			r.Get("/api/v1/leave/types", leaveHandler.ListTypes)
+			r.Get("/api/v1/leave/balances", leaveHandler.GetBalances)
+			r.Post("/api/v1/leave/{leave_id}/cancel", leaveHandler.CancelLeave)
 
 			// Payslip
 			r.Get("/api/v1/payslip/{period_id}", payrollHandler.GetMyPayslip)
@@ -65,8 +108,100 @@ func NewRouter(
 
 			// Payroll Management
 			r.Post("/api/v1/admin/payroll-period", payrollHandler.CreatePayrollPeriod)
+			r.Post("/api/v1/admin/payroll-period/generate", payrollHandler.GeneratePayrollPeriods)
+			r.Get("/api/v1/admin/payroll-period/{period_id}", payrollHandler.GetPayrollPeriod)
+			r.Post("/api/v1/admin/payroll-period/{period_id}/open", payrollHandler.OpenPayrollPeriod)
+			r.Post("/api/v1/admin/payroll-period/{period_id}/close", payrollHandler.ClosePayrollPeriod)
 			r.Post("/api/v1/admin/payroll/{period_id}/run", payrollHandler.RunPayroll)
+			r.Post("/api/v1/admin/payroll/{period_id}/preview", payrollHandler.PreviewPayroll)
+			r.Post("/api/v1/admin/payroll/{period_id}/reverse", payrollHandler.ReversePayroll)
+			r.Post("/api/v1/admin/payroll/{period_id}/paid", payrollHandler.MarkPayrollPaid)
+			r.Get("/api/v1/admin/payroll/jobs/{job_id}", payrollHandler.GetPayrollJob)
+			r.Get("/api/v1/admin/payroll/jobs/{job_id}/items", payrollHandler.GetPayrollJobItems)
+
+			// Pay Components
+			r.Post("/api/v1/admin/pay-components", payComponentHandler.CreateComponent)
+			r.Get("/api/v1/admin/pay-components", payComponentHandler.ListComponents)
+			r.Put("/api/v1/admin/pay-components/{component_id}", payComponentHandler.UpdateComponent)
+			r.Post("/api/v1/admin/employees/{user_id}/pay-components", payComponentHandler.AssignComponent)
+			r.Get("/api/v1/admin/employees/{user_id}/pay-components", payComponentHandler.ListAssignments)
+			r.Post("/api/v1/admin/pay-component-assignments/{assignment_id}/end", payComponentHandler.EndAssignment)
+
+			// Reimbursement Categories
+			r.Post("/api/v1/admin/reimbursement-categories", reimbursementHandler.CreateCategory)
+			r.Get("/api/v1/admin/reimbursement-categories", reimbursementHandler.ListCategories)
+			r.Put("/api/v1/admin/reimbursement-categories/{category_id}", reimbursementHandler.UpdateCategory)
+
+			// Holiday Calendar
+			r.Post("/api/v1/admin/holidays", holidayHandler.CreateHoliday)
+			r.Get("/api/v1/admin/holidays", holidayHandler.ListHolidays)
+			r.Post("/api/v1/admin/holidays/import", holidayHandler.ImportHolidays)
+			r.Put("/api/v1/admin/holidays/{holiday_id}", holidayHandler.UpdateHoliday)
+			r.Delete("/api/v1/admin/holidays/{holiday_id}", holidayHandler.DeleteHoliday)
+
+			// Shifts & Roster
+			r.Post("/api/v1/admin/shifts", shiftHandler.CreateShift)
+			r.Get("/api/v1/admin/shifts", shiftHandler.ListShifts)
+			r.Put("/api/v1/admin/shifts/{shift_id}", shiftHandler.UpdateShift)
+			r.Put("/api/v1/admin/employees/{user_id}/roster", shiftHandler.AssignRoster)
+			r.Get("/api/v1/admin/employees/{user_id}/roster", shiftHandler.GetRoster)
+
+			// Leave Types & Entitlements
+			r.Post("/api/v1/admin/leave-types", leaveHandler.CreateType)
+			r.Get("/api/v1/admin/leave-types", leaveHandler.ListTypes)
+			r.Put("/api/v1/admin/leave-types/{type_id}", leaveHandler.UpdateType)
+			r.Post("/api/v1/admin/leave-entitlements", leaveHandler.GrantEntitlements)
+
+			// Employees
+			r.Put("/api/v1/admin/employees/{user_id}/location", employeeHandler.UpdateLocation)
+			r.Put("/api/v1/admin/employees/{user_id}/pay-group", employeeHandler.UpdatePayGroup)
+			r.Put("/api/v1/admin/employees/{user_id}/manager", employeeHandler.UpdateManager)
+			r.Put("/api/v1/admin/employees/{user_id}/grade", employeeHandler.UpdateGrade)
+			r.Put("/api/v1/admin/employees/{user_id}/tax-status", employeeHandler.UpdateTaxStatus)
+			r.Put("/api/v1/admin/employees/{user_id}/timezone", employeeHandler.UpdateTimeZone)
+		})
+
+		// --- Payroll & Reimbursement Review Routes (admin & approver) ---
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("admin", "approver"))
+
 			r.Get("/api/v1/admin/payroll/{period_id}/summary", payrollHandler.GetPayrollSummary)
+			r.Get("/api/v1/admin/payroll/{period_id}/employees/{user_id}/payslips", payrollHandler.GetPayslipVersions)
+			r.Get("/api/v1/admin/payroll/{period_id}/employees/{user_id}/payslip-diff", payrollHandler.DiffPayslips)
+
+			// Reimbursements
+			r.Get("/api/v1/admin/reimbursements", reimbursementHandler.ListReimbursements)
+			r.Get("/api/v1/admin/reimbursements/{reimbursement_id}/history", reimbursementHandler.GetStatusHistory)
+			r.Get("/api/v1/admin/reimbursements/{reimbursement_id}/revisions", reimbursementHandler.GetRevisions)
+			r.Get("/api/v1/admin/reimbursements/{reimbursement_id}/attachments/{attachment_id}", reimbursementHandler.DownloadAttachment)
+		})
+
+		// --- Submission Review Routes (admin & manager) ---
+		// Manager hanya dapat melihat dan memutuskan pengajuan bawahan langsungnya.
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("admin", "manager"))
+
+			r.Get("/api/v1/admin/overtime", overtimeHandler.ListOvertimes)
+			r.Post("/api/v1/admin/overtime/{overtime_id}/approve", overtimeHandler.ApproveOvertime)
+			r.Post("/api/v1/admin/overtime/{overtime_id}/reject", overtimeHandler.RejectOvertime)
+			r.Get("/api/v1/admin/overtime/{overtime_id}/revisions", overtimeHandler.GetRevisions)
+
+			r.Get("/api/v1/admin/attendance-corrections", attendanceHandler.ListCorrections)
+			r.Post("/api/v1/admin/attendance-corrections/{correction_id}/approve", attendanceHandler.ApproveCorrection)
+			r.Post("/api/v1/admin/attendance-corrections/{correction_id}/reject", attendanceHandler.RejectCorrection)
+
+			r.Get("/api/v1/admin/leave", leaveHandler.ListLeave)
+			r.Post("/api/v1/admin/leave/{leave_id}/approve", leaveHandler.ApproveLeave)
+			r.Post("/api/v1/admin/leave/{leave_id}/reject", leaveHandler.RejectLeave)
+		})
+
+		// --- Approver Routes ---
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RoleMiddleware("approver"))
+
+			r.Post("/api/v1/approver/payroll/{period_id}/approve", payrollHandler.ApprovePayroll)
+			r.Post("/api/v1/approver/reimbursements/{reimbursement_id}/approve", reimbursementHandler.ApproveReimbursement)
+			r.Post("/api/v1/approver/reimbursements/{reimbursement_id}/reject", reimbursementHandler.RejectReimbursement)
 		})
 	})
 
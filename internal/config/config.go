@@ -1,12 +1,20 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// minPayrollJobLease adalah lease job payroll terpendek yang diterima. Worker
+// memperpanjang lease setiap sepertiga durasinya, sehingga lease yang terlalu
+// pendek membanjiri database atau membuat job diambil ulang worker lain
+// sebelum sempat diperpanjang.
+const minPayrollJobLease = 30 * time.Second
+
 type Config struct {
 	AppPort   string
 	DBHost    string
@@ -16,6 +24,40 @@ type Config struct {
 	DBName    string
 	JWTSecret string
 	RunSeeder bool
+
+	// Zona waktu IANA perusahaan untuk karyawan yang tidak menetapkan zona
+	// waktunya sendiri, mis. "Asia/Jakarta".
+	DefaultTimeZone string
+
+	// Aturan pembulatan kalkulasi payslip (lihat pkg/money).
+	PayrollRoundingMode  string
+	PayrollRoundingScale int
+
+	// Eksekusi job payroll di background.
+	PayrollWorkers         int           // Jumlah karyawan yang dihitung bersamaan
+	PayrollJobPollInterval time.Duration // Jeda pengecekan antrean job
+	PayrollJobLease        time.Duration // Lama job dikunci oleh satu worker
+
+	// Parameter iuran BPJS yang berbeda per perusahaan atau berubah tiap tahun.
+	BPJSJKKRateBps int    // Tarif JKK sesuai kelompok risiko, dalam basis poin
+	BPJSJKNWageCap string // Batas upah iuran JKN
+	BPJSJPWageCap  string // Batas upah iuran JP
+
+	// Kebijakan proration gaji pokok perusahaan dan per kelompok penggajian
+	// (lihat payroll.ParseProrationPolicy).
+	PayrollProration       string // mis. "working_days" atau "fixed_divisor:21"
+	PayrollProrationGroups string // mis. "operator=fixed_divisor:22,staff=calendar_days"
+	PayrollOvertimeMethod  string // "statutory" (Kepmen 102/2004) atau "flat"
+
+	// Penyimpanan berkas bukti reimbursement: "local" (direktori
+	// ReceiptStorageDir) atau "s3" (object storage yang kompatibel dengan S3).
+	ReceiptStorage           string
+	ReceiptStorageDir        string
+	ReceiptS3Endpoint        string
+	ReceiptS3Region          string
+	ReceiptS3Bucket          string
+	ReceiptS3AccessKeyID     string
+	ReceiptS3SecretAccessKey string
 }
 
 func Load() (*Config, error) {
@@ -24,6 +66,32 @@ func Load() (*Config, error) {
 	}
 
 	runSeeder, _ := strconv.ParseBool(os.Getenv("RUN_SEEDER"))
+	roundingScale, err := strconv.Atoi(getEnv("PAYROLL_ROUNDING_SCALE", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PAYROLL_ROUNDING_SCALE: %w", err)
+	}
+	payrollWorkers, err := strconv.Atoi(getEnv("PAYROLL_WORKERS", "4"))
+	if err != nil || payrollWorkers < 1 {
+		return nil, fmt.Errorf("invalid PAYROLL_WORKERS: %q", os.Getenv("PAYROLL_WORKERS"))
+	}
+	pollInterval, err := time.ParseDuration(getEnv("PAYROLL_JOB_POLL_INTERVAL", "2s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PAYROLL_JOB_POLL_INTERVAL: %w", err)
+	}
+	if pollInterval <= 0 {
+		return nil, fmt.Errorf("invalid PAYROLL_JOB_POLL_INTERVAL: %s must be positive", pollInterval)
+	}
+	jobLease, err := time.ParseDuration(getEnv("PAYROLL_JOB_LEASE", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PAYROLL_JOB_LEASE: %w", err)
+	}
+	if jobLease < minPayrollJobLease {
+		return nil, fmt.Errorf("invalid PAYROLL_JOB_LEASE: %s is shorter than the minimum of %s", jobLease, minPayrollJobLease)
+	}
+	jkkRate, err := strconv.Atoi(getEnv("BPJS_JKK_RATE_BPS", "24"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid BPJS_JKK_RATE_BPS: %w", err)
+	}
 
 	return &Config{
 		AppPort:   getEnv("APP_PORT", "8080"),
@@ -34,6 +102,31 @@ func Load() (*Config, error) {
 		DBName:    getEnv("DB_NAME", "payroll_db"),
 		JWTSecret: getEnv("JWT_SECRET", "default_secret"),
 		RunSeeder: runSeeder,
+
+		DefaultTimeZone: getEnv("DEFAULT_TIME_ZONE", "Asia/Jakarta"),
+
+		PayrollRoundingMode:  getEnv("PAYROLL_ROUNDING_MODE", "half_up"),
+		PayrollRoundingScale: roundingScale,
+
+		PayrollWorkers:         payrollWorkers,
+		PayrollJobPollInterval: pollInterval,
+		PayrollJobLease:        jobLease,
+
+		BPJSJKKRateBps: jkkRate,
+		BPJSJKNWageCap: getEnv("BPJS_JKN_WAGE_CAP", "12000000"),
+		BPJSJPWageCap:  getEnv("BPJS_JP_WAGE_CAP", "10547400"),
+
+		PayrollProration:       getEnv("PAYROLL_PRORATION", "working_days"),
+		PayrollProrationGroups: getEnv("PAYROLL_PRORATION_GROUPS", ""),
+		PayrollOvertimeMethod:  getEnv("PAYROLL_OVERTIME_METHOD", "statutory"),
+
+		ReceiptStorage:           getEnv("RECEIPT_STORAGE", "local"),
+		ReceiptStorageDir:        getEnv("RECEIPT_STORAGE_DIR", "./storage/receipts"),
+		ReceiptS3Endpoint:        getEnv("RECEIPT_S3_ENDPOINT", ""),
+		ReceiptS3Region:          getEnv("RECEIPT_S3_REGION", "us-east-1"),
+		ReceiptS3Bucket:          getEnv("RECEIPT_S3_BUCKET", ""),
+		ReceiptS3AccessKeyID:     getEnv("RECEIPT_S3_ACCESS_KEY_ID", ""),
+		ReceiptS3SecretAccessKey: getEnv("RECEIPT_S3_SECRET_ACCESS_KEY", ""),
 	}, nil
 }
 
@@ -0,0 +1,148 @@
+// Package approval berisi alur persetujuan yang dipakai bersama oleh
+// pengajuan karyawan (lembur, cuti, koreksi absensi): status pending yang
+// diputuskan reviewer atau dibatalkan pemiliknya, serta batas kewenangan
+// reviewer atas karyawan.
+package approval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+	"gorm.io/gorm"
+)
+
+// Status adalah status persetujuan sebuah pengajuan. Pengajuan dibuat pending
+// lalu diputuskan sekali: approved, rejected, atau cancelled oleh pemiliknya.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusApproved  Status = "approved"
+	StatusRejected  Status = "rejected"
+	StatusCancelled Status = "cancelled"
+)
+
+var (
+	// ErrInvalidStatusTransition dikembalikan untuk perubahan status yang
+	// tidak diizinkan, mis. menyetujui pengajuan yang sudah ditolak.
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+	// ErrNotReviewer dikembalikan jika reviewer tidak berwenang atas
+	// pengajuan karyawan tersebut.
+	ErrNotReviewer = errors.New("not allowed to review this employee's request")
+	// ErrReasonRequired dikembalikan saat menolak pengajuan tanpa alasan.
+	ErrReasonRequired = errors.New("a reason is required")
+	// ErrModified dikembalikan jika pengajuan diubah proses lain di antara
+	// pembacaan dan penyimpanan.
+	ErrModified = errors.New("request was modified by another process")
+)
+
+// ParseStatus memvalidasi nama status persetujuan.
+func ParseStatus(s string) (Status, error) {
+	switch st := Status(s); st {
+	case StatusPending, StatusApproved, StatusRejected, StatusCancelled:
+		return st, nil
+	}
+	return "", fmt.Errorf("unknown status %q", s)
+}
+
+// Decision adalah status persetujuan beserta keputusan reviewer. Di-embed
+// oleh model pengajuan sehingga kolom dan field JSON-nya tetap rata.
+type Decision struct {
+	Status       Status     `json:"status" gorm:"size:16;default:'pending';index"`
+	ReviewedBy   string     `gorm:"size:36" json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+	ReviewReason string     `json:"review_reason,omitempty"` // Wajib saat ditolak
+}
+
+// CheckPending memastikan pengajuan masih pending sehingga masih boleh
+// diubah pemiliknya.
+func (d Decision) CheckPending() error {
+	if d.Status != StatusPending {
+		return fmt.Errorf("%w: %s request can no longer be changed", ErrInvalidStatusTransition, d.Status)
+	}
+	return nil
+}
+
+// TransitionTo memindahkan pengajuan dari pending ke status next. Keputusan
+// approve/reject mencatat reviewer, waktu, dan alasannya; pembatalan oleh
+// pemilik tidak mencatat reviewer.
+func (d *Decision) TransitionTo(next Status, actorID, reason string, at time.Time) error {
+	if d.Status != StatusPending || next == StatusPending {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, d.Status, next)
+	}
+	d.Status = next
+	if next != StatusCancelled {
+		d.ReviewedBy = actorID
+		d.ReviewedAt = &at
+		d.ReviewReason = reason
+	}
+	return nil
+}
+
+// Decide menjalankan TransitionTo lalu menyimpannya lewat save, yaitu
+// pembaruan bersyarat atas status from agar dua keputusan yang bersamaan
+// tidak saling menimpa. save mengembalikan false jika status sudah diubah
+// proses lain.
+func (d *Decision) Decide(next Status, actorID, reason string, at time.Time, save func(from Status) (bool, error)) error {
+	from := d.Status
+	if err := d.TransitionTo(next, actorID, reason, at); err != nil {
+		return err
+	}
+	ok, err := save(from)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrModified
+	}
+	return nil
+}
+
+// ManagerScope mengembalikan batas daftar pengajuan yang boleh dilihat
+// reviewer: managerID kosong untuk admin (semua karyawan), ID manager untuk
+// manager (bawahan langsung). Peran lain bukan reviewer.
+func ManagerScope(reviewer employee.Reviewer) (managerID string, err error) {
+	switch reviewer.Role {
+	case "admin":
+		return "", nil
+	case "manager":
+		return reviewer.ID, nil
+	}
+	return "", ErrNotReviewer
+}
+
+// Authorize memastikan reviewer berwenang memutuskan pengajuan milik
+// karyawan ownerID.
+func Authorize(ctx context.Context, employees employee.Repository, reviewer employee.Reviewer, ownerID string) error {
+	owner, err := employees.GetByID(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	if !reviewer.CanReview(owner) {
+		return ErrNotReviewer
+	}
+	return nil
+}
+
+// RejectReason merapikan alasan penolakan, yang wajib diisi.
+func RejectReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return "", ErrReasonRequired
+	}
+	return reason, nil
+}
+
+// CheckOwner memastikan pengajuan milik ownerID diakses oleh pemiliknya
+// sendiri. Pengajuan milik karyawan lain diperlakukan sebagai tidak
+// ditemukan agar keberadaannya tidak bocor.
+func CheckOwner(ownerID, userID string) error {
+	if ownerID != userID {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
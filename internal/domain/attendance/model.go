@@ -3,21 +3,148 @@ package attendance
 import (
 	"time"
 
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/shift"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// Attendance adalah ringkasan kehadiran harian karyawan. Satu karyawan hanya
+// memiliki satu baris per tanggal; payroll menghitung hari hadir dari baris
+// ini. Date adalah tanggal mulai shift, sehingga shift malam tetap satu hari
+// walaupun clock-out terjadi keesokan harinya. FirstIn, LastOut,
+// WorkedMinutes, LateMinutes, dan EarlyLeaveMinutes diturunkan dari Events.
 type Attendance struct {
-	ID        string    `gorm:"primaryKey"`
-	UserID    string    `gorm:"index;uniqueIndex:idx_user_date"`
-	Date      time.Time `gorm:"type:date;uniqueIndex:idx_user_date"`
+	ID                string     `json:"id" gorm:"primaryKey"`
+	UserID            string     `json:"user_id" gorm:"index;uniqueIndex:idx_user_date"`
+	Date              time.Time  `json:"date" gorm:"type:date;uniqueIndex:idx_user_date"`
+	ShiftID           string     `json:"shift_id,omitempty" gorm:"size:36"`
+	ScheduledStart    *time.Time `json:"scheduled_start,omitempty"`
+	ScheduledEnd      *time.Time `json:"scheduled_end,omitempty"`
+	FirstIn           *time.Time `json:"first_in,omitempty"`
+	LastOut           *time.Time `json:"last_out,omitempty"`
+	WorkedMinutes     int        `json:"worked_minutes"`
+	LateMinutes       int        `json:"late_minutes"`
+	EarlyLeaveMinutes int        `json:"early_leave_minutes"`
+	Events            []Event    `json:"events,omitempty" gorm:"foreignKey:AttendanceID"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	CreatedBy         string     `gorm:"size:36" json:"created_by"`
+	UpdatedBy         string     `gorm:"size:36" json:"updated_by"`
+}
+
+func (a *Attendance) BeforeCreate(tx *gorm.DB) error {
+	a.ID = uuid.New().String()
+	return nil
+}
+
+// EventType adalah jenis kejadian absensi.
+type EventType string
+
+const (
+	EventClockIn  EventType = "clock_in"
+	EventClockOut EventType = "clock_out"
+)
+
+// Event adalah satu kejadian clock-in atau clock-out beserta waktunya.
+type Event struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	AttendanceID string    `json:"attendance_id" gorm:"size:36;index"`
+	UserID       string    `json:"user_id" gorm:"size:36;index"`
+	Type         EventType `json:"type" gorm:"size:16"`
+	At           time.Time `json:"at"`
+	CreatedAt    time.Time `json:"created_at"`
+	CreatedBy    string    `gorm:"size:36" json:"created_by"`
+}
+
+func (Event) TableName() string {
+	return "attendance_events"
+}
+
+func (e *Event) BeforeCreate(tx *gorm.DB) error {
+	e.ID = uuid.New().String()
+	return nil
+}
+
+// ClockedIn melaporkan apakah kejadian terakhir pada events adalah clock-in
+// yang belum ditutup clock-out.
+func ClockedIn(events []Event) bool {
+	return len(events) > 0 && events[len(events)-1].Type == EventClockIn
+}
+
+// Schedule mencatat shift yang dijadwalkan roster pada absensi. Jadwal tanpa
+// shift tidak mengubah apa pun.
+func (a *Attendance) Schedule(schedule shift.Schedule, loc *time.Location) error {
+	if !schedule.Working() {
+		return nil
+	}
+	start, end, err := schedule.Shift.Window(a.Date, loc)
+	if err != nil {
+		return err
+	}
+	a.ShiftID = schedule.Shift.ID
+	a.ScheduledStart, a.ScheduledEnd = &start, &end
+	return nil
+}
+
+// Summarize menghitung ulang ringkasan dari events yang terurut menurut
+// waktu. Jam kerja adalah jumlah setiap pasangan clock-in dan clock-out,
+// sehingga jeda di antaranya tidak dihitung dan clock-in yang masih terbuka
+// belum dihitung. Keterlambatan dan pulang awal dihitung terhadap jadwal
+// shift, jika ada.
+func (a *Attendance) Summarize(events []Event) {
+	a.FirstIn, a.LastOut = nil, nil
+	var worked time.Duration
+	var openedAt *time.Time
+	for i := range events {
+		at := events[i].At
+		switch events[i].Type {
+		case EventClockIn:
+			if a.FirstIn == nil {
+				a.FirstIn = &at
+			}
+			openedAt = &at
+		case EventClockOut:
+			a.LastOut = &at
+			if openedAt != nil {
+				worked += at.Sub(*openedAt)
+				openedAt = nil
+			}
+		}
+	}
+	a.WorkedMinutes = int(worked / time.Minute)
+
+	a.LateMinutes, a.EarlyLeaveMinutes = 0, 0
+	if a.ScheduledStart != nil && a.FirstIn != nil && a.FirstIn.After(*a.ScheduledStart) {
+		a.LateMinutes = int(a.FirstIn.Sub(*a.ScheduledStart) / time.Minute)
+	}
+	if a.ScheduledEnd != nil && a.LastOut != nil && !ClockedIn(events) && a.LastOut.Before(*a.ScheduledEnd) {
+		a.EarlyLeaveMinutes = int(a.ScheduledEnd.Sub(*a.LastOut) / time.Minute)
+	}
+}
+
+// Correction adalah permintaan karyawan untuk mencatat kehadiran pada tanggal
+// yang sudah lewat tetapi lupa diabsen. Absensi baru dibuat saat koreksi
+// disetujui, dengan CreatedBy berisi reviewer yang menyetujuinya.
+type Correction struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	UserID       string    `json:"user_id" gorm:"size:36;index"`
+	Date         time.Time `json:"date" gorm:"type:date"`
+	Reason       string    `json:"reason"`
+	AttendanceID string    `json:"attendance_id,omitempty" gorm:"size:36"` // Diisi saat disetujui
+	// Status persetujuan; absensi baru dibuat saat koreksi approved.
+	approval.Decision
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	CreatedBy string    `gorm:"size:36" json:"created_by"`
 	UpdatedBy string    `gorm:"size:36" json:"updated_by"`
 }
 
-func (a *Attendance) BeforeCreate(tx *gorm.DB) error {
-	a.ID = uuid.New().String()
+func (Correction) TableName() string {
+	return "attendance_corrections"
+}
+
+func (c *Correction) BeforeCreate(tx *gorm.DB) error {
+	c.ID = uuid.New().String()
 	return nil
 }
@@ -2,13 +2,68 @@ package attendance
 
 import (
 	"context"
+	"time"
 
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
 	"gorm.io/gorm"
 )
 
 type Repository interface {
+	// WithTransaction menjalankan fn di dalam satu transaksi database.
+	WithTransaction(ctx context.Context, fn func(repo Repository) error) error
+	// LockDay mencegah clock-in dan clock-out karyawan yang sama diproses
+	// paralel sampai transaksi selesai. Harus dipanggil di dalam
+	// WithTransaction.
+	LockDay(ctx context.Context, userID string) error
 	CreateAttendance(ctx context.Context, attendance *Attendance) error
 	HasAttendanceOnDate(ctx context.Context, userID string, date string) (bool, error)
+	// GetAttendanceOnDate mengembalikan absensi karyawan pada tanggal
+	// (YYYY-MM-DD) beserta kejadiannya, terurut menurut waktu.
+	GetAttendanceOnDate(ctx context.Context, userID string, date string) (*Attendance, error)
+	// SaveSummary menyimpan absensi baru atau memperbarui ringkasan absensi
+	// yang sudah ada.
+	SaveSummary(ctx context.Context, attendance *Attendance) error
+	CreateEvent(ctx context.Context, event *Event) error
+	// ListAttendances mengembalikan absensi sesuai filter, diurutkan menurut
+	// tanggal. CountAttendances menghitung totalnya tanpa Limit dan Offset.
+	ListAttendances(ctx context.Context, filter ListFilter) ([]Attendance, error)
+	CountAttendances(ctx context.Context, filter ListFilter) (int64, error)
+
+	CreateCorrection(ctx context.Context, correction *Correction) error
+	GetCorrection(ctx context.Context, id string) (*Correction, error)
+	// HasPendingCorrection melaporkan apakah karyawan masih memiliki koreksi
+	// pending untuk tanggal (YYYY-MM-DD).
+	HasPendingCorrection(ctx context.Context, userID string, date string) (bool, error)
+	// ListCorrections mengembalikan koreksi sesuai filter, diurutkan menurut
+	// tanggal. CountCorrections menghitung totalnya tanpa Limit dan Offset.
+	ListCorrections(ctx context.Context, filter CorrectionFilter) ([]Correction, error)
+	CountCorrections(ctx context.Context, filter CorrectionFilter) (int64, error)
+	// UpdateCorrectionStatus menyimpan perubahan status hanya jika status di
+	// database masih from. Nilai false berarti koreksi sudah diubah proses
+	// lain.
+	UpdateCorrectionStatus(ctx context.Context, correction *Correction, from approval.Status) (bool, error)
+}
+
+// ListFilter membatasi daftar absensi. Field kosong tidak membatasi; Limit 0
+// berarti tanpa batas jumlah.
+type ListFilter struct {
+	UserID    string
+	StartDate *time.Time
+	EndDate   *time.Time
+	Limit     int
+	Offset    int
+}
+
+// CorrectionFilter membatasi daftar koreksi absensi. Field kosong tidak
+// membatasi; Limit 0 berarti tanpa batas jumlah.
+type CorrectionFilter struct {
+	Status    approval.Status
+	UserID    string
+	ManagerID string // Hanya koreksi bawahan langsung manager ini
+	StartDate *time.Time
+	EndDate   *time.Time
+	Limit     int
+	Offset    int
 }
 
 type repository struct {
@@ -19,6 +74,19 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db}
 }
 
+func (r *repository) WithTransaction(ctx context.Context, fn func(repo Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&repository{tx})
+	})
+}
+
+// dayLockKey adalah ruang kunci advisory lock absensi per karyawan.
+const dayLockKey = 72010021
+
+func (r *repository) LockDay(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Exec("SELECT pg_advisory_xact_lock(?, hashtext(?))", dayLockKey, userID).Error
+}
+
 func (r *repository) CreateAttendance(ctx context.Context, attendance *Attendance) error {
 	return r.db.WithContext(ctx).Create(attendance).Error
 }
@@ -31,3 +99,138 @@ func (r *repository) HasAttendanceOnDate(ctx context.Context, userID string, dat
 	}
 	return count > 0, nil
 }
+
+func (r *repository) GetAttendanceOnDate(ctx context.Context, userID string, date string) (*Attendance, error) {
+	var attendance Attendance
+	err := r.db.WithContext(ctx).
+		Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("at") }).
+		Where("user_id = ? AND date = ?", userID, date).
+		First(&attendance).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attendance, nil
+}
+
+func (r *repository) SaveSummary(ctx context.Context, attendance *Attendance) error {
+	if attendance.ID == "" {
+		return r.db.WithContext(ctx).Omit("Events").Create(attendance).Error
+	}
+	return r.db.WithContext(ctx).Model(&Attendance{}).Where("id = ?", attendance.ID).Updates(map[string]interface{}{
+		"first_in":            attendance.FirstIn,
+		"last_out":            attendance.LastOut,
+		"worked_minutes":      attendance.WorkedMinutes,
+		"late_minutes":        attendance.LateMinutes,
+		"early_leave_minutes": attendance.EarlyLeaveMinutes,
+		"updated_by":          attendance.UpdatedBy,
+	}).Error
+}
+
+func (r *repository) CreateEvent(ctx context.Context, event *Event) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r *repository) ListAttendances(ctx context.Context, filter ListFilter) ([]Attendance, error) {
+	q := r.filtered(ctx, filter).Order("date, created_at")
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit).Offset(filter.Offset)
+	}
+	var attendances []Attendance
+	err := q.Find(&attendances).Error
+	return attendances, err
+}
+
+func (r *repository) CountAttendances(ctx context.Context, filter ListFilter) (int64, error) {
+	var count int64
+	err := r.filtered(ctx, filter).Model(&Attendance{}).Count(&count).Error
+	return count, err
+}
+
+func (r *repository) filtered(ctx context.Context, filter ListFilter) *gorm.DB {
+	q := r.db.WithContext(ctx)
+	if filter.UserID != "" {
+		q = q.Where("user_id = ?", filter.UserID)
+	}
+	if filter.StartDate != nil {
+		q = q.Where("date >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		q = q.Where("date <= ?", *filter.EndDate)
+	}
+	return q
+}
+
+func (r *repository) CreateCorrection(ctx context.Context, correction *Correction) error {
+	return r.db.WithContext(ctx).Create(correction).Error
+}
+
+func (r *repository) GetCorrection(ctx context.Context, id string) (*Correction, error) {
+	var correction Correction
+	if err := r.db.WithContext(ctx).First(&correction, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &correction, nil
+}
+
+func (r *repository) HasPendingCorrection(ctx context.Context, userID string, date string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Correction{}).
+		Where("user_id = ? AND date = ? AND status = ?", userID, date, approval.StatusPending).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *repository) ListCorrections(ctx context.Context, filter CorrectionFilter) ([]Correction, error) {
+	q := r.correctionsFiltered(ctx, filter).Order("date, created_at")
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit).Offset(filter.Offset)
+	}
+	var corrections []Correction
+	err := q.Find(&corrections).Error
+	return corrections, err
+}
+
+func (r *repository) CountCorrections(ctx context.Context, filter CorrectionFilter) (int64, error) {
+	var count int64
+	err := r.correctionsFiltered(ctx, filter).Model(&Correction{}).Count(&count).Error
+	return count, err
+}
+
+func (r *repository) correctionsFiltered(ctx context.Context, filter CorrectionFilter) *gorm.DB {
+	q := r.db.WithContext(ctx)
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	if filter.UserID != "" {
+		q = q.Where("user_id = ?", filter.UserID)
+	}
+	if filter.ManagerID != "" {
+		q = q.Where("user_id IN (?)", r.db.Table("employees").Select("id").Where("manager_id = ?", filter.ManagerID))
+	}
+	if filter.StartDate != nil {
+		q = q.Where("date >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		q = q.Where("date <= ?", *filter.EndDate)
+	}
+	return q
+}
+
+func (r *repository) UpdateCorrectionStatus(ctx context.Context, correction *Correction, from approval.Status) (bool, error) {
+	updates := map[string]interface{}{
+		"status":        correction.Status,
+		"attendance_id": correction.AttendanceID,
+		"reviewed_by":   correction.ReviewedBy,
+		"reviewed_at":   correction.ReviewedAt,
+		"review_reason": correction.ReviewReason,
+		"updated_by":    correction.UpdatedBy,
+	}
+	res := r.db.WithContext(ctx).Model(&Correction{}).Where("id = ? AND status = ?", correction.ID, from).Updates(updates)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
@@ -3,29 +3,218 @@ package attendance
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/periodlock"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/shift"
+	"gorm.io/gorm"
 )
 
 type Service interface {
 	SubmitAttendance(ctx context.Context, userID string) error
+	// ClockIn mencatat kedatangan karyawan saat ini. Clock-in pertama pada
+	// suatu hari juga mencatat kehadiran hari tersebut.
+	ClockIn(ctx context.Context, userID string) (*Attendance, error)
+	// ClockOut menutup clock-in karyawan yang masih terbuka hari ini.
+	ClockOut(ctx context.Context, userID string) (*Attendance, error)
+	// ListMyAttendance mengembalikan absensi milik userID beserta jumlah
+	// seluruhnya sebelum Limit dan Offset diterapkan.
+	ListMyAttendance(ctx context.Context, userID string, filter ListFilter) ([]Attendance, int64, error)
+
+	// RequestCorrection mengajukan koreksi absensi pending untuk tanggal kerja
+	// yang sudah lewat tetapi belum diabsen.
+	RequestCorrection(ctx context.Context, userID string, date time.Time, reason string) (*Correction, error)
+	// ListCorrections menampilkan koreksi yang boleh diputuskan reviewer:
+	// semua untuk admin, bawahan langsung untuk manager.
+	ListCorrections(ctx context.Context, reviewer employee.Reviewer, filter CorrectionFilter) ([]Correction, error)
+	// ListMyCorrections mengembalikan koreksi milik userID beserta jumlah
+	// seluruhnya sebelum Limit dan Offset diterapkan.
+	ListMyCorrections(ctx context.Context, userID string, filter CorrectionFilter) ([]Correction, int64, error)
+	// ApproveCorrection menyetujui koreksi pending dan membuat absensinya
+	// atas nama reviewer. RejectCorrection menolaknya; alasan wajib diisi.
+	ApproveCorrection(ctx context.Context, correctionID string, reviewer employee.Reviewer) (*Correction, error)
+	RejectCorrection(ctx context.Context, correctionID, reason string, reviewer employee.Reviewer) (*Correction, error)
+	// CancelCorrection membatalkan koreksi pending milik userID sendiri.
+	CancelCorrection(ctx context.Context, correctionID, userID string) (*Correction, error)
+}
+
+// HolidayCalendar melaporkan apakah sebuah tanggal adalah hari libur bagi
+// karyawan sesuai lokasinya.
+type HolidayCalendar interface {
+	IsHolidayFor(ctx context.Context, userID string, date time.Time) (bool, error)
+}
+
+// TimeZones mengembalikan zona waktu karyawan. "Hari ini" dan akhir pekan
+// dihitung pada zona tersebut.
+type TimeZones interface {
+	TimeZoneFor(ctx context.Context, userID string) (*time.Location, error)
 }
 
+// ErrHoliday dikembalikan jika absensi diajukan pada hari libur.
+var ErrHoliday = errors.New("cannot submit attendance on a holiday")
+
+// ErrNotScheduled dikembalikan jika roster menjadwalkan karyawan libur.
+var ErrNotScheduled = errors.New("not scheduled to work on this day")
+
+var (
+	// ErrAlreadyClockedIn dikembalikan saat clock-in sebelum clock-in
+	// sebelumnya ditutup.
+	ErrAlreadyClockedIn = errors.New("already clocked in")
+	// ErrNotClockedIn dikembalikan saat clock-out tanpa clock-in yang terbuka
+	// hari ini.
+	ErrNotClockedIn = errors.New("not clocked in today")
+)
+
+var (
+	// ErrNotPastDate dikembalikan saat mengajukan koreksi untuk hari ini atau
+	// tanggal yang akan datang.
+	ErrNotPastDate = errors.New("corrections are only for past dates; use clock-in for today")
+	// ErrAlreadyRecorded dikembalikan jika tanggal koreksi sudah memiliki
+	// absensi.
+	ErrAlreadyRecorded = errors.New("attendance already recorded for this date")
+	// ErrCorrectionPending dikembalikan jika karyawan masih memiliki koreksi
+	// pending untuk tanggal yang sama.
+	ErrCorrectionPending = errors.New("a correction for this date is already pending")
+)
+
 type service struct {
-	repo Repository
+	repo      Repository
+	employees employee.Repository
+	lock      periodlock.Guard
+	holidays  HolidayCalendar
+	zones     TimeZones
+	schedules shift.Schedules
+	now       func() time.Time
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo}
+// Option mengubah konfigurasi opsional dari service attendance.
+type Option func(*service)
+
+// WithPeriodLock menolak absensi, clock-in/clock-out, dan koreksi absensi
+// pada tanggal yang periode payroll-nya sudah ditutup.
+func WithPeriodLock(lock periodlock.Checker) Option {
+	return func(s *service) {
+		s.lock = periodlock.Guard{Checker: lock}
+	}
 }
 
-func (s *service) SubmitAttendance(ctx context.Context, userID string) error {
-	today := time.Now()
+// WithHolidayCalendar menolak absensi pada hari libur nasional, cuti bersama,
+// dan libur perusahaan yang berlaku bagi karyawan.
+func WithHolidayCalendar(c HolidayCalendar) Option {
+	return func(s *service) {
+		s.holidays = c
+	}
+}
+
+// WithTimeZones menghitung tanggal absensi pada zona waktu masing-masing
+// karyawan. Tanpa opsi ini dipakai zona waktu dari WithClock.
+func WithTimeZones(z TimeZones) Option {
+	return func(s *service) {
+		s.zones = z
+	}
+}
+
+// WithSchedules menentukan hari kerja dan jam shift dari roster karyawan.
+// Pada tanggal yang diroster, roster menggantikan pola Senin-Jumat dan
+// kalender hari libur.
+func WithSchedules(schedules shift.Schedules) Option {
+	return func(s *service) {
+		s.schedules = schedules
+	}
+}
+
+// WithClock mengganti sumber waktu saat ini. Default: time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(s *service) {
+		s.now = now
+	}
+}
+
+func NewService(repo Repository, employees employee.Repository, opts ...Option) Service {
+	s := &service{repo: repo, employees: employees, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// nowFor mengembalikan waktu saat ini pada zona waktu karyawan.
+func (s *service) nowFor(ctx context.Context, userID string) (time.Time, error) {
+	now := s.now()
+	if s.zones == nil {
+		return now, nil
+	}
+	loc, err := s.zones.TimeZoneFor(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return now.In(loc), nil
+}
+
+// civilDate mengembalikan tanggal kalender t pada zonanya sendiri sebagai
+// tengah malam UTC, sehingga kolom date menyimpan tanggal yang sama apa pun
+// zona waktu sesi database.
+func civilDate(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// scheduleFor mengembalikan jadwal karyawan pada tanggal kalender date.
+func (s *service) scheduleFor(ctx context.Context, userID string, date time.Time) (shift.Schedule, error) {
+	if s.schedules == nil {
+		return shift.Schedule{Date: civilDate(date)}, nil
+	}
+	return s.schedules.ScheduleFor(ctx, userID, date)
+}
+
+// checkWorkday memastikan karyawan dijadwalkan bekerja pada today: menurut
+// roster jika ada, selain itu bukan akhir pekan maupun hari libur karyawan.
+func (s *service) checkWorkday(ctx context.Context, userID string, today time.Time, schedule shift.Schedule) error {
+	if schedule.Rostered {
+		if !schedule.Working() {
+			return ErrNotScheduled
+		}
+		return nil
+	}
 
 	// Users cannot submit on weekends
 	if today.Weekday() == time.Saturday || today.Weekday() == time.Sunday {
 		return errors.New("cannot submit attendance on a weekend")
 	}
 
+	if s.holidays != nil {
+		isHoliday, err := s.holidays.IsHolidayFor(ctx, userID, today)
+		if err != nil {
+			return err
+		}
+		if isHoliday {
+			return ErrHoliday
+		}
+	}
+	return nil
+}
+
+func (s *service) SubmitAttendance(ctx context.Context, userID string) error {
+	today, err := s.nowFor(ctx, userID)
+	if err != nil {
+		return err
+	}
+	schedule, err := s.scheduleFor(ctx, userID, today)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkWorkday(ctx, userID, today, schedule); err != nil {
+		return err
+	}
+
+	if err := s.lock.Check(ctx, today); err != nil {
+		return err
+	}
+
 	// Submissions on the same day should count as one
 	dateStr := today.Format("2006-01-02")
 	hasSubmitted, err := s.repo.HasAttendanceOnDate(ctx, userID, dateStr)
@@ -38,10 +227,315 @@ func (s *service) SubmitAttendance(ctx context.Context, userID string) error {
 
 	attendance := &Attendance{
 		UserID:    userID,
-		Date:      today,
+		Date:      civilDate(today),
 		CreatedBy: userID,
 		UpdatedBy: userID,
 	}
 
 	return s.repo.CreateAttendance(ctx, attendance)
 }
+
+func (s *service) ClockIn(ctx context.Context, userID string) (*Attendance, error) {
+	now, err := s.nowFor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := s.scheduleFor(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkWorkday(ctx, userID, now, schedule); err != nil {
+		return nil, err
+	}
+	return s.record(ctx, userID, EventClockIn, now, now, schedule)
+}
+
+func (s *service) ClockOut(ctx context.Context, userID string) (*Attendance, error) {
+	now, err := s.nowFor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	day, err := s.clockOutDay(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	return s.record(ctx, userID, EventClockOut, now, day, shift.Schedule{})
+}
+
+// clockOutDay mengembalikan tanggal absensi yang ditutup oleh clock-out pada
+// now: kemarin jika shift malam kemarin yang melewati tengah malam masih
+// terbuka, selain itu hari ini.
+func (s *service) clockOutDay(ctx context.Context, userID string, now time.Time) (time.Time, error) {
+	if s.schedules == nil {
+		return now, nil
+	}
+	yesterday := now.AddDate(0, 0, -1)
+	schedule, err := s.schedules.ScheduleFor(ctx, userID, yesterday)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !schedule.Working() || !schedule.Shift.CrossesMidnight() {
+		return now, nil
+	}
+	attendance, err := s.repo.GetAttendanceOnDate(ctx, userID, yesterday.Format("2006-01-02"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return now, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	if ClockedIn(attendance.Events) {
+		return yesterday, nil
+	}
+	return now, nil
+}
+
+// record menyimpan kejadian absensi pada saat now ke absensi tanggal day dan
+// memperbarui ringkasannya dalam satu transaksi. schedule adalah jadwal day
+// yang dicatat jika absensi day baru dibuat.
+func (s *service) record(ctx context.Context, userID string, eventType EventType, now, day time.Time, schedule shift.Schedule) (*Attendance, error) {
+	if err := s.lock.Check(ctx, day); err != nil {
+		return nil, err
+	}
+
+	var attendance *Attendance
+	err := s.repo.WithTransaction(ctx, func(repo Repository) error {
+		if err := repo.LockDay(ctx, userID); err != nil {
+			return err
+		}
+		var err error
+		attendance, err = repo.GetAttendanceOnDate(ctx, userID, day.Format("2006-01-02"))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			attendance = &Attendance{UserID: userID, Date: civilDate(day), CreatedBy: userID}
+			if err := attendance.Schedule(schedule, day.Location()); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+
+		clockedIn := ClockedIn(attendance.Events)
+		if eventType == EventClockIn && clockedIn {
+			return ErrAlreadyClockedIn
+		}
+		if eventType == EventClockOut && !clockedIn {
+			return ErrNotClockedIn
+		}
+
+		event := Event{UserID: userID, Type: eventType, At: now, CreatedBy: userID}
+		events := append(attendance.Events, event)
+		attendance.Summarize(events)
+		attendance.UpdatedBy = userID
+		if err := repo.SaveSummary(ctx, attendance); err != nil {
+			return err
+		}
+		event.AttendanceID = attendance.ID
+		if err := repo.CreateEvent(ctx, &event); err != nil {
+			return err
+		}
+		events[len(events)-1] = event
+		attendance.Events = events
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attendance, nil
+}
+
+func (s *service) ListMyAttendance(ctx context.Context, userID string, filter ListFilter) ([]Attendance, int64, error) {
+	filter.UserID = userID
+	attendances, err := s.repo.ListAttendances(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.CountAttendances(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return attendances, total, nil
+}
+
+func (s *service) RequestCorrection(ctx context.Context, userID string, date time.Time, reason string) (*Correction, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, approval.ErrReasonRequired
+	}
+	now, err := s.nowFor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	date = civilDate(date)
+	if !date.Before(civilDate(now)) {
+		return nil, ErrNotPastDate
+	}
+	schedule, err := s.scheduleFor(ctx, userID, date)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkWorkday(ctx, userID, date, schedule); err != nil {
+		return nil, err
+	}
+	if err := s.lock.Check(ctx, date); err != nil {
+		return nil, err
+	}
+
+	correction := &Correction{
+		UserID:    userID,
+		Date:      date,
+		Reason:    reason,
+		Decision:  approval.Decision{Status: approval.StatusPending},
+		CreatedBy: userID,
+		UpdatedBy: userID,
+	}
+	err = s.repo.WithTransaction(ctx, func(repo Repository) error {
+		if err := repo.LockDay(ctx, userID); err != nil {
+			return err
+		}
+		dateStr := date.Format("2006-01-02")
+		recorded, err := repo.HasAttendanceOnDate(ctx, userID, dateStr)
+		if err != nil {
+			return err
+		}
+		if recorded {
+			return ErrAlreadyRecorded
+		}
+		pending, err := repo.HasPendingCorrection(ctx, userID, dateStr)
+		if err != nil {
+			return err
+		}
+		if pending {
+			return ErrCorrectionPending
+		}
+		return repo.CreateCorrection(ctx, correction)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return correction, nil
+}
+
+func (s *service) ListCorrections(ctx context.Context, reviewer employee.Reviewer, filter CorrectionFilter) ([]Correction, error) {
+	managerID, err := approval.ManagerScope(reviewer)
+	if err != nil {
+		return nil, err
+	}
+	filter.ManagerID = managerID
+	return s.repo.ListCorrections(ctx, filter)
+}
+
+func (s *service) ListMyCorrections(ctx context.Context, userID string, filter CorrectionFilter) ([]Correction, int64, error) {
+	filter.UserID = userID
+	filter.ManagerID = ""
+	corrections, err := s.repo.ListCorrections(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.CountCorrections(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return corrections, total, nil
+}
+
+func (s *service) ApproveCorrection(ctx context.Context, correctionID string, reviewer employee.Reviewer) (*Correction, error) {
+	correction, err := s.reviewable(ctx, correctionID, reviewer)
+	if err != nil {
+		return nil, err
+	}
+	// Kehadiran baru hanya dibayar jika payroll periodenya belum dihitung;
+	// periode yang sudah dihitung harus di-reverse lebih dulu.
+	if err := s.lock.CheckCalculated(ctx, correction.Date); err != nil {
+		return nil, err
+	}
+	now, err := s.nowFor(ctx, correction.UserID)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := s.scheduleFor(ctx, correction.UserID, correction.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.WithTransaction(ctx, func(repo Repository) error {
+		if err := repo.LockDay(ctx, correction.UserID); err != nil {
+			return err
+		}
+		recorded, err := repo.HasAttendanceOnDate(ctx, correction.UserID, correction.Date.Format("2006-01-02"))
+		if err != nil {
+			return err
+		}
+		if recorded {
+			return ErrAlreadyRecorded
+		}
+		attendance := &Attendance{
+			UserID:    correction.UserID,
+			Date:      correction.Date,
+			CreatedBy: reviewer.ID,
+			UpdatedBy: reviewer.ID,
+		}
+		if err := attendance.Schedule(schedule, now.Location()); err != nil {
+			return err
+		}
+		if err := repo.CreateAttendance(ctx, attendance); err != nil {
+			return err
+		}
+		correction.AttendanceID = attendance.ID
+		return s.transition(ctx, repo, correction, approval.StatusApproved, reviewer.ID, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return correction, nil
+}
+
+func (s *service) RejectCorrection(ctx context.Context, correctionID, reason string, reviewer employee.Reviewer) (*Correction, error) {
+	reason, err := approval.RejectReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	correction, err := s.reviewable(ctx, correctionID, reviewer)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.transition(ctx, s.repo, correction, approval.StatusRejected, reviewer.ID, reason); err != nil {
+		return nil, err
+	}
+	return correction, nil
+}
+
+// reviewable mengembalikan koreksi setelah memastikan reviewer berwenang atas
+// karyawan pemiliknya.
+func (s *service) reviewable(ctx context.Context, correctionID string, reviewer employee.Reviewer) (*Correction, error) {
+	correction, err := s.repo.GetCorrection(ctx, correctionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := approval.Authorize(ctx, s.employees, reviewer, correction.UserID); err != nil {
+		return nil, err
+	}
+	return correction, nil
+}
+
+func (s *service) CancelCorrection(ctx context.Context, correctionID, userID string) (*Correction, error) {
+	correction, err := s.repo.GetCorrection(ctx, correctionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := approval.CheckOwner(correction.UserID, userID); err != nil {
+		return nil, err
+	}
+	if err := s.transition(ctx, s.repo, correction, approval.StatusCancelled, userID, ""); err != nil {
+		return nil, err
+	}
+	return correction, nil
+}
+
+// transition memutuskan atau membatalkan koreksi dengan pembaruan bersyarat
+// melalui repo, yang dapat berupa repository transaksi.
+func (s *service) transition(ctx context.Context, repo Repository, correction *Correction, next approval.Status, actorID, reason string) error {
+	return correction.Decide(next, actorID, reason, s.now(), func(from approval.Status) (bool, error) {
+		correction.UpdatedBy = actorID
+		return repo.UpdateCorrectionStatus(ctx, correction, from)
+	})
+}
@@ -5,15 +5,48 @@ import (
 	"testing"
 	"time"
 
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/auth"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/periodlock"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/shift"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
-// MockSubmissionRepository adalah implementasi mock untuk submission.Repository
+// MockAttendanceRepository adalah implementasi mock untuk attendance.Repository
 type MockAttendanceRepository struct {
 	mock.Mock
 }
 
+func (m *MockAttendanceRepository) WithTransaction(ctx context.Context, fn func(repo Repository) error) error {
+	return fn(m)
+}
+
+func (m *MockAttendanceRepository) LockDay(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockAttendanceRepository) GetAttendanceOnDate(ctx context.Context, userID string, date string) (*Attendance, error) {
+	args := m.Called(ctx, userID, date)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Attendance), args.Error(1)
+}
+
+func (m *MockAttendanceRepository) SaveSummary(ctx context.Context, attendance *Attendance) error {
+	args := m.Called(ctx, attendance)
+	return args.Error(0)
+}
+
+func (m *MockAttendanceRepository) CreateEvent(ctx context.Context, event *Event) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
 func (m *MockAttendanceRepository) CreateAttendance(ctx context.Context, attendance *Attendance) error {
 	args := m.Called(ctx, attendance)
 	return args.Error(0)
@@ -24,19 +57,86 @@ func (m *MockAttendanceRepository) HasAttendanceOnDate(ctx context.Context, user
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockAttendanceRepository) ListAttendances(ctx context.Context, filter ListFilter) ([]Attendance, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]Attendance), args.Error(1)
+}
+
+func (m *MockAttendanceRepository) CountAttendances(ctx context.Context, filter ListFilter) (int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAttendanceRepository) CreateCorrection(ctx context.Context, correction *Correction) error {
+	args := m.Called(ctx, correction)
+	return args.Error(0)
+}
+
+func (m *MockAttendanceRepository) GetCorrection(ctx context.Context, id string) (*Correction, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Correction), args.Error(1)
+}
+
+func (m *MockAttendanceRepository) HasPendingCorrection(ctx context.Context, userID string, date string) (bool, error) {
+	args := m.Called(ctx, userID, date)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAttendanceRepository) ListCorrections(ctx context.Context, filter CorrectionFilter) ([]Correction, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]Correction), args.Error(1)
+}
+
+func (m *MockAttendanceRepository) CountCorrections(ctx context.Context, filter CorrectionFilter) (int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAttendanceRepository) UpdateCorrectionStatus(ctx context.Context, correction *Correction, from approval.Status) (bool, error) {
+	args := m.Called(ctx, correction, from)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockHolidayCalendar adalah implementasi mock untuk attendance.HolidayCalendar
+type MockHolidayCalendar struct {
+	mock.Mock
+}
+
+func (m *MockHolidayCalendar) IsHolidayFor(ctx context.Context, userID string, date time.Time) (bool, error) {
+	args := m.Called(ctx, userID, date)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockTimeZones adalah implementasi mock untuk attendance.TimeZones
+type MockTimeZones struct {
+	mock.Mock
+}
+
+func (m *MockTimeZones) TimeZoneFor(ctx context.Context, userID string) (*time.Location, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(*time.Location), args.Error(1)
+}
+
+// weekday adalah Rabu pagi, sehingga tes tidak bergantung pada hari dijalankan.
+var weekday = time.Date(2025, 9, 10, 8, 0, 0, 0, time.UTC)
+
+func fixedClock(t time.Time) Option {
+	return WithClock(func() time.Time { return t })
+}
+
 func TestSubmissionService(t *testing.T) {
 	t.Run("SubmitAttendance - Success", func(t *testing.T) {
 		// Arrange
 		mockRepo := new(MockAttendanceRepository)
-		submissionService := NewService(mockRepo)
+		submissionService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(weekday))
 		ctx := context.Background()
 		userID := "user-123"
 
-		// Asumsikan hari ini bukan weekend untuk tes ini
-		todayStr := time.Now().Format("2006-01-02")
-
-		mockRepo.On("HasAttendanceOnDate", ctx, userID, todayStr).Return(false, nil).Once()
-		mockRepo.On("CreateAttendance", ctx, mock.AnythingOfType("*submission.Attendance")).Return(nil).Once()
+		mockRepo.On("HasAttendanceOnDate", ctx, userID, "2025-09-10").Return(false, nil).Once()
+		mockRepo.On("CreateAttendance", ctx, mock.AnythingOfType("*attendance.Attendance")).Return(nil).Once()
 
 		// Act
 		err := submissionService.SubmitAttendance(ctx, userID)
@@ -49,12 +149,11 @@ func TestSubmissionService(t *testing.T) {
 	t.Run("SubmitAttendance - Fail because already submitted", func(t *testing.T) {
 		// Arrange
 		mockRepo := new(MockAttendanceRepository)
-		submissionService := NewService(mockRepo)
+		submissionService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(weekday))
 		ctx := context.Background()
 		userID := "user-123"
-		todayStr := time.Now().Format("2006-01-02")
 
-		mockRepo.On("HasAttendanceOnDate", ctx, userID, todayStr).Return(true, nil).Once()
+		mockRepo.On("HasAttendanceOnDate", ctx, userID, "2025-09-10").Return(true, nil).Once()
 
 		// Act
 		err := submissionService.SubmitAttendance(ctx, userID)
@@ -65,4 +164,415 @@ func TestSubmissionService(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("SubmitAttendance - Fail because period is closed", func(t *testing.T) {
+		mockRepo := new(MockAttendanceRepository) // mock tidak akan dipanggil
+		mockLock := new(periodlock.MockChecker)
+		submissionService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(weekday), WithPeriodLock(mockLock))
+		ctx := context.Background()
+
+		mockLock.On("IsDateLocked", ctx, weekday).Return(true, nil).Once()
+
+		err := submissionService.SubmitAttendance(ctx, "user-123")
+
+		assert.ErrorIs(t, err, periodlock.ErrLocked)
+		mockLock.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "CreateAttendance", mock.Anything, mock.Anything)
+	})
+
+	t.Run("SubmitAttendance - Fail because it is a holiday", func(t *testing.T) {
+		mockRepo := new(MockAttendanceRepository) // mock tidak akan dipanggil
+		mockHolidays := new(MockHolidayCalendar)
+		submissionService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(weekday), WithHolidayCalendar(mockHolidays))
+		ctx := context.Background()
+
+		mockHolidays.On("IsHolidayFor", ctx, "user-123", weekday).Return(true, nil).Once()
+
+		err := submissionService.SubmitAttendance(ctx, "user-123")
+
+		assert.ErrorIs(t, err, ErrHoliday)
+		mockHolidays.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "HasAttendanceOnDate", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAttendanceTimeZones(t *testing.T) {
+	ctx := context.Background()
+	wit := time.FixedZone("WIT", 9*3600)
+
+	t.Run("SubmitAttendance - Today is the employee's calendar date", func(t *testing.T) {
+		mockRepo := new(MockAttendanceRepository)
+		mockZones := new(MockTimeZones)
+		// Selasa 20.00 UTC sudah Rabu 05.00 WIT.
+		attendanceService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(time.Date(2025, 9, 9, 20, 0, 0, 0, time.UTC)), WithTimeZones(mockZones))
+
+		mockZones.On("TimeZoneFor", ctx, "user-123").Return(wit, nil).Once()
+		mockRepo.On("HasAttendanceOnDate", ctx, "user-123", "2025-09-10").Return(false, nil).Once()
+		mockRepo.On("CreateAttendance", ctx, mock.MatchedBy(func(a *Attendance) bool {
+			return a.Date.Equal(time.Date(2025, 9, 10, 0, 0, 0, 0, time.UTC))
+		})).Return(nil).Once()
+
+		err := attendanceService.SubmitAttendance(ctx, "user-123")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("ClockIn - Weekend in the employee's time zone", func(t *testing.T) {
+		mockRepo := new(MockAttendanceRepository) // mock tidak akan dipanggil
+		mockZones := new(MockTimeZones)
+		// Jumat 18.00 UTC sudah Sabtu 03.00 WIT.
+		attendanceService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(time.Date(2025, 9, 12, 18, 0, 0, 0, time.UTC)), WithTimeZones(mockZones))
+
+		mockZones.On("TimeZoneFor", ctx, "user-123").Return(wit, nil).Once()
+
+		_, err := attendanceService.ClockIn(ctx, "user-123")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "weekend")
+		mockRepo.AssertNotCalled(t, "LockDay", mock.Anything, mock.Anything)
+	})
+}
+
+func TestListMyAttendance(t *testing.T) {
+	t.Run("ListMyAttendance - Only the employee's own records", func(t *testing.T) {
+		mockRepo := new(MockAttendanceRepository)
+		attendanceService := NewService(mockRepo, new(auth.MockEmployeeRepository))
+		ctx := context.Background()
+		start := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+
+		// user_id dari query tidak boleh menampilkan absensi karyawan lain.
+		expected := ListFilter{UserID: "user-123", StartDate: &start, Limit: 20, Offset: 20}
+		mockRepo.On("ListAttendances", ctx, expected).Return([]Attendance{{ID: "att-21", UserID: "user-123", Date: weekday}}, nil).Once()
+		mockRepo.On("CountAttendances", ctx, expected).Return(int64(21), nil).Once()
+
+		items, total, err := attendanceService.ListMyAttendance(ctx, "user-123", ListFilter{UserID: "user-999", StartDate: &start, Limit: 20, Offset: 20})
+
+		assert.NoError(t, err)
+		assert.Len(t, items, 1)
+		assert.Equal(t, int64(21), total)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestClockInOut(t *testing.T) {
+	ctx := context.Background()
+	at := func(hour, minute int) time.Time {
+		return time.Date(2025, 9, 10, hour, minute, 0, 0, time.UTC)
+	}
+
+	t.Run("ClockIn - First clock-in records the day", func(t *testing.T) {
+		mockRepo := new(MockAttendanceRepository)
+		attendanceService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(at(8, 5)))
+
+		mockRepo.On("LockDay", ctx, "user-123").Return(nil).Once()
+		mockRepo.On("GetAttendanceOnDate", ctx, "user-123", "2025-09-10").Return(nil, gorm.ErrRecordNotFound).Once()
+		mockRepo.On("SaveSummary", ctx, mock.MatchedBy(func(a *Attendance) bool {
+			return a.ID == "" && a.FirstIn.Equal(at(8, 5)) && a.LastOut == nil && a.CreatedBy == "user-123"
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*Attendance).ID = "att-1"
+		}).Return(nil).Once()
+		mockRepo.On("CreateEvent", ctx, &Event{AttendanceID: "att-1", UserID: "user-123", Type: EventClockIn, At: at(8, 5), CreatedBy: "user-123"}).Return(nil).Once()
+
+		attendance, err := attendanceService.ClockIn(ctx, "user-123")
+
+		assert.NoError(t, err)
+		assert.Len(t, attendance.Events, 1)
+		assert.Equal(t, 0, attendance.WorkedMinutes)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("ClockOut - Worked hours exclude breaks", func(t *testing.T) {
+		mockRepo := new(MockAttendanceRepository)
+		attendanceService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(at(17, 30)))
+
+		existing := &Attendance{ID: "att-1", UserID: "user-123", Date: at(8, 5), Events: []Event{
+			{Type: EventClockIn, At: at(8, 0)},
+			{Type: EventClockOut, At: at(12, 0)},
+			{Type: EventClockIn, At: at(13, 0)},
+		}}
+		mockRepo.On("LockDay", ctx, "user-123").Return(nil).Once()
+		mockRepo.On("GetAttendanceOnDate", ctx, "user-123", "2025-09-10").Return(existing, nil).Once()
+		mockRepo.On("SaveSummary", ctx, existing).Return(nil).Once()
+		mockRepo.On("CreateEvent", ctx, mock.AnythingOfType("*attendance.Event")).Return(nil).Once()
+
+		attendance, err := attendanceService.ClockOut(ctx, "user-123")
+
+		assert.NoError(t, err)
+		assert.Equal(t, at(8, 0), *attendance.FirstIn)
+		assert.Equal(t, at(17, 30), *attendance.LastOut)
+		assert.Equal(t, 4*60+4*60+30, attendance.WorkedMinutes)
+		assert.Len(t, attendance.Events, 4)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("ClockIn and ClockOut - Must alternate", func(t *testing.T) {
+		mockRepo := new(MockAttendanceRepository)
+		attendanceService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(at(9, 0)))
+
+		open := &Attendance{ID: "att-1", Events: []Event{{Type: EventClockIn, At: at(8, 0)}}}
+		mockRepo.On("LockDay", ctx, mock.Anything).Return(nil)
+		mockRepo.On("GetAttendanceOnDate", ctx, "user-123", "2025-09-10").Return(open, nil).Once()
+		mockRepo.On("GetAttendanceOnDate", ctx, "user-456", "2025-09-10").Return(nil, gorm.ErrRecordNotFound).Once()
+
+		_, err := attendanceService.ClockIn(ctx, "user-123")
+		assert.ErrorIs(t, err, ErrAlreadyClockedIn)
+
+		_, err = attendanceService.ClockOut(ctx, "user-456")
+		assert.ErrorIs(t, err, ErrNotClockedIn)
+		mockRepo.AssertNotCalled(t, "CreateEvent", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ClockIn - Not on a weekend", func(t *testing.T) {
+		mockRepo := new(MockAttendanceRepository) // mock tidak akan dipanggil
+		attendanceService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(time.Date(2025, 9, 13, 8, 0, 0, 0, time.UTC)))
+
+		_, err := attendanceService.ClockIn(ctx, "user-123")
+
+		assert.Error(t, err)
+		mockRepo.AssertNotCalled(t, "LockDay", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAttendanceRoster(t *testing.T) {
+	ctx := context.Background()
+	morning := &shift.Shift{ID: "shift-001", Code: "MORNING", StartTime: "08:00", EndTime: "17:00"}
+	night := &shift.Shift{ID: "shift-002", Code: "NIGHT", StartTime: "22:00", EndTime: "06:00"}
+
+	t.Run("ClockIn - Rostered weekend shift records lateness", func(t *testing.T) {
+		mockRepo := new(MockAttendanceRepository)
+		mockSchedules := new(shift.MockSchedules)
+		saturday := time.Date(2025, 9, 13, 8, 10, 0, 0, time.UTC)
+		attendanceService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(saturday), WithSchedules(mockSchedules))
+
+		mockSchedules.On("ScheduleFor", ctx, "user-123", saturday).Return(shift.Schedule{Rostered: true, Shift: morning}, nil).Once()
+		mockRepo.On("LockDay", ctx, "user-123").Return(nil).Once()
+		mockRepo.On("GetAttendanceOnDate", ctx, "user-123", "2025-09-13").Return(nil, gorm.ErrRecordNotFound).Once()
+		mockRepo.On("SaveSummary", ctx, mock.AnythingOfType("*attendance.Attendance")).Return(nil).Once()
+		mockRepo.On("CreateEvent", ctx, mock.AnythingOfType("*attendance.Event")).Return(nil).Once()
+
+		attendance, err := attendanceService.ClockIn(ctx, "user-123")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "shift-001", attendance.ShiftID)
+		assert.Equal(t, time.Date(2025, 9, 13, 17, 0, 0, 0, time.UTC), *attendance.ScheduledEnd)
+		assert.Equal(t, 10, attendance.LateMinutes)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("SubmitAttendance - Rejected on a rostered day off", func(t *testing.T) {
+		mockRepo := new(MockAttendanceRepository) // mock tidak akan dipanggil
+		mockSchedules := new(shift.MockSchedules)
+		attendanceService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(weekday), WithSchedules(mockSchedules))
+
+		mockSchedules.On("ScheduleFor", ctx, "user-123", weekday).Return(shift.Schedule{Rostered: true}, nil).Once()
+
+		err := attendanceService.SubmitAttendance(ctx, "user-123")
+
+		assert.ErrorIs(t, err, ErrNotScheduled)
+		mockRepo.AssertNotCalled(t, "HasAttendanceOnDate", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ClockOut - Night shift closes the previous day", func(t *testing.T) {
+		mockRepo := new(MockAttendanceRepository)
+		mockSchedules := new(shift.MockSchedules)
+		now := time.Date(2025, 10, 1, 5, 30, 0, 0, time.UTC)
+		attendanceService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(now), WithSchedules(mockSchedules))
+
+		start := time.Date(2025, 9, 30, 22, 0, 0, 0, time.UTC)
+		end := time.Date(2025, 10, 1, 6, 0, 0, 0, time.UTC)
+		open := &Attendance{ID: "att-1", UserID: "user-123", Date: time.Date(2025, 9, 30, 0, 0, 0, 0, time.UTC),
+			ShiftID: "shift-002", ScheduledStart: &start, ScheduledEnd: &end,
+			Events: []Event{{Type: EventClockIn, At: start}}}
+		mockSchedules.On("ScheduleFor", ctx, "user-123", now.AddDate(0, 0, -1)).Return(shift.Schedule{Rostered: true, Shift: night}, nil).Once()
+		mockRepo.On("GetAttendanceOnDate", ctx, "user-123", "2025-09-30").Return(open, nil).Twice()
+		mockRepo.On("LockDay", ctx, "user-123").Return(nil).Once()
+		mockRepo.On("SaveSummary", ctx, open).Return(nil).Once()
+		mockRepo.On("CreateEvent", ctx, mock.AnythingOfType("*attendance.Event")).Return(nil).Once()
+
+		attendance, err := attendanceService.ClockOut(ctx, "user-123")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 7*60+30, attendance.WorkedMinutes)
+		assert.Equal(t, 30, attendance.EarlyLeaveMinutes)
+		assert.Equal(t, 0, attendance.LateMinutes)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestAttendanceCorrection(t *testing.T) {
+	ctx := context.Background()
+	tuesday := time.Date(2025, 9, 9, 0, 0, 0, 0, time.UTC)
+	pending := func() *Correction {
+		return &Correction{ID: "corr-001", UserID: "user-123", Date: tuesday, Reason: "Lupa absen", Decision: approval.Decision{Status: approval.StatusPending}}
+	}
+	report := &employee.Employee{ID: "user-123", Role: "employee", ManagerID: "manager-001"}
+	manager := employee.Reviewer{ID: "manager-001", Role: "manager"}
+
+	t.Run("RequestCorrection - Created as pending", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(MockAttendanceRepository)
+		mockLock := new(periodlock.MockChecker)
+		attendanceService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(weekday), WithPeriodLock(mockLock))
+
+		mockLock.On("IsDateLocked", ctx, tuesday).Return(false, nil).Once()
+		mockRepo.On("LockDay", ctx, "user-123").Return(nil).Once()
+		mockRepo.On("HasAttendanceOnDate", ctx, "user-123", "2025-09-09").Return(false, nil).Once()
+		mockRepo.On("HasPendingCorrection", ctx, "user-123", "2025-09-09").Return(false, nil).Once()
+		mockRepo.On("CreateCorrection", ctx, mock.MatchedBy(func(c *Correction) bool {
+			return c.Status == approval.StatusPending && c.Date.Equal(tuesday) && c.Reason == "Lupa absen" && c.CreatedBy == "user-123"
+		})).Return(nil).Once()
+
+		// Act
+		_, err := attendanceService.RequestCorrection(ctx, "user-123", tuesday, " Lupa absen ")
+
+		// Assert
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockLock.AssertExpectations(t)
+	})
+
+	t.Run("RequestCorrection - Rejects invalid dates", func(t *testing.T) {
+		mockRepo := new(MockAttendanceRepository)
+		mockLock := new(periodlock.MockChecker)
+		attendanceService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(weekday), WithPeriodLock(mockLock))
+
+		_, err := attendanceService.RequestCorrection(ctx, "user-123", tuesday, "  ")
+		assert.ErrorIs(t, err, approval.ErrReasonRequired)
+
+		_, err = attendanceService.RequestCorrection(ctx, "user-123", weekday, "Lupa absen")
+		assert.ErrorIs(t, err, ErrNotPastDate)
+
+		_, err = attendanceService.RequestCorrection(ctx, "user-123", time.Date(2025, 9, 7, 0, 0, 0, 0, time.UTC), "Lupa absen")
+		assert.EqualError(t, err, "cannot submit attendance on a weekend")
+
+		mockLock.On("IsDateLocked", ctx, tuesday).Return(true, nil).Once()
+		_, err = attendanceService.RequestCorrection(ctx, "user-123", tuesday, "Lupa absen")
+		assert.ErrorIs(t, err, periodlock.ErrLocked)
+		mockRepo.AssertNotCalled(t, "CreateCorrection", mock.Anything, mock.Anything)
+	})
+
+	t.Run("RequestCorrection - Not for recorded or already requested dates", func(t *testing.T) {
+		mockRepo := new(MockAttendanceRepository)
+		attendanceService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(weekday))
+
+		mockRepo.On("LockDay", ctx, mock.Anything).Return(nil)
+		mockRepo.On("HasAttendanceOnDate", ctx, "user-123", "2025-09-09").Return(true, nil).Once()
+		mockRepo.On("HasAttendanceOnDate", ctx, "user-456", "2025-09-09").Return(false, nil).Once()
+		mockRepo.On("HasPendingCorrection", ctx, "user-456", "2025-09-09").Return(true, nil).Once()
+
+		_, err := attendanceService.RequestCorrection(ctx, "user-123", tuesday, "Lupa absen")
+		assert.ErrorIs(t, err, ErrAlreadyRecorded)
+
+		_, err = attendanceService.RequestCorrection(ctx, "user-456", tuesday, "Lupa absen")
+		assert.ErrorIs(t, err, ErrCorrectionPending)
+		mockRepo.AssertNotCalled(t, "CreateCorrection", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ApproveCorrection - Attendance is created by the approver", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(MockAttendanceRepository)
+		mockEmployees := new(auth.MockEmployeeRepository)
+		mockLock := new(periodlock.MockChecker)
+		attendanceService := NewService(mockRepo, mockEmployees, fixedClock(weekday), WithPeriodLock(mockLock))
+
+		mockRepo.On("GetCorrection", ctx, "corr-001").Return(pending(), nil).Once()
+		mockEmployees.On("GetByID", ctx, "user-123").Return(report, nil).Once()
+		mockLock.On("IsDateLocked", ctx, tuesday).Return(false, nil).Once()
+		mockLock.On("IsDateCalculated", ctx, tuesday).Return(false, nil).Once()
+		mockRepo.On("LockDay", ctx, "user-123").Return(nil).Once()
+		mockRepo.On("HasAttendanceOnDate", ctx, "user-123", "2025-09-09").Return(false, nil).Once()
+		mockRepo.On("CreateAttendance", ctx, mock.MatchedBy(func(a *Attendance) bool {
+			return a.UserID == "user-123" && a.Date.Equal(tuesday) && a.CreatedBy == "manager-001" && a.UpdatedBy == "manager-001"
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*Attendance).ID = "att-001"
+		}).Return(nil).Once()
+		mockRepo.On("UpdateCorrectionStatus", ctx, mock.MatchedBy(func(c *Correction) bool {
+			return c.Status == approval.StatusApproved && c.AttendanceID == "att-001" && c.ReviewedBy == "manager-001"
+		}), approval.StatusPending).Return(true, nil).Once()
+
+		// Act
+		correction, err := attendanceService.ApproveCorrection(ctx, "corr-001", manager)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, weekday, *correction.ReviewedAt)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("ApproveCorrection - Manager of another team is rejected", func(t *testing.T) {
+		mockRepo := new(MockAttendanceRepository)
+		mockEmployees := new(auth.MockEmployeeRepository)
+		attendanceService := NewService(mockRepo, mockEmployees, fixedClock(weekday))
+
+		mockRepo.On("GetCorrection", ctx, "corr-001").Return(pending(), nil).Once()
+		mockEmployees.On("GetByID", ctx, "user-123").Return(report, nil).Once()
+
+		_, err := attendanceService.ApproveCorrection(ctx, "corr-001", employee.Reviewer{ID: "manager-002", Role: "manager"})
+
+		assert.ErrorIs(t, err, approval.ErrNotReviewer)
+		mockRepo.AssertNotCalled(t, "CreateAttendance", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ApproveCorrection - Date inside a closed period", func(t *testing.T) {
+		mockRepo := new(MockAttendanceRepository)
+		mockEmployees := new(auth.MockEmployeeRepository)
+		mockLock := new(periodlock.MockChecker)
+		attendanceService := NewService(mockRepo, mockEmployees, fixedClock(weekday), WithPeriodLock(mockLock))
+
+		mockRepo.On("GetCorrection", ctx, "corr-001").Return(pending(), nil).Once()
+		mockEmployees.On("GetByID", ctx, "user-123").Return(report, nil).Once()
+		mockLock.On("IsDateLocked", ctx, tuesday).Return(true, nil).Once()
+
+		_, err := attendanceService.ApproveCorrection(ctx, "corr-001", employee.Reviewer{ID: "admin-001", Role: "admin"})
+
+		assert.ErrorIs(t, err, periodlock.ErrLocked)
+		mockRepo.AssertNotCalled(t, "CreateAttendance", mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "UpdateCorrectionStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ApproveCorrection - Date inside a calculated or paid period", func(t *testing.T) {
+		mockRepo := new(MockAttendanceRepository)
+		mockEmployees := new(auth.MockEmployeeRepository)
+		mockLock := new(periodlock.MockChecker)
+		attendanceService := NewService(mockRepo, mockEmployees, fixedClock(weekday), WithPeriodLock(mockLock))
+
+		mockRepo.On("GetCorrection", ctx, "corr-001").Return(pending(), nil).Once()
+		mockEmployees.On("GetByID", ctx, "user-123").Return(report, nil).Once()
+		mockLock.On("IsDateLocked", ctx, tuesday).Return(false, nil).Once()
+		mockLock.On("IsDateCalculated", ctx, tuesday).Return(true, nil).Once()
+
+		_, err := attendanceService.ApproveCorrection(ctx, "corr-001", employee.Reviewer{ID: "admin-001", Role: "admin"})
+
+		assert.ErrorIs(t, err, periodlock.ErrCalculated)
+		mockRepo.AssertNotCalled(t, "CreateAttendance", mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "UpdateCorrectionStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("RejectCorrection - Reason is required", func(t *testing.T) {
+		mockRepo := new(MockAttendanceRepository) // mock tidak akan dipanggil
+		attendanceService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(weekday))
+
+		_, err := attendanceService.RejectCorrection(ctx, "corr-001", "", manager)
+
+		assert.ErrorIs(t, err, approval.ErrReasonRequired)
+		mockRepo.AssertNotCalled(t, "GetCorrection", mock.Anything, mock.Anything)
+	})
+
+	t.Run("CancelCorrection - Only the owner can cancel", func(t *testing.T) {
+		mockRepo := new(MockAttendanceRepository)
+		attendanceService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(weekday))
+
+		mockRepo.On("GetCorrection", ctx, "corr-001").Return(pending(), nil)
+		mockRepo.On("UpdateCorrectionStatus", ctx, mock.MatchedBy(func(c *Correction) bool {
+			return c.Status == approval.StatusCancelled && c.UpdatedBy == "user-123" && c.ReviewedBy == ""
+		}), approval.StatusPending).Return(true, nil).Once()
+
+		_, err := attendanceService.CancelCorrection(ctx, "corr-001", "user-456")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		correction, err := attendanceService.CancelCorrection(ctx, "corr-001", "user-123")
+		assert.NoError(t, err)
+		assert.Equal(t, approval.StatusCancelled, correction.Status)
+		mockRepo.AssertExpectations(t)
+	})
 }
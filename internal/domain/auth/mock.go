@@ -37,6 +37,52 @@ func (m *MockEmployeeRepository) GetAllEmployees(ctx context.Context) ([]employe
 	return args.Get(0).([]employee.Employee), args.Error(1)
 }
 
+func (m *MockEmployeeRepository) GetByID(ctx context.Context, id string) (*employee.Employee, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*employee.Employee), args.Error(1)
+}
+
+func (m *MockEmployeeRepository) GetByIDs(ctx context.Context, ids []string) ([]employee.Employee, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]employee.Employee), args.Error(1)
+}
+
+func (m *MockEmployeeRepository) UpdateLocation(ctx context.Context, id, location, updatedBy string) error {
+	args := m.Called(ctx, id, location, updatedBy)
+	return args.Error(0)
+}
+
+func (m *MockEmployeeRepository) UpdateGrade(ctx context.Context, id, grade, updatedBy string) error {
+	args := m.Called(ctx, id, grade, updatedBy)
+	return args.Error(0)
+}
+
+func (m *MockEmployeeRepository) UpdateTaxStatus(ctx context.Context, id, taxStatus, updatedBy string) error {
+	args := m.Called(ctx, id, taxStatus, updatedBy)
+	return args.Error(0)
+}
+
+func (m *MockEmployeeRepository) UpdateTimeZone(ctx context.Context, id, timeZone, updatedBy string) error {
+	args := m.Called(ctx, id, timeZone, updatedBy)
+	return args.Error(0)
+}
+
+func (m *MockEmployeeRepository) UpdatePayGroup(ctx context.Context, id, payGroup, updatedBy string) error {
+	args := m.Called(ctx, id, payGroup, updatedBy)
+	return args.Error(0)
+}
+
+func (m *MockEmployeeRepository) UpdateManager(ctx context.Context, id, managerID, updatedBy string) error {
+	args := m.Called(ctx, id, managerID, updatedBy)
+	return args.Error(0)
+}
+
 func TestAuthService(t *testing.T) {
 	mockEmployeeRepo := new(MockEmployeeRepository)
 	authService := NewService(mockEmployeeRepo, "test-secret")
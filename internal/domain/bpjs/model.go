@@ -0,0 +1,70 @@
+package bpjs
+
+import "github.com/dzakaeryan20/dealls-hris/pkg/money"
+
+// Program adalah program jaminan sosial BPJS.
+type Program string
+
+const (
+	ProgramJKN Program = "JKN" // Jaminan Kesehatan Nasional (BPJS Kesehatan)
+	ProgramJHT Program = "JHT" // Jaminan Hari Tua
+	ProgramJP  Program = "JP"  // Jaminan Pensiun
+	ProgramJKK Program = "JKK" // Jaminan Kecelakaan Kerja
+	ProgramJKM Program = "JKM" // Jaminan Kematian
+)
+
+// Programs adalah urutan program yang dihitung untuk setiap payslip.
+var Programs = []Program{ProgramJKN, ProgramJHT, ProgramJP, ProgramJKK, ProgramJKM}
+
+// TaxableForEmployee melaporkan apakah iuran bagian pemberi kerja untuk
+// program ini merupakan penghasilan kena PPh 21 bagi karyawan
+// (premi JKK, JKM, dan JKN yang dibayar pemberi kerja).
+func (p Program) TaxableForEmployee() bool {
+	return p == ProgramJKN || p == ProgramJKK || p == ProgramJKM
+}
+
+// Pension melaporkan apakah iuran bagian karyawan untuk program ini dapat
+// dikurangkan dari penghasilan bruto (iuran JHT dan JP).
+func (p Program) Pension() bool {
+	return p == ProgramJHT || p == ProgramJP
+}
+
+// Rate adalah tarif iuran dalam basis poin (1% = 100) beserta batas upah.
+type Rate struct {
+	EmployeeBps int64
+	EmployerBps int64
+	// WageCap adalah batas atas upah yang dipakai sebagai dasar iuran.
+	// Nilai nol berarti tanpa batas.
+	WageCap money.Money
+}
+
+// Config menyimpan tarif iuran per program.
+type Config struct {
+	Rates map[Program]Rate
+}
+
+// DefaultConfig mengembalikan tarif yang berlaku saat ini:
+//   - JKN 5% (4% pemberi kerja, 1% karyawan), batas upah Rp12.000.000
+//   - JHT 5,7% (3,7% pemberi kerja, 2% karyawan), tanpa batas upah
+//   - JP 3% (2% pemberi kerja, 1% karyawan), batas upah Rp10.547.400 (2025)
+//   - JKK 0,24% pemberi kerja (kelompok risiko sangat rendah)
+//   - JKM 0,3% pemberi kerja
+func DefaultConfig() Config {
+	return Config{
+		Rates: map[Program]Rate{
+			ProgramJKN: {EmployeeBps: 100, EmployerBps: 400, WageCap: money.FromMajor(12000000, money.IDR)},
+			ProgramJHT: {EmployeeBps: 200, EmployerBps: 370},
+			ProgramJP:  {EmployeeBps: 100, EmployerBps: 200, WageCap: money.FromMajor(10547400, money.IDR)},
+			ProgramJKK: {EmployerBps: 24},
+			ProgramJKM: {EmployerBps: 30},
+		},
+	}
+}
+
+// Contribution adalah hasil perhitungan iuran satu program.
+type Contribution struct {
+	Program        Program
+	Wage           money.Money // Upah dasar setelah batas upah
+	EmployeeAmount money.Money // Dipotong dari gaji karyawan
+	EmployerAmount money.Money // Ditanggung pemberi kerja
+}
@@ -0,0 +1,56 @@
+// Package bpjs menghitung iuran BPJS Kesehatan dan BPJS Ketenagakerjaan
+// bagian karyawan dan bagian pemberi kerja.
+package bpjs
+
+import "github.com/dzakaeryan20/dealls-hris/pkg/money"
+
+// Service mendefinisikan kontrak perhitungan iuran BPJS.
+type Service interface {
+	// Calculate menghitung iuran semua program dari upah bulanan.
+	Calculate(wage money.Money) []Contribution
+	// CalculateShare menghitung iuran untuk num/den bulan, mis. 1/2 untuk
+	// periode semi-bulanan. Setiap iuran dibulatkan satu kali.
+	CalculateShare(wage money.Money, num, den int64) []Contribution
+}
+
+type service struct {
+	cfg Config
+}
+
+// NewService membuat instance baru dari service BPJS.
+func NewService(cfg Config) Service {
+	return &service{cfg}
+}
+
+// contributionRounding: iuran dibulatkan half-up ke Rupiah penuh.
+var contributionRounding = money.Rounding{Mode: money.RoundHalfUp, Scale: 0}
+
+func (s *service) Calculate(wage money.Money) []Contribution {
+	return s.CalculateShare(wage, 1, 1)
+}
+
+func (s *service) CalculateShare(wage money.Money, num, den int64) []Contribution {
+	contributions := make([]Contribution, 0, len(Programs))
+	for _, program := range Programs {
+		rate, ok := s.cfg.Rates[program]
+		if !ok {
+			continue
+		}
+
+		base := wage
+		if base.IsNegative() {
+			base = money.Zero(wage.Currency())
+		}
+		if rate.WageCap.IsPositive() && base.Cmp(rate.WageCap) > 0 {
+			base = rate.WageCap
+		}
+
+		contributions = append(contributions, Contribution{
+			Program:        program,
+			Wage:           base,
+			EmployeeAmount: base.MulDiv(rate.EmployeeBps*num, 10000*den, contributionRounding),
+			EmployerAmount: base.MulDiv(rate.EmployerBps*num, 10000*den, contributionRounding),
+		})
+	}
+	return contributions
+}
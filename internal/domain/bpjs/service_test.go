@@ -0,0 +1,82 @@
+package bpjs
+
+import (
+	"testing"
+
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func idr(v int64) money.Money { return money.FromMajor(v, money.IDR) }
+
+func TestCalculate(t *testing.T) {
+	svc := NewService(DefaultConfig())
+
+	tests := []struct {
+		name     string
+		wage     money.Money
+		employee map[Program]money.Money
+		employer map[Program]money.Money
+	}{
+		{
+			name: "Upah di bawah semua batas",
+			wage: idr(5000000),
+			employee: map[Program]money.Money{
+				ProgramJKN: idr(50000), ProgramJHT: idr(100000), ProgramJP: idr(50000),
+				ProgramJKK: idr(0), ProgramJKM: idr(0),
+			},
+			employer: map[Program]money.Money{
+				ProgramJKN: idr(200000), ProgramJHT: idr(185000), ProgramJP: idr(100000),
+				ProgramJKK: idr(12000), ProgramJKM: idr(15000),
+			},
+		},
+		{
+			name: "Upah di atas batas JKN dan JP",
+			wage: idr(20000000),
+			employee: map[Program]money.Money{
+				ProgramJKN: idr(120000), ProgramJHT: idr(400000), ProgramJP: idr(105474),
+				ProgramJKK: idr(0), ProgramJKM: idr(0),
+			},
+			employer: map[Program]money.Money{
+				ProgramJKN: idr(480000), ProgramJHT: idr(740000), ProgramJP: idr(210948),
+				ProgramJKK: idr(48000), ProgramJKM: idr(60000),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := svc.Calculate(tt.wage)
+			assert.Len(t, got, len(Programs))
+			for _, c := range got {
+				assert.True(t, tt.employee[c.Program].Equal(c.EmployeeAmount), "%s employee: got %s", c.Program, c.EmployeeAmount)
+				assert.True(t, tt.employer[c.Program].Equal(c.EmployerAmount), "%s employer: got %s", c.Program, c.EmployerAmount)
+			}
+		})
+	}
+}
+
+func TestCalculateShare(t *testing.T) {
+	svc := NewService(DefaultConfig())
+
+	tests := []struct {
+		name     string
+		num, den int64
+		jht, jkn money.Money
+	}{
+		{name: "Semi-bulanan", num: 1, den: 2, jht: idr(50000), jkn: idr(25000)},
+		// 100.000 x 12/52 = 23.076,92 dan 50.000 x 12/52 = 11.538,46.
+		{name: "Mingguan", num: 12, den: 52, jht: idr(23077), jkn: idr(11538)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[Program]money.Money{}
+			for _, c := range svc.CalculateShare(idr(5000000), tt.num, tt.den) {
+				got[c.Program] = c.EmployeeAmount
+			}
+			assert.True(t, tt.jht.Equal(got[ProgramJHT]), "JHT: got %s", got[ProgramJHT])
+			assert.True(t, tt.jkn.Equal(got[ProgramJKN]), "JKN: got %s", got[ProgramJKN])
+		})
+	}
+}
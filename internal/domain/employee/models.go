@@ -3,6 +3,7 @@ package employee
 import (
 	"time"
 
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -12,8 +13,14 @@ type Employee struct {
 	ID           string `gorm:"primaryKey"`
 	Username     string `gorm:"uniqueIndex"`
 	PasswordHash string
-	Role         string  // 'admin' or 'employee'
-	BaseSalary   float64 // Only for employees
+	Role         string      // 'admin', 'approver', 'manager', or 'employee'
+	BaseSalary   money.Money // Only for employees
+	TaxStatus    string      `gorm:"size:8;default:'TK/0'"` // PTKP status, e.g. 'TK/0', 'K/1'
+	Location     string      `gorm:"size:64"`               // Lokasi kerja untuk hari libur lokal; kosong = hanya libur umum
+	PayGroup     string      `gorm:"size:32"`               // Kelompok penggajian untuk kebijakan proration; kosong = kebijakan perusahaan
+	ManagerID    string      `gorm:"size:36;index"`         // Atasan langsung yang menyetujui pengajuan karyawan
+	Grade        string      `gorm:"size:32"`               // Golongan untuk batas reimbursement; kosong = batas default
+	TimeZone     string      `gorm:"size:64"`               // Zona waktu IANA, mis. "Asia/Makassar"; kosong = zona waktu perusahaan
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	CreatedBy    string `gorm:"size:36" json:"created_by"`
@@ -25,7 +32,7 @@ func (u *Employee) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
-func NewUser(username, password, role string, salary float64) (*Employee, error) {
+func NewUser(username, password, role string, salary money.Money) (*Employee, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
@@ -37,3 +44,25 @@ func NewUser(username, password, role string, salary float64) (*Employee, error)
 		BaseSalary:   salary,
 	}, nil
 }
+
+// Reviewer adalah pengguna yang memutuskan pengajuan karyawan (mis. lembur).
+type Reviewer struct {
+	ID   string
+	Role string
+}
+
+// CanReview melaporkan apakah reviewer boleh memutuskan pengajuan emp. Admin
+// dapat memutuskan pengajuan siapa pun; manager hanya pengajuan bawahan
+// langsungnya. Tidak ada yang boleh memutuskan pengajuannya sendiri.
+func (r Reviewer) CanReview(emp *Employee) bool {
+	if emp.ID == r.ID {
+		return false
+	}
+	switch r.Role {
+	case "admin":
+		return true
+	case "manager":
+		return emp.ManagerID == r.ID
+	}
+	return false
+}
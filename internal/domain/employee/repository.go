@@ -10,6 +10,23 @@ type Repository interface {
 	Create(ctx context.Context, user *Employee) error
 	GetByUsername(ctx context.Context, username string) (*Employee, error)
 	GetAllEmployees(ctx context.Context) ([]Employee, error)
+	GetByID(ctx context.Context, id string) (*Employee, error)
+	GetByIDs(ctx context.Context, ids []string) ([]Employee, error)
+	// UpdateLocation mengubah lokasi kerja karyawan yang menentukan hari
+	// libur yang berlaku baginya.
+	UpdateLocation(ctx context.Context, id, location, updatedBy string) error
+	// UpdatePayGroup mengubah kelompok penggajian karyawan yang menentukan
+	// kebijakan proration gajinya.
+	UpdatePayGroup(ctx context.Context, id, payGroup, updatedBy string) error
+	// UpdateManager mengubah atasan langsung karyawan.
+	UpdateManager(ctx context.Context, id, managerID, updatedBy string) error
+	// UpdateGrade mengubah golongan karyawan yang menentukan batas
+	// reimbursement-nya.
+	UpdateGrade(ctx context.Context, id, grade, updatedBy string) error
+	// UpdateTaxStatus mengubah status PTKP karyawan.
+	UpdateTaxStatus(ctx context.Context, id, taxStatus, updatedBy string) error
+	// UpdateTimeZone mengubah zona waktu karyawan.
+	UpdateTimeZone(ctx context.Context, id, timeZone, updatedBy string) error
 }
 
 type repository struct {
@@ -39,3 +56,91 @@ func (r *repository) GetAllEmployees(ctx context.Context) ([]Employee, error) {
 	}
 	return users, nil
 }
+
+func (r *repository) GetByIDs(ctx context.Context, ids []string) ([]Employee, error) {
+	var users []Employee
+	if err := r.db.WithContext(ctx).Where("role = ? AND id IN ?", "employee", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id string) (*Employee, error) {
+	var user Employee
+	if err := r.db.WithContext(ctx).First(&user, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *repository) UpdateLocation(ctx context.Context, id, location, updatedBy string) error {
+	res := r.db.WithContext(ctx).Model(&Employee{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"location": location, "updated_by": updatedBy})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *repository) UpdatePayGroup(ctx context.Context, id, payGroup, updatedBy string) error {
+	res := r.db.WithContext(ctx).Model(&Employee{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"pay_group": payGroup, "updated_by": updatedBy})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *repository) UpdateGrade(ctx context.Context, id, grade, updatedBy string) error {
+	res := r.db.WithContext(ctx).Model(&Employee{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"grade": grade, "updated_by": updatedBy})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *repository) UpdateManager(ctx context.Context, id, managerID, updatedBy string) error {
+	res := r.db.WithContext(ctx).Model(&Employee{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"manager_id": managerID, "updated_by": updatedBy})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *repository) UpdateTaxStatus(ctx context.Context, id, taxStatus, updatedBy string) error {
+	res := r.db.WithContext(ctx).Model(&Employee{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"tax_status": taxStatus, "updated_by": updatedBy})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *repository) UpdateTimeZone(ctx context.Context, id, timeZone, updatedBy string) error {
+	res := r.db.WithContext(ctx).Model(&Employee{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"time_zone": timeZone, "updated_by": updatedBy})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
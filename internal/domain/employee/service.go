@@ -2,24 +2,153 @@ package employee
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/tax"
+	"gorm.io/gorm"
 )
 
+// ErrInvalidManager dikembalikan jika atasan yang ditetapkan tidak ada,
+// bukan manager, atau karyawan itu sendiri.
+var ErrInvalidManager = errors.New("manager must be another user with the manager role")
+
+// ErrInvalidTimeZone dikembalikan untuk zona waktu yang bukan nama zona IANA.
+var ErrInvalidTimeZone = errors.New("time zone must be an IANA name such as Asia/Jakarta")
+
+// ErrInvalidTaxStatus dikembalikan untuk status PTKP yang tidak dikenal.
+var ErrInvalidTaxStatus = errors.New("invalid PTKP status")
+
 // In a larger app, this service would handle employee-related business logic,
 // like updating profiles, password resets, etc. For this project, it's
 // minimal as the repository is sufficient for the auth service's needs.
 type Service interface {
 	// Placeholder for future methods
 	Create(ctx context.Context, employee *Employee) error
+	// UpdateLocation mengubah lokasi kerja karyawan. Lokasi kosong berarti
+	// karyawan hanya mengikuti hari libur yang berlaku untuk semua lokasi.
+	UpdateLocation(ctx context.Context, userID, location, adminID string) error
+	// UpdatePayGroup mengubah kelompok penggajian karyawan. Kelompok kosong
+	// berarti karyawan mengikuti kebijakan proration perusahaan.
+	UpdatePayGroup(ctx context.Context, userID, payGroup, adminID string) error
+	// UpdateManager menetapkan atasan langsung yang menyetujui pengajuan
+	// karyawan. managerID kosong menghapus atasan.
+	UpdateManager(ctx context.Context, userID, managerID, adminID string) error
+	// UpdateGrade mengubah golongan karyawan. Golongan kosong berarti
+	// karyawan mengikuti batas reimbursement default.
+	UpdateGrade(ctx context.Context, userID, grade, adminID string) error
+	// UpdateTaxStatus mengubah status PTKP karyawan (mis. TK/0, K/1) yang
+	// menentukan PTKP dan kategori TER PPh 21-nya. Status kosong berarti
+	// tax.DefaultStatus.
+	UpdateTaxStatus(ctx context.Context, userID, taxStatus, adminID string) error
+	// UpdateTimeZone mengubah zona waktu karyawan. Zona kosong berarti
+	// karyawan mengikuti zona waktu perusahaan.
+	UpdateTimeZone(ctx context.Context, userID, timeZone, adminID string) error
+	// TimeZoneFor mengembalikan zona waktu karyawan, atau zona waktu
+	// perusahaan jika karyawan tidak menetapkannya.
+	TimeZoneFor(ctx context.Context, userID string) (*time.Location, error)
 }
 
 type service struct {
-	repo Repository
+	repo        Repository
+	defaultZone *time.Location
+}
+
+// Option mengubah konfigurasi opsional dari service employee.
+type Option func(*service)
+
+// WithDefaultTimeZone menetapkan zona waktu perusahaan bagi karyawan yang
+// tidak memiliki zona waktu sendiri. Default: UTC.
+func WithDefaultTimeZone(loc *time.Location) Option {
+	return func(s *service) {
+		s.defaultZone = loc
+	}
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo}
+func NewService(repo Repository, opts ...Option) Service {
+	s := &service{repo: repo, defaultZone: time.UTC}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *service) Create(ctx context.Context, employee *Employee) error {
 	return s.repo.Create(ctx, employee)
 }
+
+func (s *service) UpdateLocation(ctx context.Context, userID, location, adminID string) error {
+	return s.repo.UpdateLocation(ctx, userID, strings.TrimSpace(location), adminID)
+}
+
+func (s *service) UpdatePayGroup(ctx context.Context, userID, payGroup, adminID string) error {
+	return s.repo.UpdatePayGroup(ctx, userID, strings.TrimSpace(payGroup), adminID)
+}
+
+func (s *service) UpdateGrade(ctx context.Context, userID, grade, adminID string) error {
+	return s.repo.UpdateGrade(ctx, userID, strings.TrimSpace(grade), adminID)
+}
+
+func (s *service) UpdateTaxStatus(ctx context.Context, userID, taxStatus, adminID string) error {
+	status, err := tax.ParseStatus(taxStatus)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTaxStatus, err)
+	}
+	return s.repo.UpdateTaxStatus(ctx, userID, string(status), adminID)
+}
+
+func (s *service) UpdateManager(ctx context.Context, userID, managerID, adminID string) error {
+	managerID = strings.TrimSpace(managerID)
+	if managerID != "" {
+		if managerID == userID {
+			return ErrInvalidManager
+		}
+		manager, err := s.repo.GetByID(ctx, managerID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidManager
+			}
+			return err
+		}
+		if manager.Role != "manager" {
+			return ErrInvalidManager
+		}
+	}
+	return s.repo.UpdateManager(ctx, userID, managerID, adminID)
+}
+
+func (s *service) UpdateTimeZone(ctx context.Context, userID, timeZone, adminID string) error {
+	timeZone = strings.TrimSpace(timeZone)
+	if timeZone != "" {
+		if _, err := LoadTimeZone(timeZone); err != nil {
+			return err
+		}
+	}
+	return s.repo.UpdateTimeZone(ctx, userID, timeZone, adminID)
+}
+
+func (s *service) TimeZoneFor(ctx context.Context, userID string) (*time.Location, error) {
+	emp, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if emp.TimeZone == "" {
+		return s.defaultZone, nil
+	}
+	return LoadTimeZone(emp.TimeZone)
+}
+
+// LoadTimeZone memuat zona waktu IANA. "Local" ditolak karena bergantung pada
+// konfigurasi server.
+func LoadTimeZone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, ErrInvalidTimeZone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimeZone
+	}
+	return loc, nil
+}
@@ -0,0 +1,59 @@
+package employee
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockRepository adalah implementasi mock untuk Repository. Method yang tidak
+// di-override akan panic karena Repository yang di-embed bernilai nil.
+type mockRepository struct {
+	mock.Mock
+	Repository
+}
+
+func (m *mockRepository) UpdateTaxStatus(ctx context.Context, id, taxStatus, updatedBy string) error {
+	args := m.Called(ctx, id, taxStatus, updatedBy)
+	return args.Error(0)
+}
+
+func TestUpdateTaxStatus(t *testing.T) {
+	t.Run("Normalizes a known PTKP status", func(t *testing.T) {
+		repo := new(mockRepository)
+		svc := NewService(repo)
+
+		ctx := context.Background()
+		repo.On("UpdateTaxStatus", ctx, "user-001", "K/1", "admin-001").Return(nil).Once()
+
+		err := svc.UpdateTaxStatus(ctx, "user-001", " k/1 ", "admin-001")
+
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Empty status resets to TK/0", func(t *testing.T) {
+		repo := new(mockRepository)
+		svc := NewService(repo)
+
+		ctx := context.Background()
+		repo.On("UpdateTaxStatus", ctx, "user-001", "TK/0", "admin-001").Return(nil).Once()
+
+		err := svc.UpdateTaxStatus(ctx, "user-001", "", "admin-001")
+
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown status is rejected", func(t *testing.T) {
+		repo := new(mockRepository)
+		svc := NewService(repo)
+
+		err := svc.UpdateTaxStatus(context.Background(), "user-001", "K/4", "admin-001")
+
+		assert.ErrorIs(t, err, ErrInvalidTaxStatus)
+		repo.AssertNotCalled(t, "UpdateTaxStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,62 @@
+package holiday
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Type adalah jenis hari libur.
+type Type string
+
+const (
+	TypeNational    Type = "national"     // Hari libur nasional
+	TypeCutiBersama Type = "cuti_bersama" // Cuti bersama yang ditetapkan pemerintah
+	TypeCompany     Type = "company"      // Libur yang ditetapkan perusahaan
+)
+
+// Holiday adalah satu hari libur. Location kosong berarti berlaku untuk semua
+// lokasi; selain itu hanya berlaku untuk karyawan dengan lokasi yang sama.
+type Holiday struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	Date      time.Time `json:"date" gorm:"type:date;uniqueIndex:idx_holidays_date_location"`
+	Name      string    `json:"name"`
+	Type      Type      `json:"type" gorm:"size:16"`
+	Location  string    `json:"location" gorm:"size:64;uniqueIndex:idx_holidays_date_location"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	CreatedBy string    `gorm:"size:36" json:"created_by"`
+	UpdatedBy string    `gorm:"size:36" json:"updated_by"`
+}
+
+func (h *Holiday) BeforeCreate(tx *gorm.DB) error {
+	h.ID = uuid.New().String()
+	return nil
+}
+
+// IsWorkingDay melaporkan apakah date adalah hari kerja: bukan Sabtu, Minggu,
+// maupun salah satu dari holidays.
+func IsWorkingDay(date time.Time, holidays []Holiday) bool {
+	if wd := date.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return false
+	}
+	day := date.Format("2006-01-02")
+	for _, h := range holidays {
+		if h.Date.Format("2006-01-02") == day {
+			return false
+		}
+	}
+	return true
+}
+
+// WorkingDays menghitung jumlah hari kerja dari start sampai end (inklusif).
+func WorkingDays(start, end time.Time, holidays []Holiday) int {
+	days := 0
+	for current := start; !current.After(end); current = current.AddDate(0, 0, 1) {
+		if IsWorkingDay(current, holidays) {
+			days++
+		}
+	}
+	return days
+}
@@ -0,0 +1,91 @@
+package holiday
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(ctx context.Context, holiday *Holiday) error
+	Get(ctx context.Context, id string) (*Holiday, error)
+	Update(ctx context.Context, holiday *Holiday) error
+	Delete(ctx context.Context, id string) error
+	// List mengembalikan hari libur dalam satu tahun. Jika location tidak
+	// kosong, hanya hari libur untuk lokasi tersebut yang dikembalikan.
+	List(ctx context.Context, year int, location string) ([]Holiday, error)
+	// ReplaceYear mengganti seluruh hari libur satu tahun untuk location
+	// dengan holidays dalam satu transaksi.
+	ReplaceYear(ctx context.Context, year int, location string, holidays []Holiday) error
+	// GetBetween mengembalikan hari libur dari start sampai end (inklusif) yang
+	// berlaku di location, yaitu hari libur umum dan khusus lokasi tersebut.
+	GetBetween(ctx context.Context, start, end time.Time, location string) ([]Holiday, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r *repository) Create(ctx context.Context, holiday *Holiday) error {
+	return r.db.WithContext(ctx).Create(holiday).Error
+}
+
+func (r *repository) Get(ctx context.Context, id string) (*Holiday, error) {
+	var holiday Holiday
+	if err := r.db.WithContext(ctx).First(&holiday, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &holiday, nil
+}
+
+func (r *repository) Update(ctx context.Context, holiday *Holiday) error {
+	return r.db.WithContext(ctx).Save(holiday).Error
+}
+
+func (r *repository) Delete(ctx context.Context, id string) error {
+	res := r.db.WithContext(ctx).Delete(&Holiday{}, "id = ?", id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *repository) List(ctx context.Context, year int, location string) ([]Holiday, error) {
+	var holidays []Holiday
+	q := r.db.WithContext(ctx).Where("EXTRACT(YEAR FROM date) = ?", year)
+	if location != "" {
+		q = q.Where("location = ?", location)
+	}
+	err := q.Order("date, location").Find(&holidays).Error
+	return holidays, err
+}
+
+func (r *repository) ReplaceYear(ctx context.Context, year int, location string, holidays []Holiday) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("EXTRACT(YEAR FROM date) = ? AND location = ?", year, location).Delete(&Holiday{}).Error; err != nil {
+			return err
+		}
+		if len(holidays) == 0 {
+			return nil
+		}
+		return tx.Create(&holidays).Error
+	})
+}
+
+func (r *repository) GetBetween(ctx context.Context, start, end time.Time, location string) ([]Holiday, error) {
+	var holidays []Holiday
+	err := r.db.WithContext(ctx).
+		Where("date >= ? AND date <= ?", start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Where("location = '' OR location = ?", location).
+		Order("date").
+		Find(&holidays).Error
+	return holidays, err
+}
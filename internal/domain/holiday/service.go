@@ -0,0 +1,143 @@
+package holiday
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+)
+
+// Service mengelola kalender hari libur yang dipakai perhitungan hari kerja
+// payroll dan validasi absensi.
+type Service interface {
+	CreateHoliday(ctx context.Context, holiday *Holiday, adminID string) error
+	UpdateHoliday(ctx context.Context, id string, update HolidayUpdate, adminID string) (*Holiday, error)
+	DeleteHoliday(ctx context.Context, id string) error
+	ListHolidays(ctx context.Context, year int, location string) ([]Holiday, error)
+	// ImportHolidays mengganti seluruh hari libur satu tahun untuk location
+	// (kosong = semua lokasi) dengan holidays, mis. daftar libur nasional dan
+	// cuti bersama dari SKB menteri.
+	ImportHolidays(ctx context.Context, year int, location string, holidays []Holiday, adminID string) ([]Holiday, error)
+	// HolidaysBetween mengembalikan hari libur yang berlaku di location.
+	HolidaysBetween(ctx context.Context, start, end time.Time, location string) ([]Holiday, error)
+	// IsHolidayFor melaporkan apakah date adalah hari libur bagi karyawan
+	// sesuai lokasinya.
+	IsHolidayFor(ctx context.Context, userID string, date time.Time) (bool, error)
+}
+
+// HolidayUpdate berisi field hari libur yang boleh diubah. Field nil tidak
+// diubah. Untuk memindahkan tanggal, hapus lalu buat ulang hari libur.
+type HolidayUpdate struct {
+	Name *string `json:"name"`
+	Type *Type   `json:"type"`
+}
+
+type service struct {
+	repo      Repository
+	employees employee.Repository
+}
+
+func NewService(repo Repository, employees employee.Repository) Service {
+	return &service{repo: repo, employees: employees}
+}
+
+func (s *service) CreateHoliday(ctx context.Context, holiday *Holiday, adminID string) error {
+	if err := validate(holiday); err != nil {
+		return err
+	}
+	holiday.CreatedBy = adminID
+	holiday.UpdatedBy = adminID
+	return s.repo.Create(ctx, holiday)
+}
+
+func (s *service) UpdateHoliday(ctx context.Context, id string, update HolidayUpdate, adminID string) (*Holiday, error) {
+	holiday, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if update.Name != nil {
+		holiday.Name = *update.Name
+	}
+	if update.Type != nil {
+		holiday.Type = *update.Type
+	}
+	if err := validate(holiday); err != nil {
+		return nil, err
+	}
+	holiday.UpdatedBy = adminID
+	if err := s.repo.Update(ctx, holiday); err != nil {
+		return nil, err
+	}
+	return holiday, nil
+}
+
+func (s *service) DeleteHoliday(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *service) ListHolidays(ctx context.Context, year int, location string) ([]Holiday, error) {
+	return s.repo.List(ctx, year, strings.TrimSpace(location))
+}
+
+func (s *service) ImportHolidays(ctx context.Context, year int, location string, holidays []Holiday, adminID string) ([]Holiday, error) {
+	location = strings.TrimSpace(location)
+	seen := make(map[string]bool, len(holidays))
+	for i := range holidays {
+		h := &holidays[i]
+		h.Location = location
+		if err := validate(h); err != nil {
+			return nil, fmt.Errorf("holiday %d: %w", i+1, err)
+		}
+		if h.Date.Year() != year {
+			return nil, fmt.Errorf("holiday %d: date %s is not in %d", i+1, h.Date.Format("2006-01-02"), year)
+		}
+		day := h.Date.Format("2006-01-02")
+		if seen[day] {
+			return nil, fmt.Errorf("holiday %d: duplicate date %s", i+1, day)
+		}
+		seen[day] = true
+		h.CreatedBy = adminID
+		h.UpdatedBy = adminID
+	}
+
+	if err := s.repo.ReplaceYear(ctx, year, location, holidays); err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+func (s *service) HolidaysBetween(ctx context.Context, start, end time.Time, location string) ([]Holiday, error) {
+	return s.repo.GetBetween(ctx, start, end, location)
+}
+
+func (s *service) IsHolidayFor(ctx context.Context, userID string, date time.Time) (bool, error) {
+	emp, err := s.employees.GetByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	holidays, err := s.repo.GetBetween(ctx, date, date, emp.Location)
+	if err != nil {
+		return false, err
+	}
+	return len(holidays) > 0, nil
+}
+
+func validate(h *Holiday) error {
+	h.Name = strings.TrimSpace(h.Name)
+	h.Location = strings.TrimSpace(h.Location)
+	if h.Date.IsZero() {
+		return errors.New("holiday date is required")
+	}
+	if h.Name == "" {
+		return errors.New("holiday name is required")
+	}
+	switch h.Type {
+	case TypeNational, TypeCutiBersama, TypeCompany:
+	default:
+		return errors.New("holiday type must be national, cuti_bersama or company")
+	}
+	return nil
+}
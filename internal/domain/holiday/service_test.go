@@ -0,0 +1,143 @@
+package holiday
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/auth"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockHolidayRepository adalah implementasi mock untuk holiday.Repository
+type MockHolidayRepository struct {
+	mock.Mock
+}
+
+func (m *MockHolidayRepository) Create(ctx context.Context, holiday *Holiday) error {
+	args := m.Called(ctx, holiday)
+	return args.Error(0)
+}
+func (m *MockHolidayRepository) Get(ctx context.Context, id string) (*Holiday, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Holiday), args.Error(1)
+}
+func (m *MockHolidayRepository) Update(ctx context.Context, holiday *Holiday) error {
+	args := m.Called(ctx, holiday)
+	return args.Error(0)
+}
+func (m *MockHolidayRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockHolidayRepository) List(ctx context.Context, year int, location string) ([]Holiday, error) {
+	args := m.Called(ctx, year, location)
+	return args.Get(0).([]Holiday), args.Error(1)
+}
+func (m *MockHolidayRepository) ReplaceYear(ctx context.Context, year int, location string, holidays []Holiday) error {
+	args := m.Called(ctx, year, location, holidays)
+	return args.Error(0)
+}
+func (m *MockHolidayRepository) GetBetween(ctx context.Context, start, end time.Time, location string) ([]Holiday, error) {
+	args := m.Called(ctx, start, end, location)
+	return args.Get(0).([]Holiday), args.Error(1)
+}
+
+func day(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+func TestHolidayService(t *testing.T) {
+	t.Run("CreateHoliday - Validates name and type", func(t *testing.T) {
+		mockRepo := new(MockHolidayRepository) // mock tidak akan dipanggil
+		holidayService := NewService(mockRepo, new(auth.MockEmployeeRepository))
+		ctx := context.Background()
+
+		err := holidayService.CreateHoliday(ctx, &Holiday{Date: day("2026-08-17"), Name: " ", Type: TypeNational}, "admin-001")
+		assert.EqualError(t, err, "holiday name is required")
+
+		err = holidayService.CreateHoliday(ctx, &Holiday{Date: day("2026-08-17"), Name: "HUT RI", Type: "regional"}, "admin-001")
+		assert.Error(t, err)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ImportHolidays - Replaces the year for a location", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(MockHolidayRepository)
+		holidayService := NewService(mockRepo, new(auth.MockEmployeeRepository))
+		ctx := context.Background()
+		holidays := []Holiday{
+			{Date: day("2026-03-19"), Name: "Hari Suci Nyepi", Type: TypeNational},
+			{Date: day("2026-03-18"), Name: "Cuti Bersama Nyepi", Type: TypeCutiBersama},
+		}
+		mockRepo.On("ReplaceYear", ctx, 2026, "Bali", mock.MatchedBy(func(hs []Holiday) bool {
+			return len(hs) == 2 && hs[0].Location == "Bali" && hs[1].CreatedBy == "admin-001"
+		})).Return(nil).Once()
+
+		// Act
+		imported, err := holidayService.ImportHolidays(ctx, 2026, " Bali ", holidays, "admin-001")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, imported, 2)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("ImportHolidays - Rejects dates outside the year and duplicates", func(t *testing.T) {
+		mockRepo := new(MockHolidayRepository) // mock tidak akan dipanggil
+		holidayService := NewService(mockRepo, new(auth.MockEmployeeRepository))
+		ctx := context.Background()
+
+		_, err := holidayService.ImportHolidays(ctx, 2026, "", []Holiday{
+			{Date: day("2027-01-01"), Name: "Tahun Baru", Type: TypeNational},
+		}, "admin-001")
+		assert.ErrorContains(t, err, "is not in 2026")
+
+		_, err = holidayService.ImportHolidays(ctx, 2026, "", []Holiday{
+			{Date: day("2026-01-01"), Name: "Tahun Baru", Type: TypeNational},
+			{Date: day("2026-01-01"), Name: "Tahun Baru Masehi", Type: TypeNational},
+		}, "admin-001")
+		assert.ErrorContains(t, err, "duplicate date 2026-01-01")
+		mockRepo.AssertNotCalled(t, "ReplaceYear", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("IsHolidayFor - Uses the employee location", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(MockHolidayRepository)
+		mockEmployeeRepo := new(auth.MockEmployeeRepository)
+		holidayService := NewService(mockRepo, mockEmployeeRepo)
+		ctx := context.Background()
+		date := day("2026-03-19")
+
+		mockEmployeeRepo.On("GetByID", ctx, "user-001").Return(&employee.Employee{ID: "user-001", Location: "Bali"}, nil).Once()
+		mockRepo.On("GetBetween", ctx, date, date, "Bali").Return([]Holiday{{Date: date, Name: "Hari Suci Nyepi", Location: "Bali"}}, nil).Once()
+
+		// Act
+		isHoliday, err := holidayService.IsHolidayFor(ctx, "user-001", date)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, isHoliday)
+		mockRepo.AssertExpectations(t)
+		mockEmployeeRepo.AssertExpectations(t)
+	})
+}
+
+func TestWorkingDays(t *testing.T) {
+	// Agustus 2026 memiliki 21 hari kerja; 17 Agustus jatuh pada hari Senin,
+	// sedangkan libur pada hari Sabtu tidak mengurangi hari kerja.
+	holidays := []Holiday{
+		{Date: day("2026-08-17"), Name: "HUT RI", Type: TypeNational},
+		{Date: day("2026-08-22"), Name: "Libur Sabtu", Type: TypeCompany},
+	}
+	assert.Equal(t, 21, WorkingDays(day("2026-08-01"), day("2026-08-31"), nil))
+	assert.Equal(t, 20, WorkingDays(day("2026-08-01"), day("2026-08-31"), holidays))
+	assert.False(t, IsWorkingDay(day("2026-08-17"), holidays))
+	assert.True(t, IsWorkingDay(day("2026-08-18"), holidays))
+}
@@ -0,0 +1,134 @@
+package leave
+
+import (
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Accrual menentukan kapan jatah tahunan sebuah jenis cuti dapat dipakai.
+type Accrual string
+
+const (
+	// AccrualUpfront: seluruh jatah tahunan dapat dipakai sejak 1 Januari.
+	AccrualUpfront Accrual = "upfront"
+	// AccrualMonthly: jatah bertambah 1/12 setiap awal bulan, dibulatkan ke
+	// bawah.
+	AccrualMonthly Accrual = "monthly"
+)
+
+// Type adalah jenis cuti yang ditetapkan admin (mis. ANNUAL, SICK, UNPAID).
+type Type struct {
+	ID   string `json:"id" gorm:"primaryKey"`
+	Code string `json:"code" gorm:"uniqueIndex;size:32"`
+	Name string `json:"name"`
+	// Paid menentukan perlakuan payroll: hari cuti berbayar dibayar seperti
+	// hari hadir, sedangkan hari cuti tidak berbayar dipotong.
+	Paid bool `json:"paid"`
+	// AnnualDays adalah jatah hari kerja per tahun. 0 berarti jenis cuti ini
+	// tidak memakai saldo, mis. sakit dengan surat dokter.
+	AnnualDays int     `json:"annual_days"`
+	Accrual    Accrual `json:"accrual" gorm:"size:16;default:'upfront'"`
+	// CarryOverDays adalah sisa jatah paling banyak yang dibawa ke tahun
+	// berikutnya.
+	CarryOverDays int       `json:"carry_over_days"`
+	Active        bool      `json:"active" gorm:"default:true"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	CreatedBy     string    `gorm:"size:36" json:"created_by"`
+	UpdatedBy     string    `gorm:"size:36" json:"updated_by"`
+}
+
+func (Type) TableName() string { return "leave_types" }
+
+func (t *Type) BeforeCreate(tx *gorm.DB) error {
+	t.ID = uuid.New().String()
+	return nil
+}
+
+// Tracked melaporkan apakah pengajuan jenis cuti ini mengurangi saldo.
+func (t Type) Tracked() bool {
+	return t.AnnualDays > 0
+}
+
+// Accrued mengembalikan bagian jatah tahunan days yang sudah dapat dipakai
+// pada tanggal asOf.
+func (t Type) Accrued(days int, asOf time.Time) int {
+	if t.Accrual == AccrualMonthly {
+		return days * int(asOf.Month()) / 12
+	}
+	return days
+}
+
+// Request adalah pengajuan cuti dari StartDate sampai EndDate (inklusif)
+// dalam satu tahun kalender.
+type Request struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	UserID    string    `json:"user_id" gorm:"size:36;index"`
+	TypeID    string    `json:"type_id" gorm:"size:36;index"`
+	StartDate time.Time `json:"start_date" gorm:"type:date"`
+	EndDate   time.Time `json:"end_date" gorm:"type:date"`
+	// Days adalah jumlah hari kerja karyawan dalam rentang tanggal; hanya
+	// hari ini yang mengurangi saldo.
+	Days int `json:"days"`
+	// Paid disalin dari jenis cuti saat diajukan agar perubahan jenis cuti
+	// tidak mengubah perlakuan pengajuan yang sudah ada.
+	Paid   bool   `json:"paid"`
+	Reason string `json:"reason"`
+	// Status persetujuan; hanya cuti approved yang diperhitungkan payroll.
+	approval.Decision
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	CreatedBy string    `gorm:"size:36" json:"created_by"`
+	UpdatedBy string    `gorm:"size:36" json:"updated_by"`
+}
+
+func (Request) TableName() string { return "leave_requests" }
+
+func (r *Request) BeforeCreate(tx *gorm.DB) error {
+	r.ID = uuid.New().String()
+	return nil
+}
+
+// Overlaps melaporkan apakah rentang tanggal pengajuan beririsan dengan
+// start..end.
+func (r Request) Overlaps(start, end time.Time) bool {
+	return !r.StartDate.After(end) && !r.EndDate.Before(start)
+}
+
+// Entitlement adalah jatah tahunan karyawan untuk satu jenis cuti, termasuk
+// sisa jatah tahun sebelumnya yang dibawa.
+type Entitlement struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	UserID      string    `json:"user_id" gorm:"size:36;uniqueIndex:idx_leave_entitlements_user_type_year"`
+	TypeID      string    `json:"type_id" gorm:"size:36;uniqueIndex:idx_leave_entitlements_user_type_year"`
+	Year        int       `json:"year" gorm:"uniqueIndex:idx_leave_entitlements_user_type_year"`
+	Days        int       `json:"days"`
+	CarriedOver int       `json:"carried_over"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedBy   string    `gorm:"size:36" json:"created_by"`
+	UpdatedBy   string    `gorm:"size:36" json:"updated_by"`
+}
+
+func (Entitlement) TableName() string { return "leave_entitlements" }
+
+func (e *Entitlement) BeforeCreate(tx *gorm.DB) error {
+	e.ID = uuid.New().String()
+	return nil
+}
+
+// Balance adalah saldo satu jenis cuti pada satu tahun.
+type Balance struct {
+	TypeID      string `json:"type_id"`
+	TypeCode    string `json:"type_code"`
+	Year        int    `json:"year"`
+	Entitled    int    `json:"entitled"`     // Jatah tahunan
+	CarriedOver int    `json:"carried_over"` // Sisa jatah tahun sebelumnya
+	Accrued     int    `json:"accrued"`      // Bagian jatah tahunan yang sudah dapat dipakai
+	Used        int    `json:"used"`         // Hari cuti approved
+	Pending     int    `json:"pending"`      // Hari cuti yang menunggu keputusan
+	Available   int    `json:"available"`    // CarriedOver + Accrued - Used - Pending
+}
@@ -0,0 +1,201 @@
+package leave
+
+import (
+	"context"
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	WithTransaction(ctx context.Context, fn func(repo Repository) error) error
+	// LockRequests mencegah pengajuan cuti karyawan yang sama diproses
+	// paralel hingga transaksi selesai, sehingga saldo dan tumpang tindih
+	// diperiksa terhadap data terbaru. Harus dipanggil di dalam
+	// WithTransaction.
+	LockRequests(ctx context.Context, userID string) error
+
+	CreateType(ctx context.Context, t *Type) error
+	GetType(ctx context.Context, id string) (*Type, error)
+	ListTypes(ctx context.Context) ([]Type, error)
+	UpdateType(ctx context.Context, t *Type) error
+
+	// GetEntitlement mengembalikan gorm.ErrRecordNotFound jika jatah tahun
+	// tersebut belum diberikan.
+	GetEntitlement(ctx context.Context, userID, typeID string, year int) (*Entitlement, error)
+	// SaveEntitlements menyimpan entitlements; jatah yang sudah ada untuk
+	// karyawan, jenis cuti, dan tahun yang sama hanya diperbarui sisa yang
+	// dibawanya.
+	SaveEntitlements(ctx context.Context, entitlements []Entitlement) error
+
+	CreateRequest(ctx context.Context, request *Request) error
+	GetRequest(ctx context.Context, id string) (*Request, error)
+	ListRequests(ctx context.Context, filter ListFilter) ([]Request, error)
+	// CountRequests menghitung cuti sesuai filter tanpa Limit dan Offset.
+	CountRequests(ctx context.Context, filter ListFilter) (int64, error)
+	// GetActiveRequests mengembalikan cuti pending dan approved milik userID
+	// yang beririsan dengan start..end.
+	GetActiveRequests(ctx context.Context, userID string, start, end time.Time) ([]Request, error)
+	// GetApprovedRequests mengembalikan cuti approved milik userID yang
+	// beririsan dengan start..end.
+	GetApprovedRequests(ctx context.Context, userID string, start, end time.Time) ([]Request, error)
+	// UpdateRequestStatus menyimpan perubahan status hanya jika status di
+	// database masih from. Nilai false berarti cuti sudah diubah proses lain.
+	UpdateRequestStatus(ctx context.Context, request *Request, from approval.Status) (bool, error)
+}
+
+// ListFilter membatasi daftar cuti. Field kosong tidak membatasi; Limit 0
+// berarti tanpa batas jumlah. StartDate dan EndDate memilih cuti yang
+// beririsan dengan rentang tersebut.
+type ListFilter struct {
+	Status    approval.Status
+	UserID    string
+	ManagerID string // Hanya cuti bawahan langsung manager ini
+	StartDate *time.Time
+	EndDate   *time.Time
+	Limit     int
+	Offset    int
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r *repository) WithTransaction(ctx context.Context, fn func(repo Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&repository{tx})
+	})
+}
+
+// requestsLockKey adalah ruang kunci advisory lock pengajuan cuti per
+// karyawan.
+const requestsLockKey = 72010024
+
+func (r *repository) LockRequests(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Exec("SELECT pg_advisory_xact_lock(?, hashtext(?))", requestsLockKey, userID).Error
+}
+
+func (r *repository) CreateType(ctx context.Context, t *Type) error {
+	return r.db.WithContext(ctx).Create(t).Error
+}
+
+func (r *repository) GetType(ctx context.Context, id string) (*Type, error) {
+	var t Type
+	if err := r.db.WithContext(ctx).First(&t, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *repository) ListTypes(ctx context.Context) ([]Type, error) {
+	var types []Type
+	err := r.db.WithContext(ctx).Order("code").Find(&types).Error
+	return types, err
+}
+
+func (r *repository) UpdateType(ctx context.Context, t *Type) error {
+	return r.db.WithContext(ctx).Save(t).Error
+}
+
+func (r *repository) GetEntitlement(ctx context.Context, userID, typeID string, year int) (*Entitlement, error) {
+	var e Entitlement
+	if err := r.db.WithContext(ctx).First(&e, "user_id = ? AND type_id = ? AND year = ?", userID, typeID, year).Error; err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *repository) SaveEntitlements(ctx context.Context, entitlements []Entitlement) error {
+	if len(entitlements) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type_id"}, {Name: "year"}},
+		DoUpdates: clause.AssignmentColumns([]string{"carried_over", "updated_at", "updated_by"}),
+	}).Create(&entitlements).Error
+}
+
+func (r *repository) CreateRequest(ctx context.Context, request *Request) error {
+	return r.db.WithContext(ctx).Create(request).Error
+}
+
+func (r *repository) GetRequest(ctx context.Context, id string) (*Request, error) {
+	var request Request
+	if err := r.db.WithContext(ctx).First(&request, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *repository) ListRequests(ctx context.Context, filter ListFilter) ([]Request, error) {
+	q := r.filtered(ctx, filter).Order("start_date, created_at")
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit).Offset(filter.Offset)
+	}
+	var requests []Request
+	err := q.Find(&requests).Error
+	return requests, err
+}
+
+func (r *repository) CountRequests(ctx context.Context, filter ListFilter) (int64, error) {
+	var count int64
+	err := r.filtered(ctx, filter).Model(&Request{}).Count(&count).Error
+	return count, err
+}
+
+func (r *repository) filtered(ctx context.Context, filter ListFilter) *gorm.DB {
+	q := r.db.WithContext(ctx)
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	if filter.UserID != "" {
+		q = q.Where("user_id = ?", filter.UserID)
+	}
+	if filter.ManagerID != "" {
+		q = q.Where("user_id IN (?)", r.db.Table("employees").Select("id").Where("manager_id = ?", filter.ManagerID))
+	}
+	if filter.StartDate != nil {
+		q = q.Where("end_date >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		q = q.Where("start_date <= ?", *filter.EndDate)
+	}
+	return q
+}
+
+func (r *repository) GetActiveRequests(ctx context.Context, userID string, start, end time.Time) ([]Request, error) {
+	var requests []Request
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND start_date <= ? AND end_date >= ? AND status IN ?", userID, end, start, []approval.Status{approval.StatusPending, approval.StatusApproved}).
+		Order("start_date").Find(&requests).Error
+	return requests, err
+}
+
+func (r *repository) GetApprovedRequests(ctx context.Context, userID string, start, end time.Time) ([]Request, error) {
+	var requests []Request
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND start_date <= ? AND end_date >= ? AND status = ?", userID, end, start, approval.StatusApproved).
+		Order("start_date").Find(&requests).Error
+	return requests, err
+}
+
+func (r *repository) UpdateRequestStatus(ctx context.Context, request *Request, from approval.Status) (bool, error) {
+	updates := map[string]interface{}{
+		"status":        request.Status,
+		"reviewed_by":   request.ReviewedBy,
+		"reviewed_at":   request.ReviewedAt,
+		"review_reason": request.ReviewReason,
+		"updated_by":    request.UpdatedBy,
+	}
+	res := r.db.WithContext(ctx).Model(&Request{}).Where("id = ? AND status = ?", request.ID, from).Updates(updates)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
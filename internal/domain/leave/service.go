@@ -0,0 +1,541 @@
+package leave
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/periodlock"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/shift"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	CreateType(ctx context.Context, t *Type, adminID string) error
+	ListTypes(ctx context.Context) ([]Type, error)
+	UpdateType(ctx context.Context, id string, update TypeUpdate, adminID string) (*Type, error)
+	// GrantEntitlements memberikan jatah tahun year kepada setiap karyawan
+	// untuk setiap jenis cuti aktif yang memakai saldo, beserta sisa jatah
+	// tahun sebelumnya yang boleh dibawa. Menjalankan ulang menghitung ulang
+	// sisa yang dibawa tanpa mengubah jatah yang sudah diberikan.
+	GrantEntitlements(ctx context.Context, year int, adminID string) ([]Entitlement, error)
+	// GetBalances mengembalikan saldo setiap jenis cuti aktif yang memakai
+	// saldo pada tahun asOf, dengan akrual sampai tanggal asOf.
+	GetBalances(ctx context.Context, userID string, asOf time.Time) ([]Balance, error)
+
+	// SubmitRequest membuat pengajuan cuti berstatus pending. Untuk jenis
+	// cuti yang memakai saldo, hari kerja yang diajukan tidak boleh melebihi
+	// saldo yang tersedia pada tanggal mulai cuti.
+	SubmitRequest(ctx context.Context, userID, typeID string, start, end time.Time, reason string) (*Request, error)
+	// ListRequests menampilkan pengajuan cuti yang boleh diputuskan
+	// reviewer: semua untuk admin, bawahan langsung untuk manager.
+	ListRequests(ctx context.Context, reviewer employee.Reviewer, filter ListFilter) ([]Request, error)
+	// ListMyRequests mengembalikan cuti milik userID beserta jumlah
+	// seluruhnya sebelum Limit dan Offset diterapkan.
+	ListMyRequests(ctx context.Context, userID string, filter ListFilter) ([]Request, int64, error)
+	// ApproveRequest dan RejectRequest memutuskan cuti pending. Alasan wajib
+	// diisi saat menolak.
+	ApproveRequest(ctx context.Context, requestID string, reviewer employee.Reviewer) (*Request, error)
+	RejectRequest(ctx context.Context, requestID, reason string, reviewer employee.Reviewer) (*Request, error)
+	// CancelRequest membatalkan cuti pending milik userID sendiri.
+	CancelRequest(ctx context.Context, requestID, userID string) (*Request, error)
+}
+
+// TypeUpdate berisi field jenis cuti yang boleh diubah. Field nil tidak
+// diubah. Code tidak dapat diubah.
+type TypeUpdate struct {
+	Name          *string  `json:"name"`
+	Paid          *bool    `json:"paid"`
+	AnnualDays    *int     `json:"annual_days"`
+	Accrual       *Accrual `json:"accrual"`
+	CarryOverDays *int     `json:"carry_over_days"`
+	Active        *bool    `json:"active"`
+}
+
+// HolidayCalendar melaporkan apakah sebuah tanggal adalah hari libur yang
+// berlaku bagi karyawan.
+type HolidayCalendar interface {
+	IsHolidayFor(ctx context.Context, userID string, date time.Time) (bool, error)
+}
+
+var (
+	// ErrUnknownType dikembalikan untuk jenis cuti yang tidak ada atau sudah
+	// dinonaktifkan.
+	ErrUnknownType = errors.New("unknown or inactive leave type")
+	// ErrInsufficientBalance dikembalikan jika hari cuti melebihi saldo.
+	ErrInsufficientBalance = errors.New("insufficient leave balance")
+	// ErrOverlap dikembalikan jika tanggal cuti beririsan dengan cuti lain
+	// yang masih pending atau sudah approved.
+	ErrOverlap = errors.New("leave overlaps another pending or approved leave")
+)
+
+type service struct {
+	repo      Repository
+	employees employee.Repository
+	lock      periodlock.Guard
+	holidays  HolidayCalendar
+	schedules shift.Schedules
+	now       func() time.Time
+}
+
+// Option mengubah konfigurasi opsional dari service leave.
+type Option func(*service)
+
+// WithPeriodLock menolak pengajuan dan persetujuan cuti yang bertanggal di
+// dalam periode payroll yang sudah ditutup.
+func WithPeriodLock(lock periodlock.Checker) Option {
+	return func(s *service) {
+		s.lock = periodlock.Guard{Checker: lock}
+	}
+}
+
+// WithHolidayCalendar tidak menghitung hari libur karyawan sebagai hari cuti.
+func WithHolidayCalendar(c HolidayCalendar) Option {
+	return func(s *service) {
+		s.holidays = c
+	}
+}
+
+// WithSchedules menghitung hari cuti dari roster shift karyawan pada tanggal
+// yang diroster.
+func WithSchedules(schedules shift.Schedules) Option {
+	return func(s *service) {
+		s.schedules = schedules
+	}
+}
+
+// WithClock mengganti sumber waktu saat ini. Default: time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(s *service) {
+		s.now = now
+	}
+}
+
+func NewService(repo Repository, employees employee.Repository, opts ...Option) Service {
+	s := &service{repo: repo, employees: employees, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+var typeCodePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,31}$`)
+
+func (s *service) CreateType(ctx context.Context, t *Type, adminID string) error {
+	t.Code = strings.ToUpper(strings.TrimSpace(t.Code))
+	if !typeCodePattern.MatchString(t.Code) {
+		return errors.New("leave type code must be 2-32 characters of A-Z, 0-9 or underscore")
+	}
+	if t.Accrual == "" {
+		t.Accrual = AccrualUpfront
+	}
+	if err := validateType(t); err != nil {
+		return err
+	}
+
+	t.Active = true
+	t.CreatedBy = adminID
+	t.UpdatedBy = adminID
+	return s.repo.CreateType(ctx, t)
+}
+
+func (s *service) ListTypes(ctx context.Context) ([]Type, error) {
+	return s.repo.ListTypes(ctx)
+}
+
+func (s *service) UpdateType(ctx context.Context, id string, update TypeUpdate, adminID string) (*Type, error) {
+	t, err := s.repo.GetType(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if update.Name != nil {
+		t.Name = *update.Name
+	}
+	if update.Paid != nil {
+		t.Paid = *update.Paid
+	}
+	if update.AnnualDays != nil {
+		t.AnnualDays = *update.AnnualDays
+	}
+	if update.Accrual != nil {
+		t.Accrual = *update.Accrual
+	}
+	if update.CarryOverDays != nil {
+		t.CarryOverDays = *update.CarryOverDays
+	}
+	if update.Active != nil {
+		t.Active = *update.Active
+	}
+	if err := validateType(t); err != nil {
+		return nil, err
+	}
+
+	t.UpdatedBy = adminID
+	if err := s.repo.UpdateType(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func validateType(t *Type) error {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		return errors.New("leave type name is required")
+	}
+	if t.AnnualDays < 0 || t.AnnualDays > 366 {
+		return errors.New("annual_days must be between 0 and 366")
+	}
+	if t.Accrual != AccrualUpfront && t.Accrual != AccrualMonthly {
+		return fmt.Errorf("unknown accrual %q", t.Accrual)
+	}
+	if t.CarryOverDays < 0 || t.CarryOverDays > t.AnnualDays {
+		return errors.New("carry_over_days must be between 0 and annual_days")
+	}
+	return nil
+}
+
+func (s *service) GrantEntitlements(ctx context.Context, year int, adminID string) ([]Entitlement, error) {
+	if year < 2000 || year > 9999 {
+		return nil, errors.New("year must be between 2000 and 9999")
+	}
+	types, err := s.trackedTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	employees, err := s.employees.GetAllEmployees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prevStart, prevEnd := yearBounds(year - 1)
+	var entitlements []Entitlement
+	for _, emp := range employees {
+		requests, err := s.repo.GetActiveRequests(ctx, emp.ID, prevStart, prevEnd)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range types {
+			carried, err := s.carryOver(ctx, emp.ID, t, year-1, requests)
+			if err != nil {
+				return nil, err
+			}
+			entitlements = append(entitlements, Entitlement{
+				UserID:      emp.ID,
+				TypeID:      t.ID,
+				Year:        year,
+				Days:        t.AnnualDays,
+				CarriedOver: carried,
+				CreatedBy:   adminID,
+				UpdatedBy:   adminID,
+			})
+		}
+	}
+
+	if err := s.repo.SaveEntitlements(ctx, entitlements); err != nil {
+		return nil, err
+	}
+	return entitlements, nil
+}
+
+// carryOver menghitung sisa jatah jenis cuti t pada prevYear yang dibawa ke
+// tahun berikutnya. requests adalah cuti pending dan approved milik userID
+// pada prevYear. Tanpa jatah yang benar-benar diberikan pada prevYear (mis.
+// karyawan baru bergabung) tidak ada sisa yang dibawa.
+func (s *service) carryOver(ctx context.Context, userID string, t Type, prevYear int, requests []Request) (int, error) {
+	prev, err := s.repo.GetEntitlement(ctx, userID, t.ID, prevYear)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	// Hanya cuti approved yang mengurangi sisa jatah yang dibawa.
+	used, _ := usage(requests, t.ID)
+	remaining := prev.Days + prev.CarriedOver - used
+	return min(max(remaining, 0), t.CarryOverDays), nil
+}
+
+func (s *service) GetBalances(ctx context.Context, userID string, asOf time.Time) ([]Balance, error) {
+	types, err := s.trackedTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	start, end := yearBounds(asOf.Year())
+	requests, err := s.repo.GetActiveRequests(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]Balance, 0, len(types))
+	for _, t := range types {
+		balance, err := balanceFor(ctx, s.repo, userID, t, asOf, requests)
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+	return balances, nil
+}
+
+// trackedTypes mengembalikan jenis cuti aktif yang memakai saldo.
+func (s *service) trackedTypes(ctx context.Context) ([]Type, error) {
+	types, err := s.repo.ListTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var tracked []Type
+	for _, t := range types {
+		if t.Active && t.Tracked() {
+			tracked = append(tracked, t)
+		}
+	}
+	return tracked, nil
+}
+
+// entitlementFor mengembalikan jatah userID untuk jenis cuti t pada year.
+// Jika jatah tahun tersebut belum diberikan, berlaku jatah tahunan jenis cuti
+// tanpa sisa yang dibawa.
+func entitlementFor(ctx context.Context, repo Repository, userID string, t Type, year int) (Entitlement, error) {
+	e, err := repo.GetEntitlement(ctx, userID, t.ID, year)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Entitlement{UserID: userID, TypeID: t.ID, Year: year, Days: t.AnnualDays}, nil
+	}
+	if err != nil {
+		return Entitlement{}, err
+	}
+	return *e, nil
+}
+
+// balanceFor menghitung saldo jenis cuti t pada tahun asOf. requests adalah
+// cuti pending dan approved milik userID pada tahun tersebut.
+func balanceFor(ctx context.Context, repo Repository, userID string, t Type, asOf time.Time, requests []Request) (Balance, error) {
+	e, err := entitlementFor(ctx, repo, userID, t, asOf.Year())
+	if err != nil {
+		return Balance{}, err
+	}
+	used, pending := usage(requests, t.ID)
+	b := Balance{
+		TypeID:      t.ID,
+		TypeCode:    t.Code,
+		Year:        asOf.Year(),
+		Entitled:    e.Days,
+		CarriedOver: e.CarriedOver,
+		Accrued:     t.Accrued(e.Days, asOf),
+		Used:        used,
+		Pending:     pending,
+	}
+	b.Available = b.CarriedOver + b.Accrued - b.Used - b.Pending
+	return b, nil
+}
+
+// usage menjumlahkan hari cuti approved dan pending untuk jenis cuti typeID.
+func usage(requests []Request, typeID string) (used, pending int) {
+	for _, r := range requests {
+		if r.TypeID != typeID {
+			continue
+		}
+		switch r.Status {
+		case approval.StatusApproved:
+			used += r.Days
+		case approval.StatusPending:
+			pending += r.Days
+		}
+	}
+	return used, pending
+}
+
+// yearBounds mengembalikan tanggal pertama dan terakhir tahun year.
+func yearBounds(year int) (start, end time.Time) {
+	return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+}
+
+func (s *service) SubmitRequest(ctx context.Context, userID, typeID string, start, end time.Time, reason string) (*Request, error) {
+	if end.Before(start) {
+		return nil, errors.New("end date must not be before start date")
+	}
+	if start.Year() != end.Year() {
+		return nil, errors.New("leave cannot span two years; submit one request per year")
+	}
+	t, err := s.repo.GetType(ctx, typeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !t.Active) {
+		return nil, ErrUnknownType
+	}
+	if err != nil {
+		return nil, err
+	}
+	days, err := s.workingDays(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if days == 0 {
+		return nil, errors.New("leave must include at least one working day")
+	}
+	if err := s.lock.CheckRange(ctx, start, end); err != nil {
+		return nil, err
+	}
+
+	request := &Request{
+		UserID:    userID,
+		TypeID:    t.ID,
+		StartDate: start,
+		EndDate:   end,
+		Days:      days,
+		Paid:      t.Paid,
+		Reason:    strings.TrimSpace(reason),
+		Decision:  approval.Decision{Status: approval.StatusPending},
+		CreatedBy: userID,
+		UpdatedBy: userID,
+	}
+
+	// Saldo dan tumpang tindih dihitung ulang di dalam lock agar pengajuan
+	// paralel tidak bersama-sama melampaui saldo.
+	err = s.repo.WithTransaction(ctx, func(repo Repository) error {
+		if err := repo.LockRequests(ctx, userID); err != nil {
+			return err
+		}
+		yearStart, yearEnd := yearBounds(start.Year())
+		requests, err := repo.GetActiveRequests(ctx, userID, yearStart, yearEnd)
+		if err != nil {
+			return err
+		}
+		for _, r := range requests {
+			if r.Overlaps(start, end) {
+				return ErrOverlap
+			}
+		}
+		if t.Tracked() {
+			balance, err := balanceFor(ctx, repo, userID, *t, start, requests)
+			if err != nil {
+				return err
+			}
+			if days > balance.Available {
+				return fmt.Errorf("%w: %d days requested, %d available", ErrInsufficientBalance, days, balance.Available)
+			}
+		}
+		return repo.CreateRequest(ctx, request)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// workingDays menghitung hari kerja userID dari start sampai end: tanggal
+// yang diroster shift, atau Senin-Jumat yang bukan hari libur karyawan jika
+// tanggal tersebut tidak diroster.
+func (s *service) workingDays(ctx context.Context, userID string, start, end time.Time) (int, error) {
+	days := 0
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		if s.schedules != nil {
+			schedule, err := s.schedules.ScheduleFor(ctx, userID, date)
+			if err != nil {
+				return 0, err
+			}
+			if schedule.Rostered {
+				if schedule.Working() {
+					days++
+				}
+				continue
+			}
+		}
+		if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			continue
+		}
+		if s.holidays != nil {
+			isHoliday, err := s.holidays.IsHolidayFor(ctx, userID, date)
+			if err != nil {
+				return 0, err
+			}
+			if isHoliday {
+				continue
+			}
+		}
+		days++
+	}
+	return days, nil
+}
+
+func (s *service) ListRequests(ctx context.Context, reviewer employee.Reviewer, filter ListFilter) ([]Request, error) {
+	managerID, err := approval.ManagerScope(reviewer)
+	if err != nil {
+		return nil, err
+	}
+	filter.ManagerID = managerID
+	return s.repo.ListRequests(ctx, filter)
+}
+
+func (s *service) ListMyRequests(ctx context.Context, userID string, filter ListFilter) ([]Request, int64, error) {
+	filter.UserID = userID
+	filter.ManagerID = ""
+	requests, err := s.repo.ListRequests(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.CountRequests(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return requests, total, nil
+}
+
+func (s *service) ApproveRequest(ctx context.Context, requestID string, reviewer employee.Reviewer) (*Request, error) {
+	return s.review(ctx, requestID, approval.StatusApproved, "", reviewer)
+}
+
+func (s *service) RejectRequest(ctx context.Context, requestID, reason string, reviewer employee.Reviewer) (*Request, error) {
+	reason, err := approval.RejectReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	return s.review(ctx, requestID, approval.StatusRejected, reason, reviewer)
+}
+
+// review memutuskan cuti pending setelah memastikan reviewer berwenang atas
+// karyawan pemilik cuti. Seperti koreksi absensi, persetujuan untuk tanggal di
+// periode yang payroll-nya sudah dihitung ditolak karena cutinya tidak akan
+// dibayar atau dipotong kecuali payroll periode itu di-reverse lebih dulu.
+func (s *service) review(ctx context.Context, requestID string, next approval.Status, reason string, reviewer employee.Reviewer) (*Request, error) {
+	request, err := s.repo.GetRequest(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if err := approval.Authorize(ctx, s.employees, reviewer, request.UserID); err != nil {
+		return nil, err
+	}
+	if next == approval.StatusApproved {
+		if err := s.lock.CheckCalculatedRange(ctx, request.StartDate, request.EndDate); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.transition(ctx, request, next, reviewer.ID, reason); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func (s *service) CancelRequest(ctx context.Context, requestID, userID string) (*Request, error) {
+	request, err := s.repo.GetRequest(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if err := approval.CheckOwner(request.UserID, userID); err != nil {
+		return nil, err
+	}
+	if err := s.transition(ctx, request, approval.StatusCancelled, userID, ""); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// transition memutuskan atau membatalkan cuti dengan pembaruan bersyarat.
+func (s *service) transition(ctx context.Context, request *Request, next approval.Status, actorID, reason string) error {
+	return request.Decide(next, actorID, reason, s.now(), func(from approval.Status) (bool, error) {
+		request.UpdatedBy = actorID
+		return s.repo.UpdateRequestStatus(ctx, request, from)
+	})
+}
@@ -0,0 +1,496 @@
+package leave
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/auth"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/periodlock"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/shift"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockLeaveRepository adalah implementasi mock untuk leave.Repository
+type MockLeaveRepository struct {
+	mock.Mock
+}
+
+func (m *MockLeaveRepository) WithTransaction(ctx context.Context, fn func(repo Repository) error) error {
+	return fn(m)
+}
+
+func (m *MockLeaveRepository) LockRequests(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockLeaveRepository) CreateType(ctx context.Context, t *Type) error {
+	args := m.Called(ctx, t)
+	return args.Error(0)
+}
+
+func (m *MockLeaveRepository) GetType(ctx context.Context, id string) (*Type, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Type), args.Error(1)
+}
+
+func (m *MockLeaveRepository) ListTypes(ctx context.Context) ([]Type, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Type), args.Error(1)
+}
+
+func (m *MockLeaveRepository) UpdateType(ctx context.Context, t *Type) error {
+	args := m.Called(ctx, t)
+	return args.Error(0)
+}
+
+func (m *MockLeaveRepository) GetEntitlement(ctx context.Context, userID, typeID string, year int) (*Entitlement, error) {
+	args := m.Called(ctx, userID, typeID, year)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Entitlement), args.Error(1)
+}
+
+func (m *MockLeaveRepository) SaveEntitlements(ctx context.Context, entitlements []Entitlement) error {
+	args := m.Called(ctx, entitlements)
+	return args.Error(0)
+}
+
+func (m *MockLeaveRepository) CreateRequest(ctx context.Context, request *Request) error {
+	args := m.Called(ctx, request)
+	return args.Error(0)
+}
+
+func (m *MockLeaveRepository) GetRequest(ctx context.Context, id string) (*Request, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Request), args.Error(1)
+}
+
+func (m *MockLeaveRepository) ListRequests(ctx context.Context, filter ListFilter) ([]Request, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]Request), args.Error(1)
+}
+
+func (m *MockLeaveRepository) CountRequests(ctx context.Context, filter ListFilter) (int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLeaveRepository) GetActiveRequests(ctx context.Context, userID string, start, end time.Time) ([]Request, error) {
+	args := m.Called(ctx, userID, start, end)
+	return args.Get(0).([]Request), args.Error(1)
+}
+
+func (m *MockLeaveRepository) GetApprovedRequests(ctx context.Context, userID string, start, end time.Time) ([]Request, error) {
+	args := m.Called(ctx, userID, start, end)
+	return args.Get(0).([]Request), args.Error(1)
+}
+
+func (m *MockLeaveRepository) UpdateRequestStatus(ctx context.Context, request *Request, from approval.Status) (bool, error) {
+	args := m.Called(ctx, request, from)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockHolidayCalendar adalah implementasi mock untuk leave.HolidayCalendar
+type MockHolidayCalendar struct {
+	mock.Mock
+}
+
+func (m *MockHolidayCalendar) IsHolidayFor(ctx context.Context, userID string, date time.Time) (bool, error) {
+	args := m.Called(ctx, userID, date)
+	return args.Bool(0), args.Error(1)
+}
+
+func day(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+var (
+	annual = Type{ID: "type-annual", Code: "ANNUAL", Name: "Cuti Tahunan", Paid: true, AnnualDays: 12, Accrual: AccrualUpfront, CarryOverDays: 5, Active: true}
+	sick   = Type{ID: "type-sick", Code: "SICK", Name: "Sakit", Paid: true, Accrual: AccrualUpfront, Active: true}
+)
+
+func TestLeaveTypes(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("CreateType - Normalises code and validates carry-over", func(t *testing.T) {
+		mockRepo := new(MockLeaveRepository)
+		leaveService := NewService(mockRepo, new(auth.MockEmployeeRepository))
+
+		err := leaveService.CreateType(ctx, &Type{Code: "annual", Name: "Cuti Tahunan", AnnualDays: 12, CarryOverDays: 13}, "admin-001")
+		assert.EqualError(t, err, "carry_over_days must be between 0 and annual_days")
+
+		err = leaveService.CreateType(ctx, &Type{Code: "annual", Name: "Cuti Tahunan", AnnualDays: 12, Accrual: "weekly"}, "admin-001")
+		assert.EqualError(t, err, `unknown accrual "weekly"`)
+		mockRepo.AssertNotCalled(t, "CreateType", mock.Anything, mock.Anything)
+
+		mockRepo.On("CreateType", ctx, mock.MatchedBy(func(t *Type) bool {
+			return t.Code == "ANNUAL" && t.Accrual == AccrualUpfront && t.Active && t.CreatedBy == "admin-001"
+		})).Return(nil).Once()
+
+		err = leaveService.CreateType(ctx, &Type{Code: " annual ", Name: "Cuti Tahunan", AnnualDays: 12}, "admin-001")
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Accrued - Monthly accrual grows each month", func(t *testing.T) {
+		monthly := Type{AnnualDays: 12, Accrual: AccrualMonthly}
+
+		assert.Equal(t, 1, monthly.Accrued(12, day("2025-01-15")))
+		assert.Equal(t, 7, monthly.Accrued(12, day("2025-07-01")))
+		assert.Equal(t, 12, annual.Accrued(12, day("2025-01-01")))
+	})
+}
+
+func TestLeaveSubmission(t *testing.T) {
+	ctx := context.Background()
+	yearStart, yearEnd := day("2025-01-01"), day("2025-12-31")
+	noEntitlement := func(repo *MockLeaveRepository, typeID string) {
+		repo.On("GetEntitlement", ctx, "user-123", typeID, 2025).Return(nil, gorm.ErrRecordNotFound)
+	}
+
+	t.Run("SubmitRequest - Counts working days only", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(MockLeaveRepository)
+		mockHolidays := new(MockHolidayCalendar)
+		leaveService := NewService(mockRepo, new(auth.MockEmployeeRepository), WithHolidayCalendar(mockHolidays))
+
+		mockRepo.On("GetType", ctx, "type-annual").Return(&annual, nil).Once()
+		// Jumat 12 September sampai Selasa 16 September; Senin 15 libur.
+		mockHolidays.On("IsHolidayFor", ctx, "user-123", day("2025-09-15")).Return(true, nil)
+		mockHolidays.On("IsHolidayFor", ctx, "user-123", mock.Anything).Return(false, nil)
+		mockRepo.On("LockRequests", ctx, "user-123").Return(nil).Once()
+		mockRepo.On("GetActiveRequests", ctx, "user-123", yearStart, yearEnd).Return([]Request{}, nil).Once()
+		noEntitlement(mockRepo, "type-annual")
+		mockRepo.On("CreateRequest", ctx, mock.MatchedBy(func(r *Request) bool {
+			return r.Days == 2 && r.Paid && r.Status == approval.StatusPending && r.CreatedBy == "user-123"
+		})).Return(nil).Once()
+
+		// Act
+		request, err := leaveService.SubmitRequest(ctx, "user-123", "type-annual", day("2025-09-12"), day("2025-09-16"), "Liburan")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 2, request.Days)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("SubmitRequest - Rostered dates override weekdays", func(t *testing.T) {
+		mockRepo := new(MockLeaveRepository)
+		mockSchedules := new(shift.MockSchedules)
+		leaveService := NewService(mockRepo, new(auth.MockEmployeeRepository), WithSchedules(mockSchedules))
+
+		mockRepo.On("GetType", ctx, "type-sick").Return(&sick, nil).Once()
+		// Sabtu diroster masuk, Minggu tidak diroster.
+		mockSchedules.On("ScheduleFor", ctx, "user-123", day("2025-09-13")).
+			Return(shift.Schedule{Rostered: true, Shift: &shift.Shift{Code: "MORNING"}}, nil).Once()
+		mockSchedules.On("ScheduleFor", ctx, "user-123", day("2025-09-14")).Return(shift.Schedule{}, nil).Once()
+		mockRepo.On("LockRequests", ctx, "user-123").Return(nil).Once()
+		mockRepo.On("GetActiveRequests", ctx, "user-123", yearStart, yearEnd).Return([]Request{}, nil).Once()
+		mockRepo.On("CreateRequest", ctx, mock.MatchedBy(func(r *Request) bool { return r.Days == 1 })).Return(nil).Once()
+
+		_, err := leaveService.SubmitRequest(ctx, "user-123", "type-sick", day("2025-09-13"), day("2025-09-14"), "Demam")
+
+		assert.NoError(t, err)
+		// Cuti sakit tidak memakai saldo.
+		mockRepo.AssertNotCalled(t, "GetEntitlement", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("SubmitRequest - Fail because balance is insufficient", func(t *testing.T) {
+		mockRepo := new(MockLeaveRepository)
+		leaveService := NewService(mockRepo, new(auth.MockEmployeeRepository))
+
+		mockRepo.On("GetType", ctx, "type-annual").Return(&annual, nil).Once()
+		mockRepo.On("LockRequests", ctx, "user-123").Return(nil).Once()
+		// 12 hari jatah + 2 dibawa, 10 approved dan 3 pending: tersisa 1 hari.
+		mockRepo.On("GetActiveRequests", ctx, "user-123", yearStart, yearEnd).Return([]Request{
+			{TypeID: "type-annual", StartDate: day("2025-03-03"), EndDate: day("2025-03-14"), Days: 10, Decision: approval.Decision{Status: approval.StatusApproved}},
+			{TypeID: "type-annual", StartDate: day("2025-06-02"), EndDate: day("2025-06-04"), Days: 3, Decision: approval.Decision{Status: approval.StatusPending}},
+			{TypeID: "type-sick", StartDate: day("2025-07-01"), EndDate: day("2025-07-04"), Days: 4, Decision: approval.Decision{Status: approval.StatusApproved}},
+		}, nil).Once()
+		mockRepo.On("GetEntitlement", ctx, "user-123", "type-annual", 2025).
+			Return(&Entitlement{Days: 12, CarriedOver: 2}, nil).Once()
+
+		_, err := leaveService.SubmitRequest(ctx, "user-123", "type-annual", day("2025-09-15"), day("2025-09-16"), "")
+
+		assert.ErrorIs(t, err, ErrInsufficientBalance)
+		assert.EqualError(t, err, "insufficient leave balance: 2 days requested, 1 available")
+		mockRepo.AssertNotCalled(t, "CreateRequest", mock.Anything, mock.Anything)
+	})
+
+	t.Run("SubmitRequest - Monthly accrual limits early leave", func(t *testing.T) {
+		mockRepo := new(MockLeaveRepository)
+		leaveService := NewService(mockRepo, new(auth.MockEmployeeRepository))
+		monthly := annual
+		monthly.Accrual = AccrualMonthly
+
+		mockRepo.On("GetType", ctx, "type-annual").Return(&monthly, nil).Once()
+		mockRepo.On("LockRequests", ctx, "user-123").Return(nil).Once()
+		mockRepo.On("GetActiveRequests", ctx, "user-123", yearStart, yearEnd).Return([]Request{}, nil).Once()
+		noEntitlement(mockRepo, "type-annual")
+
+		// Pada Februari baru 2 dari 12 hari yang terakru.
+		_, err := leaveService.SubmitRequest(ctx, "user-123", "type-annual", day("2025-02-10"), day("2025-02-12"), "")
+
+		assert.EqualError(t, err, "insufficient leave balance: 3 days requested, 2 available")
+	})
+
+	t.Run("SubmitRequest - Fail because dates overlap", func(t *testing.T) {
+		mockRepo := new(MockLeaveRepository)
+		leaveService := NewService(mockRepo, new(auth.MockEmployeeRepository))
+
+		mockRepo.On("GetType", ctx, "type-sick").Return(&sick, nil).Once()
+		mockRepo.On("LockRequests", ctx, "user-123").Return(nil).Once()
+		mockRepo.On("GetActiveRequests", ctx, "user-123", yearStart, yearEnd).Return([]Request{
+			{TypeID: "type-annual", StartDate: day("2025-09-10"), EndDate: day("2025-09-12"), Days: 3, Decision: approval.Decision{Status: approval.StatusPending}},
+		}, nil).Once()
+
+		_, err := leaveService.SubmitRequest(ctx, "user-123", "type-sick", day("2025-09-12"), day("2025-09-15"), "")
+
+		assert.ErrorIs(t, err, ErrOverlap)
+		mockRepo.AssertNotCalled(t, "CreateRequest", mock.Anything, mock.Anything)
+	})
+
+	t.Run("SubmitRequest - Fail because a date is in a closed period", func(t *testing.T) {
+		mockRepo := new(MockLeaveRepository)
+		mockLock := new(periodlock.MockChecker)
+		leaveService := NewService(mockRepo, new(auth.MockEmployeeRepository), WithPeriodLock(mockLock))
+
+		mockRepo.On("GetType", ctx, "type-annual").Return(&annual, nil).Once()
+		// Hanya hari terakhir cuti yang masuk periode yang sudah ditutup.
+		mockLock.On("IsDateLocked", ctx, day("2025-09-15")).Return(false, nil).Once()
+		mockLock.On("IsDateLocked", ctx, day("2025-09-16")).Return(true, nil).Once()
+
+		_, err := leaveService.SubmitRequest(ctx, "user-123", "type-annual", day("2025-09-15"), day("2025-09-16"), "")
+
+		assert.ErrorIs(t, err, periodlock.ErrLocked)
+		mockRepo.AssertNotCalled(t, "CreateRequest", mock.Anything, mock.Anything)
+		mockLock.AssertExpectations(t)
+	})
+
+	t.Run("SubmitRequest - Rejects invalid ranges and types", func(t *testing.T) {
+		mockRepo := new(MockLeaveRepository)
+		leaveService := NewService(mockRepo, new(auth.MockEmployeeRepository))
+		inactive := sick
+		inactive.Active = false
+
+		_, err := leaveService.SubmitRequest(ctx, "user-123", "type-annual", day("2025-12-30"), day("2026-01-02"), "")
+		assert.EqualError(t, err, "leave cannot span two years; submit one request per year")
+
+		mockRepo.On("GetType", ctx, "type-sick").Return(&inactive, nil).Once()
+		_, err = leaveService.SubmitRequest(ctx, "user-123", "type-sick", day("2025-09-10"), day("2025-09-10"), "")
+		assert.ErrorIs(t, err, ErrUnknownType)
+
+		mockRepo.On("GetType", ctx, "type-annual").Return(&annual, nil).Once()
+		_, err = leaveService.SubmitRequest(ctx, "user-123", "type-annual", day("2025-09-13"), day("2025-09-14"), "")
+		assert.EqualError(t, err, "leave must include at least one working day")
+		mockRepo.AssertNotCalled(t, "CreateRequest", mock.Anything, mock.Anything)
+	})
+}
+
+func TestLeaveEntitlements(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("GrantEntitlements - Carry-over is capped", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(MockLeaveRepository)
+		mockEmployees := new(auth.MockEmployeeRepository)
+		leaveService := NewService(mockRepo, mockEmployees)
+
+		mockRepo.On("ListTypes", ctx).Return([]Type{annual, sick}, nil).Once()
+		mockEmployees.On("GetAllEmployees", ctx).Return([]employee.Employee{{ID: "user-123"}, {ID: "user-456"}}, nil).Once()
+		// user-123 memakai 4 dari 12 hari: sisa 8, dibawa paling banyak 5.
+		mockRepo.On("GetActiveRequests", ctx, "user-123", day("2024-01-01"), day("2024-12-31")).Return([]Request{
+			{TypeID: "type-annual", Days: 4, Decision: approval.Decision{Status: approval.StatusApproved}},
+			{TypeID: "type-annual", Days: 2, Decision: approval.Decision{Status: approval.StatusPending}},
+		}, nil).Once()
+		mockRepo.On("GetEntitlement", ctx, "user-123", "type-annual", 2024).Return(&Entitlement{Days: 12}, nil).Once()
+		// user-456 memakai 11 dari 12 hari + 1 dibawa: sisa 2.
+		mockRepo.On("GetActiveRequests", ctx, "user-456", day("2024-01-01"), day("2024-12-31")).Return([]Request{
+			{TypeID: "type-annual", Days: 11, Decision: approval.Decision{Status: approval.StatusApproved}},
+		}, nil).Once()
+		mockRepo.On("GetEntitlement", ctx, "user-456", "type-annual", 2024).Return(&Entitlement{Days: 12, CarriedOver: 1}, nil).Once()
+		mockRepo.On("SaveEntitlements", ctx, mock.MatchedBy(func(es []Entitlement) bool {
+			return len(es) == 2 &&
+				es[0].UserID == "user-123" && es[0].Year == 2025 && es[0].Days == 12 && es[0].CarriedOver == 5 &&
+				es[1].UserID == "user-456" && es[1].CarriedOver == 2 && es[1].CreatedBy == "admin-001"
+		})).Return(nil).Once()
+
+		// Act
+		entitlements, err := leaveService.GrantEntitlements(ctx, 2025, "admin-001")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, entitlements, 2)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("GrantEntitlements - No carry-over without a previous-year entitlement", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(MockLeaveRepository)
+		mockEmployees := new(auth.MockEmployeeRepository)
+		leaveService := NewService(mockRepo, mockEmployees)
+
+		mockRepo.On("ListTypes", ctx).Return([]Type{annual}, nil).Once()
+		mockEmployees.On("GetAllEmployees", ctx).Return([]employee.Employee{{ID: "user-789"}}, nil).Once()
+		mockRepo.On("GetActiveRequests", ctx, "user-789", day("2024-01-01"), day("2024-12-31")).Return([]Request{}, nil).Once()
+		// Jatah 2024 tidak pernah diberikan, mis. karyawan baru bergabung.
+		mockRepo.On("GetEntitlement", ctx, "user-789", "type-annual", 2024).Return(nil, gorm.ErrRecordNotFound).Once()
+		mockRepo.On("SaveEntitlements", ctx, mock.MatchedBy(func(es []Entitlement) bool {
+			return len(es) == 1 && es[0].Days == 12 && es[0].CarriedOver == 0
+		})).Return(nil).Once()
+
+		// Act
+		entitlements, err := leaveService.GrantEntitlements(ctx, 2025, "admin-001")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 0, entitlements[0].CarriedOver)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("GetBalances - Tracked types only", func(t *testing.T) {
+		mockRepo := new(MockLeaveRepository)
+		leaveService := NewService(mockRepo, new(auth.MockEmployeeRepository))
+
+		mockRepo.On("ListTypes", ctx).Return([]Type{annual, sick}, nil).Once()
+		mockRepo.On("GetActiveRequests", ctx, "user-123", day("2025-01-01"), day("2025-12-31")).Return([]Request{
+			{TypeID: "type-annual", Days: 3, Decision: approval.Decision{Status: approval.StatusApproved}},
+			{TypeID: "type-annual", Days: 1, Decision: approval.Decision{Status: approval.StatusPending}},
+		}, nil).Once()
+		mockRepo.On("GetEntitlement", ctx, "user-123", "type-annual", 2025).Return(&Entitlement{Days: 12, CarriedOver: 5}, nil).Once()
+
+		balances, err := leaveService.GetBalances(ctx, "user-123", day("2025-09-10"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Balance{{
+			TypeID: "type-annual", TypeCode: "ANNUAL", Year: 2025,
+			Entitled: 12, CarriedOver: 5, Accrued: 12, Used: 3, Pending: 1, Available: 13,
+		}}, balances)
+	})
+}
+
+func TestLeaveApproval(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 9, 10, 9, 0, 0, 0, time.UTC)
+	pending := func() *Request {
+		return &Request{ID: "leave-001", UserID: "user-123", TypeID: "type-annual",
+			StartDate: day("2025-09-15"), EndDate: day("2025-09-15"), Days: 1, Decision: approval.Decision{Status: approval.StatusPending}}
+	}
+	report := &employee.Employee{ID: "user-123", Role: "employee", ManagerID: "manager-001"}
+
+	t.Run("ApproveRequest - Manager approves a direct report", func(t *testing.T) {
+		mockRepo := new(MockLeaveRepository)
+		mockEmployees := new(auth.MockEmployeeRepository)
+		leaveService := NewService(mockRepo, mockEmployees, WithClock(func() time.Time { return now }))
+
+		mockRepo.On("GetRequest", ctx, "leave-001").Return(pending(), nil).Once()
+		mockEmployees.On("GetByID", ctx, "user-123").Return(report, nil).Once()
+		mockRepo.On("UpdateRequestStatus", ctx, mock.MatchedBy(func(r *Request) bool {
+			return r.Status == approval.StatusApproved && r.ReviewedBy == "manager-001"
+		}), approval.StatusPending).Return(true, nil).Once()
+
+		request, err := leaveService.ApproveRequest(ctx, "leave-001", employee.Reviewer{ID: "manager-001", Role: "manager"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, now, *request.ReviewedAt)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("ApproveRequest - Manager of another team is rejected", func(t *testing.T) {
+		mockRepo := new(MockLeaveRepository)
+		mockEmployees := new(auth.MockEmployeeRepository)
+		leaveService := NewService(mockRepo, mockEmployees)
+
+		mockRepo.On("GetRequest", ctx, "leave-001").Return(pending(), nil).Once()
+		mockEmployees.On("GetByID", ctx, "user-123").Return(report, nil).Once()
+
+		_, err := leaveService.ApproveRequest(ctx, "leave-001", employee.Reviewer{ID: "manager-002", Role: "manager"})
+
+		assert.ErrorIs(t, err, approval.ErrNotReviewer)
+		mockRepo.AssertNotCalled(t, "UpdateRequestStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ApproveRequest - Fail because the period is closed", func(t *testing.T) {
+		mockRepo := new(MockLeaveRepository)
+		mockEmployees := new(auth.MockEmployeeRepository)
+		mockLock := new(periodlock.MockChecker)
+		leaveService := NewService(mockRepo, mockEmployees, WithPeriodLock(mockLock))
+
+		mockRepo.On("GetRequest", ctx, "leave-001").Return(pending(), nil).Once()
+		mockEmployees.On("GetByID", ctx, "user-123").Return(report, nil).Once()
+		mockLock.On("IsDateLocked", ctx, day("2025-09-15")).Return(true, nil).Once()
+
+		_, err := leaveService.ApproveRequest(ctx, "leave-001", employee.Reviewer{ID: "manager-001", Role: "manager"})
+
+		assert.ErrorIs(t, err, periodlock.ErrLocked)
+		mockRepo.AssertNotCalled(t, "UpdateRequestStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ApproveRequest - Fail because the period is already calculated", func(t *testing.T) {
+		mockRepo := new(MockLeaveRepository)
+		mockEmployees := new(auth.MockEmployeeRepository)
+		mockLock := new(periodlock.MockChecker)
+		leaveService := NewService(mockRepo, mockEmployees, WithPeriodLock(mockLock))
+
+		mockRepo.On("GetRequest", ctx, "leave-001").Return(pending(), nil).Once()
+		mockEmployees.On("GetByID", ctx, "user-123").Return(report, nil).Once()
+		mockLock.On("IsDateLocked", ctx, day("2025-09-15")).Return(false, nil).Once()
+		mockLock.On("IsDateCalculated", ctx, day("2025-09-15")).Return(true, nil).Once()
+
+		_, err := leaveService.ApproveRequest(ctx, "leave-001", employee.Reviewer{ID: "manager-001", Role: "manager"})
+
+		assert.ErrorIs(t, err, periodlock.ErrCalculated)
+		mockRepo.AssertNotCalled(t, "UpdateRequestStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("RejectRequest - Allowed even when the period is calculated", func(t *testing.T) {
+		mockRepo := new(MockLeaveRepository)
+		mockEmployees := new(auth.MockEmployeeRepository)
+		mockLock := new(periodlock.MockChecker) // tidak dipanggil untuk penolakan
+		leaveService := NewService(mockRepo, mockEmployees, WithPeriodLock(mockLock))
+
+		mockRepo.On("GetRequest", ctx, "leave-001").Return(pending(), nil).Once()
+		mockEmployees.On("GetByID", ctx, "user-123").Return(report, nil).Once()
+		mockRepo.On("UpdateRequestStatus", ctx, mock.MatchedBy(func(r *Request) bool {
+			return r.Status == approval.StatusRejected
+		}), approval.StatusPending).Return(true, nil).Once()
+
+		_, err := leaveService.RejectRequest(ctx, "leave-001", "Tim sedang kurang orang", employee.Reviewer{ID: "manager-001", Role: "manager"})
+
+		assert.NoError(t, err)
+		mockLock.AssertNotCalled(t, "IsDateCalculated", mock.Anything, mock.Anything)
+	})
+
+	t.Run("CancelRequest - Only the owner can cancel", func(t *testing.T) {
+		mockRepo := new(MockLeaveRepository)
+		leaveService := NewService(mockRepo, new(auth.MockEmployeeRepository))
+
+		mockRepo.On("GetRequest", ctx, "leave-001").Return(pending(), nil)
+		mockRepo.On("UpdateRequestStatus", ctx, mock.MatchedBy(func(r *Request) bool {
+			return r.Status == approval.StatusCancelled && r.UpdatedBy == "user-123" && r.ReviewedBy == ""
+		}), approval.StatusPending).Return(true, nil).Once()
+
+		_, err := leaveService.CancelRequest(ctx, "leave-001", "user-456")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		request, err := leaveService.CancelRequest(ctx, "leave-001", "user-123")
+		assert.NoError(t, err)
+		assert.Equal(t, approval.StatusCancelled, request.Status)
+		mockRepo.AssertExpectations(t)
+	})
+}
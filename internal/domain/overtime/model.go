@@ -3,15 +3,19 @@ package overtime
 import (
 	"time"
 
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type Overtime struct {
-	ID        string    `gorm:"primaryKey"`
-	UserID    string    `gorm:"index"`
-	Date      time.Time `gorm:"type:date"`
-	Hours     int
+	ID     string    `json:"id" gorm:"primaryKey"`
+	UserID string    `json:"user_id" gorm:"index"`
+	Date   time.Time `json:"date" gorm:"type:date"`
+	Hours  int       `json:"hours"`
+	// Status persetujuan; hanya lembur approved yang dibayar oleh payroll.
+	approval.Decision
+	PayslipID string    `gorm:"size:36;index" json:"payslip_id,omitempty"` // Payslip yang membayar lembur ini
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	CreatedBy string    `gorm:"size:36" json:"created_by"`
@@ -22,3 +26,20 @@ func (o *Overtime) BeforeCreate(tx *gorm.DB) error {
 	o.ID = uuid.New().String()
 	return nil
 }
+
+// Revision menyimpan nilai lembur sebelum diubah oleh karyawan.
+type Revision struct {
+	ID         string    `json:"id" gorm:"primaryKey"`
+	OvertimeID string    `json:"overtime_id" gorm:"index;size:36"`
+	Date       time.Time `json:"date" gorm:"type:date"`
+	Hours      int       `json:"hours"`
+	ChangedBy  string    `json:"changed_by" gorm:"size:36"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+func (Revision) TableName() string { return "overtime_revisions" }
+
+func (r *Revision) BeforeCreate(tx *gorm.DB) error {
+	r.ID = uuid.New().String()
+	return nil
+}
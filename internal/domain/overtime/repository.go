@@ -2,12 +2,40 @@ package overtime
 
 import (
 	"context"
+	"time"
 
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
 	"gorm.io/gorm"
 )
 
 type Repository interface {
 	CreateOvertime(ctx context.Context, overtime *Overtime) error
+	GetOvertime(ctx context.Context, id string) (*Overtime, error)
+	ListOvertimes(ctx context.Context, filter ListFilter) ([]Overtime, error)
+	// CountOvertimes menghitung lembur sesuai filter tanpa Limit dan Offset.
+	CountOvertimes(ctx context.Context, filter ListFilter) (int64, error)
+	// UpdateOvertimeStatus menyimpan perubahan status hanya jika status di
+	// database masih from. Nilai false berarti lembur sudah diubah proses lain.
+	UpdateOvertimeStatus(ctx context.Context, overtime *Overtime, from approval.Status) (bool, error)
+	// UpdateOvertime menyimpan tanggal dan jam lembur yang diubah karyawan
+	// beserta revision berisi nilai sebelumnya, hanya jika lembur masih
+	// pending. Nilai false berarti lembur sudah diubah proses lain.
+	UpdateOvertime(ctx context.Context, overtime *Overtime, revision Revision) (bool, error)
+	// GetRevisions mengembalikan nilai-nilai lembur sebelum diubah, dari
+	// yang terlama.
+	GetRevisions(ctx context.Context, overtimeID string) ([]Revision, error)
+}
+
+// ListFilter membatasi daftar lembur. Field kosong tidak membatasi; Limit 0
+// berarti tanpa batas jumlah.
+type ListFilter struct {
+	Status    approval.Status
+	UserID    string
+	ManagerID string // Hanya lembur bawahan langsung manager ini
+	StartDate *time.Time
+	EndDate   *time.Time
+	Limit     int
+	Offset    int
 }
 
 type repository struct {
@@ -21,3 +49,89 @@ func NewRepository(db *gorm.DB) Repository {
 func (r *repository) CreateOvertime(ctx context.Context, overtime *Overtime) error {
 	return r.db.WithContext(ctx).Create(overtime).Error
 }
+
+func (r *repository) GetOvertime(ctx context.Context, id string) (*Overtime, error) {
+	var overtime Overtime
+	if err := r.db.WithContext(ctx).First(&overtime, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &overtime, nil
+}
+
+func (r *repository) ListOvertimes(ctx context.Context, filter ListFilter) ([]Overtime, error) {
+	q := r.filtered(ctx, filter).Order("date, created_at")
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit).Offset(filter.Offset)
+	}
+	var overtimes []Overtime
+	err := q.Find(&overtimes).Error
+	return overtimes, err
+}
+
+func (r *repository) CountOvertimes(ctx context.Context, filter ListFilter) (int64, error) {
+	var count int64
+	err := r.filtered(ctx, filter).Model(&Overtime{}).Count(&count).Error
+	return count, err
+}
+
+func (r *repository) filtered(ctx context.Context, filter ListFilter) *gorm.DB {
+	q := r.db.WithContext(ctx)
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	if filter.UserID != "" {
+		q = q.Where("user_id = ?", filter.UserID)
+	}
+	if filter.ManagerID != "" {
+		q = q.Where("user_id IN (?)", r.db.Table("employees").Select("id").Where("manager_id = ?", filter.ManagerID))
+	}
+	if filter.StartDate != nil {
+		q = q.Where("date >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		q = q.Where("date <= ?", *filter.EndDate)
+	}
+	return q
+}
+
+func (r *repository) UpdateOvertimeStatus(ctx context.Context, overtime *Overtime, from approval.Status) (bool, error) {
+	updates := map[string]interface{}{
+		"status":        overtime.Status,
+		"reviewed_by":   overtime.ReviewedBy,
+		"reviewed_at":   overtime.ReviewedAt,
+		"review_reason": overtime.ReviewReason,
+		"updated_by":    overtime.UpdatedBy,
+	}
+	res := r.db.WithContext(ctx).Model(&Overtime{}).Where("id = ? AND status = ?", overtime.ID, from).Updates(updates)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *repository) UpdateOvertime(ctx context.Context, overtime *Overtime, revision Revision) (bool, error) {
+	updated := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"date":       overtime.Date,
+			"hours":      overtime.Hours,
+			"updated_by": overtime.UpdatedBy,
+		}
+		res := tx.Model(&Overtime{}).Where("id = ? AND status = ?", overtime.ID, approval.StatusPending).Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected != 1 {
+			return nil
+		}
+		updated = true
+		return tx.Create(&revision).Error
+	})
+	return updated, err
+}
+
+func (r *repository) GetRevisions(ctx context.Context, overtimeID string) ([]Revision, error) {
+	var revisions []Revision
+	err := r.db.WithContext(ctx).Where("overtime_id = ?", overtimeID).Order("changed_at").Find(&revisions).Error
+	return revisions, err
+}
@@ -3,39 +3,302 @@ package overtime
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/periodlock"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/shift"
 )
 
 type Service interface {
 	// SubmitAttendance(ctx context.Context, userID string) error
+	// SubmitOvertime membuat pengajuan lembur berstatus pending.
 	SubmitOvertime(ctx context.Context, userID string, date time.Time, hours int) error
 	// SubmitReimbursement(ctx context.Context, userID string, date time.Time, description string, amount float64) error
+
+	// ListOvertimes menampilkan pengajuan lembur yang boleh diputuskan
+	// reviewer: semua untuk admin, bawahan langsung untuk manager.
+	ListOvertimes(ctx context.Context, reviewer employee.Reviewer, filter ListFilter) ([]Overtime, error)
+	// ListMyOvertimes mengembalikan lembur milik userID beserta jumlah
+	// seluruhnya sebelum Limit dan Offset diterapkan.
+	ListMyOvertimes(ctx context.Context, userID string, filter ListFilter) ([]Overtime, int64, error)
+	// ApproveOvertime dan RejectOvertime memutuskan lembur pending. Alasan
+	// wajib diisi saat menolak.
+	ApproveOvertime(ctx context.Context, overtimeID string, reviewer employee.Reviewer) (*Overtime, error)
+	RejectOvertime(ctx context.Context, overtimeID, reason string, reviewer employee.Reviewer) (*Overtime, error)
+	// UpdateOvertime mengubah tanggal dan jam lembur pending milik userID
+	// sendiri; nilai sebelumnya disimpan sebagai Revision.
+	UpdateOvertime(ctx context.Context, overtimeID, userID string, date time.Time, hours int) (*Overtime, error)
+	// CancelOvertime membatalkan lembur pending milik userID sendiri.
+	CancelOvertime(ctx context.Context, overtimeID, userID string) (*Overtime, error)
+	// GetRevisions mengembalikan riwayat perubahan lembur bagi pemiliknya
+	// atau reviewer yang berwenang atasnya.
+	GetRevisions(ctx context.Context, overtimeID string, viewer employee.Reviewer) ([]Revision, error)
 }
 
 type service struct {
-	repo Repository
+	repo      Repository
+	employees employee.Repository
+	lock      periodlock.Guard
+	zones     TimeZones
+	schedules shift.Schedules
+	now       func() time.Time
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo}
+// TimeZones mengembalikan zona waktu karyawan. Batas pukul 17.00 untuk lembur
+// hari ini dihitung pada zona tersebut.
+type TimeZones interface {
+	TimeZoneFor(ctx context.Context, userID string) (*time.Location, error)
 }
 
-func (s *service) SubmitOvertime(ctx context.Context, userID string, date time.Time, hours int) error {
-	if date.Format("2006-01-02") == time.Now().Format("2006-01-02") && time.Now().Hour() < 17 {
-		return errors.New("overtime can only be submitted after 5 PM")
+// Option mengubah konfigurasi opsional dari service overtime.
+type Option func(*service)
+
+// WithPeriodLock menolak pengajuan, perubahan, pembatalan, dan persetujuan
+// lembur pada tanggal yang periode payroll-nya sudah ditutup.
+func WithPeriodLock(lock periodlock.Checker) Option {
+	return func(s *service) {
+		s.lock = periodlock.Guard{Checker: lock}
+	}
+}
+
+// WithSchedules mengganti batas pukul 17.00 dengan akhir shift yang diroster
+// pada tanggal lembur.
+func WithSchedules(schedules shift.Schedules) Option {
+	return func(s *service) {
+		s.schedules = schedules
+	}
+}
+
+// WithTimeZones menghitung "hari ini" dan batas pukul 17.00 pada zona waktu
+// masing-masing karyawan. Tanpa opsi ini dipakai zona waktu dari WithClock.
+func WithTimeZones(z TimeZones) Option {
+	return func(s *service) {
+		s.zones = z
+	}
+}
+
+// WithClock mengganti sumber waktu saat ini. Default: time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(s *service) {
+		s.now = now
+	}
+}
+
+func NewService(repo Repository, employees employee.Repository, opts ...Option) Service {
+	s := &service{repo: repo, employees: employees, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// validate memastikan tanggal dan jam lembur userID dapat diajukan. date
+// adalah tanggal kalender karyawan.
+func (s *service) validate(ctx context.Context, userID string, date time.Time, hours int) error {
+	now := s.now()
+	if s.zones != nil {
+		loc, err := s.zones.TimeZoneFor(ctx, userID)
+		if err != nil {
+			return err
+		}
+		now = now.In(loc)
+	}
+	if err := s.checkCutoff(ctx, userID, date, now); err != nil {
+		return err
 	}
 
 	if hours <= 0 || hours > 3 {
 		return errors.New("overtime must be between 1 and 3 hours")
 	}
+	return nil
+}
+
+// checkCutoff memastikan lembur pada date diajukan setelah jam kerja hari itu
+// berakhir: akhir shift yang diroster (termasuk shift malam yang berakhir
+// keesokan harinya), atau pukul 17.00 untuk lembur hari ini.
+func (s *service) checkCutoff(ctx context.Context, userID string, date, now time.Time) error {
+	if s.schedules != nil {
+		schedule, err := s.schedules.ScheduleFor(ctx, userID, date)
+		if err != nil {
+			return err
+		}
+		if schedule.Working() {
+			_, end, err := schedule.Shift.Window(date, now.Location())
+			if err != nil {
+				return err
+			}
+			if now.Before(end) {
+				return fmt.Errorf("overtime can only be submitted after the shift ends at %s", end.Format("2006-01-02 15:04"))
+			}
+			return nil
+		}
+	}
+	if date.Format("2006-01-02") == now.Format("2006-01-02") && now.Hour() < 17 {
+		return errors.New("overtime can only be submitted after 5 PM")
+	}
+	return nil
+}
+
+func (s *service) SubmitOvertime(ctx context.Context, userID string, date time.Time, hours int) error {
+	if err := s.validate(ctx, userID, date, hours); err != nil {
+		return err
+	}
+
+	if err := s.lock.Check(ctx, date); err != nil {
+		return err
+	}
 
 	overtime := &Overtime{
 		UserID:    userID,
 		Date:      date,
 		Hours:     hours,
+		Decision:  approval.Decision{Status: approval.StatusPending},
 		CreatedBy: userID,
 		UpdatedBy: userID,
 	}
 
 	return s.repo.CreateOvertime(ctx, overtime)
 }
+
+func (s *service) ListOvertimes(ctx context.Context, reviewer employee.Reviewer, filter ListFilter) ([]Overtime, error) {
+	managerID, err := approval.ManagerScope(reviewer)
+	if err != nil {
+		return nil, err
+	}
+	filter.ManagerID = managerID
+	return s.repo.ListOvertimes(ctx, filter)
+}
+
+func (s *service) ListMyOvertimes(ctx context.Context, userID string, filter ListFilter) ([]Overtime, int64, error) {
+	filter.UserID = userID
+	filter.ManagerID = ""
+	overtimes, err := s.repo.ListOvertimes(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.CountOvertimes(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return overtimes, total, nil
+}
+
+func (s *service) ApproveOvertime(ctx context.Context, overtimeID string, reviewer employee.Reviewer) (*Overtime, error) {
+	return s.review(ctx, overtimeID, approval.StatusApproved, "", reviewer)
+}
+
+func (s *service) RejectOvertime(ctx context.Context, overtimeID, reason string, reviewer employee.Reviewer) (*Overtime, error) {
+	reason, err := approval.RejectReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	return s.review(ctx, overtimeID, approval.StatusRejected, reason, reviewer)
+}
+
+// review memutuskan lembur pending setelah memastikan reviewer berwenang atas
+// karyawan pemilik lembur. Persetujuan untuk tanggal di periode yang sudah
+// ditutup ditolak karena tidak akan pernah dibayar.
+func (s *service) review(ctx context.Context, overtimeID string, next approval.Status, reason string, reviewer employee.Reviewer) (*Overtime, error) {
+	overtime, err := s.repo.GetOvertime(ctx, overtimeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := approval.Authorize(ctx, s.employees, reviewer, overtime.UserID); err != nil {
+		return nil, err
+	}
+	if next == approval.StatusApproved {
+		if err := s.lock.Check(ctx, overtime.Date); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.transition(ctx, overtime, next, reviewer.ID, reason); err != nil {
+		return nil, err
+	}
+	return overtime, nil
+}
+
+// ownPending mengembalikan lembur pending milik userID yang tanggalnya belum
+// masuk periode payroll yang ditutup.
+func (s *service) ownPending(ctx context.Context, overtimeID, userID string) (*Overtime, error) {
+	overtime, err := s.repo.GetOvertime(ctx, overtimeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := approval.CheckOwner(overtime.UserID, userID); err != nil {
+		return nil, err
+	}
+	if err := overtime.CheckPending(); err != nil {
+		return nil, err
+	}
+	if err := s.lock.Check(ctx, overtime.Date); err != nil {
+		return nil, err
+	}
+	return overtime, nil
+}
+
+func (s *service) UpdateOvertime(ctx context.Context, overtimeID, userID string, date time.Time, hours int) (*Overtime, error) {
+	overtime, err := s.ownPending(ctx, overtimeID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validate(ctx, userID, date, hours); err != nil {
+		return nil, err
+	}
+	if err := s.lock.Check(ctx, date); err != nil {
+		return nil, err
+	}
+
+	revision := Revision{
+		OvertimeID: overtime.ID,
+		Date:       overtime.Date,
+		Hours:      overtime.Hours,
+		ChangedBy:  userID,
+		ChangedAt:  s.now(),
+	}
+	overtime.Date = date
+	overtime.Hours = hours
+	overtime.UpdatedBy = userID
+	ok, err := s.repo.UpdateOvertime(ctx, overtime, revision)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, approval.ErrModified
+	}
+	return overtime, nil
+}
+
+func (s *service) CancelOvertime(ctx context.Context, overtimeID, userID string) (*Overtime, error) {
+	overtime, err := s.ownPending(ctx, overtimeID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.transition(ctx, overtime, approval.StatusCancelled, userID, ""); err != nil {
+		return nil, err
+	}
+	return overtime, nil
+}
+
+func (s *service) GetRevisions(ctx context.Context, overtimeID string, viewer employee.Reviewer) ([]Revision, error) {
+	overtime, err := s.repo.GetOvertime(ctx, overtimeID)
+	if err != nil {
+		return nil, err
+	}
+	if overtime.UserID != viewer.ID {
+		if err := approval.Authorize(ctx, s.employees, viewer, overtime.UserID); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.GetRevisions(ctx, overtimeID)
+}
+
+// transition memutuskan atau membatalkan lembur dengan pembaruan bersyarat.
+func (s *service) transition(ctx context.Context, overtime *Overtime, next approval.Status, actorID, reason string) error {
+	return overtime.Decide(next, actorID, reason, s.now(), func(from approval.Status) (bool, error) {
+		overtime.UpdatedBy = actorID
+		return s.repo.UpdateOvertimeStatus(ctx, overtime, from)
+	})
+}
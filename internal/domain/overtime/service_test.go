@@ -5,11 +5,17 @@ import (
 	"testing"
 	"time"
 
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/auth"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/periodlock"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/shift"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
-// MockSubmissionRepository adalah implementasi mock untuk submission.Repository
+// MockOvertimeRepository adalah implementasi mock untuk overtime.Repository
 type MockOvertimeRepository struct {
 	mock.Mock
 }
@@ -19,20 +25,383 @@ func (m *MockOvertimeRepository) CreateOvertime(ctx context.Context, overtime *O
 	return args.Error(0)
 }
 
+func (m *MockOvertimeRepository) GetOvertime(ctx context.Context, id string) (*Overtime, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Overtime), args.Error(1)
+}
+
+func (m *MockOvertimeRepository) ListOvertimes(ctx context.Context, filter ListFilter) ([]Overtime, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]Overtime), args.Error(1)
+}
+
+func (m *MockOvertimeRepository) CountOvertimes(ctx context.Context, filter ListFilter) (int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockOvertimeRepository) UpdateOvertime(ctx context.Context, overtime *Overtime, revision Revision) (bool, error) {
+	args := m.Called(ctx, overtime, revision)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOvertimeRepository) GetRevisions(ctx context.Context, overtimeID string) ([]Revision, error) {
+	args := m.Called(ctx, overtimeID)
+	return args.Get(0).([]Revision), args.Error(1)
+}
+
+func (m *MockOvertimeRepository) UpdateOvertimeStatus(ctx context.Context, overtime *Overtime, from approval.Status) (bool, error) {
+	args := m.Called(ctx, overtime, from)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockTimeZones adalah implementasi mock untuk overtime.TimeZones
+type MockTimeZones struct {
+	mock.Mock
+}
+
+func (m *MockTimeZones) TimeZoneFor(ctx context.Context, userID string) (*time.Location, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(*time.Location), args.Error(1)
+}
+
+// evening adalah Rabu pukul 18.00, setelah batas pengajuan lembur.
+var evening = time.Date(2025, 9, 10, 18, 0, 0, 0, time.UTC)
+
+func fixedClock(t time.Time) Option {
+	return WithClock(func() time.Time { return t })
+}
+
 func TestSubmissionService(t *testing.T) {
 	t.Run("SubmitOvertime - Fail because hours are more than 3", func(t *testing.T) {
 		// Arrange
 		mockRepo := new(MockOvertimeRepository)
-		submissionService := NewService(mockRepo)
+		submissionService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(evening))
 		ctx := context.Background()
 		userID := "user-123"
 
 		// Act
-		err := submissionService.SubmitOvertime(ctx, userID, time.Now(), 4)
+		err := submissionService.SubmitOvertime(ctx, userID, evening, 4)
 
 		// Assert
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "must be between 1 and 3 hours")
 	})
 
+	t.Run("SubmitOvertime - Fail because submitted before 5 PM", func(t *testing.T) {
+		mockRepo := new(MockOvertimeRepository)
+		submissionService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(evening.Add(-2*time.Hour)))
+
+		err := submissionService.SubmitOvertime(context.Background(), "user-123", evening, 2)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "after 5 PM")
+	})
+
+	t.Run("SubmitOvertime - 5 PM cutoff in the employee's time zone", func(t *testing.T) {
+		mockRepo := new(MockOvertimeRepository)
+		mockZones := new(MockTimeZones)
+		// 10.30 UTC adalah 17.30 WIB dan 18.30 WITA, tetapi baru 10.30 bagi karyawan di UTC.
+		submissionService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(evening.Add(-7*time.Hour-30*time.Minute)), WithTimeZones(mockZones))
+		ctx := context.Background()
+		today := time.Date(2025, 9, 10, 0, 0, 0, 0, time.UTC)
+
+		mockZones.On("TimeZoneFor", ctx, "user-wib").Return(time.FixedZone("WIB", 7*3600), nil).Once()
+		mockZones.On("TimeZoneFor", ctx, "user-utc").Return(time.UTC, nil).Once()
+		mockRepo.On("CreateOvertime", ctx, mock.AnythingOfType("*overtime.Overtime")).Return(nil).Once()
+
+		assert.NoError(t, submissionService.SubmitOvertime(ctx, "user-wib", today, 2))
+
+		err := submissionService.SubmitOvertime(ctx, "user-utc", today, 2)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "after 5 PM")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("SubmitOvertime - Cutoff at the end of the rostered shift", func(t *testing.T) {
+		mockRepo := new(MockOvertimeRepository)
+		mockSchedules := new(shift.MockSchedules)
+		ctx := context.Background()
+		saturday := time.Date(2025, 9, 13, 0, 0, 0, 0, time.UTC)
+		wednesday := time.Date(2025, 9, 10, 0, 0, 0, 0, time.UTC)
+		early := shift.Schedule{Rostered: true, Shift: &shift.Shift{StartTime: "06:00", EndTime: "14:00"}}
+		night := shift.Schedule{Rostered: true, Shift: &shift.Shift{StartTime: "22:00", EndTime: "06:00"}}
+
+		mockSchedules.On("ScheduleFor", ctx, "user-123", saturday).Return(early, nil)
+		mockSchedules.On("ScheduleFor", ctx, "user-123", wednesday).Return(night, nil)
+		mockRepo.On("CreateOvertime", ctx, mock.AnythingOfType("*overtime.Overtime")).Return(nil).Twice()
+
+		// Shift pagi berakhir pukul 14.00, sebelum batas pukul 17.00.
+		afternoon := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(saturday.Add(15*time.Hour)), WithSchedules(mockSchedules))
+		assert.NoError(t, afternoon.SubmitOvertime(ctx, "user-123", saturday, 2))
+
+		// Shift malam Rabu baru berakhir Kamis pukul 06.00.
+		midnight := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(wednesday.Add(29*time.Hour)), WithSchedules(mockSchedules))
+		err := midnight.SubmitOvertime(ctx, "user-123", wednesday, 2)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "after the shift ends at 2025-09-11 06:00")
+
+		morning := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(wednesday.Add(31*time.Hour)), WithSchedules(mockSchedules))
+		assert.NoError(t, morning.SubmitOvertime(ctx, "user-123", wednesday, 2))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("SubmitOvertime - Fail because period is closed", func(t *testing.T) {
+		mockRepo := new(MockOvertimeRepository) // mock tidak akan dipanggil
+		mockLock := new(periodlock.MockChecker)
+		submissionService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(evening), WithPeriodLock(mockLock))
+		ctx := context.Background()
+		date := time.Date(2025, 8, 20, 0, 0, 0, 0, time.UTC)
+
+		mockLock.On("IsDateLocked", ctx, date).Return(true, nil).Once()
+
+		err := submissionService.SubmitOvertime(ctx, "user-123", date, 2)
+
+		assert.ErrorIs(t, err, periodlock.ErrLocked)
+		mockLock.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "CreateOvertime", mock.Anything, mock.Anything)
+	})
+}
+
+func TestOvertimeApproval(t *testing.T) {
+	date := time.Date(2025, 9, 9, 0, 0, 0, 0, time.UTC)
+	pending := func() *Overtime {
+		return &Overtime{ID: "ot-001", UserID: "user-123", Date: date, Hours: 2, Decision: approval.Decision{Status: approval.StatusPending}}
+	}
+	report := &employee.Employee{ID: "user-123", Role: "employee", ManagerID: "manager-001"}
+	manager := employee.Reviewer{ID: "manager-001", Role: "manager"}
+
+	t.Run("SubmitOvertime - Created as pending", func(t *testing.T) {
+		mockRepo := new(MockOvertimeRepository)
+		submissionService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(evening))
+		ctx := context.Background()
+
+		mockRepo.On("CreateOvertime", ctx, mock.MatchedBy(func(o *Overtime) bool {
+			return o.Status == approval.StatusPending && o.UserID == "user-123"
+		})).Return(nil).Once()
+
+		err := submissionService.SubmitOvertime(ctx, "user-123", date, 2)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("ApproveOvertime - Manager approves a direct report", func(t *testing.T) {
+		mockRepo := new(MockOvertimeRepository)
+		mockEmployees := new(auth.MockEmployeeRepository)
+		mockLock := new(periodlock.MockChecker)
+		submissionService := NewService(mockRepo, mockEmployees, fixedClock(evening), WithPeriodLock(mockLock))
+		ctx := context.Background()
+
+		mockRepo.On("GetOvertime", ctx, "ot-001").Return(pending(), nil).Once()
+		mockEmployees.On("GetByID", ctx, "user-123").Return(report, nil).Once()
+		mockLock.On("IsDateLocked", ctx, date).Return(false, nil).Once()
+		mockRepo.On("UpdateOvertimeStatus", ctx, mock.MatchedBy(func(o *Overtime) bool {
+			return o.Status == approval.StatusApproved && o.ReviewedBy == "manager-001" && o.UpdatedBy == "manager-001"
+		}), approval.StatusPending).Return(true, nil).Once()
+
+		item, err := submissionService.ApproveOvertime(ctx, "ot-001", manager)
+
+		assert.NoError(t, err)
+		assert.Equal(t, approval.StatusApproved, item.Status)
+		assert.Equal(t, evening, *item.ReviewedAt)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("ApproveOvertime - Manager of another team is rejected", func(t *testing.T) {
+		mockRepo := new(MockOvertimeRepository)
+		mockEmployees := new(auth.MockEmployeeRepository)
+		submissionService := NewService(mockRepo, mockEmployees, fixedClock(evening))
+		ctx := context.Background()
+
+		mockRepo.On("GetOvertime", ctx, "ot-001").Return(pending(), nil).Once()
+		mockEmployees.On("GetByID", ctx, "user-123").Return(report, nil).Once()
+
+		_, err := submissionService.ApproveOvertime(ctx, "ot-001", employee.Reviewer{ID: "manager-002", Role: "manager"})
+
+		assert.ErrorIs(t, err, approval.ErrNotReviewer)
+		mockRepo.AssertNotCalled(t, "UpdateOvertimeStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ApproveOvertime - Date inside a closed period", func(t *testing.T) {
+		mockRepo := new(MockOvertimeRepository)
+		mockEmployees := new(auth.MockEmployeeRepository)
+		mockLock := new(periodlock.MockChecker)
+		submissionService := NewService(mockRepo, mockEmployees, fixedClock(evening), WithPeriodLock(mockLock))
+		ctx := context.Background()
+
+		mockRepo.On("GetOvertime", ctx, "ot-001").Return(pending(), nil).Once()
+		mockEmployees.On("GetByID", ctx, "user-123").Return(report, nil).Once()
+		mockLock.On("IsDateLocked", ctx, date).Return(true, nil).Once()
+
+		_, err := submissionService.ApproveOvertime(ctx, "ot-001", employee.Reviewer{ID: "admin-001", Role: "admin"})
+
+		assert.ErrorIs(t, err, periodlock.ErrLocked)
+		mockRepo.AssertNotCalled(t, "UpdateOvertimeStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ApproveOvertime - Decided concurrently", func(t *testing.T) {
+		mockRepo := new(MockOvertimeRepository)
+		mockEmployees := new(auth.MockEmployeeRepository)
+		submissionService := NewService(mockRepo, mockEmployees, fixedClock(evening))
+		ctx := context.Background()
+
+		mockRepo.On("GetOvertime", ctx, "ot-001").Return(pending(), nil).Once()
+		mockEmployees.On("GetByID", ctx, "user-123").Return(report, nil).Once()
+		mockRepo.On("UpdateOvertimeStatus", ctx, mock.Anything, approval.StatusPending).Return(false, nil).Once()
+
+		_, err := submissionService.ApproveOvertime(ctx, "ot-001", manager)
+
+		assert.ErrorIs(t, err, approval.ErrModified)
+	})
+
+	t.Run("RejectOvertime - Reason is required", func(t *testing.T) {
+		mockRepo := new(MockOvertimeRepository)
+		submissionService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(evening))
+
+		_, err := submissionService.RejectOvertime(context.Background(), "ot-001", "  ", manager)
+
+		assert.ErrorIs(t, err, approval.ErrReasonRequired)
+		mockRepo.AssertNotCalled(t, "GetOvertime", mock.Anything, mock.Anything)
+	})
+
+	t.Run("RejectOvertime - Records the reason", func(t *testing.T) {
+		mockRepo := new(MockOvertimeRepository)
+		mockEmployees := new(auth.MockEmployeeRepository)
+		submissionService := NewService(mockRepo, mockEmployees, fixedClock(evening))
+		ctx := context.Background()
+
+		mockRepo.On("GetOvertime", ctx, "ot-001").Return(pending(), nil).Once()
+		mockEmployees.On("GetByID", ctx, "user-123").Return(report, nil).Once()
+		mockRepo.On("UpdateOvertimeStatus", ctx, mock.Anything, approval.StatusPending).Return(true, nil).Once()
+
+		item, err := submissionService.RejectOvertime(ctx, "ot-001", "Tidak ada perintah lembur", manager)
+
+		assert.NoError(t, err)
+		assert.Equal(t, approval.StatusRejected, item.Status)
+		assert.Equal(t, "Tidak ada perintah lembur", item.ReviewReason)
+	})
+
+	t.Run("CancelOvertime - Only the owner can cancel", func(t *testing.T) {
+		mockRepo := new(MockOvertimeRepository)
+		submissionService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(evening))
+		ctx := context.Background()
+
+		mockRepo.On("GetOvertime", ctx, "ot-001").Return(pending(), nil).Once()
+
+		_, err := submissionService.CancelOvertime(ctx, "ot-001", "user-999")
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("CancelOvertime - Approved overtime cannot be cancelled", func(t *testing.T) {
+		mockRepo := new(MockOvertimeRepository)
+		submissionService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(evening))
+		ctx := context.Background()
+
+		approved := pending()
+		approved.Status = approval.StatusApproved
+		mockRepo.On("GetOvertime", ctx, "ot-001").Return(approved, nil).Once()
+
+		_, err := submissionService.CancelOvertime(ctx, "ot-001", "user-123")
+
+		assert.ErrorIs(t, err, approval.ErrInvalidStatusTransition)
+		mockRepo.AssertNotCalled(t, "UpdateOvertimeStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("UpdateOvertime - Keeps the previous values", func(t *testing.T) {
+		mockRepo := new(MockOvertimeRepository)
+		mockLock := new(periodlock.MockChecker)
+		submissionService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(evening), WithPeriodLock(mockLock))
+		ctx := context.Background()
+		newDate := date.AddDate(0, 0, -1)
+
+		mockRepo.On("GetOvertime", ctx, "ot-001").Return(pending(), nil).Once()
+		mockLock.On("IsDateLocked", ctx, date).Return(false, nil).Once()
+		mockLock.On("IsDateLocked", ctx, newDate).Return(false, nil).Once()
+		mockRepo.On("UpdateOvertime", ctx, mock.MatchedBy(func(o *Overtime) bool {
+			return o.Date.Equal(newDate) && o.Hours == 3 && o.UpdatedBy == "user-123"
+		}), Revision{OvertimeID: "ot-001", Date: date, Hours: 2, ChangedBy: "user-123", ChangedAt: evening}).Return(true, nil).Once()
+
+		item, err := submissionService.UpdateOvertime(ctx, "ot-001", "user-123", newDate, 3)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, item.Hours)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("UpdateOvertime - Rules of a new submission apply", func(t *testing.T) {
+		mockRepo := new(MockOvertimeRepository)
+		mockLock := new(periodlock.MockChecker)
+		submissionService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(evening), WithPeriodLock(mockLock))
+		ctx := context.Background()
+		closedDate := time.Date(2025, 8, 20, 0, 0, 0, 0, time.UTC)
+
+		mockRepo.On("GetOvertime", ctx, "ot-001").Return(pending(), nil)
+		mockLock.On("IsDateLocked", ctx, date).Return(false, nil)
+		mockLock.On("IsDateLocked", ctx, closedDate).Return(true, nil).Once()
+
+		_, err := submissionService.UpdateOvertime(ctx, "ot-001", "user-123", date, 4)
+		assert.ErrorContains(t, err, "between 1 and 3 hours")
+
+		_, err = submissionService.UpdateOvertime(ctx, "ot-001", "user-123", closedDate, 2)
+		assert.ErrorIs(t, err, periodlock.ErrLocked)
+
+		_, err = submissionService.UpdateOvertime(ctx, "ot-001", "user-999", date, 2)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		mockRepo.AssertNotCalled(t, "UpdateOvertime", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("CancelOvertime - Not after the period is closed", func(t *testing.T) {
+		mockRepo := new(MockOvertimeRepository)
+		mockLock := new(periodlock.MockChecker)
+		submissionService := NewService(mockRepo, new(auth.MockEmployeeRepository), fixedClock(evening), WithPeriodLock(mockLock))
+		ctx := context.Background()
+
+		mockRepo.On("GetOvertime", ctx, "ot-001").Return(pending(), nil).Once()
+		mockLock.On("IsDateLocked", ctx, date).Return(true, nil).Once()
+
+		_, err := submissionService.CancelOvertime(ctx, "ot-001", "user-123")
+
+		assert.ErrorIs(t, err, periodlock.ErrLocked)
+		mockRepo.AssertNotCalled(t, "UpdateOvertimeStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("GetRevisions - Owner and the owner's reviewers only", func(t *testing.T) {
+		mockRepo := new(MockOvertimeRepository)
+		mockEmployees := new(auth.MockEmployeeRepository)
+		submissionService := NewService(mockRepo, mockEmployees)
+		ctx := context.Background()
+
+		mockRepo.On("GetOvertime", ctx, "ot-001").Return(pending(), nil)
+		mockEmployees.On("GetByID", ctx, "user-123").Return(report, nil)
+		mockRepo.On("GetRevisions", ctx, "ot-001").Return([]Revision{{OvertimeID: "ot-001", Hours: 1}}, nil)
+
+		revisions, err := submissionService.GetRevisions(ctx, "ot-001", employee.Reviewer{ID: "user-123", Role: "employee"})
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 1)
+
+		_, err = submissionService.GetRevisions(ctx, "ot-001", manager)
+		assert.NoError(t, err)
+
+		_, err = submissionService.GetRevisions(ctx, "ot-001", employee.Reviewer{ID: "manager-002", Role: "manager"})
+		assert.ErrorIs(t, err, approval.ErrNotReviewer)
+	})
+
+	t.Run("ListOvertimes - Manager sees only direct reports", func(t *testing.T) {
+		mockRepo := new(MockOvertimeRepository)
+		submissionService := NewService(mockRepo, new(auth.MockEmployeeRepository))
+		ctx := context.Background()
+
+		mockRepo.On("ListOvertimes", ctx, ListFilter{Status: approval.StatusPending, ManagerID: "manager-001"}).Return([]Overtime{*pending()}, nil).Once()
+
+		items, err := submissionService.ListOvertimes(ctx, manager, ListFilter{Status: approval.StatusPending})
+
+		assert.NoError(t, err)
+		assert.Len(t, items, 1)
+		mockRepo.AssertExpectations(t)
+	})
 }
@@ -0,0 +1,139 @@
+package paycomponent
+
+import (
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Kind menentukan apakah komponen menambah (earning) atau mengurangi
+// (deduction) take home pay.
+type Kind string
+
+const (
+	KindEarning   Kind = "earning"
+	KindDeduction Kind = "deduction"
+)
+
+// CalculationType menentukan cara menghitung nilai komponen.
+type CalculationType string
+
+const (
+	// CalculationFixed: nilai tetap per periode (mis. tunjangan jabatan).
+	CalculationFixed CalculationType = "fixed"
+	// CalculationPerAttendanceDay: nilai x jumlah hari hadir (mis. uang makan).
+	CalculationPerAttendanceDay CalculationType = "per_attendance_day"
+	// CalculationPercentageOfBase: persentase dari gaji pokok.
+	CalculationPercentageOfBase CalculationType = "percentage_of_base"
+)
+
+// Component adalah definisi komponen gaji yang dikelola admin.
+type Component struct {
+	ID              string          `json:"id" gorm:"primaryKey"`
+	Code            string          `json:"code" gorm:"uniqueIndex;size:32"`
+	Name            string          `json:"name"`
+	Kind            Kind            `json:"kind" gorm:"size:16"`
+	CalculationType CalculationType `json:"calculation_type" gorm:"size:32"`
+	Amount          money.Money     `json:"amount"`         // Untuk fixed dan per_attendance_day
+	PercentageBps   int64           `json:"percentage_bps"` // Untuk percentage_of_base (100 = 1%)
+	Taxable         bool            `json:"taxable"`        // Hanya berlaku untuk earning
+	Active          bool            `json:"active" gorm:"default:true"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	CreatedBy       string          `gorm:"size:36" json:"created_by"`
+	UpdatedBy       string          `gorm:"size:36" json:"updated_by"`
+}
+
+func (Component) TableName() string { return "pay_components" }
+
+func (c *Component) BeforeCreate(tx *gorm.DB) error {
+	c.ID = uuid.New().String()
+	return nil
+}
+
+// Assignment menetapkan sebuah komponen untuk seorang karyawan dalam rentang
+// tanggal berlaku. Amount dan PercentageBps opsional untuk menimpa nilai
+// default dari komponen.
+type Assignment struct {
+	ID            string       `json:"id" gorm:"primaryKey"`
+	UserID        string       `json:"user_id" gorm:"index"`
+	ComponentID   string       `json:"component_id" gorm:"index;size:36"`
+	Component     Component    `json:"component" gorm:"foreignKey:ComponentID"`
+	Amount        *money.Money `json:"amount,omitempty"`
+	PercentageBps *int64       `json:"percentage_bps,omitempty"`
+	EffectiveFrom time.Time    `json:"effective_from" gorm:"type:date"`
+	EffectiveTo   *time.Time   `json:"effective_to,omitempty" gorm:"type:date"` // nil = tanpa batas akhir
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+	CreatedBy     string       `gorm:"size:36" json:"created_by"`
+	UpdatedBy     string       `gorm:"size:36" json:"updated_by"`
+}
+
+func (Assignment) TableName() string { return "pay_component_assignments" }
+
+func (a *Assignment) BeforeCreate(tx *gorm.DB) error {
+	a.ID = uuid.New().String()
+	return nil
+}
+
+// Input adalah data karyawan pada suatu periode yang dibutuhkan untuk
+// mengevaluasi komponen.
+type Input struct {
+	BaseSalary   money.Money
+	AttendedDays int
+	// ShareNum/ShareDen adalah porsi bulan yang dibayar periode (mis. 1/2
+	// untuk semi-bulanan). Komponen fixed dan percentage_of_base adalah
+	// nilai per bulan sehingga dikalikan porsi ini. Nol = satu bulan penuh.
+	ShareNum, ShareDen int64
+}
+
+// share mengembalikan porsi bulan periode sebagai pecahan.
+func (in Input) share() (num, den int64) {
+	if in.ShareDen == 0 {
+		return 1, 1
+	}
+	return in.ShareNum, in.ShareDen
+}
+
+// Evaluate menghitung nilai assignment untuk satu periode payroll.
+// Komponen fixed dan persentase dibulatkan sekali sesuai aturan r.
+func (a Assignment) Evaluate(in Input, r money.Rounding) money.Money {
+	amount := a.Component.Amount
+	if a.Amount != nil {
+		amount = *a.Amount
+	}
+	bps := a.Component.PercentageBps
+	if a.PercentageBps != nil {
+		bps = *a.PercentageBps
+	}
+
+	num, den := in.share()
+	switch a.Component.CalculationType {
+	case CalculationPerAttendanceDay:
+		return amount.Mul(int64(in.AttendedDays))
+	case CalculationPercentageOfBase:
+		return in.BaseSalary.MulDiv(bps*num, 10000*den, r)
+	}
+	return amount.MulDiv(num, den, r)
+}
+
+// Rate mengembalikan nilai per unit untuk ditampilkan di payslip.
+func (a Assignment) Rate(in Input, r money.Rounding) money.Money {
+	if a.Component.CalculationType == CalculationPerAttendanceDay {
+		if a.Amount != nil {
+			return *a.Amount
+		}
+		return a.Component.Amount
+	}
+	return a.Evaluate(in, r)
+}
+
+// Quantity mengembalikan jumlah unit untuk ditampilkan di payslip.
+func (a Assignment) Quantity(in Input) float64 {
+	if a.Component.CalculationType == CalculationPerAttendanceDay {
+		return float64(in.AttendedDays)
+	}
+	return 1
+}
@@ -0,0 +1,89 @@
+package paycomponent
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	CreateComponent(ctx context.Context, component *Component) error
+	GetComponent(ctx context.Context, id string) (*Component, error)
+	ListComponents(ctx context.Context) ([]Component, error)
+	UpdateComponent(ctx context.Context, component *Component) error
+	CreateAssignment(ctx context.Context, assignment *Assignment) error
+	GetAssignment(ctx context.Context, id string) (*Assignment, error)
+	ListAssignments(ctx context.Context, userID string) ([]Assignment, error)
+	UpdateAssignment(ctx context.Context, assignment *Assignment) error
+	GetActiveAssignments(ctx context.Context, userID string, start, end time.Time) ([]Assignment, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r *repository) CreateComponent(ctx context.Context, component *Component) error {
+	return r.db.WithContext(ctx).Create(component).Error
+}
+
+func (r *repository) GetComponent(ctx context.Context, id string) (*Component, error) {
+	var component Component
+	if err := r.db.WithContext(ctx).First(&component, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &component, nil
+}
+
+func (r *repository) ListComponents(ctx context.Context) ([]Component, error) {
+	var components []Component
+	err := r.db.WithContext(ctx).Order("code").Find(&components).Error
+	return components, err
+}
+
+func (r *repository) UpdateComponent(ctx context.Context, component *Component) error {
+	return r.db.WithContext(ctx).Save(component).Error
+}
+
+func (r *repository) CreateAssignment(ctx context.Context, assignment *Assignment) error {
+	return r.db.WithContext(ctx).Omit("Component").Create(assignment).Error
+}
+
+func (r *repository) GetAssignment(ctx context.Context, id string) (*Assignment, error) {
+	var assignment Assignment
+	if err := r.db.WithContext(ctx).Preload("Component").First(&assignment, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+func (r *repository) ListAssignments(ctx context.Context, userID string) ([]Assignment, error) {
+	var assignments []Assignment
+	err := r.db.WithContext(ctx).Preload("Component").
+		Where("user_id = ?", userID).
+		Order("effective_from").
+		Find(&assignments).Error
+	return assignments, err
+}
+
+func (r *repository) UpdateAssignment(ctx context.Context, assignment *Assignment) error {
+	return r.db.WithContext(ctx).Omit("Component").Save(assignment).Error
+}
+
+// GetActiveAssignments mengambil assignment milik karyawan yang masa
+// berlakunya beririsan dengan periode [start, end] dan komponennya aktif.
+func (r *repository) GetActiveAssignments(ctx context.Context, userID string, start, end time.Time) ([]Assignment, error) {
+	var assignments []Assignment
+	err := r.db.WithContext(ctx).
+		Joins("Component").
+		Where("pay_component_assignments.user_id = ? AND pay_component_assignments.effective_from <= ?", userID, end).
+		Where("pay_component_assignments.effective_to IS NULL OR pay_component_assignments.effective_to >= ?", start).
+		Where(`"Component".active = ?`, true).
+		Order(`"Component".code`).
+		Find(&assignments).Error
+	return assignments, err
+}
@@ -0,0 +1,193 @@
+package paycomponent
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
+)
+
+// Service mengelola definisi komponen gaji dan penetapannya ke karyawan.
+type Service interface {
+	CreateComponent(ctx context.Context, component *Component, adminID string) error
+	ListComponents(ctx context.Context) ([]Component, error)
+	UpdateComponent(ctx context.Context, id string, update ComponentUpdate, adminID string) (*Component, error)
+	AssignComponent(ctx context.Context, assignment *Assignment, adminID string) error
+	ListAssignments(ctx context.Context, userID string) ([]Assignment, error)
+	EndAssignment(ctx context.Context, id string, effectiveTo time.Time, adminID string) (*Assignment, error)
+}
+
+// ComponentUpdate berisi field komponen yang boleh diubah. Field nil tidak
+// diubah. Code, Kind, dan CalculationType tidak dapat diubah agar payslip
+// lama tetap konsisten.
+type ComponentUpdate struct {
+	Name          *string      `json:"name"`
+	Amount        *money.Money `json:"amount"`
+	PercentageBps *int64       `json:"percentage_bps"`
+	Taxable       *bool        `json:"taxable"`
+	Active        *bool        `json:"active"`
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo}
+}
+
+var codePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,31}$`)
+
+// reservedCodes adalah kode line payslip yang dihasilkan sendiri oleh payroll.
+var reservedCodes = []string{"BASIC_SALARY", "OVERTIME", "REIMBURSEMENT", "PPH21", "ABSENCE_DEDUCTION"}
+
+func (s *service) CreateComponent(ctx context.Context, component *Component, adminID string) error {
+	component.Code = strings.ToUpper(strings.TrimSpace(component.Code))
+	if !codePattern.MatchString(component.Code) {
+		return errors.New("component code must be 2-32 characters of A-Z, 0-9 or underscore")
+	}
+	for _, reserved := range reservedCodes {
+		if component.Code == reserved {
+			return errors.New("component code is reserved by payroll")
+		}
+	}
+	if strings.HasPrefix(component.Code, "BPJS_") {
+		return errors.New("component code is reserved by payroll")
+	}
+	if component.Name == "" {
+		return errors.New("component name is required")
+	}
+	if component.Kind != KindEarning && component.Kind != KindDeduction {
+		return errors.New("component kind must be earning or deduction")
+	}
+	if component.Kind == KindDeduction {
+		component.Taxable = false
+	}
+	if err := validateValue(component.CalculationType, component.Amount, component.PercentageBps); err != nil {
+		return err
+	}
+
+	component.Active = true
+	component.CreatedBy = adminID
+	component.UpdatedBy = adminID
+	return s.repo.CreateComponent(ctx, component)
+}
+
+func (s *service) ListComponents(ctx context.Context) ([]Component, error) {
+	return s.repo.ListComponents(ctx)
+}
+
+func (s *service) UpdateComponent(ctx context.Context, id string, update ComponentUpdate, adminID string) (*Component, error) {
+	component, err := s.repo.GetComponent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != nil {
+		if *update.Name == "" {
+			return nil, errors.New("component name is required")
+		}
+		component.Name = *update.Name
+	}
+	if update.Amount != nil {
+		component.Amount = *update.Amount
+	}
+	if update.PercentageBps != nil {
+		component.PercentageBps = *update.PercentageBps
+	}
+	if update.Taxable != nil {
+		component.Taxable = *update.Taxable && component.Kind == KindEarning
+	}
+	if update.Active != nil {
+		component.Active = *update.Active
+	}
+	if err := validateValue(component.CalculationType, component.Amount, component.PercentageBps); err != nil {
+		return nil, err
+	}
+
+	component.UpdatedBy = adminID
+	if err := s.repo.UpdateComponent(ctx, component); err != nil {
+		return nil, err
+	}
+	return component, nil
+}
+
+func (s *service) AssignComponent(ctx context.Context, assignment *Assignment, adminID string) error {
+	if assignment.UserID == "" {
+		return errors.New("user id is required")
+	}
+	if assignment.EffectiveFrom.IsZero() {
+		return errors.New("effective from date is required")
+	}
+	if assignment.EffectiveTo != nil && assignment.EffectiveTo.Before(assignment.EffectiveFrom) {
+		return errors.New("effective to date cannot be before effective from date")
+	}
+
+	component, err := s.repo.GetComponent(ctx, assignment.ComponentID)
+	if err != nil {
+		return errors.New("pay component not found")
+	}
+	if !component.Active {
+		return errors.New("pay component is inactive")
+	}
+
+	amount := component.Amount
+	if assignment.Amount != nil {
+		amount = *assignment.Amount
+	}
+	bps := component.PercentageBps
+	if assignment.PercentageBps != nil {
+		bps = *assignment.PercentageBps
+	}
+	if err := validateValue(component.CalculationType, amount, bps); err != nil {
+		return err
+	}
+
+	assignment.CreatedBy = adminID
+	assignment.UpdatedBy = adminID
+	if err := s.repo.CreateAssignment(ctx, assignment); err != nil {
+		return err
+	}
+	assignment.Component = *component
+	return nil
+}
+
+func (s *service) ListAssignments(ctx context.Context, userID string) ([]Assignment, error) {
+	return s.repo.ListAssignments(ctx, userID)
+}
+
+func (s *service) EndAssignment(ctx context.Context, id string, effectiveTo time.Time, adminID string) (*Assignment, error) {
+	assignment, err := s.repo.GetAssignment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if effectiveTo.Before(assignment.EffectiveFrom) {
+		return nil, errors.New("effective to date cannot be before effective from date")
+	}
+
+	assignment.EffectiveTo = &effectiveTo
+	assignment.UpdatedBy = adminID
+	if err := s.repo.UpdateAssignment(ctx, assignment); err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+func validateValue(calc CalculationType, amount money.Money, bps int64) error {
+	switch calc {
+	case CalculationFixed, CalculationPerAttendanceDay:
+		if !amount.IsPositive() {
+			return errors.New("component amount must be positive")
+		}
+	case CalculationPercentageOfBase:
+		if bps <= 0 || bps > 10000 {
+			return errors.New("component percentage must be between 1 and 10000 basis points")
+		}
+	default:
+		return errors.New("calculation type must be fixed, per_attendance_day or percentage_of_base")
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package paycomponent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPayComponentRepository adalah implementasi mock untuk paycomponent.Repository
+type MockPayComponentRepository struct {
+	mock.Mock
+}
+
+func (m *MockPayComponentRepository) CreateComponent(ctx context.Context, component *Component) error {
+	args := m.Called(ctx, component)
+	return args.Error(0)
+}
+func (m *MockPayComponentRepository) GetComponent(ctx context.Context, id string) (*Component, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Component), args.Error(1)
+}
+func (m *MockPayComponentRepository) ListComponents(ctx context.Context) ([]Component, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Component), args.Error(1)
+}
+func (m *MockPayComponentRepository) UpdateComponent(ctx context.Context, component *Component) error {
+	args := m.Called(ctx, component)
+	return args.Error(0)
+}
+func (m *MockPayComponentRepository) CreateAssignment(ctx context.Context, assignment *Assignment) error {
+	args := m.Called(ctx, assignment)
+	return args.Error(0)
+}
+func (m *MockPayComponentRepository) GetAssignment(ctx context.Context, id string) (*Assignment, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Assignment), args.Error(1)
+}
+func (m *MockPayComponentRepository) ListAssignments(ctx context.Context, userID string) ([]Assignment, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]Assignment), args.Error(1)
+}
+func (m *MockPayComponentRepository) UpdateAssignment(ctx context.Context, assignment *Assignment) error {
+	args := m.Called(ctx, assignment)
+	return args.Error(0)
+}
+func (m *MockPayComponentRepository) GetActiveAssignments(ctx context.Context, userID string, start, end time.Time) ([]Assignment, error) {
+	args := m.Called(ctx, userID, start, end)
+	return args.Get(0).([]Assignment), args.Error(1)
+}
+
+func TestPayComponentService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("CreateComponent - Success", func(t *testing.T) {
+		mockRepo := new(MockPayComponentRepository)
+		svc := NewService(mockRepo)
+		component := &Component{
+			Code:            "meal",
+			Name:            "Uang Makan",
+			Kind:            KindEarning,
+			CalculationType: CalculationPerAttendanceDay,
+			Amount:          money.FromMajor(50000, money.IDR),
+		}
+		mockRepo.On("CreateComponent", ctx, component).Return(nil).Once()
+
+		err := svc.CreateComponent(ctx, component, "admin-001")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "MEAL", component.Code)
+		assert.True(t, component.Active)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("CreateComponent - Fail because code is reserved", func(t *testing.T) {
+		svc := NewService(new(MockPayComponentRepository))
+		err := svc.CreateComponent(ctx, &Component{
+			Code:            "BPJS_EXTRA",
+			Name:            "Extra",
+			Kind:            KindDeduction,
+			CalculationType: CalculationFixed,
+			Amount:          money.FromMajor(1000, money.IDR),
+		}, "admin-001")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "reserved")
+	})
+
+	t.Run("CreateComponent - Fail because percentage is missing", func(t *testing.T) {
+		svc := NewService(new(MockPayComponentRepository))
+		err := svc.CreateComponent(ctx, &Component{
+			Code:            "POSITION",
+			Name:            "Tunjangan Jabatan",
+			Kind:            KindEarning,
+			CalculationType: CalculationPercentageOfBase,
+		}, "admin-001")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "percentage")
+	})
+
+	t.Run("AssignComponent - Fail because component is inactive", func(t *testing.T) {
+		mockRepo := new(MockPayComponentRepository)
+		svc := NewService(mockRepo)
+		mockRepo.On("GetComponent", ctx, "comp-001").Return(&Component{ID: "comp-001", Active: false}, nil).Once()
+
+		err := svc.AssignComponent(ctx, &Assignment{
+			UserID:        "user-001",
+			ComponentID:   "comp-001",
+			EffectiveFrom: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC),
+		}, "admin-001")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "inactive")
+	})
+
+	t.Run("AssignComponent - Fail because component not found", func(t *testing.T) {
+		mockRepo := new(MockPayComponentRepository)
+		svc := NewService(mockRepo)
+		mockRepo.On("GetComponent", ctx, "missing").Return(nil, errors.New("record not found")).Once()
+
+		err := svc.AssignComponent(ctx, &Assignment{
+			UserID:        "user-001",
+			ComponentID:   "missing",
+			EffectiveFrom: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC),
+		}, "admin-001")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestAssignmentEvaluate(t *testing.T) {
+	in := Input{BaseSalary: money.FromMajor(5000000, money.IDR), AttendedDays: 18}
+	override := money.FromMajor(75000, money.IDR)
+	bps := int64(1000)
+
+	tests := []struct {
+		name       string
+		assignment Assignment
+		want       money.Money
+	}{
+		{
+			name: "fixed",
+			assignment: Assignment{Component: Component{
+				CalculationType: CalculationFixed, Amount: money.FromMajor(500000, money.IDR),
+			}},
+			want: money.FromMajor(500000, money.IDR),
+		},
+		{
+			name: "per attendance day with override",
+			assignment: Assignment{Amount: &override, Component: Component{
+				CalculationType: CalculationPerAttendanceDay, Amount: money.FromMajor(50000, money.IDR),
+			}},
+			want: money.FromMajor(1350000, money.IDR),
+		},
+		{
+			name: "percentage of base with override",
+			assignment: Assignment{PercentageBps: &bps, Component: Component{
+				CalculationType: CalculationPercentageOfBase, PercentageBps: 500,
+			}},
+			want: money.FromMajor(500000, money.IDR),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.assignment.Evaluate(in, money.DefaultRounding)
+			assert.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+		})
+	}
+}
+
+func TestAssignmentEvaluateShare(t *testing.T) {
+	// Periode semi-bulanan membayar setengah komponen bulanan; uang harian
+	// tetap mengikuti hari hadir.
+	in := Input{BaseSalary: money.FromMajor(5000000, money.IDR), AttendedDays: 9, ShareNum: 1, ShareDen: 2}
+
+	fixed := Assignment{Component: Component{CalculationType: CalculationFixed, Amount: money.FromMajor(500000, money.IDR)}}
+	percentage := Assignment{Component: Component{CalculationType: CalculationPercentageOfBase, PercentageBps: 1000}}
+	daily := Assignment{Component: Component{CalculationType: CalculationPerAttendanceDay, Amount: money.FromMajor(50000, money.IDR)}}
+
+	assert.True(t, money.FromMajor(250000, money.IDR).Equal(fixed.Evaluate(in, money.DefaultRounding)))
+	assert.True(t, money.FromMajor(250000, money.IDR).Equal(percentage.Evaluate(in, money.DefaultRounding)))
+	assert.True(t, money.FromMajor(450000, money.IDR).Equal(daily.Evaluate(in, money.DefaultRounding)))
+}
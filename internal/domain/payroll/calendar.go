@@ -0,0 +1,157 @@
+package payroll
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidPeriod dikembalikan jika rentang tanggal atau jenis periode tidak
+// valid.
+var ErrInvalidPeriod = errors.New("invalid payroll period")
+
+// ErrPeriodOverlap dikembalikan jika periode beririsan dengan periode yang
+// sudah ada, sehingga kehadiran yang sama akan dibayar dua kali.
+var ErrPeriodOverlap = errors.New("payroll period overlaps an existing period")
+
+// periodLengths adalah batas panjang periode (dalam hari, inklusif) untuk
+// setiap jenis periode. Periode off-cycle tidak dibatasi.
+var periodLengths = map[PeriodType][2]int{
+	PeriodMonthly:     {28, 31},
+	PeriodSemiMonthly: {13, 16},
+	PeriodWeekly:      {7, 7},
+}
+
+// validatePeriod memastikan jenis periode dikenal dan panjangnya sesuai.
+func validatePeriod(periodType PeriodType, start, end time.Time) error {
+	if start.After(end) {
+		return fmt.Errorf("%w: start date cannot be after end date", ErrInvalidPeriod)
+	}
+	if periodType == PeriodOffCycle {
+		return nil
+	}
+	limits, ok := periodLengths[periodType]
+	if !ok {
+		return fmt.Errorf("%w: unknown period type %q", ErrInvalidPeriod, periodType)
+	}
+	days := daysBetween(start, end) + 1
+	if days < limits[0] || days > limits[1] {
+		return fmt.Errorf("%w: a %s period must span %d to %d days, got %d", ErrInvalidPeriod, periodType, limits[0], limits[1], days)
+	}
+	return nil
+}
+
+// PayCalendar mendefinisikan cara periode payroll satu tahun dibentuk.
+type PayCalendar struct {
+	Year int
+	Type PeriodType
+	// StartDay adalah tanggal mulai periode bulanan (1-28). Jika lebih dari 1,
+	// periode bulan M dimulai pada tanggal StartDay bulan sebelumnya, mis.
+	// StartDay 26: periode Januari = 26 Desember - 25 Januari. Default: 1.
+	StartDay int
+	// SplitDay adalah tanggal terakhir paruh pertama periode semi-bulanan
+	// (13-16). Default: 15. Paruh kedua setiap bulan juga harus 13-16 hari,
+	// sehingga 13 dan 14 ditolak karena bulan 31 hari, dan 16 hanya berlaku
+	// pada tahun kabisat karena Februari 28 hari.
+	SplitDay int
+	// FirstStartDate adalah tanggal mulai periode mingguan pertama dalam
+	// tahun tersebut. Default: hari Senin pertama.
+	FirstStartDate *time.Time
+}
+
+// Periods membentuk rentang tanggal seluruh periode dalam tahun kalender.
+func (c PayCalendar) Periods() ([][2]time.Time, error) {
+	if c.Year < 1 {
+		return nil, fmt.Errorf("%w: year is required", ErrInvalidPeriod)
+	}
+
+	var periods [][2]time.Time
+	switch c.Type {
+	case PeriodMonthly:
+		startDay := c.StartDay
+		if startDay == 0 {
+			startDay = 1
+		}
+		if startDay < 1 || startDay > 28 {
+			return nil, fmt.Errorf("%w: start_day must be between 1 and 28", ErrInvalidPeriod)
+		}
+		for month := time.January; month <= time.December; month++ {
+			if startDay == 1 {
+				start := calendarDate(c.Year, month, 1)
+				periods = append(periods, [2]time.Time{start, start.AddDate(0, 1, -1)})
+				continue
+			}
+			periods = append(periods, [2]time.Time{calendarDate(c.Year, month-1, startDay), calendarDate(c.Year, month, startDay-1)})
+		}
+	case PeriodSemiMonthly:
+		splitDay := c.SplitDay
+		if splitDay == 0 {
+			splitDay = 15
+		}
+		if splitDay < 13 || splitDay > 16 {
+			return nil, fmt.Errorf("%w: split_day must be between 13 and 16", ErrInvalidPeriod)
+		}
+		for month := time.January; month <= time.December; month++ {
+			first := calendarDate(c.Year, month, 1)
+			second := [2]time.Time{calendarDate(c.Year, month, splitDay+1), first.AddDate(0, 1, -1)}
+			if days := daysBetween(second[0], second[1]) + 1; days < 13 || days > 16 {
+				return nil, fmt.Errorf("%w: split_day %d leaves %s %d with a %d-day second half; it must span 13 to 16 days", ErrInvalidPeriod, splitDay, month, c.Year, days)
+			}
+			periods = append(periods, [2]time.Time{first, calendarDate(c.Year, month, splitDay)}, second)
+		}
+	case PeriodWeekly:
+		start := calendarDate(c.Year, time.January, 1)
+		if c.FirstStartDate != nil {
+			start = calendarDate(c.FirstStartDate.Date())
+			if start.Year() != c.Year {
+				return nil, fmt.Errorf("%w: first_start_date must fall in %d", ErrInvalidPeriod, c.Year)
+			}
+		} else {
+			for start.Weekday() != time.Monday {
+				start = start.AddDate(0, 0, 1)
+			}
+		}
+		for ; start.Year() == c.Year; start = start.AddDate(0, 0, 7) {
+			periods = append(periods, [2]time.Time{start, start.AddDate(0, 0, 6)})
+		}
+	default:
+		return nil, fmt.Errorf("%w: cannot generate %q periods", ErrInvalidPeriod, c.Type)
+	}
+	return periods, nil
+}
+
+// gapWarnings memeriksa apakah ada tanggal yang tidak tercakup periode di
+// antara period dan periode sebelum (prev) atau sesudahnya (next).
+func gapWarnings(period, prev, next *PayrollPeriod) []PeriodWarning {
+	var warnings []PeriodWarning
+	if prev != nil {
+		if gap := daysBetween(prev.EndDate, period.StartDate) - 1; gap > 0 {
+			warnings = append(warnings, PeriodWarning{
+				PayrollPeriodID: period.ID,
+				Code:            WarningGapBefore,
+				Message:         fmt.Sprintf("%d day(s) between %s and %s are not covered by any period", gap, prev.EndDate.Format("2006-01-02"), period.StartDate.Format("2006-01-02")),
+			})
+		}
+	}
+	if next != nil {
+		if gap := daysBetween(period.EndDate, next.StartDate) - 1; gap > 0 {
+			warnings = append(warnings, PeriodWarning{
+				PayrollPeriodID: period.ID,
+				Code:            WarningGapAfter,
+				Message:         fmt.Sprintf("%d day(s) between %s and %s are not covered by any period", gap, period.EndDate.Format("2006-01-02"), next.StartDate.Format("2006-01-02")),
+			})
+		}
+	}
+	return warnings
+}
+
+// calendarDate membuat tanggal tengah malam UTC; bulan di luar 1-12 dinormalisasi
+// ke tahun sebelum/sesudahnya.
+func calendarDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// daysBetween menghitung selisih hari kalender dari a ke b.
+func daysBetween(a, b time.Time) int {
+	return int(calendarDate(b.Date()).Sub(calendarDate(a.Date())).Hours() / 24)
+}
@@ -1,21 +1,81 @@
 package payroll
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// PeriodStatus adalah tahap siklus hidup periode payroll.
+type PeriodStatus string
+
+const (
+	PeriodDraft      PeriodStatus = "draft"      // Baru dibuat, belum menerima payroll
+	PeriodOpen       PeriodStatus = "open"       // Siap dijalankan payroll-nya
+	PeriodCalculated PeriodStatus = "calculated" // Payslip sudah dihitung, menunggu approval
+	PeriodApproved   PeriodStatus = "approved"   // Disetujui approver; payslip terlihat karyawan
+	PeriodPaid       PeriodStatus = "paid"       // Gaji sudah dibayarkan
+	PeriodClosed     PeriodStatus = "closed"     // Dikunci; pengajuan di dalam periode ditolak
+)
+
+// periodTransitions adalah transisi status yang diizinkan. Kembali ke open
+// dari calculated/approved terjadi saat payroll di-reverse.
+var periodTransitions = map[PeriodStatus][]PeriodStatus{
+	PeriodDraft:      {PeriodOpen},
+	PeriodOpen:       {PeriodCalculated},
+	PeriodCalculated: {PeriodApproved, PeriodOpen},
+	PeriodApproved:   {PeriodPaid, PeriodOpen},
+	PeriodPaid:       {PeriodClosed},
+}
+
+// ErrInvalidPeriodTransition dikembalikan untuk perubahan status periode
+// yang tidak diizinkan.
+var ErrInvalidPeriodTransition = errors.New("invalid payroll period transition")
+
+// CanTransitionTo melaporkan apakah status dapat berubah menjadi next.
+func (s PeriodStatus) CanTransitionTo(next PeriodStatus) bool {
+	for _, allowed := range periodTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// PayslipsVisible melaporkan apakah payslip periode sudah boleh dilihat
+// karyawan, yaitu setelah disetujui approver.
+func (s PeriodStatus) PayslipsVisible() bool {
+	return s == PeriodApproved || s == PeriodPaid || s == PeriodClosed
+}
+
+// PeriodType adalah jenis periode payroll sesuai kalender penggajian.
+type PeriodType string
+
+const (
+	PeriodMonthly     PeriodType = "monthly"      // Satu periode per bulan (28-31 hari)
+	PeriodSemiMonthly PeriodType = "semi_monthly" // Dua periode per bulan (13-16 hari)
+	PeriodWeekly      PeriodType = "weekly"       // Tujuh hari
+	PeriodOffCycle    PeriodType = "off_cycle"    // Di luar kalender reguler, panjang bebas
+)
+
 type PayrollPeriod struct {
-	ID        string    `json:"id" gorm:"primaryKey"`
-	StartDate time.Time `json:"start_date"`
-	EndDate   time.Time `json:"end_date"`
-	Status    string    `json:"status" gorm:"default:'pending'"` // pending, processing, completed
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	CreatedBy string    `gorm:"size:36" json:"created_by"`
-	UpdatedBy string    `gorm:"size:36" json:"updated_by"`
+	ID         string       `json:"id" gorm:"primaryKey"`
+	Type       PeriodType   `json:"type" gorm:"size:16;default:'monthly'"`
+	StartDate  time.Time    `json:"start_date"`
+	EndDate    time.Time    `json:"end_date"`
+	Status     PeriodStatus `json:"status" gorm:"size:16;default:'draft'"`
+	ApprovedBy string       `gorm:"size:36" json:"approved_by,omitempty"`
+	ApprovedAt *time.Time   `json:"approved_at,omitempty"`
+	PaidAt     *time.Time   `json:"paid_at,omitempty"`
+	ClosedAt   *time.Time   `json:"closed_at,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+	CreatedBy  string       `gorm:"size:36" json:"created_by"`
+	UpdatedBy  string       `gorm:"size:36" json:"updated_by"`
 }
 
 func (p *PayrollPeriod) BeforeCreate(tx *gorm.DB) error {
@@ -23,27 +83,68 @@ func (p *PayrollPeriod) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
-func NewPayrollPeriod(start, end time.Time) *PayrollPeriod {
+func NewPayrollPeriod(periodType PeriodType, start, end time.Time) *PayrollPeriod {
 	return &PayrollPeriod{
+		Type:      periodType,
 		StartDate: start,
 		EndDate:   end,
-		Status:    "pending",
+		Status:    PeriodDraft,
 	}
 }
 
+// TransitionTo memindahkan periode ke status next dan mencatat siapa serta
+// kapan perubahan terjadi. Persetujuan sebelumnya dihapus saat periode
+// dibuka kembali.
+func (p *PayrollPeriod) TransitionTo(next PeriodStatus, actorID string, at time.Time) error {
+	if !p.Status.CanTransitionTo(next) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidPeriodTransition, p.Status, next)
+	}
+	p.Status = next
+	p.UpdatedBy = actorID
+	switch next {
+	case PeriodOpen:
+		p.ApprovedBy = ""
+		p.ApprovedAt = nil
+	case PeriodApproved:
+		p.ApprovedBy = actorID
+		p.ApprovedAt = &at
+	case PeriodPaid:
+		p.PaidAt = &at
+	case PeriodClosed:
+		p.ClosedAt = &at
+	}
+	return nil
+}
+
+// Payslip adalah slip gaji seorang karyawan untuk satu periode. Rincian
+// penghasilan, potongan, dan biaya pemberi kerja disimpan sebagai Lines;
+// kolom total hanya turunan dari lines (lihat Recalculate).
+//
+// Payroll yang dibatalkan (reverse) tidak menghapus payslip, melainkan
+// menandainya void. Eksekusi ulang membuat payslip dengan Version berikutnya;
+// hanya boleh ada satu payslip aktif (belum void) per karyawan per periode.
 type Payslip struct {
-	ID                 string    `json:"id" gorm:"primaryKey"`
-	UserID             string    `json:"user_id" gorm:"index"`
-	PayrollPeriodID    string    `json:"payroll_period_id" gorm:"index"`
-	BaseSalary         float64   `json:"base_salary"`
-	ProratedSalary     float64   `json:"prorated_salary"`
-	OvertimePay        float64   `json:"overtime_pay"`
-	ReimbursementTotal float64   `json:"reimbursement_total"`
-	TotalPay           float64   `json:"total_pay"`
-	CreatedAt          time.Time `json:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at"`
-	CreatedBy          string    `gorm:"size:36" json:"created_by"`
-	UpdatedBy          string    `gorm:"size:36" json:"updated_by"`
+	ID              string        `json:"id" gorm:"primaryKey"`
+	UserID          string        `json:"user_id" gorm:"uniqueIndex:idx_payslips_user_period_version;uniqueIndex:idx_payslips_active_user_period,where:voided_at IS NULL"`
+	PayrollPeriodID string        `json:"payroll_period_id" gorm:"uniqueIndex:idx_payslips_user_period_version;uniqueIndex:idx_payslips_active_user_period,where:voided_at IS NULL;index"`
+	Version         int           `json:"version" gorm:"uniqueIndex:idx_payslips_user_period_version;default:1"`
+	VoidedAt        *time.Time    `json:"voided_at,omitempty"`
+	VoidReason      string        `json:"void_reason,omitempty"`
+	VoidedBy        string        `gorm:"size:36" json:"voided_by,omitempty"`
+	BaseSalary      money.Money   `json:"base_salary"`
+	Lines           []PayslipLine `json:"lines" gorm:"foreignKey:PayslipID"`
+	TotalEarnings   money.Money   `json:"total_earnings"`
+	TotalDeductions money.Money   `json:"total_deductions"`
+	EmployerCost    money.Money   `json:"employer_cost"`
+	TotalPay        money.Money   `json:"total_pay"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+	CreatedBy       string        `gorm:"size:36" json:"created_by"`
+	UpdatedBy       string        `gorm:"size:36" json:"updated_by"`
+
+	// OvertimeIDs adalah lembur yang dibayar payslip ini. Tidak disimpan;
+	// dipakai untuk menandai Overtime.PayslipID saat payslip disimpan.
+	OvertimeIDs []string `json:"-" gorm:"-"`
 }
 
 func (p *Payslip) BeforeCreate(tx *gorm.DB) error {
@@ -51,14 +152,273 @@ func (p *Payslip) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// Settlement adalah payslip aktif karyawan pada sebuah periode payroll. Absensi
+// dibayar oleh payslip periode yang mencakup tanggalnya (lihat
+// FindSettlement); lembur dan reimbursement merujuk payslip yang membayarnya
+// lewat PayslipID, yang bisa berada di periode setelah tanggalnya.
+type Settlement struct {
+	PayrollPeriodID string       `json:"payroll_period_id"`
+	PeriodStatus    PeriodStatus `json:"period_status"`
+	PayslipID       string       `json:"payslip_id"`
+	StartDate       time.Time    `json:"start_date"`
+	EndDate         time.Time    `json:"end_date"`
+}
+
+// FindSettlement mengembalikan settlement yang periodenya mencakup date, atau
+// nil jika tidak ada.
+func FindSettlement(settlements []Settlement, date time.Time) *Settlement {
+	day := date.Format("2006-01-02")
+	for i := range settlements {
+		s := &settlements[i]
+		if day >= s.StartDate.Format("2006-01-02") && day <= s.EndDate.Format("2006-01-02") {
+			return s
+		}
+	}
+	return nil
+}
+
+// AddLine menambahkan baris ke payslip dengan urutan sesuai penambahan.
+func (p *Payslip) AddLine(line PayslipLine) {
+	line.Sequence = len(p.Lines) + 1
+	p.Lines = append(p.Lines, line)
+}
+
+// Recalculate menghitung ulang kolom total dari lines. TotalPay adalah
+// total penghasilan dikurangi total potongan; biaya pemberi kerja tidak
+// mempengaruhi take home pay.
+func (p *Payslip) Recalculate() {
+	cur := p.BaseSalary.Currency()
+	p.TotalEarnings = money.Zero(cur)
+	p.TotalDeductions = money.Zero(cur)
+	p.EmployerCost = money.Zero(cur)
+
+	for _, l := range p.Lines {
+		switch l.Type {
+		case LineEarning:
+			p.TotalEarnings = p.TotalEarnings.Add(l.Amount)
+		case LineDeduction:
+			p.TotalDeductions = p.TotalDeductions.Add(l.Amount)
+		case LineEmployerCost:
+			p.EmployerCost = p.EmployerCost.Add(l.Amount)
+		}
+	}
+	p.TotalPay = p.TotalEarnings.Sub(p.TotalDeductions)
+}
+
+// TaxableIncome adalah penghasilan bruto kena PPh 21: semua line yang
+// ditandai Taxable, termasuk premi yang dibayar pemberi kerja. Potongan yang
+// ditandai Taxable (mis. potongan tidak hadir) mengurangi penghasilan bruto.
+func (p *Payslip) TaxableIncome() money.Money {
+	total := money.Zero(p.BaseSalary.Currency())
+	for _, l := range p.Lines {
+		if !l.Taxable {
+			continue
+		}
+		if l.Type == LineDeduction {
+			total = total.Sub(l.Amount)
+		} else {
+			total = total.Add(l.Amount)
+		}
+	}
+	return total
+}
+
+// SumLines menjumlahkan amount dari line dengan tipe dan kode tertentu.
+func (p *Payslip) SumLines(lineType LineType, codes ...string) money.Money {
+	total := money.Zero(p.BaseSalary.Currency())
+	for _, l := range p.Lines {
+		if l.Type != lineType {
+			continue
+		}
+		for _, code := range codes {
+			if l.Code == code {
+				total = total.Add(l.Amount)
+				break
+			}
+		}
+	}
+	return total
+}
+
+// LineType adalah jenis baris payslip.
+type LineType string
+
+const (
+	LineEarning      LineType = "earning"
+	LineDeduction    LineType = "deduction"
+	LineEmployerCost LineType = "employer_cost"
+)
+
+// Kode baris payslip yang dihasilkan oleh RunPayroll.
+const (
+	CodeBasicSalary   = "BASIC_SALARY"
+	CodeOvertime      = "OVERTIME"
+	CodeReimbursement = "REIMBURSEMENT"
+	CodeIncomeTax     = "PPH21"
+	CodeBPJSPrefix    = "BPJS_"
+)
+
+// PayslipLine adalah satu baris rincian payslip. Amount adalah nilai yang
+// berlaku; Quantity dan Rate bersifat informatif (mis. 4 hari x rate harian).
+type PayslipLine struct {
+	ID          string      `json:"-" gorm:"primaryKey"`
+	PayslipID   string      `json:"-" gorm:"index;size:36"`
+	Sequence    int         `json:"-"`
+	Type        LineType    `json:"type" gorm:"size:16"`
+	Code        string      `json:"code" gorm:"size:32"`
+	Description string      `json:"description"`
+	Quantity    float64     `json:"quantity" gorm:"type:numeric(12,2)"`
+	Rate        money.Money `json:"rate"`
+	Amount      money.Money `json:"amount"`
+	Taxable     bool        `json:"taxable"`                               // Termasuk (atau, untuk potongan, mengurangi) penghasilan bruto PPh 21
+	ReferenceID string      `json:"reference_id,omitempty" gorm:"size:36"` // Sumber line, mis. ID reimbursement
+}
+
+func (l *PayslipLine) BeforeCreate(tx *gorm.DB) error {
+	l.ID = uuid.New().String()
+	return nil
+}
+
 // Summary models for API responses
 type Summary struct {
 	PayrollPeriodID string        `json:"payroll_period_id"`
 	EmployeePays    []EmployeePay `json:"employee_pays"`
-	TotalPayout     float64       `json:"total_payout"`
+	TotalPayout     money.Money   `json:"total_payout"`
+	// TotalEmployerCost adalah total biaya pemberi kerja (iuran BPJS bagian
+	// pemberi kerja), dirinci per kode line pada EmployerCostByCode.
+	TotalEmployerCost  money.Money            `json:"total_employer_cost"`
+	EmployerCostByCode map[string]money.Money `json:"employer_cost_by_code"`
 }
 
 type EmployeePay struct {
-	UserID      string  `json:"user_id"`
-	TakeHomePay float64 `json:"take_home_pay"`
+	UserID       string      `json:"user_id"`
+	TakeHomePay  money.Money `json:"take_home_pay"`
+	EmployerCost money.Money `json:"employer_cost"`
+}
+
+// PayslipDiff adalah perbandingan dua versi payslip seorang karyawan dalam
+// satu periode. Lines hanya berisi kode yang nilainya berubah.
+type PayslipDiff struct {
+	UserID          string     `json:"user_id"`
+	PayrollPeriodID string     `json:"payroll_period_id"`
+	FromVersion     int        `json:"from_version"`
+	ToVersion       int        `json:"to_version"`
+	Lines           []LineDiff `json:"lines"`
+	TotalEarnings   AmountDiff `json:"total_earnings"`
+	TotalDeductions AmountDiff `json:"total_deductions"`
+	EmployerCost    AmountDiff `json:"employer_cost"`
+	TotalPay        AmountDiff `json:"total_pay"`
+}
+
+// LineDiff adalah perubahan jumlah line dengan tipe dan kode yang sama.
+// Beberapa line dengan kode sama (mis. beberapa reimbursement) dijumlahkan.
+type LineDiff struct {
+	Type LineType `json:"type"`
+	Code string   `json:"code"`
+	AmountDiff
+}
+
+// AmountDiff adalah nilai sebelum, sesudah, dan selisihnya.
+type AmountDiff struct {
+	From  money.Money `json:"from"`
+	To    money.Money `json:"to"`
+	Delta money.Money `json:"delta"`
+}
+
+func newAmountDiff(from, to money.Money) AmountDiff {
+	return AmountDiff{From: from, To: to, Delta: to.Sub(from)}
+}
+
+// Preview adalah hasil simulasi payroll (dry-run) yang tidak disimpan.
+type Preview struct {
+	PayrollPeriodID string           `json:"payroll_period_id"`
+	Payslips        []Payslip        `json:"payslips"`
+	Summary         Summary          `json:"summary"`
+	Warnings        []PreviewWarning `json:"warnings"`
+}
+
+// Kode PreviewWarning.
+const (
+	WarningZeroAttendance     = "zero_attendance"
+	WarningMissingSalary      = "missing_salary"
+	WarningLargeReimbursement = "large_reimbursement"
+	WarningCalculationFailed  = "calculation_failed"
+)
+
+// PreviewWarning menandai hal yang perlu diperiksa admin sebelum payroll
+// dijalankan.
+type PreviewWarning struct {
+	UserID  string `json:"user_id"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Kode PeriodWarning.
+const (
+	WarningGapBefore = "gap_before" // Ada tanggal tanpa periode sebelum periode ini
+	WarningGapAfter  = "gap_after"  // Ada tanggal tanpa periode setelah periode ini
+)
+
+// PeriodWarning menandai hal yang perlu diperiksa admin setelah membuat
+// periode payroll, tanpa menggagalkan pembuatannya.
+type PeriodWarning struct {
+	PayrollPeriodID string `json:"payroll_period_id"`
+	Code            string `json:"code"`
+	Message         string `json:"message"`
+}
+
+// Status PayrollJob.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// Status PayrollJobItem.
+const (
+	JobItemPending   = "pending"
+	JobItemSucceeded = "succeeded"
+	JobItemFailed    = "failed"
+)
+
+// PayrollJob adalah antrean eksekusi payroll satu periode yang disimpan di
+// database dan diproses oleh Worker di luar request HTTP. Hanya boleh ada satu
+// job aktif (queued/running) per periode.
+type PayrollJob struct {
+	ID                 string     `json:"id" gorm:"primaryKey"`
+	PayrollPeriodID    string     `json:"payroll_period_id" gorm:"size:36;uniqueIndex:idx_payroll_jobs_active_period,where:status = 'queued' OR status = 'running'"`
+	Status             string     `json:"status" gorm:"size:16;index;default:'queued'"`
+	TotalEmployees     int        `json:"total_employees"`
+	ProcessedEmployees int        `json:"processed_employees"`
+	FailedEmployees    int        `json:"failed_employees"`
+	Error              string     `json:"error,omitempty"`
+	Attempts           int        `json:"attempts"`
+	LockedUntil        *time.Time `json:"-"` // Lease worker; job running yang lease-nya habis diambil ulang
+	StartedAt          *time.Time `json:"started_at,omitempty"`
+	FinishedAt         *time.Time `json:"finished_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	CreatedBy          string     `gorm:"size:36" json:"created_by"`
+	UpdatedBy          string     `gorm:"size:36" json:"updated_by"`
+}
+
+func (j *PayrollJob) BeforeCreate(tx *gorm.DB) error {
+	j.ID = uuid.New().String()
+	return nil
+}
+
+// PayrollJobItem mencatat progres perhitungan seorang karyawan dalam job.
+type PayrollJobItem struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	JobID     string    `json:"job_id" gorm:"size:36;uniqueIndex:idx_payroll_job_items_job_user"`
+	UserID    string    `json:"user_id" gorm:"size:36;uniqueIndex:idx_payroll_job_items_job_user"`
+	Status    string    `json:"status" gorm:"size:16;default:'pending'"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (i *PayrollJobItem) BeforeCreate(tx *gorm.DB) error {
+	i.ID = uuid.New().String()
+	return nil
 }
@@ -0,0 +1,190 @@
+// File: internal/domain/payroll/overtime.go
+package payroll
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/overtime"
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
+)
+
+// ErrInvalidOvertimePolicy dikembalikan untuk metode lembur yang tidak dikenal.
+var ErrInvalidOvertimePolicy = errors.New("invalid overtime policy")
+
+// OvertimeMethod menentukan cara upah lembur dihitung.
+type OvertimeMethod string
+
+const (
+	// OvertimeFlat: jam lembur x 2 x rate per jam, dengan rate per jam =
+	// rate harian kebijakan proration / 8.
+	OvertimeFlat OvertimeMethod = "flat"
+	// OvertimeStatutory: Kepmenakertrans No. 102/MEN/VI/2004 untuk 5 hari
+	// kerja seminggu, dengan upah sejam = 1/173 gaji pokok bulanan.
+	OvertimeStatutory OvertimeMethod = "statutory"
+)
+
+// DefaultOvertimeMethod mempertahankan perhitungan lembur flat.
+const DefaultOvertimeMethod = OvertimeFlat
+
+// statutoryMonthlyHours adalah pembagi upah sejam menurut Kepmen 102/2004.
+const statutoryMonthlyHours = 173
+
+// ParseOvertimeMethod memvalidasi nama metode lembur.
+func ParseOvertimeMethod(s string) (OvertimeMethod, error) {
+	switch m := OvertimeMethod(strings.TrimSpace(s)); m {
+	case OvertimeFlat, OvertimeStatutory:
+		return m, nil
+	}
+	return "", fmt.Errorf("%w: unknown method %q", ErrInvalidOvertimePolicy, s)
+}
+
+// overtimeTier adalah rentang jam lembur dalam satu hari dengan pengali yang
+// sama. Multiplier dalam persepuluhan (15 = 1,5x); Hours 0 berarti seluruh
+// jam sisanya.
+type overtimeTier struct {
+	Hours      int
+	Multiplier int64
+}
+
+// Tarif lembur Kepmen 102/2004 pasal 11 untuk 5 hari kerja seminggu. Hari
+// libur resmi dan hari istirahat mingguan memakai tarif yang sama.
+var (
+	workdayOvertimeTiers = []overtimeTier{{Hours: 1, Multiplier: 15}, {Hours: 0, Multiplier: 20}}
+	restDayOvertimeTiers = []overtimeTier{{Hours: 8, Multiplier: 20}, {Hours: 1, Multiplier: 30}, {Hours: 0, Multiplier: 40}}
+)
+
+// overtimeBucket mengumpulkan jam lembur satu periode per jenis hari dan
+// tingkat tarif, sehingga tiap tingkat tampil sebagai satu line payslip.
+type overtimeBucket struct {
+	restDay    bool
+	tier       int // indeks tingkat di tabel tarif
+	firstHour  int // jam ke- awal tingkat
+	lastHour   int // jam ke- akhir tingkat; 0 = seterusnya
+	multiplier int64
+	hours      int64
+}
+
+// statutoryOvertimeLines menghitung lembur sesuai Kepmen 102/2004. Jam lembur
+// dijumlahkan per tanggal lalu dibagi ke tingkat tarif hari tersebut;
+// hasilnya disajikan satu line per jenis hari dan tingkat tarif.
+func statutoryOvertimeLines(base money.Money, overtimes []overtime.Overtime, calendar locationCalendar, r money.Rounding) []PayslipLine {
+	hoursByDate := make(map[time.Time]int)
+	for _, ot := range overtimes {
+		hoursByDate[calendarDate(ot.Date.Date())] += ot.Hours
+	}
+
+	buckets := make(map[[2]int]*overtimeBucket)
+	for date, hours := range hoursByDate {
+		restDay := !calendar.isWorkingDay(date)
+		tiers := workdayOvertimeTiers
+		if restDay {
+			tiers = restDayOvertimeTiers
+		}
+
+		first := 1
+		for i, tier := range tiers {
+			if hours <= 0 {
+				break
+			}
+			n := hours
+			last := 0
+			if tier.Hours > 0 {
+				n = min(hours, tier.Hours)
+				last = first + tier.Hours - 1
+			}
+			key := [2]int{boolIndex(restDay), i}
+			b, ok := buckets[key]
+			if !ok {
+				b = &overtimeBucket{restDay: restDay, tier: i, firstHour: first, lastHour: last, multiplier: tier.Multiplier}
+				buckets[key] = b
+			}
+			b.hours += int64(n)
+			hours -= n
+			first += tier.Hours
+		}
+	}
+
+	ordered := make([]*overtimeBucket, 0, len(buckets))
+	for _, b := range buckets {
+		ordered = append(ordered, b)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].restDay != ordered[j].restDay {
+			return !ordered[i].restDay
+		}
+		return ordered[i].tier < ordered[j].tier
+	})
+
+	lines := make([]PayslipLine, 0, len(ordered))
+	for _, b := range ordered {
+		lines = append(lines, PayslipLine{
+			Type:        LineEarning,
+			Code:        CodeOvertime,
+			Description: b.description(),
+			Quantity:    float64(b.hours),
+			Rate:        base.MulDiv(b.multiplier, statutoryMonthlyHours*10, r),
+			Amount:      base.MulDiv(b.hours*b.multiplier, statutoryMonthlyHours*10, r),
+			Taxable:     true,
+		})
+	}
+	return lines
+}
+
+// description mis. "Lembur hari kerja jam ke-1 (1,5x)" atau "Lembur hari
+// libur jam ke-10 dst (4x)".
+func (b *overtimeBucket) description() string {
+	day := "hari kerja"
+	if b.restDay {
+		day = "hari libur"
+	}
+	hours := fmt.Sprintf("jam ke-%d", b.firstHour)
+	switch {
+	case b.lastHour == 0:
+		hours += " dst"
+	case b.lastHour > b.firstHour:
+		hours = fmt.Sprintf("jam ke-%d s.d. %d", b.firstHour, b.lastHour)
+	}
+	multiplier := fmt.Sprintf("%d", b.multiplier/10)
+	if b.multiplier%10 != 0 {
+		multiplier = fmt.Sprintf("%d,%d", b.multiplier/10, b.multiplier%10)
+	}
+	return fmt.Sprintf("Lembur %s %s (%sx)", day, hours, multiplier)
+}
+
+func boolIndex(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// overtimeLines menghasilkan line lembur sesuai metode yang dikonfigurasi.
+// Metode flat tetap memakai rate harian dari kebijakan proration, yaitu porsi
+// gaji bulanan periode dibagi dailyDivisor; metode statutory memakai gaji
+// bulanan penuh.
+func (s *service) overtimeLines(base money.Money, share periodShare, overtimes []overtime.Overtime, calendar locationCalendar, dailyDivisor int64) []PayslipLine {
+	if s.overtimeMethod == OvertimeStatutory {
+		return statutoryOvertimeLines(base, overtimes, calendar, s.rounding)
+	}
+
+	var hours int64
+	for _, ot := range overtimes {
+		hours += int64(ot.Hours)
+	}
+	if hours <= 0 {
+		return nil
+	}
+	return []PayslipLine{{
+		Type:        LineEarning,
+		Code:        CodeOvertime,
+		Description: "Lembur (2x rate per jam)",
+		Quantity:    float64(hours),
+		Rate:        share.of(base, 2, dailyDivisor*8, s.rounding),
+		Amount:      share.of(base, hours*2, dailyDivisor*8, s.rounding),
+		Taxable:     true,
+	}}
+}
@@ -0,0 +1,292 @@
+// File: internal/domain/payroll/proration.go
+package payroll
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
+)
+
+// ErrInvalidProration dikembalikan untuk kebijakan proration yang tidak
+// dikenal atau pembaginya tidak valid.
+var ErrInvalidProration = errors.New("invalid proration policy")
+
+// ProrationMethod menentukan cara gaji pokok dihitung terhadap kehadiran.
+type ProrationMethod string
+
+const (
+	// ProrationWorkingDays: gaji x hari hadir / hari kerja periode.
+	ProrationWorkingDays ProrationMethod = "working_days"
+	// ProrationCalendarDays: gaji x (hari kalender - hari kerja tidak
+	// hadir) / hari kalender periode.
+	ProrationCalendarDays ProrationMethod = "calendar_days"
+	// ProrationFixedDivisor: gaji x hari hadir / pembagi tetap (mis. 21 atau
+	// 22), paling banyak gaji penuh.
+	ProrationFixedDivisor ProrationMethod = "fixed_divisor"
+	// ProrationAbsenceDeduction: gaji penuh, lalu dipotong hari kerja tidak
+	// hadir x rate harian.
+	ProrationAbsenceDeduction ProrationMethod = "absence_deduction"
+)
+
+const (
+	// CodeAbsenceDeduction adalah kode line potongan ketidakhadiran.
+	CodeAbsenceDeduction = "ABSENCE_DEDUCTION"
+	// CodeUnpaidLeave adalah kode line potongan cuti tidak berbayar.
+	CodeUnpaidLeave = "UNPAID_LEAVE"
+)
+
+// ProrationPolicy adalah kebijakan proration gaji pokok. Rate harian yang
+// dihasilkan juga menjadi dasar rate per jam (rate harian / 8) untuk lembur
+// metode flat.
+type ProrationPolicy struct {
+	Method ProrationMethod
+	// Divisor adalah pembagi rate harian: wajib untuk fixed_divisor,
+	// opsional untuk absence_deduction (0 = hari kerja periode).
+	Divisor int
+}
+
+// DefaultProrationPolicy memprorata gaji terhadap hari kerja periode.
+var DefaultProrationPolicy = ProrationPolicy{Method: ProrationWorkingDays}
+
+// ParseProrationPolicy membaca kebijakan dalam format "<method>[:<divisor>]",
+// mis. "calendar_days" atau "fixed_divisor:21".
+func ParseProrationPolicy(s string) (ProrationPolicy, error) {
+	method, divisor, hasDivisor := strings.Cut(strings.TrimSpace(s), ":")
+	p := ProrationPolicy{Method: ProrationMethod(method)}
+	if hasDivisor {
+		n, err := strconv.Atoi(divisor)
+		if err != nil {
+			return ProrationPolicy{}, fmt.Errorf("%w: divisor %q", ErrInvalidProration, divisor)
+		}
+		p.Divisor = n
+	}
+	if err := p.Validate(); err != nil {
+		return ProrationPolicy{}, err
+	}
+	return p, nil
+}
+
+// Validate memeriksa kombinasi metode dan pembagi.
+func (p ProrationPolicy) Validate() error {
+	switch p.Method {
+	case ProrationWorkingDays, ProrationCalendarDays:
+		if p.Divisor != 0 {
+			return fmt.Errorf("%w: %s does not take a divisor", ErrInvalidProration, p.Method)
+		}
+	case ProrationFixedDivisor:
+		if p.Divisor < 1 || p.Divisor > 31 {
+			return fmt.Errorf("%w: %s requires a divisor between 1 and 31", ErrInvalidProration, p.Method)
+		}
+	case ProrationAbsenceDeduction:
+		if p.Divisor < 0 || p.Divisor > 31 {
+			return fmt.Errorf("%w: %s divisor must be between 1 and 31, or 0 for working days", ErrInvalidProration, p.Method)
+		}
+	default:
+		return fmt.Errorf("%w: unknown method %q", ErrInvalidProration, p.Method)
+	}
+	return nil
+}
+
+// String mengembalikan kebijakan dalam format yang dibaca ParseProrationPolicy.
+func (p ProrationPolicy) String() string {
+	if p.Divisor == 0 {
+		return string(p.Method)
+	}
+	return fmt.Sprintf("%s:%d", p.Method, p.Divisor)
+}
+
+// prorationDays adalah jumlah hari yang dibutuhkan kebijakan proration
+// untuk satu karyawan dalam satu periode.
+type prorationDays struct {
+	Working  int // Hari kerja periode di lokasi karyawan
+	Calendar int // Hari kalender periode
+	Attended int // Hari hadir
+	// PaidLeave dan UnpaidLeave adalah hari kerja tidak hadir yang tertutup
+	// cuti approved berbayar dan tidak berbayar.
+	PaidLeave   int
+	UnpaidLeave int
+}
+
+// paid adalah hari kerja yang dibayar pada line gaji pokok: hari hadir dan
+// hari cuti. Hari cuti tidak berbayar dipotong kembali pada line
+// UNPAID_LEAVE.
+func (d prorationDays) paid() int {
+	return d.Attended + d.PaidLeave + d.UnpaidLeave
+}
+
+// absent adalah hari kerja yang tidak dihadiri tanpa cuti.
+func (d prorationDays) absent() int {
+	return max(d.Working-d.paid(), 0)
+}
+
+// forPeriod menyesuaikan kebijakan dengan periode yang tidak membayar satu
+// bulan penuh. Pembagi tetap (fixed_divisor dan absence_deduction) adalah
+// hari per bulan, sehingga periode seperti itu memakai hari kerja periode.
+func (p ProrationPolicy) forPeriod(share periodShare) ProrationPolicy {
+	if share.full() || p.Divisor == 0 {
+		return p
+	}
+	if p.Method == ProrationFixedDivisor {
+		return ProrationPolicy{Method: ProrationWorkingDays}
+	}
+	return ProrationPolicy{Method: p.Method}
+}
+
+// periodShare adalah porsi satu bulan yang dibayar sebuah periode, num/den.
+// Gaji pokok, iuran BPJS, dan komponen tetap adalah nilai per bulan sehingga
+// dikalikan porsi ini.
+type periodShare struct {
+	num, den int64
+}
+
+// fullMonth adalah porsi periode bulanan.
+var fullMonth = periodShare{1, 1}
+
+// shareOf menentukan porsi bulan periode: semi-bulanan 1/2, mingguan 12/52
+// (52 minggu dibagi 12 bulan), dan off-cycle hari kalender periode dibagi
+// jumlah hari bulan tanggal akhirnya.
+func shareOf(period *PayrollPeriod) periodShare {
+	switch period.Type {
+	case PeriodSemiMonthly:
+		return periodShare{1, 2}
+	case PeriodWeekly:
+		return periodShare{12, 52}
+	case PeriodOffCycle:
+		end := period.EndDate
+		monthDays := calendarDate(end.Year(), end.Month()+1, 0).Day()
+		return periodShare{int64(daysBetween(period.StartDate, end) + 1), int64(monthDays)}
+	}
+	return fullMonth
+}
+
+// full melaporkan apakah porsi ini satu bulan penuh.
+func (s periodShare) full() bool {
+	return s.num == s.den
+}
+
+// of menghitung m x num/den x porsi periode dengan satu kali pembulatan.
+func (s periodShare) of(m money.Money, num, den int64, r money.Rounding) money.Money {
+	return m.MulDiv(num*s.num, den*s.den, r)
+}
+
+// dailyDivisor adalah pembagi gaji pokok untuk rate harian.
+func (p ProrationPolicy) dailyDivisor(d prorationDays) int64 {
+	switch p.Method {
+	case ProrationCalendarDays:
+		return int64(d.Calendar)
+	case ProrationFixedDivisor:
+		return int64(p.Divisor)
+	case ProrationAbsenceDeduction:
+		if p.Divisor > 0 {
+			return int64(p.Divisor)
+		}
+	}
+	return int64(d.Working)
+}
+
+// salaryLines menghasilkan line gaji pokok (dan potongan ketidakhadiran)
+// sesuai kebijakan. base adalah gaji pokok bulanan; share adalah porsinya
+// yang dibayar periode ini. Seperti line lain, setiap amount dihitung eksak
+// dari gaji pokok lalu dibulatkan satu kali.
+func (p ProrationPolicy) salaryLines(base money.Money, share periodShare, d prorationDays, r money.Rounding) []PayslipLine {
+	divisor := p.dailyDivisor(d)
+	rate := share.of(base, 1, divisor, r)
+
+	switch p.Method {
+	case ProrationCalendarDays:
+		paid := int64(max(d.Calendar-d.absent(), 0))
+		return unpaidLeaveLines([]PayslipLine{{
+			Type:        LineEarning,
+			Code:        CodeBasicSalary,
+			Description: fmt.Sprintf("Gaji pokok (%d dari %d hari kalender)", paid, d.Calendar),
+			Quantity:    float64(paid),
+			Rate:        rate,
+			Amount:      share.of(base, paid, divisor, r),
+			Taxable:     true,
+		}}, base, share, min(int64(d.UnpaidLeave), paid), divisor, r)
+	case ProrationFixedDivisor:
+		paid := min(int64(d.paid()), divisor)
+		// Yang dibayar bersih adalah hari hadir dan cuti berbayar, dibatasi
+		// pembagi; cuti tidak berbayar hanya memotong selisihnya dengan hari
+		// pada line gaji pokok.
+		unpaid := paid - min(int64(d.paid()-d.UnpaidLeave), divisor)
+		return unpaidLeaveLines([]PayslipLine{{
+			Type:        LineEarning,
+			Code:        CodeBasicSalary,
+			Description: fmt.Sprintf("Gaji pokok (%d hari hadir, pembagi %d)", paid, divisor),
+			Quantity:    float64(paid),
+			Rate:        rate,
+			Amount:      share.of(base, paid, divisor, r),
+			Taxable:     true,
+		}}, base, share, unpaid, divisor, r)
+	case ProrationAbsenceDeduction:
+		full := share.of(base, 1, 1, r)
+		lines := []PayslipLine{{
+			Type:        LineEarning,
+			Code:        CodeBasicSalary,
+			Description: "Gaji pokok",
+			Quantity:    1,
+			Rate:        full,
+			Amount:      full,
+			Taxable:     true,
+		}}
+		absent := min(int64(d.absent()), divisor)
+		if absent > 0 {
+			// Potongan ini mengurangi penghasilan bruto PPh 21 (Taxable).
+			lines = append(lines, PayslipLine{
+				Type:        LineDeduction,
+				Code:        CodeAbsenceDeduction,
+				Description: fmt.Sprintf("Potongan tidak hadir (%d hari)", absent),
+				Quantity:    float64(absent),
+				Rate:        rate,
+				Amount:      share.of(base, absent, divisor, r),
+				Taxable:     true,
+			})
+		}
+		return unpaidLeaveLines(lines, base, share, min(int64(d.UnpaidLeave), divisor-absent), divisor, r)
+	}
+
+	paid := int64(d.paid())
+	return unpaidLeaveLines([]PayslipLine{{
+		Type:        LineEarning,
+		Code:        CodeBasicSalary,
+		Description: fmt.Sprintf("Gaji pokok (%d dari %d hari kerja)", paid, d.Working),
+		Quantity:    float64(paid),
+		Rate:        rate,
+		Amount:      share.of(base, paid, divisor, r),
+		Taxable:     true,
+	}}, base, share, int64(d.UnpaidLeave), divisor, r)
+}
+
+// unpaidLeaveLines menambahkan potongan days hari cuti tidak berbayar x rate
+// harian pada lines. Seperti potongan tidak hadir, potongan ini mengurangi
+// penghasilan bruto PPh 21.
+func unpaidLeaveLines(lines []PayslipLine, base money.Money, share periodShare, days, divisor int64, r money.Rounding) []PayslipLine {
+	if days <= 0 {
+		return lines
+	}
+	return append(lines, PayslipLine{
+		Type:        LineDeduction,
+		Code:        CodeUnpaidLeave,
+		Description: fmt.Sprintf("Potongan cuti tidak berbayar (%d hari)", days),
+		Quantity:    float64(days),
+		Rate:        share.of(base, 1, divisor, r),
+		Amount:      share.of(base, days, divisor, r),
+		Taxable:     true,
+	})
+}
+
+// prorationFor memilih kebijakan untuk karyawan: kebijakan kelompok
+// penggajiannya jika ada, selain itu kebijakan perusahaan.
+func (s *service) prorationFor(emp employee.Employee) ProrationPolicy {
+	if emp.PayGroup != "" {
+		if p, ok := s.groupProration[emp.PayGroup]; ok {
+			return p
+		}
+	}
+	return s.proration
+}
@@ -5,23 +5,105 @@ import (
 	"context"
 	"time"
 
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/attendance"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/overtime"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/reimbursement"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Repository mendefinisikan kontrak untuk semua operasi database terkait payroll.
 type Repository interface {
+	// WithTransaction menjalankan fn di dalam satu transaksi database. Repository
+	// yang diberikan ke fn terikat pada transaksi tersebut; jika fn mengembalikan
+	// error, seluruh perubahan dibatalkan.
+	WithTransaction(ctx context.Context, fn func(repo Repository) error) error
 	CreatePayrollPeriod(ctx context.Context, period *PayrollPeriod) error
 	GetPayrollPeriod(ctx context.Context, id string) (*PayrollPeriod, error)
-	UpdatePayrollPeriodStatus(ctx context.Context, id, status string, updatedByID string) error
+	// LockPayrollPeriods mencegah pembuatan periode berjalan paralel sampai
+	// transaksi selesai. Harus dipanggil di dalam WithTransaction.
+	LockPayrollPeriods(ctx context.Context) error
+	// GetOverlappingPeriods mengembalikan periode yang beririsan dengan
+	// rentang tanggal start sampai end (inklusif).
+	GetOverlappingPeriods(ctx context.Context, start, end time.Time) ([]PayrollPeriod, error)
+	// GetAdjacentPeriods mengembalikan periode terakhir yang berakhir sebelum
+	// start dan periode pertama yang dimulai setelah end; nil jika tidak ada.
+	GetAdjacentPeriods(ctx context.Context, start, end time.Time) (prev, next *PayrollPeriod, err error)
+	// UpdatePayrollPeriod menyimpan status dan data siklus hidup periode hanya
+	// jika status di database masih from. Mengembalikan false jika periode
+	// sudah diubah oleh proses lain.
+	UpdatePayrollPeriod(ctx context.Context, period *PayrollPeriod, from PeriodStatus) (bool, error)
+	// IsDateLocked melaporkan apakah tanggal berada di dalam periode yang
+	// sudah ditutup (closed).
+	IsDateLocked(ctx context.Context, date time.Time) (bool, error)
+	// IsDateCalculated melaporkan apakah tanggal berada di dalam periode yang
+	// sudah melewati status open.
+	IsDateCalculated(ctx context.Context, date time.Time) (bool, error)
 	GetAttendances(ctx context.Context, userID string, start, end time.Time) ([]attendance.Attendance, error)
-	GetOvertimes(ctx context.Context, userID string, start, end time.Time) ([]overtime.Overtime, error)
-	GetReimbursements(ctx context.Context, userID string, start, end time.Time) ([]reimbursement.Reimbursement, error)
+	// GetOvertimes mengembalikan semua lembur approved yang belum dibayar
+	// payslip mana pun dan bertanggal sampai end, termasuk yang bertanggal di
+	// periode sebelumnya tetapi baru disetujui setelah periode itu dihitung.
+	GetOvertimes(ctx context.Context, userID string, end time.Time) ([]overtime.Overtime, error)
+	// SettleOvertimes menandai lembur ids dibayar oleh payslipID, hanya jika
+	// masih approved dan belum dibayar. Mengembalikan jumlah lembur yang
+	// ditandai.
+	SettleOvertimes(ctx context.Context, payslipID string, ids []string) (int64, error)
+	// UnsettleOvertimes melepas lembur dari payslip aktif periode sehingga
+	// ikut dibayar pada perhitungan berikutnya.
+	UnsettleOvertimes(ctx context.Context, periodID string) error
+	// GetReimbursements mengembalikan semua reimbursement approved yang belum
+	// dibayar dan bertanggal sampai end, termasuk yang bertanggal di periode
+	// sebelumnya tetapi baru disetujui setelah periode itu dihitung.
+	GetReimbursements(ctx context.Context, userID string, end time.Time) ([]reimbursement.Reimbursement, error)
+	GetReimbursementsByIDs(ctx context.Context, ids []string) ([]reimbursement.Reimbursement, error)
+	// GetSettledReimbursements mengembalikan reimbursement yang dibayar oleh
+	// payslip aktif periode.
+	GetSettledReimbursements(ctx context.Context, periodID string) ([]reimbursement.Reimbursement, error)
+	// UpdateReimbursementStatus menyimpan perubahan status reimbursement
+	// beserta riwayatnya (lihat reimbursement.Repository).
+	UpdateReimbursementStatus(ctx context.Context, r *reimbursement.Reimbursement, from reimbursement.Status, history reimbursement.StatusHistory) (bool, error)
 	CreatePayslip(ctx context.Context, payslip *Payslip) error
+	// GetPayslip, GetPayslipsByPeriod, dan GetYearToDatePayslips hanya
+	// mengembalikan payslip aktif (belum void).
 	GetPayslip(ctx context.Context, userID, periodID string) (*Payslip, error)
 	GetPayslipsByPeriod(ctx context.Context, periodID string) ([]Payslip, error)
+	GetYearToDatePayslips(ctx context.Context, userID string, before time.Time) ([]Payslip, error)
+	// GetYearToDateMonths mengembalikan bulan-bulan (tanpa duplikat) tanggal
+	// akhir periode payslip yang dikembalikan GetYearToDatePayslips.
+	GetYearToDateMonths(ctx context.Context, userID string, before time.Time) ([]time.Month, error)
+	// GetSettlements mengembalikan payslip aktif karyawan pada periode yang
+	// beririsan dengan rentang tanggal start sampai end (inklusif).
+	GetSettlements(ctx context.Context, userID string, start, end time.Time) ([]Settlement, error)
+	// GetSettlementsByPayslips mengembalikan settlement payslip aktif karyawan
+	// dengan ID payslipIDs.
+	GetSettlementsByPayslips(ctx context.Context, userID string, payslipIDs []string) ([]Settlement, error)
+	// GetPayslipVersions mengembalikan semua versi payslip karyawan dalam
+	// periode, termasuk yang sudah void, diurutkan dari versi terlama.
+	GetPayslipVersions(ctx context.Context, userID, periodID string) ([]Payslip, error)
+	// GetLatestPayslipVersions mengembalikan versi tertinggi payslip per
+	// karyawan dalam periode.
+	GetLatestPayslipVersions(ctx context.Context, periodID string) (map[string]int, error)
+	// VoidPayslips menandai semua payslip aktif periode sebagai void.
+	VoidPayslips(ctx context.Context, periodID, reason, voidedByID string, at time.Time) (int64, error)
+
+	CreatePayrollJob(ctx context.Context, job *PayrollJob) error
+	GetPayrollJob(ctx context.Context, id string) (*PayrollJob, error)
+	// HasActivePayrollJob memeriksa apakah periode masih memiliki job queued/running.
+	HasActivePayrollJob(ctx context.Context, periodID string) (bool, error)
+	// ClaimNextPayrollJob mengambil satu job queued, atau job running yang
+	// lease-nya sudah habis, lalu menandainya running sampai leaseUntil.
+	// Mengembalikan nil jika tidak ada job yang bisa diambil.
+	ClaimNextPayrollJob(ctx context.Context, now, leaseUntil time.Time) (*PayrollJob, error)
+	RenewPayrollJobLease(ctx context.Context, id string, leaseUntil time.Time) error
+	// ResetPayrollJobItems mengganti seluruh item job dengan daftar baru
+	// berstatus pending dan mengatur ulang penghitung progres job.
+	ResetPayrollJobItems(ctx context.Context, jobID string, userIDs []string) error
+	// RecordPayrollJobItem menyimpan hasil perhitungan seorang karyawan dan
+	// menambah penghitung progres job.
+	RecordPayrollJobItem(ctx context.Context, jobID, userID, status, errMsg string) error
+	FinishPayrollJob(ctx context.Context, id, status, errMsg string) error
+	GetPayrollJobItems(ctx context.Context, jobID, status string) ([]PayrollJobItem, error)
 }
 
 // repository adalah implementasi dari Repository interface menggunakan GORM.
@@ -34,6 +116,12 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db}
 }
 
+func (r *repository) WithTransaction(ctx context.Context, fn func(repo Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&repository{tx})
+	})
+}
+
 func (r *repository) CreatePayrollPeriod(ctx context.Context, period *PayrollPeriod) error {
 	return r.db.WithContext(ctx).Create(period).Error
 }
@@ -46,12 +134,80 @@ func (r *repository) GetPayrollPeriod(ctx context.Context, id string) (*PayrollP
 	return &period, nil
 }
 
-func (r *repository) UpdatePayrollPeriodStatus(ctx context.Context, id, status string, updatedByID string) error {
+// payrollPeriodLockKey adalah kunci advisory lock untuk pembuatan periode.
+const payrollPeriodLockKey = 72010011
+
+func (r *repository) LockPayrollPeriods(ctx context.Context) error {
+	return r.db.WithContext(ctx).Exec("SELECT pg_advisory_xact_lock(?)", payrollPeriodLockKey).Error
+}
+
+func (r *repository) GetOverlappingPeriods(ctx context.Context, start, end time.Time) ([]PayrollPeriod, error) {
+	var periods []PayrollPeriod
+	err := r.db.WithContext(ctx).
+		Where("start_date::date <= ? AND end_date::date >= ?", end.Format("2006-01-02"), start.Format("2006-01-02")).
+		Order("start_date").
+		Find(&periods).Error
+	return periods, err
+}
+
+func (r *repository) GetAdjacentPeriods(ctx context.Context, start, end time.Time) (*PayrollPeriod, *PayrollPeriod, error) {
+	var prev, next []PayrollPeriod
+	if err := r.db.WithContext(ctx).
+		Where("end_date::date < ?", start.Format("2006-01-02")).
+		Order("end_date DESC").Limit(1).
+		Find(&prev).Error; err != nil {
+		return nil, nil, err
+	}
+	if err := r.db.WithContext(ctx).
+		Where("start_date::date > ?", end.Format("2006-01-02")).
+		Order("start_date").Limit(1).
+		Find(&next).Error; err != nil {
+		return nil, nil, err
+	}
+	return firstPeriod(prev), firstPeriod(next), nil
+}
+
+// firstPeriod mengembalikan elemen pertama periods, atau nil jika kosong.
+func firstPeriod(periods []PayrollPeriod) *PayrollPeriod {
+	if len(periods) == 0 {
+		return nil
+	}
+	return &periods[0]
+}
+
+func (r *repository) UpdatePayrollPeriod(ctx context.Context, period *PayrollPeriod, from PeriodStatus) (bool, error) {
 	updates := map[string]interface{}{
-		"status":     status,
-		"updated_by": updatedByID,
+		"status":      period.Status,
+		"approved_by": period.ApprovedBy,
+		"approved_at": period.ApprovedAt,
+		"paid_at":     period.PaidAt,
+		"closed_at":   period.ClosedAt,
+		"updated_by":  period.UpdatedBy,
+	}
+	res := r.db.WithContext(ctx).Model(&PayrollPeriod{}).Where("id = ? AND status = ?", period.ID, from).Updates(updates)
+	if res.Error != nil {
+		return false, res.Error
 	}
-	return r.db.WithContext(ctx).Model(&PayrollPeriod{}).Where("id = ?", id).Updates(updates).Error
+	return res.RowsAffected == 1, nil
+}
+
+func (r *repository) IsDateLocked(ctx context.Context, date time.Time) (bool, error) {
+	var count int64
+	day := date.Format("2006-01-02")
+	err := r.db.WithContext(ctx).Model(&PayrollPeriod{}).
+		Where("status = ? AND start_date::date <= ? AND end_date::date >= ?", PeriodClosed, day, day).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *repository) IsDateCalculated(ctx context.Context, date time.Time) (bool, error) {
+	var count int64
+	day := date.Format("2006-01-02")
+	err := r.db.WithContext(ctx).Model(&PayrollPeriod{}).
+		Where("status IN ? AND start_date::date <= ? AND end_date::date >= ?",
+			[]PeriodStatus{PeriodCalculated, PeriodApproved, PeriodPaid, PeriodClosed}, day, day).
+		Count(&count).Error
+	return count > 0, err
 }
 
 func (r *repository) GetAttendances(ctx context.Context, userID string, start, end time.Time) ([]attendance.Attendance, error) {
@@ -60,30 +216,281 @@ func (r *repository) GetAttendances(ctx context.Context, userID string, start, e
 	return attendances, err
 }
 
-func (r *repository) GetOvertimes(ctx context.Context, userID string, start, end time.Time) ([]overtime.Overtime, error) {
+func (r *repository) GetOvertimes(ctx context.Context, userID string, end time.Time) ([]overtime.Overtime, error) {
 	var overtimes []overtime.Overtime
-	err := r.db.WithContext(ctx).Where("user_id = ? AND date >= ? AND date <= ?", userID, start, end).Find(&overtimes).Error
+	err := r.db.WithContext(ctx).Where("user_id = ? AND date <= ? AND status = ? AND payslip_id = ''", userID, end, approval.StatusApproved).Order("date").Find(&overtimes).Error
 	return overtimes, err
 }
 
-func (r *repository) GetReimbursements(ctx context.Context, userID string, start, end time.Time) ([]reimbursement.Reimbursement, error) {
+func (r *repository) SettleOvertimes(ctx context.Context, payslipID string, ids []string) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&overtime.Overtime{}).
+		Where("id IN ? AND status = ? AND payslip_id = ''", ids, approval.StatusApproved).
+		Update("payslip_id", payslipID)
+	return res.RowsAffected, res.Error
+}
+
+func (r *repository) UnsettleOvertimes(ctx context.Context, periodID string) error {
+	payslips := r.db.Model(&Payslip{}).Select("id").Where("payroll_period_id = ? AND voided_at IS NULL", periodID)
+	return r.db.WithContext(ctx).Model(&overtime.Overtime{}).Where("payslip_id IN (?)", payslips).Update("payslip_id", "").Error
+}
+
+func (r *repository) GetReimbursements(ctx context.Context, userID string, end time.Time) ([]reimbursement.Reimbursement, error) {
+	var reimbursements []reimbursement.Reimbursement
+	err := r.db.WithContext(ctx).Where("user_id = ? AND date <= ? AND status = ?", userID, end, reimbursement.StatusApproved).Order("date").Find(&reimbursements).Error
+	return reimbursements, err
+}
+
+func (r *repository) GetReimbursementsByIDs(ctx context.Context, ids []string) ([]reimbursement.Reimbursement, error) {
 	var reimbursements []reimbursement.Reimbursement
-	err := r.db.WithContext(ctx).Where("user_id = ? AND date >= ? AND date <= ?", userID, start, end).Find(&reimbursements).Error
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&reimbursements).Error
 	return reimbursements, err
 }
 
+func (r *repository) GetSettledReimbursements(ctx context.Context, periodID string) ([]reimbursement.Reimbursement, error) {
+	var reimbursements []reimbursement.Reimbursement
+	payslips := r.db.Model(&Payslip{}).Select("id").Where("payroll_period_id = ? AND voided_at IS NULL", periodID)
+	err := r.db.WithContext(ctx).Where("status = ? AND payslip_id IN (?)", reimbursement.StatusPaid, payslips).Find(&reimbursements).Error
+	return reimbursements, err
+}
+
+func (r *repository) UpdateReimbursementStatus(ctx context.Context, item *reimbursement.Reimbursement, from reimbursement.Status, history reimbursement.StatusHistory) (bool, error) {
+	return reimbursement.NewRepository(r.db).UpdateReimbursementStatus(ctx, item, from, history)
+}
+
 func (r *repository) CreatePayslip(ctx context.Context, payslip *Payslip) error {
 	return r.db.WithContext(ctx).Create(payslip).Error
 }
 
 func (r *repository) GetPayslip(ctx context.Context, userID, periodID string) (*Payslip, error) {
 	var payslip Payslip
-	err := r.db.WithContext(ctx).Where("user_id = ? AND payroll_period_id = ?", userID, periodID).First(&payslip).Error
+	err := r.db.WithContext(ctx).Scopes(preloadLines, activePayslips).Where("user_id = ? AND payroll_period_id = ?", userID, periodID).First(&payslip).Error
 	return &payslip, err
 }
 
 func (r *repository) GetPayslipsByPeriod(ctx context.Context, periodID string) ([]Payslip, error) {
 	var payslips []Payslip
-	err := r.db.WithContext(ctx).Where("payroll_period_id = ?", periodID).Find(&payslips).Error
+	err := r.db.WithContext(ctx).Scopes(preloadLines, activePayslips).Where("payroll_period_id = ?", periodID).Find(&payslips).Error
+	return payslips, err
+}
+
+// GetYearToDatePayslips mengambil payslip karyawan dari periode-periode yang
+// berakhir di tahun yang sama sebelum tanggal before. Dipakai untuk
+// perhitungan ulang PPh 21 setahun.
+func (r *repository) GetYearToDatePayslips(ctx context.Context, userID string, before time.Time) ([]Payslip, error) {
+	var payslips []Payslip
+	yearStart := time.Date(before.Year(), time.January, 1, 0, 0, 0, 0, before.Location())
+	err := r.db.WithContext(ctx).Scopes(preloadLines, activePayslips).
+		Joins("JOIN payroll_periods ON payroll_periods.id = payslips.payroll_period_id").
+		Where("payslips.user_id = ? AND payroll_periods.end_date >= ? AND payroll_periods.end_date < ?", userID, yearStart, before).
+		Find(&payslips).Error
 	return payslips, err
 }
+
+func (r *repository) GetYearToDateMonths(ctx context.Context, userID string, before time.Time) ([]time.Month, error) {
+	var months []int
+	yearStart := time.Date(before.Year(), time.January, 1, 0, 0, 0, 0, before.Location())
+	err := r.db.WithContext(ctx).Model(&Payslip{}).Scopes(activePayslips).
+		Select("DISTINCT EXTRACT(MONTH FROM payroll_periods.end_date)::int").
+		Joins("JOIN payroll_periods ON payroll_periods.id = payslips.payroll_period_id").
+		Where("payslips.user_id = ? AND payroll_periods.end_date >= ? AND payroll_periods.end_date < ?", userID, yearStart, before).
+		Scan(&months).Error
+	result := make([]time.Month, len(months))
+	for i, m := range months {
+		result[i] = time.Month(m)
+	}
+	return result, err
+}
+
+func (r *repository) GetSettlements(ctx context.Context, userID string, start, end time.Time) ([]Settlement, error) {
+	var settlements []Settlement
+	err := r.db.WithContext(ctx).Model(&Payslip{}).Scopes(activePayslips).
+		Select("payslips.payroll_period_id, payroll_periods.status AS period_status, payslips.id AS payslip_id, payroll_periods.start_date, payroll_periods.end_date").
+		Joins("JOIN payroll_periods ON payroll_periods.id = payslips.payroll_period_id").
+		Where("payslips.user_id = ? AND payroll_periods.start_date <= ? AND payroll_periods.end_date >= ?", userID, end, start).
+		Order("payroll_periods.start_date").
+		Scan(&settlements).Error
+	return settlements, err
+}
+
+func (r *repository) GetSettlementsByPayslips(ctx context.Context, userID string, payslipIDs []string) ([]Settlement, error) {
+	var settlements []Settlement
+	err := r.db.WithContext(ctx).Model(&Payslip{}).Scopes(activePayslips).
+		Select("payslips.payroll_period_id, payroll_periods.status AS period_status, payslips.id AS payslip_id, payroll_periods.start_date, payroll_periods.end_date").
+		Joins("JOIN payroll_periods ON payroll_periods.id = payslips.payroll_period_id").
+		Where("payslips.user_id = ? AND payslips.id IN ?", userID, payslipIDs).
+		Order("payroll_periods.start_date").
+		Scan(&settlements).Error
+	return settlements, err
+}
+
+func (r *repository) GetPayslipVersions(ctx context.Context, userID, periodID string) ([]Payslip, error) {
+	var payslips []Payslip
+	err := r.db.WithContext(ctx).Scopes(preloadLines).
+		Where("user_id = ? AND payroll_period_id = ?", userID, periodID).
+		Order("version").
+		Find(&payslips).Error
+	return payslips, err
+}
+
+func (r *repository) GetLatestPayslipVersions(ctx context.Context, periodID string) (map[string]int, error) {
+	var rows []struct {
+		UserID  string
+		Version int
+	}
+	err := r.db.WithContext(ctx).Model(&Payslip{}).
+		Select("user_id, MAX(version) AS version").
+		Where("payroll_period_id = ?", periodID).
+		Group("user_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[string]int, len(rows))
+	for _, row := range rows {
+		versions[row.UserID] = row.Version
+	}
+	return versions, nil
+}
+
+func (r *repository) VoidPayslips(ctx context.Context, periodID, reason, voidedByID string, at time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&Payslip{}).
+		Where("payroll_period_id = ? AND voided_at IS NULL", periodID).
+		Updates(map[string]interface{}{
+			"voided_at":   at,
+			"void_reason": reason,
+			"voided_by":   voidedByID,
+			"updated_by":  voidedByID,
+		})
+	return res.RowsAffected, res.Error
+}
+
+// activePayslips menyaring payslip yang sudah void.
+func activePayslips(db *gorm.DB) *gorm.DB {
+	return db.Where("payslips.voided_at IS NULL")
+}
+
+// preloadLines memuat lines payslip sesuai urutan tampilannya.
+func preloadLines(db *gorm.DB) *gorm.DB {
+	return db.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sequence")
+	})
+}
+
+func (r *repository) CreatePayrollJob(ctx context.Context, job *PayrollJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+func (r *repository) GetPayrollJob(ctx context.Context, id string) (*PayrollJob, error) {
+	var job PayrollJob
+	if err := r.db.WithContext(ctx).First(&job, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *repository) HasActivePayrollJob(ctx context.Context, periodID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&PayrollJob{}).
+		Where("payroll_period_id = ? AND status IN ?", periodID, []string{JobQueued, JobRunning}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ClaimNextPayrollJob memakai SELECT ... FOR UPDATE SKIP LOCKED sehingga
+// beberapa instance aplikasi dapat mengambil job dari antrean yang sama tanpa
+// saling menunggu atau memproses job yang sama.
+func (r *repository) ClaimNextPayrollJob(ctx context.Context, now, leaseUntil time.Time) (*PayrollJob, error) {
+	var job PayrollJob
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND locked_until < ?)", JobQueued, JobRunning, now).
+			Order("created_at").
+			Limit(1).
+			Find(&job)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+
+		job.Status = JobRunning
+		job.Attempts++
+		job.LockedUntil = &leaseUntil
+		if job.StartedAt == nil {
+			job.StartedAt = &now
+		}
+		return tx.Model(&job).Updates(map[string]interface{}{
+			"status":       job.Status,
+			"attempts":     job.Attempts,
+			"locked_until": job.LockedUntil,
+			"started_at":   job.StartedAt,
+		}).Error
+	})
+	if err != nil || job.ID == "" {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *repository) RenewPayrollJobLease(ctx context.Context, id string, leaseUntil time.Time) error {
+	return r.db.WithContext(ctx).Model(&PayrollJob{}).
+		Where("id = ? AND status = ?", id, JobRunning).
+		Update("locked_until", leaseUntil).Error
+}
+
+func (r *repository) ResetPayrollJobItems(ctx context.Context, jobID string, userIDs []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("job_id = ?", jobID).Delete(&PayrollJobItem{}).Error; err != nil {
+			return err
+		}
+		items := make([]PayrollJobItem, 0, len(userIDs))
+		for _, userID := range userIDs {
+			items = append(items, PayrollJobItem{JobID: jobID, UserID: userID, Status: JobItemPending})
+		}
+		if len(items) > 0 {
+			if err := tx.CreateInBatches(items, 500).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&PayrollJob{}).Where("id = ?", jobID).Updates(map[string]interface{}{
+			"total_employees":     len(userIDs),
+			"processed_employees": 0,
+			"failed_employees":    0,
+			"error":               "",
+		}).Error
+	})
+}
+
+func (r *repository) RecordPayrollJobItem(ctx context.Context, jobID, userID, status, errMsg string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&PayrollJobItem{}).Where("job_id = ? AND user_id = ?", jobID, userID).
+			Updates(map[string]interface{}{"status": status, "error": errMsg}).Error
+		if err != nil {
+			return err
+		}
+		counters := map[string]interface{}{
+			"processed_employees": gorm.Expr("processed_employees + 1"),
+		}
+		if status == JobItemFailed {
+			counters["failed_employees"] = gorm.Expr("failed_employees + 1")
+		}
+		return tx.Model(&PayrollJob{}).Where("id = ?", jobID).Updates(counters).Error
+	})
+}
+
+func (r *repository) FinishPayrollJob(ctx context.Context, id, status, errMsg string) error {
+	return r.db.WithContext(ctx).Model(&PayrollJob{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       status,
+		"error":        errMsg,
+		"locked_until": nil,
+		"finished_at":  time.Now(),
+	}).Error
+}
+
+func (r *repository) GetPayrollJobItems(ctx context.Context, jobID, status string) ([]PayrollJobItem, error) {
+	var items []PayrollJobItem
+	query := r.db.WithContext(ctx).Where("job_id = ?", jobID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("user_id").Find(&items).Error
+	return items, err
+}
@@ -4,18 +4,74 @@ package payroll
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
-
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/attendance"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/bpjs"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/holiday"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/leave"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/overtime"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/paycomponent"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/reimbursement"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/shift"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/tax"
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
 )
 
 // Service mendefinisikan kontrak untuk logika bisnis payroll.
 type Service interface {
-	CreatePayrollPeriod(ctx context.Context, startDate, endDate time.Time, adminID string) (*PayrollPeriod, error)
-	RunPayroll(ctx context.Context, periodID string, adminID string) error
+	// CreatePayrollPeriod membuat periode draft. Periode yang beririsan dengan
+	// periode lain ditolak; celah tanggal dengan periode sebelum/sesudahnya
+	// dikembalikan sebagai peringatan.
+	CreatePayrollPeriod(ctx context.Context, periodType PeriodType, startDate, endDate time.Time, adminID string) (*PayrollPeriod, []PeriodWarning, error)
+	// GeneratePayrollPeriods membuat seluruh periode satu tahun dari kalender
+	// penggajian dalam satu transaksi; jika ada yang beririsan, tidak ada
+	// periode yang dibuat.
+	GeneratePayrollPeriods(ctx context.Context, calendar PayCalendar, adminID string) ([]*PayrollPeriod, []PeriodWarning, error)
+	GetPayrollPeriod(ctx context.Context, periodID string) (*PayrollPeriod, error)
+	// OpenPayrollPeriod, ApprovePayroll, MarkPayrollPaid, dan
+	// ClosePayrollPeriod memindahkan periode sepanjang siklus
+	// draft -> open -> calculated -> approved -> paid -> closed.
+	// Transisi open -> calculated dilakukan oleh eksekusi payroll.
+	OpenPayrollPeriod(ctx context.Context, periodID, adminID string) (*PayrollPeriod, error)
+	ApprovePayroll(ctx context.Context, periodID, approverID string) (*PayrollPeriod, error)
+	MarkPayrollPaid(ctx context.Context, periodID, adminID string) (*PayrollPeriod, error)
+	ClosePayrollPeriod(ctx context.Context, periodID, adminID string) (*PayrollPeriod, error)
+	// RunPayroll memasukkan eksekusi payroll periode ke antrean. Perhitungan
+	// dijalankan oleh Worker melalui ProcessPayrollJob.
+	RunPayroll(ctx context.Context, periodID string, adminID string) (*PayrollJob, error)
+	ProcessPayrollJob(ctx context.Context, job *PayrollJob) error
+	GetPayrollJob(ctx context.Context, jobID string) (*PayrollJob, error)
+	GetPayrollJobItems(ctx context.Context, jobID, status string) ([]PayrollJobItem, error)
+	// PreviewPayroll menjalankan seluruh kalkulasi payroll periode tanpa
+	// menyimpan apa pun. userIDs kosong berarti semua karyawan.
+	PreviewPayroll(ctx context.Context, periodID string, userIDs []string) (*Preview, error)
+	// ReversePayroll membatalkan payroll periode yang sudah calculated atau
+	// approved: payslip ditandai void (tetap disimpan sebagai riwayat) dan
+	// periode kembali open agar dapat dijalankan ulang.
+	ReversePayroll(ctx context.Context, periodID, reason, adminID string) error
+	GetPayslipVersions(ctx context.Context, userID, periodID string) ([]Payslip, error)
+	// DiffPayslips membandingkan dua versi payslip. Versi 0 berarti versi
+	// sebelum terakhir (from) dan versi terakhir (to).
+	DiffPayslips(ctx context.Context, userID, periodID string, fromVersion, toVersion int) (*PayslipDiff, error)
 	GetPayslip(ctx context.Context, userID, periodID string) (*Payslip, error)
+	// GetSettlements mengembalikan payslip karyawan yang sudah dapat dilihatnya
+	// (periode approved, paid, atau closed) pada periode yang beririsan dengan
+	// rentang tanggal start sampai end.
+	GetSettlements(ctx context.Context, userID string, start, end time.Time) ([]Settlement, error)
+	// GetSettlementsByPayslips sama seperti GetSettlements untuk payslip yang
+	// dirujuk langsung, mis. oleh Overtime.PayslipID dan
+	// Reimbursement.PayslipID yang bisa dibayar di periode setelah tanggalnya.
+	GetSettlementsByPayslips(ctx context.Context, userID string, payslipIDs []string) ([]Settlement, error)
 	GetPayrollSummary(ctx context.Context, periodID string) (*Summary, error)
 }
 
@@ -24,148 +80,1248 @@ type Service interface {
 type service struct {
 	repo         Repository
 	employeeRepo employee.Repository
+	rounding     money.Rounding
+	taxService   tax.Service
+	bpjsService  bpjs.Service
+	components   paycomponent.Repository
+	holidays     HolidayCalendar
+	roster       Roster
+	leaves       Leaves
+	concurrency  int
+
+	overtimeMethod OvertimeMethod
+	proration      ProrationPolicy
+	groupProration map[string]ProrationPolicy
+}
+
+// HolidayCalendar menyediakan hari libur yang mengurangi jumlah hari kerja
+// periode.
+type HolidayCalendar interface {
+	HolidaysBetween(ctx context.Context, start, end time.Time, location string) ([]holiday.Holiday, error)
+}
+
+// Roster menyediakan jadwal shift karyawan. Pada tanggal yang diroster,
+// roster menggantikan pola Senin-Jumat dan kalender hari libur.
+type Roster interface {
+	GetRoster(ctx context.Context, userID string, start, end time.Time) ([]shift.RosterEntry, error)
+}
+
+// Leaves menyediakan cuti approved karyawan.
+type Leaves interface {
+	GetApprovedRequests(ctx context.Context, userID string, start, end time.Time) ([]leave.Request, error)
+}
+
+// Option mengubah konfigurasi opsional dari service payroll.
+type Option func(*service)
+
+// WithRounding menentukan aturan pembulatan yang dipakai pada setiap titik
+// pembulatan kalkulasi payslip. Default: half-up ke Rupiah utuh.
+func WithRounding(r money.Rounding) Option {
+	return func(s *service) {
+		s.rounding = r
+	}
+}
+
+// WithTaxService mengganti mesin perhitungan PPh 21.
+func WithTaxService(t tax.Service) Option {
+	return func(s *service) {
+		s.taxService = t
+	}
+}
+
+// WithBPJSService mengganti perhitungan iuran BPJS (mis. tarif JKK sesuai
+// kelompok risiko perusahaan).
+func WithBPJSService(b bpjs.Service) Option {
+	return func(s *service) {
+		s.bpjsService = b
+	}
+}
+
+// WithPayComponents mengaktifkan evaluasi komponen gaji (tunjangan dan
+// potongan tetap) yang ditetapkan per karyawan.
+func WithPayComponents(repo paycomponent.Repository) Option {
+	return func(s *service) {
+		s.components = repo
+	}
+}
+
+// WithRoster menghitung hari kerja dan jenis hari lembur karyawan dari
+// roster shift-nya.
+func WithRoster(r Roster) Option {
+	return func(s *service) {
+		s.roster = r
+	}
+}
+
+// WithLeaves membayar hari kerja yang tertutup cuti berbayar seperti hari
+// hadir dan memotong hari cuti tidak berbayar.
+func WithLeaves(l Leaves) Option {
+	return func(s *service) {
+		s.leaves = l
+	}
+}
+
+// WithHolidayCalendar mengurangi hari kerja periode dengan hari libur yang
+// berlaku di lokasi setiap karyawan. Tanpa opsi ini hanya Sabtu dan Minggu
+// yang tidak dihitung sebagai hari kerja.
+func WithHolidayCalendar(c HolidayCalendar) Option {
+	return func(s *service) {
+		s.holidays = c
+	}
+}
+
+// WithProration menentukan kebijakan proration perusahaan dan kebijakan
+// khusus per kelompok penggajian (employee.PayGroup). Karyawan tanpa kelompok
+// atau dengan kelompok yang tidak terdaftar memakai kebijakan perusahaan.
+// Default: DefaultProrationPolicy.
+func WithProration(company ProrationPolicy, groups map[string]ProrationPolicy) Option {
+	return func(s *service) {
+		s.proration = company
+		s.groupProration = groups
+	}
+}
+
+// WithOvertimeMethod menentukan cara upah lembur dihitung. Default:
+// DefaultOvertimeMethod (flat).
+func WithOvertimeMethod(m OvertimeMethod) Option {
+	return func(s *service) {
+		s.overtimeMethod = m
+	}
+}
+
+// WithConcurrency menentukan jumlah karyawan yang dihitung bersamaan dalam
+// satu job payroll. Default: 4.
+func WithConcurrency(n int) Option {
+	return func(s *service) {
+		if n > 0 {
+			s.concurrency = n
+		}
+	}
 }
 
 // NewService membuat instance baru dari service payroll.
-func NewService(repo Repository, employee employee.Repository) Service {
-	return &service{repo, employee}
+func NewService(repo Repository, employee employee.Repository, opts ...Option) Service {
+	s := &service{
+		repo:           repo,
+		employeeRepo:   employee,
+		rounding:       money.DefaultRounding,
+		taxService:     tax.NewService(),
+		bpjsService:    bpjs.NewService(bpjs.DefaultConfig()),
+		concurrency:    4,
+		proration:      DefaultProrationPolicy,
+		overtimeMethod: DefaultOvertimeMethod,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func (s *service) CreatePayrollPeriod(ctx context.Context, startDate, endDate time.Time, adminID string) (*PayrollPeriod, error) {
-	if startDate.After(endDate) {
-		return nil, errors.New("start date cannot be after end date")
+func (s *service) CreatePayrollPeriod(ctx context.Context, periodType PeriodType, startDate, endDate time.Time, adminID string) (*PayrollPeriod, []PeriodWarning, error) {
+	if err := validatePeriod(periodType, startDate, endDate); err != nil {
+		return nil, nil, err
 	}
 
-	period := NewPayrollPeriod(startDate, endDate)
+	period := NewPayrollPeriod(periodType, startDate, endDate)
 	period.CreatedBy = adminID
 	period.UpdatedBy = adminID
 
-	if err := s.repo.CreatePayrollPeriod(ctx, period); err != nil {
+	var warnings []PeriodWarning
+	err := s.repo.WithTransaction(ctx, func(repo Repository) error {
+		if err := repo.LockPayrollPeriods(ctx); err != nil {
+			return err
+		}
+		var err error
+		warnings, err = createPeriod(ctx, repo, period)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return period, warnings, nil
+}
+
+func (s *service) GeneratePayrollPeriods(ctx context.Context, calendar PayCalendar, adminID string) ([]*PayrollPeriod, []PeriodWarning, error) {
+	ranges, err := calendar.Periods()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var periods []*PayrollPeriod
+	var warnings []PeriodWarning
+	err = s.repo.WithTransaction(ctx, func(repo Repository) error {
+		if err := repo.LockPayrollPeriods(ctx); err != nil {
+			return err
+		}
+		for i, r := range ranges {
+			period := NewPayrollPeriod(calendar.Type, r[0], r[1])
+			period.CreatedBy = adminID
+			period.UpdatedBy = adminID
+			periodWarnings, err := createPeriod(ctx, repo, period)
+			if err != nil {
+				return err
+			}
+			periods = append(periods, period)
+			// Periode hasil kalender saling bersambung; celah hanya mungkin
+			// terjadi di awal dan akhir tahun.
+			for _, w := range periodWarnings {
+				if (i == 0 && w.Code == WarningGapBefore) || (i == len(ranges)-1 && w.Code == WarningGapAfter) {
+					warnings = append(warnings, w)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return periods, warnings, nil
+}
+
+// createPeriod menyimpan period jika tidak beririsan dengan periode lain,
+// lalu memeriksa celah dengan periode di sekitarnya.
+func createPeriod(ctx context.Context, repo Repository, period *PayrollPeriod) ([]PeriodWarning, error) {
+	overlapping, err := repo.GetOverlappingPeriods(ctx, period.StartDate, period.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	if len(overlapping) > 0 {
+		o := overlapping[0]
+		return nil, fmt.Errorf("%w: %s to %s overlaps period %s (%s to %s)", ErrPeriodOverlap,
+			period.StartDate.Format("2006-01-02"), period.EndDate.Format("2006-01-02"),
+			o.ID, o.StartDate.Format("2006-01-02"), o.EndDate.Format("2006-01-02"))
+	}
+
+	prev, next, err := repo.GetAdjacentPeriods(ctx, period.StartDate, period.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.CreatePayrollPeriod(ctx, period); err != nil {
+		return nil, err
+	}
+	return gapWarnings(period, prev, next), nil
+}
+
+func (s *service) GetPayrollPeriod(ctx context.Context, periodID string) (*PayrollPeriod, error) {
+	return s.repo.GetPayrollPeriod(ctx, periodID)
+}
+
+func (s *service) OpenPayrollPeriod(ctx context.Context, periodID, adminID string) (*PayrollPeriod, error) {
+	return s.advancePeriod(ctx, periodID, PeriodOpen, adminID)
+}
+
+func (s *service) ApprovePayroll(ctx context.Context, periodID, approverID string) (*PayrollPeriod, error) {
+	return s.advancePeriod(ctx, periodID, PeriodApproved, approverID)
+}
+
+func (s *service) MarkPayrollPaid(ctx context.Context, periodID, adminID string) (*PayrollPeriod, error) {
+	return s.advancePeriod(ctx, periodID, PeriodPaid, adminID)
+}
+
+func (s *service) ClosePayrollPeriod(ctx context.Context, periodID, adminID string) (*PayrollPeriod, error) {
+	return s.advancePeriod(ctx, periodID, PeriodClosed, adminID)
+}
+
+func (s *service) advancePeriod(ctx context.Context, periodID string, next PeriodStatus, actorID string) (*PayrollPeriod, error) {
+	period, err := s.repo.GetPayrollPeriod(ctx, periodID)
+	if err != nil {
+		return nil, err
+	}
+	if err := transitionPeriod(ctx, s.repo, period, next, actorID); err != nil {
 		return nil, err
 	}
 	return period, nil
 }
 
+// ErrPeriodModified dikembalikan jika status periode diubah proses lain
+// di antara pembacaan dan penyimpanan.
+var ErrPeriodModified = errors.New("payroll period was modified by another process")
+
+// transitionPeriod memindahkan status periode lalu menyimpannya dengan syarat
+// status di database belum berubah sejak dibaca.
+func transitionPeriod(ctx context.Context, repo Repository, period *PayrollPeriod, next PeriodStatus, actorID string) error {
+	from := period.Status
+	if err := period.TransitionTo(next, actorID, time.Now()); err != nil {
+		return err
+	}
+	updated, err := repo.UpdatePayrollPeriod(ctx, period, from)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrPeriodModified
+	}
+	return nil
+}
+
+// GetPayslip mengembalikan payslip aktif karyawan. Payslip baru terlihat
+// setelah payroll periode disetujui approver.
 func (s *service) GetPayslip(ctx context.Context, userID, periodID string) (*Payslip, error) {
+	period, err := s.repo.GetPayrollPeriod(ctx, periodID)
+	if err != nil {
+		return nil, err
+	}
+	if !period.Status.PayslipsVisible() {
+		return nil, ErrPayslipNotFound
+	}
 	return s.repo.GetPayslip(ctx, userID, periodID)
 }
 
+func (s *service) GetSettlements(ctx context.Context, userID string, start, end time.Time) ([]Settlement, error) {
+	settlements, err := s.repo.GetSettlements(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return visibleSettlements(settlements), nil
+}
+
+func (s *service) GetSettlementsByPayslips(ctx context.Context, userID string, payslipIDs []string) ([]Settlement, error) {
+	if len(payslipIDs) == 0 {
+		return nil, nil
+	}
+	settlements, err := s.repo.GetSettlementsByPayslips(ctx, userID, payslipIDs)
+	if err != nil {
+		return nil, err
+	}
+	return visibleSettlements(settlements), nil
+}
+
+// visibleSettlements menyaring settlement yang payslip-nya sudah boleh
+// dilihat karyawan.
+func visibleSettlements(settlements []Settlement) []Settlement {
+	visible := settlements[:0]
+	for _, settlement := range settlements {
+		if settlement.PeriodStatus.PayslipsVisible() {
+			visible = append(visible, settlement)
+		}
+	}
+	return visible
+}
+
 func (s *service) GetPayrollSummary(ctx context.Context, periodID string) (*Summary, error) {
 	payslips, err := s.repo.GetPayslipsByPeriod(ctx, periodID)
 	if err != nil {
 		return nil, err
 	}
+	summary := summarize(periodID, payslips)
+	return &summary, nil
+}
 
+// summarize menjumlahkan take home pay dan biaya pemberi kerja dari payslip.
+func summarize(periodID string, payslips []Payslip) Summary {
 	summary := Summary{
-		PayrollPeriodID: periodID,
-		EmployeePays:    []EmployeePay{},
-		TotalPayout:     0.0,
+		PayrollPeriodID:    periodID,
+		EmployeePays:       []EmployeePay{},
+		TotalPayout:        money.Zero(money.DefaultCurrency),
+		TotalEmployerCost:  money.Zero(money.DefaultCurrency),
+		EmployerCostByCode: map[string]money.Money{},
 	}
 
 	for _, p := range payslips {
 		summary.EmployeePays = append(summary.EmployeePays, EmployeePay{
-			UserID:      p.UserID,
-			TakeHomePay: p.TotalPay,
+			UserID:       p.UserID,
+			TakeHomePay:  p.TotalPay,
+			EmployerCost: p.EmployerCost,
 		})
-		summary.TotalPayout += p.TotalPay
+		summary.TotalPayout = summary.TotalPayout.Add(p.TotalPay)
+		summary.TotalEmployerCost = summary.TotalEmployerCost.Add(p.EmployerCost)
+		for _, l := range p.Lines {
+			if l.Type == LineEmployerCost {
+				summary.EmployerCostByCode[l.Code] = summary.EmployerCostByCode[l.Code].Add(l.Amount)
+			}
+		}
 	}
+	return summary
+}
 
-	return &summary, nil
+// ErrPayrollAlreadyRun dikembalikan jika payroll periode sudah dihitung atau
+// sedang dijalankan oleh eksekusi lain.
+var ErrPayrollAlreadyRun = errors.New("payroll for this period has already been run")
+
+// ErrPeriodNotOpen dikembalikan jika payroll dijalankan untuk periode yang
+// masih draft.
+var ErrPeriodNotOpen = errors.New("payroll period is not open")
+
+// checkRunnable memastikan payroll periode dapat dijalankan.
+func checkRunnable(period *PayrollPeriod) error {
+	switch {
+	case period.Status == PeriodDraft:
+		return ErrPeriodNotOpen
+	case !period.Status.CanTransitionTo(PeriodCalculated):
+		return ErrPayrollAlreadyRun
+	}
+	return nil
 }
 
-// RunPayroll adalah fungsi inti yang mengorkestrasi seluruh proses kalkulasi gaji.
-func (s *service) RunPayroll(ctx context.Context, periodID string, adminID string) error {
-	// 1. Ambil data periode & validasi statusnya
+// ErrPayslipNotFound dikembalikan jika versi payslip yang diminta tidak ada.
+var ErrPayslipNotFound = errors.New("payslip not found")
+
+// RunPayroll memvalidasi periode lalu membuat PayrollJob berstatus queued.
+func (s *service) RunPayroll(ctx context.Context, periodID string, adminID string) (*PayrollJob, error) {
 	period, err := s.repo.GetPayrollPeriod(ctx, periodID)
 	if err != nil {
+		return nil, err
+	}
+	if err := checkRunnable(period); err != nil {
+		return nil, err
+	}
+	active, err := s.repo.HasActivePayrollJob(ctx, period.ID)
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		return nil, ErrPayrollAlreadyRun
+	}
+
+	job := &PayrollJob{
+		PayrollPeriodID: period.ID,
+		Status:          JobQueued,
+		CreatedBy:       adminID,
+		UpdatedBy:       adminID,
+	}
+	if err := s.repo.CreatePayrollJob(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *service) GetPayrollJob(ctx context.Context, jobID string) (*PayrollJob, error) {
+	return s.repo.GetPayrollJob(ctx, jobID)
+}
+
+func (s *service) GetPayrollJobItems(ctx context.Context, jobID, status string) ([]PayrollJobItem, error) {
+	return s.repo.GetPayrollJobItems(ctx, jobID, status)
+}
+
+// ProcessPayrollJob adalah fungsi inti yang mengorkestrasi seluruh proses
+// kalkulasi gaji satu job.
+//
+// Payslip dihitung paralel dan ditampung di memori; progres per karyawan
+// dicatat ke PayrollJobItem. Payslip baru disimpan jika semua karyawan
+// berhasil dihitung, dalam satu transaksi bersama perubahan status periode
+// menjadi calculated. Jika ada yang gagal, job ditandai failed, tidak ada
+// payslip yang tersimpan, dan periode tetap open sehingga dapat dijalankan ulang.
+func (s *service) ProcessPayrollJob(ctx context.Context, job *PayrollJob) error {
+	err := s.processPayrollJob(ctx, job)
+	if err == nil {
+		return nil
+	}
+	// Worker dihentikan: biarkan job running agar diambil ulang setelah
+	// lease-nya habis.
+	if ctx.Err() != nil {
 		return err
 	}
-	if period.Status == "completed" {
-		return errors.New("payroll for this period has already been run")
+	if ferr := s.repo.FinishPayrollJob(ctx, job.ID, JobFailed, err.Error()); ferr != nil {
+		log.Printf("Failed to mark payroll job %s as failed: %v", job.ID, ferr)
 	}
+	return err
+}
 
-	// 2. Tandai periode sebagai "processing" untuk mencegah eksekusi ganda
-	if err := s.repo.UpdatePayrollPeriodStatus(ctx, period.ID, "processing", adminID); err != nil {
+func (s *service) processPayrollJob(ctx context.Context, job *PayrollJob) error {
+	// 1. Ambil data periode & validasi statusnya
+	period, err := s.repo.GetPayrollPeriod(ctx, job.PayrollPeriodID)
+	if err != nil {
+		return err
+	}
+	if err := checkRunnable(period); err != nil {
 		return err
 	}
 
-	// 3. Ambil semua data karyawan
+	// 2. Ambil semua data karyawan dan siapkan item progres
 	employees, err := s.employeeRepo.GetAllEmployees(ctx)
 	if err != nil {
-		s.repo.UpdatePayrollPeriodStatus(context.Background(), period.ID, "pending", adminID) // Rollback
 		return err
 	}
+	userIDs := make([]string, 0, len(employees))
+	for _, emp := range employees {
+		userIDs = append(userIDs, emp.ID)
+	}
+	if err := s.repo.ResetPayrollJobItems(ctx, job.ID, userIDs); err != nil {
+		return err
+	}
+
+	// 3. Hitung gaji setiap karyawan
+	var payslips []*Payslip
+	calendars, err := s.calendarsByLocation(ctx, period, employees)
+	if err != nil {
+		return err
+	}
+	if !hasWorkingDays(calendars) {
+		log.Println("No working days in the period. Payroll marked as calculated.")
+	} else {
+		var failed int
+		payslips, failed = s.calculatePayslips(ctx, employees, period, calendars, func(emp employee.Employee, _ *Payslip, err error) {
+			status, errMsg := JobItemSucceeded, ""
+			if err != nil {
+				status, errMsg = JobItemFailed, err.Error()
+			}
+			if err := s.repo.RecordPayrollJobItem(ctx, job.ID, emp.ID, status, errMsg); err != nil {
+				log.Printf("Failed to record payroll progress for user %s: %v", emp.ID, err)
+			}
+		})
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d employees failed", failed, len(employees))
+		}
+	}
+
+	// 4. Simpan semua payslip dan tandai periode "calculated" sekaligus
+	return s.repo.WithTransaction(ctx, func(repo Repository) error {
+		// Baris periode terkunci sampai transaksi selesai, sehingga eksekusi
+		// paralel akan menunggu lalu gagal karena status sudah berubah.
+		if err := transitionPeriod(ctx, repo, period, PeriodCalculated, job.CreatedBy); err != nil {
+			return err
+		}
+		// Periode yang pernah di-reverse sudah memiliki payslip void; payslip
+		// baru melanjutkan nomor versinya.
+		versions, err := repo.GetLatestPayslipVersions(ctx, period.ID)
+		if err != nil {
+			return err
+		}
+		for _, payslip := range payslips {
+			payslip.Version = versions[payslip.UserID] + 1
+			payslip.CreatedBy = job.CreatedBy
+			payslip.UpdatedBy = job.CreatedBy
+			if err := repo.CreatePayslip(ctx, payslip); err != nil {
+				return fmt.Errorf("create payslip for user %s: %w", payslip.UserID, err)
+			}
+			if err := settleOvertimes(ctx, repo, payslip); err != nil {
+				return fmt.Errorf("settle overtimes for user %s: %w", payslip.UserID, err)
+			}
+			if err := settleReimbursements(ctx, repo, payslip, job.CreatedBy, time.Now()); err != nil {
+				return fmt.Errorf("settle reimbursements for user %s: %w", payslip.UserID, err)
+			}
+		}
+		return repo.FinishPayrollJob(ctx, job.ID, JobSucceeded, "")
+	})
+}
+
+// calculatePayslips menghitung payslip seluruh karyawan dengan sejumlah
+// goroutine sesuai konfigurasi concurrency. onResult dipanggil (secara
+// konkuren) setiap kali satu karyawan selesai dihitung. Hasil disusun sesuai
+// urutan employees; entri karyawan yang gagal bernilai nil.
+func (s *service) calculatePayslips(ctx context.Context, employees []employee.Employee, period *PayrollPeriod, calendars map[string]locationCalendar,
+	onResult func(emp employee.Employee, payslip *Payslip, err error)) ([]*Payslip, int) {
+	payslips := make([]*Payslip, len(employees))
+	var failed int64
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < s.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				payslip, err := s.calculatePayslip(ctx, employees[i], period, calendars[employees[i].Location])
+				if err != nil {
+					atomic.AddInt64(&failed, 1)
+				} else {
+					payslips[i] = payslip
+				}
+				onResult(employees[i], payslip, err)
+			}
+		}()
+	}
+
+feed:
+	for i := range employees {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
 
-	workingDays := calculateWorkingDays(period.StartDate, period.EndDate)
-	if workingDays == 0 {
-		s.repo.UpdatePayrollPeriodStatus(ctx, period.ID, "completed", adminID)
-		log.Println("No working days in the period. Payroll marked as completed.")
+	return payslips, int(failed)
+}
+
+// ReversePayroll menandai payslip periode sebagai void dan mengembalikan
+// status periode ke open dalam satu transaksi. Payroll yang sudah dibayar
+// tidak dapat di-reverse.
+func (s *service) ReversePayroll(ctx context.Context, periodID, reason, adminID string) error {
+	if strings.TrimSpace(reason) == "" {
+		return errors.New("reversal reason is required")
+	}
+	period, err := s.repo.GetPayrollPeriod(ctx, periodID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.WithTransaction(ctx, func(repo Repository) error {
+		if err := transitionPeriod(ctx, repo, period, PeriodOpen, adminID); err != nil {
+			return err
+		}
+		now := time.Now()
+		// Lembur dan reimbursement yang dibayar payslip yang akan di-void
+		// dilepas agar ikut dibayar pada perhitungan ulang.
+		if err := repo.UnsettleOvertimes(ctx, period.ID); err != nil {
+			return err
+		}
+		if err := unsettleReimbursements(ctx, repo, period.ID, reason, adminID, now); err != nil {
+			return err
+		}
+		_, err = repo.VoidPayslips(ctx, period.ID, reason, adminID, now)
+		return err
+	})
+}
+
+// settleOvertimes menandai lembur yang dibayar payslip. Lembur yang sudah
+// dibayar payslip lain atau tidak lagi approved menggagalkan penyimpanan
+// agar tidak dibayar dua kali.
+func settleOvertimes(ctx context.Context, repo Repository, payslip *Payslip) error {
+	if len(payslip.OvertimeIDs) == 0 {
 		return nil
 	}
+	settled, err := repo.SettleOvertimes(ctx, payslip.ID, payslip.OvertimeIDs)
+	if err != nil {
+		return err
+	}
+	if settled != int64(len(payslip.OvertimeIDs)) {
+		return approval.ErrModified
+	}
+	return nil
+}
 
-	// 4. Lakukan iterasi untuk setiap karyawan untuk menghitung gaji
+// settleReimbursements menandai reimbursement yang dibayar payslip sebagai
+// paid, lengkap dengan riwayat statusnya.
+func settleReimbursements(ctx context.Context, repo Repository, payslip *Payslip, actorID string, at time.Time) error {
+	var ids []string
+	for _, l := range payslip.Lines {
+		if l.Code == CodeReimbursement && l.ReferenceID != "" {
+			ids = append(ids, l.ReferenceID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	items, err := repo.GetReimbursementsByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	if len(items) != len(ids) {
+		return approval.ErrModified
+	}
+	for i := range items {
+		from := items[i].Status
+		history, err := items[i].Settle(payslip.ID, actorID, at)
+		if err != nil {
+			return err
+		}
+		if err := saveReimbursementStatus(ctx, repo, &items[i], from, history); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unsettleReimbursements mengembalikan reimbursement yang dibayar payslip
+// aktif periode ke status approved.
+func unsettleReimbursements(ctx context.Context, repo Repository, periodID, reason, actorID string, at time.Time) error {
+	items, err := repo.GetSettledReimbursements(ctx, periodID)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		from := items[i].Status
+		history, err := items[i].TransitionTo(reimbursement.StatusApproved, actorID, "payroll reversed: "+reason, at)
+		if err != nil {
+			return err
+		}
+		if err := saveReimbursementStatus(ctx, repo, &items[i], from, history); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func saveReimbursementStatus(ctx context.Context, repo Repository, r *reimbursement.Reimbursement, from reimbursement.Status, history reimbursement.StatusHistory) error {
+	updated, err := repo.UpdateReimbursementStatus(ctx, r, from, history)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return approval.ErrModified
+	}
+	return nil
+}
+
+func (s *service) GetPayslipVersions(ctx context.Context, userID, periodID string) ([]Payslip, error) {
+	return s.repo.GetPayslipVersions(ctx, userID, periodID)
+}
+
+func (s *service) DiffPayslips(ctx context.Context, userID, periodID string, fromVersion, toVersion int) (*PayslipDiff, error) {
+	versions, err := s.repo.GetPayslipVersions(ctx, userID, periodID)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrPayslipNotFound
+	}
+	if toVersion == 0 {
+		toVersion = versions[len(versions)-1].Version
+	}
+	if fromVersion == 0 {
+		fromVersion = toVersion - 1
+	}
+
+	var from, to *Payslip
+	for i := range versions {
+		switch versions[i].Version {
+		case fromVersion:
+			from = &versions[i]
+		case toVersion:
+			to = &versions[i]
+		}
+	}
+	if from == nil || to == nil {
+		return nil, ErrPayslipNotFound
+	}
+	return diffPayslips(from, to), nil
+}
+
+// diffPayslips membandingkan jumlah line per tipe dan kode, sesuai urutan
+// kemunculan pertama di versi from lalu versi to.
+func diffPayslips(from, to *Payslip) *PayslipDiff {
+	type key struct {
+		lineType LineType
+		code     string
+	}
+	cur := to.BaseSalary.Currency()
+	var keys []key
+	amounts := map[key][2]money.Money{}
+	add := func(lines []PayslipLine, side int) {
+		for _, l := range lines {
+			k := key{l.Type, l.Code}
+			v, ok := amounts[k]
+			if !ok {
+				keys = append(keys, k)
+				v = [2]money.Money{money.Zero(cur), money.Zero(cur)}
+			}
+			v[side] = v[side].Add(l.Amount)
+			amounts[k] = v
+		}
+	}
+	add(from.Lines, 0)
+	add(to.Lines, 1)
+
+	diff := &PayslipDiff{
+		UserID:          to.UserID,
+		PayrollPeriodID: to.PayrollPeriodID,
+		FromVersion:     from.Version,
+		ToVersion:       to.Version,
+		Lines:           []LineDiff{},
+		TotalEarnings:   newAmountDiff(from.TotalEarnings, to.TotalEarnings),
+		TotalDeductions: newAmountDiff(from.TotalDeductions, to.TotalDeductions),
+		EmployerCost:    newAmountDiff(from.EmployerCost, to.EmployerCost),
+		TotalPay:        newAmountDiff(from.TotalPay, to.TotalPay),
+	}
+	for _, k := range keys {
+		v := amounts[k]
+		if v[0].Equal(v[1]) {
+			continue
+		}
+		diff.Lines = append(diff.Lines, LineDiff{Type: k.lineType, Code: k.code, AmountDiff: newAmountDiff(v[0], v[1])})
+	}
+	return diff
+}
+
+// largeReimbursementBps adalah batas total reimbursement terhadap gaji pokok
+// (dalam basis poin) yang ditandai sebagai tidak wajar pada preview.
+const largeReimbursementBps = 5000
+
+// ErrUnknownEmployees dikembalikan jika preview meminta karyawan yang tidak
+// ada (atau bukan karyawan).
+var ErrUnknownEmployees = errors.New("unknown employees")
+
+// PreviewPayroll menghitung payslip seperti ProcessPayrollJob tetapi tidak
+// menyimpan payslip, progres, maupun status periode.
+func (s *service) PreviewPayroll(ctx context.Context, periodID string, userIDs []string) (*Preview, error) {
+	period, err := s.repo.GetPayrollPeriod(ctx, periodID)
+	if err != nil {
+		return nil, err
+	}
+	if period.Status != PeriodDraft && period.Status != PeriodOpen {
+		return nil, ErrPayrollAlreadyRun
+	}
+
+	var employees []employee.Employee
+	if len(userIDs) > 0 {
+		employees, err = s.employeeRepo.GetByIDs(ctx, userIDs)
+	} else {
+		employees, err = s.employeeRepo.GetAllEmployees(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if missing := missingEmployees(userIDs, employees); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEmployees, strings.Join(missing, ", "))
+	}
+
+	preview := &Preview{PayrollPeriodID: period.ID, Payslips: []Payslip{}, Warnings: []PreviewWarning{}}
+	calendars, err := s.calendarsByLocation(ctx, period, employees)
+	if err != nil {
+		return nil, err
+	}
+	if hasWorkingDays(calendars) {
+		var mu sync.Mutex
+		payslips, _ := s.calculatePayslips(ctx, employees, period, calendars, func(emp employee.Employee, payslip *Payslip, err error) {
+			warnings := previewWarnings(emp, payslip, err)
+			mu.Lock()
+			preview.Warnings = append(preview.Warnings, warnings...)
+			mu.Unlock()
+		})
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for _, p := range payslips {
+			if p != nil {
+				preview.Payslips = append(preview.Payslips, *p)
+			}
+		}
+	}
+	// Urutan warning mengikuti urutan karyawan, bukan urutan selesai goroutine.
+	order := make(map[string]int, len(employees))
+	for i, emp := range employees {
+		order[emp.ID] = i
+	}
+	sort.SliceStable(preview.Warnings, func(i, j int) bool {
+		return order[preview.Warnings[i].UserID] < order[preview.Warnings[j].UserID]
+	})
+
+	preview.Summary = summarize(period.ID, preview.Payslips)
+	return preview, nil
+}
+
+// missingEmployees mengembalikan userIDs yang tidak ada di employees, sesuai
+// urutan permintaan dan tanpa duplikat.
+func missingEmployees(userIDs []string, employees []employee.Employee) []string {
+	found := make(map[string]bool, len(employees))
 	for _, emp := range employees {
-		select {
-		case <-ctx.Done(): // Cek apakah request dibatalkan oleh klien
-			log.Println("Payroll run cancelled.")
-			s.repo.UpdatePayrollPeriodStatus(context.Background(), period.ID, "pending", adminID) // Rollback
-			return ctx.Err()
-		default:
-		}
-
-		// Kalkulasi rate harian dan per jam
-		dailyRate := emp.BaseSalary / float64(workingDays)
-		hourlyRate := dailyRate / 8.0
-
-		// Ambil semua data relevan dari repository
-		attendances, _ := s.repo.GetAttendances(ctx, emp.ID, period.StartDate, period.EndDate)
-		overtimes, _ := s.repo.GetOvertimes(ctx, emp.ID, period.StartDate, period.EndDate)
-		reimbursements, _ := s.repo.GetReimbursements(ctx, emp.ID, period.StartDate, period.EndDate)
-
-		// Lakukan kalkulasi
-		proratedSalary := dailyRate * float64(len(attendances))
-		var overtimePay float64
-		for _, ot := range overtimes {
-			overtimePay += float64(ot.Hours) * hourlyRate * 2
-		}
-		var reimbursementTotal float64
-		for _, r := range reimbursements {
-			reimbursementTotal += r.Amount
-		}
-		totalPay := proratedSalary + overtimePay + reimbursementTotal
-
-		// Buat record payslip
-		payslip := &Payslip{
-			UserID:             emp.ID,
-			PayrollPeriodID:    period.ID,
-			BaseSalary:         emp.BaseSalary,
-			ProratedSalary:     proratedSalary,
-			OvertimePay:        overtimePay,
-			ReimbursementTotal: reimbursementTotal,
-			TotalPay:           totalPay,
-			CreatedBy:          adminID,
-			UpdatedBy:          adminID,
-		}
-
-		if err := s.repo.CreatePayslip(ctx, payslip); err != nil {
-			log.Printf("Failed to create payslip for user %s: %v", emp.ID, err)
+		found[emp.ID] = true
+	}
+	var missing []string
+	for _, id := range userIDs {
+		if !found[id] {
+			found[id] = true
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// previewWarnings memeriksa hasil perhitungan seorang karyawan.
+func previewWarnings(emp employee.Employee, payslip *Payslip, err error) []PreviewWarning {
+	var warnings []PreviewWarning
+	if !emp.BaseSalary.IsPositive() {
+		warnings = append(warnings, PreviewWarning{UserID: emp.ID, Code: WarningMissingSalary, Message: "employee has no base salary"})
+	}
+	if err != nil {
+		return append(warnings, PreviewWarning{UserID: emp.ID, Code: WarningCalculationFailed, Message: err.Error()})
+	}
+
+	for _, l := range payslip.Lines {
+		if l.Code == CodeBasicSalary && l.Quantity == 0 {
+			warnings = append(warnings, PreviewWarning{UserID: emp.ID, Code: WarningZeroAttendance, Message: "employee has no attendance in this period"})
+		}
+	}
+	reimbursed := payslip.SumLines(LineEarning, CodeReimbursement)
+	limit := emp.BaseSalary.MulDiv(largeReimbursementBps, 10000, money.DefaultRounding)
+	if reimbursed.IsPositive() && reimbursed.Cmp(limit) > 0 {
+		warnings = append(warnings, PreviewWarning{
+			UserID:  emp.ID,
+			Code:    WarningLargeReimbursement,
+			Message: fmt.Sprintf("reimbursements of %s exceed %d%% of base salary", reimbursed, largeReimbursementBps/100),
+		})
+	}
+	return warnings
+}
+
+// calculatePayslip menyusun payslip seorang karyawan sebagai kumpulan line.
+//
+// Rate harian (gaji / pembagi kebijakan proration, lihat ProrationPolicy) dan
+// rate lembur per jam (lihat OvertimeMethod) tidak pernah dibulatkan
+// tersendiri; setiap line dihitung eksak dari gaji pokok lalu dibulatkan
+// tepat satu kali. Total adalah penjumlahan eksak dari line
+// yang sudah dibulatkan, sehingga selalu sama dengan rincian di slip gaji.
+//
+// Gaji pokok, iuran BPJS, dan komponen tetap adalah nilai per bulan; periode
+// yang bukan bulanan membayar porsinya (lihat shareOf).
+func (s *service) calculatePayslip(ctx context.Context, emp employee.Employee, period *PayrollPeriod, calendar locationCalendar) (*Payslip, error) {
+	calendar, err := s.rosteredCalendar(ctx, emp, period, calendar)
+	if err != nil {
+		return nil, err
+	}
+	share := shareOf(period)
+	policy := s.prorationFor(emp).forPeriod(share)
+	days := prorationDays{Working: calendar.workingDays, Calendar: daysBetween(period.StartDate, period.EndDate) + 1}
+	dailyDivisor := policy.dailyDivisor(days)
+	if dailyDivisor == 0 {
+		return nil, fmt.Errorf("no working days in period for location %q", emp.Location)
+	}
+	attendances, err := s.repo.GetAttendances(ctx, emp.ID, period.StartDate, period.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	overtimes, err := s.repo.GetOvertimes(ctx, emp.ID, period.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	overtimeCalendar, err := s.overtimeCalendar(ctx, emp, period, calendar, overtimes)
+	if err != nil {
+		return nil, err
+	}
+	reimbursements, err := s.repo.GetReimbursements(ctx, emp.ID, period.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	payslip := &Payslip{
+		UserID:          emp.ID,
+		PayrollPeriodID: period.ID,
+		BaseSalary:      emp.BaseSalary,
+	}
+
+	attendedDays := int64(len(attendances))
+	days.Attended = len(attendances)
+	if days.PaidLeave, days.UnpaidLeave, err = s.leaveDays(ctx, emp, period, calendar, attendances); err != nil {
+		return nil, err
+	}
+	for _, line := range policy.salaryLines(emp.BaseSalary, share, days, s.rounding) {
+		payslip.AddLine(line)
+	}
+
+	for _, line := range s.overtimeLines(emp.BaseSalary, share, overtimes, overtimeCalendar, dailyDivisor) {
+		payslip.AddLine(line)
+	}
+	for _, ot := range overtimes {
+		payslip.OvertimeIDs = append(payslip.OvertimeIDs, ot.ID)
+	}
+
+	if err := s.addComponentLines(ctx, payslip, emp, period, share, int(attendedDays)); err != nil {
+		return nil, err
+	}
+
+	// Reimbursement bukan objek pajak dan dibayar sesuai nominal klaim.
+	for _, r := range reimbursements {
+		payslip.AddLine(PayslipLine{
+			Type:        LineEarning,
+			Code:        CodeReimbursement,
+			Description: r.Description,
+			Quantity:    1,
+			Rate:        r.Amount,
+			Amount:      r.Amount,
+			ReferenceID: r.ID,
+		})
+	}
+
+	// Iuran BPJS dihitung dari gaji pokok bulanan (bukan yang diprorata),
+	// sebesar porsi bulan periode. Premi JKK/JKM/JKN bagian pemberi kerja
+	// merupakan penghasilan kena PPh 21.
+	for _, c := range s.bpjsService.CalculateShare(emp.BaseSalary, share.num, share.den) {
+		code := CodeBPJSPrefix + string(c.Program)
+		if c.EmployeeAmount.IsPositive() {
+			payslip.AddLine(PayslipLine{
+				Type:        LineDeduction,
+				Code:        code,
+				Description: fmt.Sprintf("Iuran BPJS %s karyawan", c.Program),
+				Quantity:    1,
+				Rate:        c.EmployeeAmount,
+				Amount:      c.EmployeeAmount,
+			})
+		}
+		if c.EmployerAmount.IsPositive() {
+			payslip.AddLine(PayslipLine{
+				Type:        LineEmployerCost,
+				Code:        code,
+				Description: fmt.Sprintf("Iuran BPJS %s pemberi kerja", c.Program),
+				Quantity:    1,
+				Rate:        c.EmployerAmount,
+				Amount:      c.EmployerAmount,
+				Taxable:     c.Program.TaxableForEmployee(),
+			})
+		}
+	}
+
+	taxWithheld, err := s.withholdTax(ctx, emp, period, payslip)
+	if err != nil {
+		return nil, err
+	}
+	payslip.AddLine(PayslipLine{
+		Type:        LineDeduction,
+		Code:        CodeIncomeTax,
+		Description: "PPh 21",
+		Quantity:    1,
+		Rate:        taxWithheld,
+		Amount:      taxWithheld,
+	})
+
+	payslip.Recalculate()
+	return payslip, nil
+}
+
+// leaveDays menghitung hari kerja periode yang tertutup cuti approved
+// berbayar dan tidak berbayar. Hari dengan absensi dihitung sebagai hari
+// hadir, bukan hari cuti.
+func (s *service) leaveDays(ctx context.Context, emp employee.Employee, period *PayrollPeriod, calendar locationCalendar, attendances []attendance.Attendance) (paid, unpaid int, err error) {
+	if s.leaves == nil {
+		return 0, 0, nil
+	}
+	requests, err := s.leaves.GetApprovedRequests(ctx, emp.ID, period.StartDate, period.EndDate)
+	if err != nil || len(requests) == 0 {
+		return 0, 0, err
+	}
+	counted := make(map[string]bool, len(attendances))
+	for _, a := range attendances {
+		counted[a.Date.Format("2006-01-02")] = true
+	}
+	for _, r := range requests {
+		start, end := r.StartDate, r.EndDate
+		if start.Before(period.StartDate) {
+			start = period.StartDate
+		}
+		if end.After(period.EndDate) {
+			end = period.EndDate
+		}
+		for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+			key := day.Format("2006-01-02")
+			if counted[key] || !calendar.isWorkingDay(day) {
+				continue
+			}
+			counted[key] = true
+			if r.Paid {
+				paid++
+			} else {
+				unpaid++
+			}
+		}
+	}
+	return paid, unpaid, nil
+}
+
+// addComponentLines menambahkan line dari komponen gaji yang berlaku bagi
+// karyawan dalam periode ini. Komponen fixed dan percentage_of_base dibayar
+// sebesar porsi bulan periode.
+func (s *service) addComponentLines(ctx context.Context, payslip *Payslip, emp employee.Employee, period *PayrollPeriod, share periodShare, attendedDays int) error {
+	if s.components == nil {
+		return nil
+	}
+	assignments, err := s.components.GetActiveAssignments(ctx, emp.ID, period.StartDate, period.EndDate)
+	if err != nil {
+		return err
+	}
+
+	in := paycomponent.Input{BaseSalary: emp.BaseSalary, AttendedDays: attendedDays, ShareNum: share.num, ShareDen: share.den}
+	for _, a := range assignments {
+		amount := a.Evaluate(in, s.rounding)
+		if amount.IsZero() {
 			continue
 		}
+		lineType := LineEarning
+		if a.Component.Kind == paycomponent.KindDeduction {
+			lineType = LineDeduction
+		}
+		payslip.AddLine(PayslipLine{
+			Type:        lineType,
+			Code:        a.Component.Code,
+			Description: a.Component.Name,
+			Quantity:    a.Quantity(in),
+			Rate:        a.Rate(in, s.rounding),
+			Amount:      amount,
+			Taxable:     lineType == LineEarning && a.Component.Taxable,
+		})
 	}
+	return nil
+}
 
-	// 5. Tandai periode sebagai "completed"
-	return s.repo.UpdatePayrollPeriodStatus(ctx, period.ID, "completed", adminID)
+// pensionCodes adalah kode line iuran JHT dan JP bagian karyawan, yang
+// menjadi pengurang penghasilan bruto pada perhitungan PPh 21 setahun.
+var pensionCodes = []string{CodeBPJSPrefix + string(bpjs.ProgramJHT), CodeBPJSPrefix + string(bpjs.ProgramJP)}
+
+// withholdTax menghitung PPh 21 untuk satu payslip. Periode selain yang
+// terakhir di tahun pajak memakai tarif TER bulanan, dengan lapisan tarif
+// dipilih dari penghasilan sebulan (lihat shareOf); periode terakhir
+// (lihat closesTaxYear) menghitung ulang pajak setahun dari payslip-payslip
+// sebelumnya di tahun yang sama.
+func (s *service) withholdTax(ctx context.Context, emp employee.Employee, period *PayrollPeriod, payslip *Payslip) (money.Money, error) {
+	status, err := tax.ParseStatus(emp.TaxStatus)
+	if err != nil {
+		return money.Money{}, err
+	}
+	gross := payslip.TaxableIncome()
+	if !closesTaxYear(period) {
+		share := shareOf(period)
+		return s.taxService.PeriodWithholding(status, gross, share.num, share.den)
+	}
+
+	previous, err := s.repo.GetYearToDatePayslips(ctx, emp.ID, period.StartDate)
+	if err != nil {
+		return money.Money{}, err
+	}
+	// Batas biaya jabatan dihitung per bulan bekerja, bukan per periode.
+	months, err := s.repo.GetYearToDateMonths(ctx, emp.ID, period.StartDate)
+	if err != nil {
+		return money.Money{}, err
+	}
+	worked := map[time.Month]bool{period.EndDate.Month(): true}
+	for _, m := range months {
+		worked[m] = true
+	}
+	annual := tax.AnnualIncome{
+		Gross:                gross,
+		PensionContributions: payslip.SumLines(LineDeduction, pensionCodes...),
+		WithheldToDate:       money.Zero(gross.Currency()),
+		Months:               len(worked),
+	}
+	for _, p := range previous {
+		annual.Gross = annual.Gross.Add(p.TaxableIncome())
+		annual.PensionContributions = annual.PensionContributions.Add(p.SumLines(LineDeduction, pensionCodes...))
+		annual.WithheldToDate = annual.WithheldToDate.Add(p.SumLines(LineDeduction, CodeIncomeTax))
+	}
+	return s.taxService.DecemberWithholding(status, annual)
+}
+
+// closesTaxYear melaporkan apakah period adalah periode terakhir jenisnya
+// di tahun pajak tanggal akhirnya, yaitu periode berikut yang sama panjang
+// berakhir di tahun berikutnya. Periode bulanan yang berakhir di bulan
+// Desember selalu yang terakhir; periode off-cycle tidak pernah menghitung
+// ulang pajak setahun.
+func closesTaxYear(period *PayrollPeriod) bool {
+	end := period.EndDate
+	if end.Month() != time.December {
+		return false
+	}
+	switch period.Type {
+	case PeriodOffCycle:
+		return false
+	case PeriodSemiMonthly, PeriodWeekly:
+		return end.AddDate(0, 0, daysBetween(period.StartDate, end)+1).Year() != end.Year()
+	}
+	return true
+}
+
+// locationCalendar adalah hari kerja dan hari libur periode di satu lokasi.
+// roster (tanggal YYYY-MM-DD -> dijadwalkan bekerja) diisi per karyawan
+// dari roster shift-nya.
+type locationCalendar struct {
+	workingDays int
+	holidays    []holiday.Holiday
+	roster      map[string]bool
+}
+
+// isWorkingDay melaporkan apakah date adalah hari kerja: menurut roster jika
+// tanggal tersebut diroster, selain itu Senin-Jumat yang bukan hari libur.
+func (c locationCalendar) isWorkingDay(date time.Time) bool {
+	if working, ok := c.roster[date.Format("2006-01-02")]; ok {
+		return working
+	}
+	return holiday.IsWorkingDay(date, c.holidays)
+}
+
+// overtimeCalendar melengkapi kalender periode dengan hari libur dan roster
+// sejak tanggal lembur paling awal. Lembur yang disetujui setelah periodenya
+// dihitung dibayar pada periode berikutnya, tetapi tarif statutory tetap
+// mengikuti jenis hari pada tanggal lembur itu. Jumlah hari kerja periode
+// tidak berubah.
+func (s *service) overtimeCalendar(ctx context.Context, emp employee.Employee, period *PayrollPeriod, calendar locationCalendar, overtimes []overtime.Overtime) (locationCalendar, error) {
+	if s.overtimeMethod != OvertimeStatutory {
+		return calendar, nil
+	}
+	start := period.StartDate
+	for _, ot := range overtimes {
+		if ot.Date.Before(start) {
+			start = ot.Date
+		}
+	}
+	if !start.Before(period.StartDate) {
+		return calendar, nil
+	}
+	end := period.StartDate.AddDate(0, 0, -1)
+
+	if s.holidays != nil {
+		holidays, err := s.holidays.HolidaysBetween(ctx, start, end, emp.Location)
+		if err != nil {
+			return calendar, err
+		}
+		// Kalender lokasi dipakai bersama oleh karyawan lain; jangan ubah
+		// slice aslinya.
+		calendar.holidays = append(append([]holiday.Holiday(nil), calendar.holidays...), holidays...)
+	}
+	if s.roster != nil {
+		entries, err := s.roster.GetRoster(ctx, emp.ID, start, end)
+		if err != nil {
+			return calendar, err
+		}
+		if len(entries) > 0 {
+			roster := maps.Clone(calendar.roster)
+			if roster == nil {
+				roster = make(map[string]bool, len(entries))
+			}
+			for _, e := range entries {
+				roster[e.Date.Format("2006-01-02")] = e.ShiftID != ""
+			}
+			calendar.roster = roster
+		}
+	}
+	return calendar, nil
+}
+
+// rosteredCalendar menerapkan roster karyawan pada kalender lokasinya dan
+// menghitung ulang jumlah hari kerja periode.
+func (s *service) rosteredCalendar(ctx context.Context, emp employee.Employee, period *PayrollPeriod, calendar locationCalendar) (locationCalendar, error) {
+	if s.roster == nil {
+		return calendar, nil
+	}
+	entries, err := s.roster.GetRoster(ctx, emp.ID, period.StartDate, period.EndDate)
+	if err != nil || len(entries) == 0 {
+		return calendar, err
+	}
+	calendar.roster = make(map[string]bool, len(entries))
+	for _, e := range entries {
+		calendar.roster[e.Date.Format("2006-01-02")] = e.ShiftID != ""
+	}
+	calendar.workingDays = 0
+	for day := period.StartDate; !day.After(period.EndDate); day = day.AddDate(0, 0, 1) {
+		if calendar.isWorkingDay(day) {
+			calendar.workingDays++
+		}
+	}
+	return calendar, nil
+}
+
+// calendarsByLocation menghitung jumlah hari kerja periode untuk setiap
+// lokasi karyawan, setelah dikurangi hari libur yang berlaku di lokasi itu.
+func (s *service) calendarsByLocation(ctx context.Context, period *PayrollPeriod, employees []employee.Employee) (map[string]locationCalendar, error) {
+	calendars := make(map[string]locationCalendar)
+	for _, emp := range employees {
+		if _, ok := calendars[emp.Location]; ok {
+			continue
+		}
+		var holidays []holiday.Holiday
+		if s.holidays != nil {
+			var err error
+			holidays, err = s.holidays.HolidaysBetween(ctx, period.StartDate, period.EndDate, emp.Location)
+			if err != nil {
+				return nil, err
+			}
+		}
+		calendars[emp.Location] = locationCalendar{
+			workingDays: holiday.WorkingDays(period.StartDate, period.EndDate, holidays),
+			holidays:    holidays,
+		}
+	}
+	return calendars, nil
 }
 
-func calculateWorkingDays(start, end time.Time) int {
-	days := 0
-	current := start
-	for !current.After(end) {
-		wd := current.Weekday()
-		if wd != time.Saturday && wd != time.Sunday {
-			days++
+// hasWorkingDays melaporkan apakah ada lokasi dengan hari kerja dalam periode.
+func hasWorkingDays(calendars map[string]locationCalendar) bool {
+	for _, c := range calendars {
+		if c.workingDays > 0 {
+			return true
 		}
-		current = current.AddDate(0, 0, 1)
 	}
-	return days
+	return false
 }
@@ -2,14 +2,24 @@ package payroll
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/attendance"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/auth"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/bpjs"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/holiday"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/leave"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/overtime"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/paycomponent"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/reimbursement"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/shift"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/tax"
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -19,6 +29,29 @@ type MockPayrollRepository struct {
 	mock.Mock
 }
 
+// WithTransaction menjalankan fn langsung dengan mock yang sama; pengujian
+// rollback dilakukan dengan memastikan write tidak dipanggil setelah error.
+func (m *MockPayrollRepository) WithTransaction(ctx context.Context, fn func(repo Repository) error) error {
+	return fn(m)
+}
+func (m *MockPayrollRepository) LockPayrollPeriods(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+func (m *MockPayrollRepository) GetOverlappingPeriods(ctx context.Context, start, end time.Time) ([]PayrollPeriod, error) {
+	args := m.Called(ctx, start, end)
+	return args.Get(0).([]PayrollPeriod), args.Error(1)
+}
+func (m *MockPayrollRepository) GetAdjacentPeriods(ctx context.Context, start, end time.Time) (*PayrollPeriod, *PayrollPeriod, error) {
+	args := m.Called(ctx, start, end)
+	prev, _ := args.Get(0).(*PayrollPeriod)
+	next, _ := args.Get(1).(*PayrollPeriod)
+	return prev, next, args.Error(2)
+}
+func (m *MockPayrollRepository) UpdatePayrollPeriod(ctx context.Context, period *PayrollPeriod, from PeriodStatus) (bool, error) {
+	args := m.Called(ctx, period, from)
+	return args.Bool(0), args.Error(1)
+}
 func (m *MockPayrollRepository) CreatePayrollPeriod(ctx context.Context, period *PayrollPeriod) error {
 	args := m.Called(ctx, period)
 	return args.Error(0)
@@ -27,22 +60,46 @@ func (m *MockPayrollRepository) GetPayrollPeriod(ctx context.Context, id string)
 	args := m.Called(ctx, id)
 	return args.Get(0).(*PayrollPeriod), args.Error(1)
 }
-func (m *MockPayrollRepository) UpdatePayrollPeriodStatus(ctx context.Context, id, status string, updatedByID string) error {
-	args := m.Called(ctx, id, status, updatedByID)
-	return args.Error(0)
+func (m *MockPayrollRepository) IsDateLocked(ctx context.Context, date time.Time) (bool, error) {
+	args := m.Called(ctx, date)
+	return args.Bool(0), args.Error(1)
+}
+func (m *MockPayrollRepository) IsDateCalculated(ctx context.Context, date time.Time) (bool, error) {
+	args := m.Called(ctx, date)
+	return args.Bool(0), args.Error(1)
 }
 func (m *MockPayrollRepository) GetAttendances(ctx context.Context, userID string, start, end time.Time) ([]attendance.Attendance, error) {
 	args := m.Called(ctx, userID, start, end)
 	return args.Get(0).([]attendance.Attendance), args.Error(1)
 }
-func (m *MockPayrollRepository) GetOvertimes(ctx context.Context, userID string, start, end time.Time) ([]overtime.Overtime, error) {
-	args := m.Called(ctx, userID, start, end)
+func (m *MockPayrollRepository) GetOvertimes(ctx context.Context, userID string, end time.Time) ([]overtime.Overtime, error) {
+	args := m.Called(ctx, userID, end)
 	return args.Get(0).([]overtime.Overtime), args.Error(1)
 }
-func (m *MockPayrollRepository) GetReimbursements(ctx context.Context, userID string, start, end time.Time) ([]reimbursement.Reimbursement, error) {
-	args := m.Called(ctx, userID, start, end)
+func (m *MockPayrollRepository) SettleOvertimes(ctx context.Context, payslipID string, ids []string) (int64, error) {
+	args := m.Called(ctx, payslipID, ids)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *MockPayrollRepository) UnsettleOvertimes(ctx context.Context, periodID string) error {
+	args := m.Called(ctx, periodID)
+	return args.Error(0)
+}
+func (m *MockPayrollRepository) GetReimbursements(ctx context.Context, userID string, end time.Time) ([]reimbursement.Reimbursement, error) {
+	args := m.Called(ctx, userID, end)
+	return args.Get(0).([]reimbursement.Reimbursement), args.Error(1)
+}
+func (m *MockPayrollRepository) GetReimbursementsByIDs(ctx context.Context, ids []string) ([]reimbursement.Reimbursement, error) {
+	args := m.Called(ctx, ids)
 	return args.Get(0).([]reimbursement.Reimbursement), args.Error(1)
 }
+func (m *MockPayrollRepository) GetSettledReimbursements(ctx context.Context, periodID string) ([]reimbursement.Reimbursement, error) {
+	args := m.Called(ctx, periodID)
+	return args.Get(0).([]reimbursement.Reimbursement), args.Error(1)
+}
+func (m *MockPayrollRepository) UpdateReimbursementStatus(ctx context.Context, r *reimbursement.Reimbursement, from reimbursement.Status, history reimbursement.StatusHistory) (bool, error) {
+	args := m.Called(ctx, r, from, history)
+	return args.Bool(0), args.Error(1)
+}
 func (m *MockPayrollRepository) CreatePayslip(ctx context.Context, payslip *Payslip) error {
 	args := m.Called(ctx, payslip)
 	return args.Error(0)
@@ -56,8 +113,166 @@ func (m *MockPayrollRepository) GetPayslipsByPeriod(ctx context.Context, periodI
 	return args.Get(0).([]Payslip), args.Error(1)
 }
 
+func (m *MockPayrollRepository) GetYearToDatePayslips(ctx context.Context, userID string, before time.Time) ([]Payslip, error) {
+	args := m.Called(ctx, userID, before)
+	return args.Get(0).([]Payslip), args.Error(1)
+}
+
+func (m *MockPayrollRepository) GetYearToDateMonths(ctx context.Context, userID string, before time.Time) ([]time.Month, error) {
+	args := m.Called(ctx, userID, before)
+	return args.Get(0).([]time.Month), args.Error(1)
+}
+func (m *MockPayrollRepository) GetSettlementsByPayslips(ctx context.Context, userID string, payslipIDs []string) ([]Settlement, error) {
+	args := m.Called(ctx, userID, payslipIDs)
+	return args.Get(0).([]Settlement), args.Error(1)
+}
+func (m *MockPayrollRepository) GetSettlements(ctx context.Context, userID string, start, end time.Time) ([]Settlement, error) {
+	args := m.Called(ctx, userID, start, end)
+	return args.Get(0).([]Settlement), args.Error(1)
+}
+
+func (m *MockPayrollRepository) GetPayslipVersions(ctx context.Context, userID, periodID string) ([]Payslip, error) {
+	args := m.Called(ctx, userID, periodID)
+	return args.Get(0).([]Payslip), args.Error(1)
+}
+func (m *MockPayrollRepository) GetLatestPayslipVersions(ctx context.Context, periodID string) (map[string]int, error) {
+	args := m.Called(ctx, periodID)
+	return args.Get(0).(map[string]int), args.Error(1)
+}
+func (m *MockPayrollRepository) VoidPayslips(ctx context.Context, periodID, reason, voidedByID string, at time.Time) (int64, error) {
+	args := m.Called(ctx, periodID, reason, voidedByID, at)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *MockPayrollRepository) CreatePayrollJob(ctx context.Context, job *PayrollJob) error {
+	return m.Called(ctx, job).Error(0)
+}
+func (m *MockPayrollRepository) GetPayrollJob(ctx context.Context, id string) (*PayrollJob, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*PayrollJob), args.Error(1)
+}
+func (m *MockPayrollRepository) HasActivePayrollJob(ctx context.Context, periodID string) (bool, error) {
+	args := m.Called(ctx, periodID)
+	return args.Bool(0), args.Error(1)
+}
+func (m *MockPayrollRepository) ClaimNextPayrollJob(ctx context.Context, now, leaseUntil time.Time) (*PayrollJob, error) {
+	args := m.Called(ctx, now, leaseUntil)
+	job, _ := args.Get(0).(*PayrollJob)
+	return job, args.Error(1)
+}
+func (m *MockPayrollRepository) RenewPayrollJobLease(ctx context.Context, id string, leaseUntil time.Time) error {
+	return m.Called(ctx, id, leaseUntil).Error(0)
+}
+func (m *MockPayrollRepository) ResetPayrollJobItems(ctx context.Context, jobID string, userIDs []string) error {
+	return m.Called(ctx, jobID, userIDs).Error(0)
+}
+func (m *MockPayrollRepository) RecordPayrollJobItem(ctx context.Context, jobID, userID, status, errMsg string) error {
+	return m.Called(ctx, jobID, userID, status, errMsg).Error(0)
+}
+func (m *MockPayrollRepository) FinishPayrollJob(ctx context.Context, id, status, errMsg string) error {
+	return m.Called(ctx, id, status, errMsg).Error(0)
+}
+func (m *MockPayrollRepository) GetPayrollJobItems(ctx context.Context, jobID, status string) ([]PayrollJobItem, error) {
+	args := m.Called(ctx, jobID, status)
+	return args.Get(0).([]PayrollJobItem), args.Error(1)
+}
+
+// MockPayComponentRepository adalah implementasi mock untuk paycomponent.Repository
+type MockPayComponentRepository struct {
+	mock.Mock
+}
+
+func (m *MockPayComponentRepository) CreateComponent(ctx context.Context, c *paycomponent.Component) error {
+	return m.Called(ctx, c).Error(0)
+}
+func (m *MockPayComponentRepository) GetComponent(ctx context.Context, id string) (*paycomponent.Component, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*paycomponent.Component), args.Error(1)
+}
+func (m *MockPayComponentRepository) ListComponents(ctx context.Context) ([]paycomponent.Component, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]paycomponent.Component), args.Error(1)
+}
+func (m *MockPayComponentRepository) UpdateComponent(ctx context.Context, c *paycomponent.Component) error {
+	return m.Called(ctx, c).Error(0)
+}
+func (m *MockPayComponentRepository) CreateAssignment(ctx context.Context, a *paycomponent.Assignment) error {
+	return m.Called(ctx, a).Error(0)
+}
+func (m *MockPayComponentRepository) GetAssignment(ctx context.Context, id string) (*paycomponent.Assignment, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*paycomponent.Assignment), args.Error(1)
+}
+func (m *MockPayComponentRepository) ListAssignments(ctx context.Context, userID string) ([]paycomponent.Assignment, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]paycomponent.Assignment), args.Error(1)
+}
+func (m *MockPayComponentRepository) UpdateAssignment(ctx context.Context, a *paycomponent.Assignment) error {
+	return m.Called(ctx, a).Error(0)
+}
+func (m *MockPayComponentRepository) GetActiveAssignments(ctx context.Context, userID string, start, end time.Time) ([]paycomponent.Assignment, error) {
+	args := m.Called(ctx, userID, start, end)
+	return args.Get(0).([]paycomponent.Assignment), args.Error(1)
+}
+
+// MockHolidayCalendar adalah implementasi mock untuk payroll.HolidayCalendar
+type MockHolidayCalendar struct {
+	mock.Mock
+}
+
+func (m *MockHolidayCalendar) HolidaysBetween(ctx context.Context, start, end time.Time, location string) ([]holiday.Holiday, error) {
+	args := m.Called(ctx, start, end, location)
+	return args.Get(0).([]holiday.Holiday), args.Error(1)
+}
+
+// MockLeaves adalah implementasi mock untuk payroll.Leaves
+type MockLeaves struct {
+	mock.Mock
+}
+
+func (m *MockLeaves) GetApprovedRequests(ctx context.Context, userID string, start, end time.Time) ([]leave.Request, error) {
+	args := m.Called(ctx, userID, start, end)
+	return args.Get(0).([]leave.Request), args.Error(1)
+}
+
+// MockRoster adalah implementasi mock untuk payroll.Roster
+type MockRoster struct {
+	mock.Mock
+}
+
+func (m *MockRoster) GetRoster(ctx context.Context, userID string, start, end time.Time) ([]shift.RosterEntry, error) {
+	args := m.Called(ctx, userID, start, end)
+	return args.Get(0).([]shift.RosterEntry), args.Error(1)
+}
+
+// MockTaxService adalah implementasi mock untuk tax.Service
+type MockTaxService struct {
+	mock.Mock
+}
+
+func (m *MockTaxService) MonthlyWithholding(status tax.Status, gross money.Money) (money.Money, error) {
+	args := m.Called(status, gross)
+	return args.Get(0).(money.Money), args.Error(1)
+}
+func (m *MockTaxService) PeriodWithholding(status tax.Status, gross money.Money, num, den int64) (money.Money, error) {
+	args := m.Called(status, gross, num, den)
+	return args.Get(0).(money.Money), args.Error(1)
+}
+func (m *MockTaxService) AnnualTax(status tax.Status, in tax.AnnualIncome) (money.Money, error) {
+	args := m.Called(status, in)
+	return args.Get(0).(money.Money), args.Error(1)
+}
+func (m *MockTaxService) DecemberWithholding(status tax.Status, in tax.AnnualIncome) (money.Money, error) {
+	args := m.Called(status, in)
+	return args.Get(0).(money.Money), args.Error(1)
+}
+
+// periodWithStatus mencocokkan periode yang disimpan dengan status tertentu.
+func periodWithStatus(status PeriodStatus) interface{} {
+	return mock.MatchedBy(func(p *PayrollPeriod) bool { return p.Status == status })
+}
+
 func TestPayrollService(t *testing.T) {
-	t.Run("RunPayroll - Success", func(t *testing.T) {
+	t.Run("ProcessPayrollJob - Success", func(t *testing.T) {
 		// Arrange
 		mockPayrollRepo := new(MockPayrollRepository)
 		mockEmployeeRepo := new(auth.MockEmployeeRepository) // Menggunakan mock dari auth test
@@ -73,41 +288,1511 @@ func TestPayrollService(t *testing.T) {
 			ID:        periodID,
 			StartDate: startDate,
 			EndDate:   endDate,
-			Status:    "pending",
+			Status:    PeriodOpen,
 		}
 		mockEmployees := []employee.Employee{
-			{ID: "user-001", BaseSalary: 5000000}, // Gaji 5jt, per hari 1jt
+			{ID: "user-001", BaseSalary: money.FromMajor(5000000, money.IDR)}, // Gaji 5jt, per hari 1jt
 		}
 
-		mockAttendances := []attendance.Attendance{{}, {}, {}, {}}           // 4 hari hadir
-		mockOvertimes := []overtime.Overtime{{Hours: 2}}                     // 2 jam lembur
-		mockReimbursements := []reimbursement.Reimbursement{{Amount: 50000}} // reimburse 50rb
+		mockAttendances := []attendance.Attendance{{}, {}, {}, {}}                                                                                              // 4 hari hadir
+		mockOvertimes := []overtime.Overtime{{ID: "ot-001", Hours: 2}}                                                                                          // 2 jam lembur
+		mockReimbursements := []reimbursement.Reimbursement{{ID: "reimb-001", Status: reimbursement.StatusApproved, Amount: money.FromMajor(50000, money.IDR)}} // reimburse 50rb
 
 		// Menyiapkan ekspektasi panggilan mock
 		mockPayrollRepo.On("GetPayrollPeriod", ctx, periodID).Return(mockPeriod, nil).Once()
-		mockPayrollRepo.On("UpdatePayrollPeriodStatus", ctx, periodID, "processing", adminID).Return(nil).Once()
+		job := &PayrollJob{ID: "job-001", PayrollPeriodID: periodID, CreatedBy: adminID}
+		mockPayrollRepo.On("ResetPayrollJobItems", ctx, job.ID, []string{"user-001"}).Return(nil).Once()
+		mockPayrollRepo.On("RecordPayrollJobItem", ctx, job.ID, "user-001", JobItemSucceeded, "").Return(nil).Once()
+		mockPayrollRepo.On("FinishPayrollJob", ctx, job.ID, JobSucceeded, "").Return(nil).Once()
+		mockPayrollRepo.On("GetLatestPayslipVersions", ctx, periodID).Return(map[string]int{}, nil).Once()
+		mockPayrollRepo.On("UpdatePayrollPeriod", ctx, periodWithStatus(PeriodCalculated), PeriodOpen).Return(true, nil).Once()
 		mockEmployeeRepo.On("GetAllEmployees", ctx).Return(mockEmployees, nil).Once()
 		mockPayrollRepo.On("GetAttendances", ctx, "user-001", startDate, endDate).Return(mockAttendances, nil).Once()
-		mockPayrollRepo.On("GetOvertimes", ctx, "user-001", startDate, endDate).Return(mockOvertimes, nil).Once()
-		mockPayrollRepo.On("GetReimbursements", ctx, "user-001", startDate, endDate).Return(mockReimbursements, nil).Once()
+		mockPayrollRepo.On("GetOvertimes", ctx, "user-001", endDate).Return(mockOvertimes, nil).Once()
+		mockPayrollRepo.On("GetReimbursements", ctx, "user-001", endDate).Return(mockReimbursements, nil).Once()
 
 		// Ekspektasi kalkulasi
 		// Prorated: 1jt/hari * 4 hari = 4jt
 		// Overtime: (1jt/8jam) * 2jam * 2kali = 500rb
 		// Reimburse: 50rb
-		// Total: 4.550.000
+		// BPJS karyawan (dari gaji pokok 5jt): JKN 1% + JHT 2% + JP 1% = 200rb
+		// BPJS pemberi kerja: JKN 200rb + JHT 185rb + JP 100rb + JKK 12rb + JKM 15rb = 512rb
+		// PPh 21: bruto 4,5jt + JKN/JKK/JKM 227rb = 4.727.000, TER A 0%
+		// Total: 4.550.000 - 200.000 = 4.350.000
+		var created *Payslip
+		mockPayrollRepo.On("CreatePayslip", ctx, mock.MatchedBy(func(p *Payslip) bool {
+			return p.UserID == "user-001"
+		})).Run(func(args mock.Arguments) {
+			created = args.Get(1).(*Payslip)
+		}).Return(nil).Once()
+		// Lembur dan reimbursement yang masuk payslip ditandai dibayar dalam
+		// transaksi yang sama
+		mockPayrollRepo.On("SettleOvertimes", ctx, mock.Anything, []string{"ot-001"}).Return(int64(1), nil).Once()
+		mockPayrollRepo.On("GetReimbursementsByIDs", ctx, []string{"reimb-001"}).Return(mockReimbursements, nil).Once()
+		mockPayrollRepo.On("UpdateReimbursementStatus", ctx, mock.MatchedBy(func(r *reimbursement.Reimbursement) bool {
+			return r.ID == "reimb-001" && r.Status == reimbursement.StatusPaid && r.PaidAt != nil
+		}), reimbursement.StatusApproved, mock.MatchedBy(func(h reimbursement.StatusHistory) bool {
+			return h.FromStatus == reimbursement.StatusApproved && h.ToStatus == reimbursement.StatusPaid && h.ChangedBy == adminID
+		})).Return(true, nil).Once()
+
+		// Act
+		err := payrollService.ProcessPayrollJob(ctx, job)
+
+		// Assert
+		assert.NoError(t, err)
+		mockPayrollRepo.AssertExpectations(t)
+		mockEmployeeRepo.AssertExpectations(t)
+
+		idr := func(v int64) money.Money { return money.FromMajor(v, money.IDR) }
+		assert.True(t, created.SumLines(LineEarning, CodeBasicSalary).Equal(idr(4000000)))
+		assert.True(t, created.SumLines(LineEarning, CodeOvertime).Equal(idr(500000)))
+		assert.True(t, created.SumLines(LineEarning, CodeReimbursement).Equal(idr(50000)))
+		assert.True(t, created.SumLines(LineDeduction, "BPJS_JKN", "BPJS_JHT", "BPJS_JP").Equal(idr(200000)))
+		assert.True(t, created.SumLines(LineDeduction, CodeIncomeTax).IsZero())
+		assert.True(t, created.TaxableIncome().Equal(idr(4727000)))
+		assert.True(t, created.TotalEarnings.Equal(idr(4550000)))
+		assert.True(t, created.TotalDeductions.Equal(idr(200000)))
+		assert.True(t, created.EmployerCost.Equal(idr(512000)))
+		assert.True(t, created.TotalPay.Equal(idr(4350000)))
+		assert.Equal(t, 1, created.Version)
+	})
+
+	t.Run("ProcessPayrollJob - December PPh 21 true-up", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		mockEmployeeRepo := new(auth.MockEmployeeRepository)
+		payrollService := NewService(mockPayrollRepo, mockEmployeeRepo)
+
+		ctx := context.Background()
+		periodID := "period-012"
+		adminID := "admin-001"
+		startDate, _ := time.Parse("2006-01-02", "2025-12-01")
+		endDate, _ := time.Parse("2006-01-02", "2025-12-05") // 5 hari kerja
+
+		mockPeriod := &PayrollPeriod{ID: periodID, StartDate: startDate, EndDate: endDate, Status: PeriodOpen}
+		mockEmployees := []employee.Employee{
+			{ID: "user-001", BaseSalary: money.FromMajor(10000000, money.IDR), TaxStatus: "TK/0"},
+		}
+
+		// Januari s.d. November: bruto 10jt + premi JKN/JKK/JKM 454rb = 10.454.000,
+		// dipotong TER A 2,5% = 261.350 per bulan, iuran JHT+JP karyawan 300rb.
+		var previous []Payslip
+		for i := 0; i < 11; i++ {
+			previous = append(previous, Payslip{
+				Lines: []PayslipLine{
+					{Type: LineEarning, Code: CodeBasicSalary, Amount: money.FromMajor(10000000, money.IDR), Taxable: true},
+					{Type: LineEmployerCost, Code: "BPJS_JKN", Amount: money.FromMajor(454000, money.IDR), Taxable: true},
+					{Type: LineDeduction, Code: "BPJS_JHT", Amount: money.FromMajor(200000, money.IDR)},
+					{Type: LineDeduction, Code: "BPJS_JP", Amount: money.FromMajor(100000, money.IDR)},
+					{Type: LineDeduction, Code: CodeIncomeTax, Amount: money.FromMajor(261350, money.IDR)},
+				},
+			})
+		}
+
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, periodID).Return(mockPeriod, nil).Once()
+		job := &PayrollJob{ID: "job-001", PayrollPeriodID: periodID, CreatedBy: adminID}
+		mockPayrollRepo.On("ResetPayrollJobItems", ctx, job.ID, []string{"user-001"}).Return(nil).Once()
+		mockPayrollRepo.On("RecordPayrollJobItem", ctx, job.ID, "user-001", JobItemSucceeded, "").Return(nil).Once()
+		mockPayrollRepo.On("FinishPayrollJob", ctx, job.ID, JobSucceeded, "").Return(nil).Once()
+		mockPayrollRepo.On("GetLatestPayslipVersions", ctx, periodID).Return(map[string]int{}, nil).Once()
+		mockPayrollRepo.On("UpdatePayrollPeriod", ctx, periodWithStatus(PeriodCalculated), PeriodOpen).Return(true, nil).Once()
+		mockEmployeeRepo.On("GetAllEmployees", ctx).Return(mockEmployees, nil).Once()
+		mockPayrollRepo.On("GetAttendances", ctx, "user-001", startDate, endDate).Return([]attendance.Attendance{{}, {}, {}, {}, {}}, nil).Once()
+		mockPayrollRepo.On("GetOvertimes", ctx, "user-001", endDate).Return([]overtime.Overtime{}, nil).Once()
+		mockPayrollRepo.On("GetReimbursements", ctx, "user-001", endDate).Return([]reimbursement.Reimbursement{}, nil).Once()
+		mockPayrollRepo.On("GetYearToDatePayslips", ctx, "user-001", startDate).Return(previous, nil).Once()
+		mockPayrollRepo.On("GetYearToDateMonths", ctx, "user-001", startDate).Return([]time.Month{
+			time.January, time.February, time.March, time.April, time.May, time.June,
+			time.July, time.August, time.September, time.October, time.November,
+		}, nil).Once()
+
+		// Setahun: bruto 125.448.000 - biaya jabatan 6jt - iuran pensiun 3,6jt
+		// - PTKP 54jt = PKP 61.848.000 -> 60jt x 5% + 1.848.000 x 15% = 3.277.200.
+		// Desember: 3.277.200 - 11 x 261.350 = 402.350.
+		// Take home: 10jt - 402.350 - BPJS karyawan 400rb = 9.197.650.
 		mockPayrollRepo.On("CreatePayslip", ctx, mock.MatchedBy(func(p *Payslip) bool {
-			return p.UserID == "user-001" && p.TotalPay == 4550000
+			return p.SumLines(LineDeduction, CodeIncomeTax).Equal(money.FromMajor(402350, money.IDR)) &&
+				p.TotalPay.Equal(money.FromMajor(9197650, money.IDR))
 		})).Return(nil).Once()
 
-		mockPayrollRepo.On("UpdatePayrollPeriodStatus", ctx, periodID, "completed", adminID).Return(nil).Once()
+		// Act
+		err := payrollService.ProcessPayrollJob(ctx, job)
+
+		// Assert
+		assert.NoError(t, err)
+		mockPayrollRepo.AssertExpectations(t)
+		mockEmployeeRepo.AssertExpectations(t)
+	})
+
+	t.Run("GetPayrollSummary - Employer cost totals", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		payrollService := NewService(mockPayrollRepo, new(auth.MockEmployeeRepository))
+		ctx := context.Background()
+
+		payslips := []Payslip{
+			{
+				UserID:       "user-001",
+				TotalPay:     money.FromMajor(4350000, money.IDR),
+				EmployerCost: money.FromMajor(212000, money.IDR),
+				Lines: []PayslipLine{
+					{Type: LineEmployerCost, Code: "BPJS_JKN", Amount: money.FromMajor(200000, money.IDR)},
+					{Type: LineEmployerCost, Code: "BPJS_JKK", Amount: money.FromMajor(12000, money.IDR)},
+					{Type: LineDeduction, Code: "BPJS_JKN", Amount: money.FromMajor(50000, money.IDR)},
+				},
+			},
+			{
+				UserID:       "user-002",
+				TotalPay:     money.FromMajor(9197650, money.IDR),
+				EmployerCost: money.FromMajor(400000, money.IDR),
+				Lines: []PayslipLine{
+					{Type: LineEmployerCost, Code: "BPJS_JKN", Amount: money.FromMajor(400000, money.IDR)},
+				},
+			},
+		}
+		mockPayrollRepo.On("GetPayslipsByPeriod", ctx, "period-001").Return(payslips, nil).Once()
+
+		// Act
+		summary, err := payrollService.GetPayrollSummary(ctx, "period-001")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, summary.TotalPayout.Equal(money.FromMajor(13547650, money.IDR)))
+		assert.True(t, summary.TotalEmployerCost.Equal(money.FromMajor(612000, money.IDR)))
+		assert.True(t, summary.EmployerCostByCode["BPJS_JKN"].Equal(money.FromMajor(600000, money.IDR)))
+		assert.True(t, summary.EmployerCostByCode["BPJS_JKK"].Equal(money.FromMajor(12000, money.IDR)))
+	})
+
+	t.Run("ProcessPayrollJob - Pay components", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		mockEmployeeRepo := new(auth.MockEmployeeRepository)
+		mockComponentRepo := new(MockPayComponentRepository)
+		payrollService := NewService(mockPayrollRepo, mockEmployeeRepo, WithPayComponents(mockComponentRepo))
+
+		ctx := context.Background()
+		periodID := "period-001"
+		adminID := "admin-001"
+		startDate, _ := time.Parse("2006-01-02", "2025-09-01")
+		endDate, _ := time.Parse("2006-01-02", "2025-09-05")
+
+		mockPeriod := &PayrollPeriod{ID: periodID, StartDate: startDate, EndDate: endDate, Status: PeriodOpen}
+		mockEmployees := []employee.Employee{{ID: "user-001", BaseSalary: money.FromMajor(5000000, money.IDR)}}
+		assignments := []paycomponent.Assignment{
+			{Component: paycomponent.Component{
+				Code: "MEAL", Name: "Uang Makan", Kind: paycomponent.KindEarning,
+				CalculationType: paycomponent.CalculationPerAttendanceDay, Amount: money.FromMajor(50000, money.IDR),
+			}},
+			{Component: paycomponent.Component{
+				Code: "POSITION", Name: "Tunjangan Jabatan", Kind: paycomponent.KindEarning, Taxable: true,
+				CalculationType: paycomponent.CalculationPercentageOfBase, PercentageBps: 1000,
+			}},
+			{Component: paycomponent.Component{
+				Code: "COOP", Name: "Iuran Koperasi", Kind: paycomponent.KindDeduction,
+				CalculationType: paycomponent.CalculationFixed, Amount: money.FromMajor(100000, money.IDR),
+			}},
+		}
+
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, periodID).Return(mockPeriod, nil).Once()
+		job := &PayrollJob{ID: "job-001", PayrollPeriodID: periodID, CreatedBy: adminID}
+		mockPayrollRepo.On("ResetPayrollJobItems", ctx, job.ID, []string{"user-001"}).Return(nil).Once()
+		mockPayrollRepo.On("RecordPayrollJobItem", ctx, job.ID, "user-001", JobItemSucceeded, "").Return(nil).Once()
+		mockPayrollRepo.On("FinishPayrollJob", ctx, job.ID, JobSucceeded, "").Return(nil).Once()
+		mockPayrollRepo.On("GetLatestPayslipVersions", ctx, periodID).Return(map[string]int{}, nil).Once()
+		mockPayrollRepo.On("UpdatePayrollPeriod", ctx, periodWithStatus(PeriodCalculated), PeriodOpen).Return(true, nil).Once()
+		mockEmployeeRepo.On("GetAllEmployees", ctx).Return(mockEmployees, nil).Once()
+		mockPayrollRepo.On("GetAttendances", ctx, "user-001", startDate, endDate).Return([]attendance.Attendance{{}, {}, {}, {}}, nil).Once()
+		mockPayrollRepo.On("GetOvertimes", ctx, "user-001", endDate).Return([]overtime.Overtime{}, nil).Once()
+		mockPayrollRepo.On("GetReimbursements", ctx, "user-001", endDate).Return([]reimbursement.Reimbursement{}, nil).Once()
+		mockComponentRepo.On("GetActiveAssignments", ctx, "user-001", startDate, endDate).Return(assignments, nil).Once()
+
+		var created *Payslip
+		mockPayrollRepo.On("CreatePayslip", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			created = args.Get(1).(*Payslip)
+		}).Return(nil).Once()
+
+		// Act
+		err := payrollService.ProcessPayrollJob(ctx, job)
+
+		// Assert
+		assert.NoError(t, err)
+		mockComponentRepo.AssertExpectations(t)
+
+		idr := func(v int64) money.Money { return money.FromMajor(v, money.IDR) }
+		// Gaji 4jt + uang makan 4 x 50rb + tunjangan jabatan 10% x 5jt = 4,7jt
+		assert.True(t, created.SumLines(LineEarning, "MEAL").Equal(idr(200000)))
+		assert.True(t, created.SumLines(LineEarning, "POSITION").Equal(idr(500000)))
+		assert.True(t, created.SumLines(LineDeduction, "COOP").Equal(idr(100000)))
+		// Uang makan tidak kena pajak: 4jt + 500rb + premi JKN/JKK/JKM 227rb
+		assert.True(t, created.TaxableIncome().Equal(idr(4727000)))
+		// 4,7jt - koperasi 100rb - BPJS karyawan 200rb
+		assert.True(t, created.TotalPay.Equal(idr(4400000)))
+	})
+
+	t.Run("ProcessPayrollJob - Failure stores no payslips", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		mockEmployeeRepo := new(auth.MockEmployeeRepository)
+		payrollService := NewService(mockPayrollRepo, mockEmployeeRepo)
+
+		ctx := context.Background()
+		periodID := "period-001"
+		adminID := "admin-001"
+		startDate, _ := time.Parse("2006-01-02", "2025-09-01")
+		endDate, _ := time.Parse("2006-01-02", "2025-09-05")
+
+		mockPeriod := &PayrollPeriod{ID: periodID, StartDate: startDate, EndDate: endDate, Status: PeriodOpen}
+		mockEmployees := []employee.Employee{
+			{ID: "user-001", BaseSalary: money.FromMajor(5000000, money.IDR)},
+			{ID: "user-002", BaseSalary: money.FromMajor(6000000, money.IDR)},
+		}
+		job := &PayrollJob{ID: "job-001", PayrollPeriodID: periodID, CreatedBy: adminID}
+
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, periodID).Return(mockPeriod, nil).Once()
+		mockEmployeeRepo.On("GetAllEmployees", ctx).Return(mockEmployees, nil).Once()
+		mockPayrollRepo.On("ResetPayrollJobItems", ctx, job.ID, []string{"user-001", "user-002"}).Return(nil).Once()
+		mockPayrollRepo.On("GetAttendances", ctx, "user-001", startDate, endDate).Return([]attendance.Attendance{{}}, nil).Once()
+		mockPayrollRepo.On("GetOvertimes", ctx, "user-001", endDate).Return([]overtime.Overtime{}, nil).Once()
+		mockPayrollRepo.On("GetReimbursements", ctx, "user-001", endDate).Return([]reimbursement.Reimbursement{}, nil).Once()
+		mockPayrollRepo.On("GetAttendances", ctx, "user-002", startDate, endDate).Return([]attendance.Attendance{}, errors.New("connection reset")).Once()
+		mockPayrollRepo.On("RecordPayrollJobItem", ctx, job.ID, "user-001", JobItemSucceeded, "").Return(nil).Once()
+		mockPayrollRepo.On("RecordPayrollJobItem", ctx, job.ID, "user-002", JobItemFailed, "connection reset").Return(nil).Once()
+		mockPayrollRepo.On("FinishPayrollJob", ctx, job.ID, JobFailed, "1 of 2 employees failed").Return(nil).Once()
+
+		// Act
+		err := payrollService.ProcessPayrollJob(ctx, job)
+
+		// Assert: payslip user-001 yang berhasil pun tidak disimpan dan periode
+		// tidak berubah, sehingga tetap open dan dapat dijalankan ulang.
+		assert.Error(t, err)
+		mockPayrollRepo.AssertExpectations(t)
+		mockPayrollRepo.AssertNotCalled(t, "UpdatePayrollPeriod", mock.Anything, mock.Anything, mock.Anything)
+		mockPayrollRepo.AssertNotCalled(t, "CreatePayslip", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ProcessPayrollJob - Overtime settled by another payslip fails the run", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		mockEmployeeRepo := new(auth.MockEmployeeRepository)
+		payrollService := NewService(mockPayrollRepo, mockEmployeeRepo)
+
+		ctx := context.Background()
+		periodID := "period-001"
+		adminID := "admin-001"
+		startDate, _ := time.Parse("2006-01-02", "2025-09-01")
+		endDate, _ := time.Parse("2006-01-02", "2025-09-05")
+
+		mockPeriod := &PayrollPeriod{ID: periodID, StartDate: startDate, EndDate: endDate, Status: PeriodOpen}
+		job := &PayrollJob{ID: "job-001", PayrollPeriodID: periodID, CreatedBy: adminID}
+
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, periodID).Return(mockPeriod, nil).Once()
+		mockEmployeeRepo.On("GetAllEmployees", ctx).Return([]employee.Employee{
+			{ID: "user-001", BaseSalary: money.FromMajor(5000000, money.IDR)},
+		}, nil).Once()
+		mockPayrollRepo.On("ResetPayrollJobItems", ctx, job.ID, []string{"user-001"}).Return(nil).Once()
+		mockPayrollRepo.On("GetAttendances", ctx, "user-001", startDate, endDate).Return(make([]attendance.Attendance, 5), nil).Once()
+		mockPayrollRepo.On("GetOvertimes", ctx, "user-001", endDate).Return([]overtime.Overtime{{ID: "ot-001", Date: startDate, Hours: 2}}, nil).Once()
+		mockPayrollRepo.On("GetReimbursements", ctx, "user-001", endDate).Return([]reimbursement.Reimbursement{}, nil).Once()
+		mockPayrollRepo.On("RecordPayrollJobItem", ctx, job.ID, "user-001", JobItemSucceeded, "").Return(nil).Once()
+		mockPayrollRepo.On("UpdatePayrollPeriod", ctx, periodWithStatus(PeriodCalculated), PeriodOpen).Return(true, nil).Once()
+		mockPayrollRepo.On("GetLatestPayslipVersions", ctx, periodID).Return(map[string]int{}, nil).Once()
+		mockPayrollRepo.On("CreatePayslip", ctx, mock.Anything).Return(nil).Once()
+		// Periode lain yang berjalan bersamaan sudah membayar lembur ini.
+		mockPayrollRepo.On("SettleOvertimes", ctx, mock.Anything, []string{"ot-001"}).Return(int64(0), nil).Once()
+		mockPayrollRepo.On("FinishPayrollJob", ctx, job.ID, JobFailed, mock.Anything).Return(nil).Once()
+
+		// Act
+		err := payrollService.ProcessPayrollJob(ctx, job)
+
+		// Assert: transaksi dibatalkan sehingga lembur tidak dibayar dua kali.
+		assert.ErrorIs(t, err, approval.ErrModified)
+		mockPayrollRepo.AssertExpectations(t)
+		mockPayrollRepo.AssertNotCalled(t, "FinishPayrollJob", ctx, job.ID, JobSucceeded, "")
+	})
+
+	t.Run("ProcessPayrollJob - Period claimed by another run", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		mockEmployeeRepo := new(auth.MockEmployeeRepository)
+		payrollService := NewService(mockPayrollRepo, mockEmployeeRepo)
+
+		ctx := context.Background()
+		// Sabtu-Minggu: tidak ada hari kerja, langsung ke tahap penyimpanan.
+		startDate, _ := time.Parse("2006-01-02", "2025-09-06")
+		endDate, _ := time.Parse("2006-01-02", "2025-09-07")
+		job := &PayrollJob{ID: "job-001", PayrollPeriodID: "period-001", CreatedBy: "admin-001"}
+
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-001").Return(&PayrollPeriod{ID: "period-001", StartDate: startDate, EndDate: endDate, Status: PeriodOpen}, nil).Once()
+		mockEmployeeRepo.On("GetAllEmployees", ctx).Return([]employee.Employee{}, nil).Once()
+		mockPayrollRepo.On("ResetPayrollJobItems", ctx, job.ID, []string{}).Return(nil).Once()
+		mockPayrollRepo.On("UpdatePayrollPeriod", ctx, periodWithStatus(PeriodCalculated), PeriodOpen).Return(false, nil).Once()
+		mockPayrollRepo.On("FinishPayrollJob", ctx, job.ID, JobFailed, ErrPeriodModified.Error()).Return(nil).Once()
+
+		// Act
+		err := payrollService.ProcessPayrollJob(ctx, job)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrPeriodModified)
+		mockPayrollRepo.AssertExpectations(t)
+	})
+
+	t.Run("PreviewPayroll - Warnings without persisting", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		mockEmployeeRepo := new(auth.MockEmployeeRepository)
+		payrollService := NewService(mockPayrollRepo, mockEmployeeRepo)
+
+		ctx := context.Background()
+		periodID := "period-001"
+		startDate, _ := time.Parse("2006-01-02", "2025-09-01")
+		endDate, _ := time.Parse("2006-01-02", "2025-09-05")
+		userIDs := []string{"user-001", "user-002"}
+
+		mockPeriod := &PayrollPeriod{ID: periodID, StartDate: startDate, EndDate: endDate, Status: PeriodOpen}
+		mockEmployees := []employee.Employee{
+			{ID: "user-001", BaseSalary: money.FromMajor(5000000, money.IDR)},
+			{ID: "user-002", BaseSalary: money.Zero(money.IDR)},
+		}
+
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, periodID).Return(mockPeriod, nil).Once()
+		mockEmployeeRepo.On("GetByIDs", ctx, userIDs).Return(mockEmployees, nil).Once()
+		// user-001 tidak hadir sama sekali tetapi mengklaim reimbursement 3jt (> 50% gaji).
+		mockPayrollRepo.On("GetAttendances", ctx, "user-001", startDate, endDate).Return([]attendance.Attendance{}, nil).Once()
+		mockPayrollRepo.On("GetOvertimes", ctx, "user-001", endDate).Return([]overtime.Overtime{}, nil).Once()
+		mockPayrollRepo.On("GetReimbursements", ctx, "user-001", endDate).Return([]reimbursement.Reimbursement{
+			{Amount: money.FromMajor(3000000, money.IDR)},
+		}, nil).Once()
+		mockPayrollRepo.On("GetAttendances", ctx, "user-002", startDate, endDate).Return([]attendance.Attendance{{}, {}, {}, {}, {}}, nil).Once()
+		mockPayrollRepo.On("GetOvertimes", ctx, "user-002", endDate).Return([]overtime.Overtime{}, nil).Once()
+		mockPayrollRepo.On("GetReimbursements", ctx, "user-002", endDate).Return([]reimbursement.Reimbursement{}, nil).Once()
 
 		// Act
-		err := payrollService.RunPayroll(ctx, periodID, adminID)
+		preview, err := payrollService.PreviewPayroll(ctx, periodID, userIDs)
 
 		// Assert
 		assert.NoError(t, err)
 		mockPayrollRepo.AssertExpectations(t)
 		mockEmployeeRepo.AssertExpectations(t)
+		mockPayrollRepo.AssertNotCalled(t, "CreatePayslip", mock.Anything, mock.Anything)
+		mockPayrollRepo.AssertNotCalled(t, "UpdatePayrollPeriod", mock.Anything, mock.Anything, mock.Anything)
+
+		assert.Len(t, preview.Payslips, 2)
+		assert.Equal(t, []PreviewWarning{
+			{UserID: "user-001", Code: WarningZeroAttendance, Message: "employee has no attendance in this period"},
+			{UserID: "user-001", Code: WarningLargeReimbursement, Message: "reimbursements of 3000000.00 exceed 50% of base salary"},
+			{UserID: "user-002", Code: WarningMissingSalary, Message: "employee has no base salary"},
+		}, preview.Warnings)
+		// user-001: reimbursement 3jt - BPJS karyawan 200rb; user-002: nol.
+		assert.True(t, preview.Summary.TotalPayout.Equal(money.FromMajor(2800000, money.IDR)), "got %s", preview.Summary.TotalPayout)
+	})
+
+	t.Run("PreviewPayroll - Unknown user IDs are rejected", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		mockEmployeeRepo := new(auth.MockEmployeeRepository)
+		payrollService := NewService(mockPayrollRepo, mockEmployeeRepo)
+
+		ctx := context.Background()
+		startDate, _ := time.Parse("2006-01-02", "2025-09-01")
+		endDate, _ := time.Parse("2006-01-02", "2025-09-05")
+		userIDs := []string{"user-001", "user-404", "admin-001", "user-404"}
+
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-001").Return(&PayrollPeriod{ID: "period-001", StartDate: startDate, EndDate: endDate, Status: PeriodOpen}, nil).Once()
+		mockEmployeeRepo.On("GetByIDs", ctx, userIDs).Return([]employee.Employee{
+			{ID: "user-001", BaseSalary: money.FromMajor(5000000, money.IDR)},
+		}, nil).Once()
+
+		// Act
+		preview, err := payrollService.PreviewPayroll(ctx, "period-001", userIDs)
+
+		// Assert
+		assert.Nil(t, preview)
+		assert.ErrorIs(t, err, ErrUnknownEmployees)
+		assert.EqualError(t, err, "unknown employees: user-404, admin-001")
+		mockPayrollRepo.AssertNotCalled(t, "GetAttendances", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("PreviewPayroll - Holidays reduce working days per location", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		mockEmployeeRepo := new(auth.MockEmployeeRepository)
+		mockHolidays := new(MockHolidayCalendar)
+		payrollService := NewService(mockPayrollRepo, mockEmployeeRepo, WithHolidayCalendar(mockHolidays))
+
+		ctx := context.Background()
+		// Senin-Jumat; 17 Agustus libur nasional, 19 Agustus libur lokal Bali.
+		startDate, _ := time.Parse("2006-01-02", "2026-08-17")
+		endDate, _ := time.Parse("2006-01-02", "2026-08-21")
+		hutRI := holiday.Holiday{Date: startDate, Name: "HUT RI", Type: holiday.TypeNational}
+		localHoliday := holiday.Holiday{Date: startDate.AddDate(0, 0, 2), Name: "Libur lokal", Type: holiday.TypeCompany, Location: "Bali"}
+
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-001").Return(&PayrollPeriod{ID: "period-001", StartDate: startDate, EndDate: endDate, Status: PeriodOpen}, nil).Once()
+		mockEmployeeRepo.On("GetAllEmployees", ctx).Return([]employee.Employee{
+			{ID: "user-001", BaseSalary: money.FromMajor(4000000, money.IDR), Location: "Jakarta"},
+			{ID: "user-002", BaseSalary: money.FromMajor(3000000, money.IDR), Location: "Bali"},
+		}, nil).Once()
+		mockHolidays.On("HolidaysBetween", ctx, startDate, endDate, "Jakarta").Return([]holiday.Holiday{hutRI}, nil).Once()
+		mockHolidays.On("HolidaysBetween", ctx, startDate, endDate, "Bali").Return([]holiday.Holiday{hutRI, localHoliday}, nil).Once()
+		mockPayrollRepo.On("GetAttendances", ctx, "user-001", startDate, endDate).Return([]attendance.Attendance{{}, {}, {}, {}}, nil).Once()
+		mockPayrollRepo.On("GetAttendances", ctx, "user-002", startDate, endDate).Return([]attendance.Attendance{{}, {}, {}}, nil).Once()
+		mockPayrollRepo.On("GetOvertimes", ctx, mock.Anything, endDate).Return([]overtime.Overtime{}, nil).Twice()
+		mockPayrollRepo.On("GetReimbursements", ctx, mock.Anything, endDate).Return([]reimbursement.Reimbursement{}, nil).Twice()
+
+		// Act
+		preview, err := payrollService.PreviewPayroll(ctx, "period-001", nil)
+
+		// Assert: kedua karyawan hadir di semua hari kerjanya dan menerima gaji pokok penuh.
+		assert.NoError(t, err)
+		if assert.Len(t, preview.Payslips, 2) {
+			for i, want := range []struct {
+				description string
+				amount      money.Money
+			}{
+				{"Gaji pokok (4 dari 4 hari kerja)", money.FromMajor(4000000, money.IDR)},
+				{"Gaji pokok (3 dari 3 hari kerja)", money.FromMajor(3000000, money.IDR)},
+			} {
+				basic := preview.Payslips[i].Lines[0]
+				assert.Equal(t, CodeBasicSalary, basic.Code)
+				assert.Equal(t, want.description, basic.Description)
+				assert.True(t, basic.Amount.Equal(want.amount), "got %s", basic.Amount)
+			}
+		}
+		mockHolidays.AssertExpectations(t)
+	})
+
+	t.Run("PreviewPayroll - Reimbursement approved after its period was calculated", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		mockEmployeeRepo := new(auth.MockEmployeeRepository)
+		payrollService := NewService(mockPayrollRepo, mockEmployeeRepo)
+
+		ctx := context.Background()
+		periodID := "period-002"
+		startDate, _ := time.Parse("2006-01-02", "2025-09-08")
+		endDate, _ := time.Parse("2006-01-02", "2025-09-12")
+		lateDate, _ := time.Parse("2006-01-02", "2025-09-03") // Periode sebelumnya
+		userIDs := []string{"user-001"}
+
+		mockPeriod := &PayrollPeriod{ID: periodID, StartDate: startDate, EndDate: endDate, Status: PeriodOpen}
+		mockEmployees := []employee.Employee{
+			{ID: "user-001", BaseSalary: money.FromMajor(5000000, money.IDR)},
+		}
+
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, periodID).Return(mockPeriod, nil).Once()
+		mockEmployeeRepo.On("GetByIDs", ctx, userIDs).Return(mockEmployees, nil).Once()
+		mockPayrollRepo.On("GetAttendances", ctx, "user-001", startDate, endDate).Return([]attendance.Attendance{{}, {}, {}, {}, {}}, nil).Once()
+		mockPayrollRepo.On("GetOvertimes", ctx, "user-001", endDate).Return([]overtime.Overtime{}, nil).Once()
+		// Reimbursement bertanggal periode lalu yang baru disetujui setelah
+		// periode itu dihitung ikut dibayar di periode ini.
+		mockPayrollRepo.On("GetReimbursements", ctx, "user-001", endDate).Return([]reimbursement.Reimbursement{
+			{ID: "reimb-late", Date: lateDate, Status: reimbursement.StatusApproved, Amount: money.FromMajor(75000, money.IDR)},
+		}, nil).Once()
+
+		// Act
+		preview, err := payrollService.PreviewPayroll(ctx, periodID, userIDs)
+
+		// Assert
+		assert.NoError(t, err)
+		mockPayrollRepo.AssertExpectations(t)
+		assert.Len(t, preview.Payslips, 1)
+		payslip := preview.Payslips[0]
+		assert.True(t, payslip.SumLines(LineEarning, CodeReimbursement).Equal(money.FromMajor(75000, money.IDR)))
+		for _, line := range payslip.Lines {
+			if line.Code == CodeReimbursement {
+				assert.Equal(t, "reimb-late", line.ReferenceID)
+			}
+		}
+	})
+
+	t.Run("ProcessPayrollJob - Re-run after reversal creates next version", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		mockEmployeeRepo := new(auth.MockEmployeeRepository)
+		payrollService := NewService(mockPayrollRepo, mockEmployeeRepo)
+
+		ctx := context.Background()
+		periodID := "period-001"
+		adminID := "admin-001"
+		startDate, _ := time.Parse("2006-01-02", "2025-09-01")
+		endDate, _ := time.Parse("2006-01-02", "2025-09-05")
+		job := &PayrollJob{ID: "job-002", PayrollPeriodID: periodID, CreatedBy: adminID}
+
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, periodID).Return(&PayrollPeriod{ID: periodID, StartDate: startDate, EndDate: endDate, Status: PeriodOpen}, nil).Once()
+		mockEmployeeRepo.On("GetAllEmployees", ctx).Return([]employee.Employee{{ID: "user-001", BaseSalary: money.FromMajor(5000000, money.IDR)}}, nil).Once()
+		mockPayrollRepo.On("ResetPayrollJobItems", ctx, job.ID, []string{"user-001"}).Return(nil).Once()
+		mockPayrollRepo.On("GetAttendances", ctx, "user-001", startDate, endDate).Return([]attendance.Attendance{{}}, nil).Once()
+		mockPayrollRepo.On("GetOvertimes", ctx, "user-001", endDate).Return([]overtime.Overtime{}, nil).Once()
+		mockPayrollRepo.On("GetReimbursements", ctx, "user-001", endDate).Return([]reimbursement.Reimbursement{}, nil).Once()
+		mockPayrollRepo.On("RecordPayrollJobItem", ctx, job.ID, "user-001", JobItemSucceeded, "").Return(nil).Once()
+		mockPayrollRepo.On("UpdatePayrollPeriod", ctx, periodWithStatus(PeriodCalculated), PeriodOpen).Return(true, nil).Once()
+		// Versi 1 sudah di-void oleh reversal sebelumnya.
+		mockPayrollRepo.On("GetLatestPayslipVersions", ctx, periodID).Return(map[string]int{"user-001": 1}, nil).Once()
+		mockPayrollRepo.On("CreatePayslip", ctx, mock.MatchedBy(func(p *Payslip) bool {
+			return p.UserID == "user-001" && p.Version == 2
+		})).Return(nil).Once()
+		mockPayrollRepo.On("FinishPayrollJob", ctx, job.ID, JobSucceeded, "").Return(nil).Once()
+
+		// Act
+		err := payrollService.ProcessPayrollJob(ctx, job)
+
+		// Assert
+		assert.NoError(t, err)
+		mockPayrollRepo.AssertExpectations(t)
+	})
+
+	t.Run("ReversePayroll - Voids payslips and reopens the period", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		payrollService := NewService(mockPayrollRepo, new(auth.MockEmployeeRepository))
+
+		ctx := context.Background()
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-001").Return(&PayrollPeriod{ID: "period-001", Status: PeriodCalculated}, nil).Once()
+		mockPayrollRepo.On("UpdatePayrollPeriod", ctx, periodWithStatus(PeriodOpen), PeriodCalculated).Return(true, nil).Once()
+		mockPayrollRepo.On("UnsettleOvertimes", ctx, "period-001").Return(nil).Once()
+		paid := []reimbursement.Reimbursement{{ID: "reimb-001", Status: reimbursement.StatusPaid, PayslipID: "payslip-001"}}
+		mockPayrollRepo.On("GetSettledReimbursements", ctx, "period-001").Return(paid, nil).Once()
+		mockPayrollRepo.On("UpdateReimbursementStatus", ctx, mock.MatchedBy(func(r *reimbursement.Reimbursement) bool {
+			return r.Status == reimbursement.StatusApproved && r.PayslipID == "" && r.PaidAt == nil
+		}), reimbursement.StatusPaid, mock.MatchedBy(func(h reimbursement.StatusHistory) bool {
+			return h.FromStatus == reimbursement.StatusPaid && h.ToStatus == reimbursement.StatusApproved && h.ChangedBy == "admin-001"
+		})).Return(true, nil).Once()
+		mockPayrollRepo.On("VoidPayslips", ctx, "period-001", "Salah input lembur", "admin-001", mock.AnythingOfType("time.Time")).Return(int64(10), nil).Once()
+
+		// Act
+		err := payrollService.ReversePayroll(ctx, "period-001", "Salah input lembur", "admin-001")
+
+		// Assert
+		assert.NoError(t, err)
+		mockPayrollRepo.AssertExpectations(t)
+	})
+
+	t.Run("ReversePayroll - Rejects periods that are not calculated", func(t *testing.T) {
+		mockPayrollRepo := new(MockPayrollRepository)
+		payrollService := NewService(mockPayrollRepo, new(auth.MockEmployeeRepository))
+
+		ctx := context.Background()
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-001").Return(&PayrollPeriod{ID: "period-001", Status: PeriodOpen}, nil).Once()
+
+		err := payrollService.ReversePayroll(ctx, "period-001", "Salah input lembur", "admin-001")
+		assert.ErrorIs(t, err, ErrInvalidPeriodTransition)
+
+		err = payrollService.ReversePayroll(ctx, "period-001", " ", "admin-001")
+		assert.EqualError(t, err, "reversal reason is required")
+		mockPayrollRepo.AssertNotCalled(t, "VoidPayslips", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("DiffPayslips - Compares the last two versions", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		payrollService := NewService(mockPayrollRepo, new(auth.MockEmployeeRepository))
+		ctx := context.Background()
+
+		idr := func(v int64) money.Money { return money.FromMajor(v, money.IDR) }
+		v1 := Payslip{Version: 1, UserID: "user-001", PayrollPeriodID: "period-001", BaseSalary: idr(5000000)}
+		v1.AddLine(PayslipLine{Type: LineEarning, Code: CodeBasicSalary, Amount: idr(4000000)})
+		v1.AddLine(PayslipLine{Type: LineEarning, Code: CodeReimbursement, Amount: idr(50000)})
+		v1.AddLine(PayslipLine{Type: LineDeduction, Code: "BPJS_JKN", Amount: idr(50000)})
+		v1.Recalculate()
+		v2 := Payslip{Version: 2, UserID: "user-001", PayrollPeriodID: "period-001", BaseSalary: idr(5000000)}
+		v2.AddLine(PayslipLine{Type: LineEarning, Code: CodeBasicSalary, Amount: idr(5000000)})
+		v2.AddLine(PayslipLine{Type: LineEarning, Code: CodeReimbursement, Amount: idr(20000)})
+		v2.AddLine(PayslipLine{Type: LineEarning, Code: CodeReimbursement, Amount: idr(30000)})
+		v2.AddLine(PayslipLine{Type: LineEarning, Code: CodeOvertime, Amount: idr(250000)})
+		v2.AddLine(PayslipLine{Type: LineDeduction, Code: "BPJS_JKN", Amount: idr(50000)})
+		v2.Recalculate()
+
+		mockPayrollRepo.On("GetPayslipVersions", ctx, "user-001", "period-001").Return([]Payslip{v1, v2}, nil).Once()
+
+		// Act
+		diff, err := payrollService.DiffPayslips(ctx, "user-001", "period-001", 0, 0)
+
+		// Assert: reimbursement (dijumlahkan) dan BPJS tidak berubah, jadi tidak muncul.
+		assert.NoError(t, err)
+		assert.Equal(t, 1, diff.FromVersion)
+		assert.Equal(t, 2, diff.ToVersion)
+		assert.Len(t, diff.Lines, 2)
+		assert.Equal(t, CodeBasicSalary, diff.Lines[0].Code)
+		assert.True(t, diff.Lines[0].Delta.Equal(idr(1000000)))
+		assert.Equal(t, CodeOvertime, diff.Lines[1].Code)
+		assert.True(t, diff.Lines[1].From.IsZero())
+		assert.True(t, diff.TotalPay.Delta.Equal(idr(1250000)))
+	})
+
+	t.Run("RunPayroll - Enqueues a job", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		payrollService := NewService(mockPayrollRepo, new(auth.MockEmployeeRepository))
+
+		ctx := context.Background()
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-001").Return(&PayrollPeriod{ID: "period-001", Status: PeriodOpen}, nil).Once()
+		mockPayrollRepo.On("HasActivePayrollJob", ctx, "period-001").Return(false, nil).Once()
+		mockPayrollRepo.On("CreatePayrollJob", ctx, mock.MatchedBy(func(j *PayrollJob) bool {
+			return j.PayrollPeriodID == "period-001" && j.Status == JobQueued && j.CreatedBy == "admin-001"
+		})).Return(nil).Once()
+
+		// Act
+		job, err := payrollService.RunPayroll(ctx, "period-001", "admin-001")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, JobQueued, job.Status)
+		mockPayrollRepo.AssertExpectations(t)
+	})
+
+	t.Run("RunPayroll - Active job exists", func(t *testing.T) {
+		mockPayrollRepo := new(MockPayrollRepository)
+		payrollService := NewService(mockPayrollRepo, new(auth.MockEmployeeRepository))
+
+		ctx := context.Background()
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-001").Return(&PayrollPeriod{ID: "period-001", Status: PeriodOpen}, nil).Once()
+		mockPayrollRepo.On("HasActivePayrollJob", ctx, "period-001").Return(true, nil).Once()
+
+		_, err := payrollService.RunPayroll(ctx, "period-001", "admin-001")
+
+		assert.ErrorIs(t, err, ErrPayrollAlreadyRun)
+		mockPayrollRepo.AssertNotCalled(t, "CreatePayrollJob", mock.Anything, mock.Anything)
+	})
+
+	t.Run("RunPayroll - Calculated period is rejected", func(t *testing.T) {
+		mockPayrollRepo := new(MockPayrollRepository)
+		payrollService := NewService(mockPayrollRepo, new(auth.MockEmployeeRepository))
+
+		ctx := context.Background()
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-001").Return(&PayrollPeriod{ID: "period-001", Status: PeriodCalculated}, nil).Once()
+
+		_, err := payrollService.RunPayroll(ctx, "period-001", "admin-001")
+
+		assert.ErrorIs(t, err, ErrPayrollAlreadyRun)
+		mockPayrollRepo.AssertNotCalled(t, "HasActivePayrollJob", mock.Anything, mock.Anything)
+	})
+
+	t.Run("RunPayroll - Draft period is rejected", func(t *testing.T) {
+		mockPayrollRepo := new(MockPayrollRepository)
+		payrollService := NewService(mockPayrollRepo, new(auth.MockEmployeeRepository))
+
+		ctx := context.Background()
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-001").Return(&PayrollPeriod{ID: "period-001", Status: PeriodDraft}, nil).Once()
+
+		_, err := payrollService.RunPayroll(ctx, "period-001", "admin-001")
+
+		assert.ErrorIs(t, err, ErrPeriodNotOpen)
+	})
+
+	t.Run("ApprovePayroll - Signs off a calculated period", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		payrollService := NewService(mockPayrollRepo, new(auth.MockEmployeeRepository))
+
+		ctx := context.Background()
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-001").Return(&PayrollPeriod{ID: "period-001", Status: PeriodCalculated}, nil).Once()
+		mockPayrollRepo.On("UpdatePayrollPeriod", ctx, periodWithStatus(PeriodApproved), PeriodCalculated).Return(true, nil).Once()
+
+		// Act
+		period, err := payrollService.ApprovePayroll(ctx, "period-001", "approver-001")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "approver-001", period.ApprovedBy)
+		assert.NotNil(t, period.ApprovedAt)
+		mockPayrollRepo.AssertExpectations(t)
+	})
+
+	t.Run("ApprovePayroll - Rejects a period that has not been calculated", func(t *testing.T) {
+		mockPayrollRepo := new(MockPayrollRepository)
+		payrollService := NewService(mockPayrollRepo, new(auth.MockEmployeeRepository))
+
+		ctx := context.Background()
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-001").Return(&PayrollPeriod{ID: "period-001", Status: PeriodOpen}, nil).Once()
+
+		_, err := payrollService.ApprovePayroll(ctx, "period-001", "approver-001")
+
+		assert.ErrorIs(t, err, ErrInvalidPeriodTransition)
+		mockPayrollRepo.AssertNotCalled(t, "UpdatePayrollPeriod", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ClosePayrollPeriod - Only paid periods can be closed", func(t *testing.T) {
+		mockPayrollRepo := new(MockPayrollRepository)
+		payrollService := NewService(mockPayrollRepo, new(auth.MockEmployeeRepository))
+
+		ctx := context.Background()
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-001").Return(&PayrollPeriod{ID: "period-001", Status: PeriodApproved}, nil).Once()
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-002").Return(&PayrollPeriod{ID: "period-002", Status: PeriodPaid}, nil).Once()
+		mockPayrollRepo.On("UpdatePayrollPeriod", ctx, periodWithStatus(PeriodClosed), PeriodPaid).Return(true, nil).Once()
+
+		_, err := payrollService.ClosePayrollPeriod(ctx, "period-001", "admin-001")
+		assert.ErrorIs(t, err, ErrInvalidPeriodTransition)
+
+		period, err := payrollService.ClosePayrollPeriod(ctx, "period-002", "admin-001")
+		assert.NoError(t, err)
+		assert.NotNil(t, period.ClosedAt)
+		mockPayrollRepo.AssertExpectations(t)
+	})
+
+	t.Run("GetPayslip - Hidden until the period is approved", func(t *testing.T) {
+		mockPayrollRepo := new(MockPayrollRepository)
+		payrollService := NewService(mockPayrollRepo, new(auth.MockEmployeeRepository))
+
+		ctx := context.Background()
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-001").Return(&PayrollPeriod{ID: "period-001", Status: PeriodCalculated}, nil).Once()
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-002").Return(&PayrollPeriod{ID: "period-002", Status: PeriodApproved}, nil).Once()
+		mockPayrollRepo.On("GetPayslip", ctx, "user-001", "period-002").Return(&Payslip{UserID: "user-001", PayrollPeriodID: "period-002"}, nil).Once()
+
+		_, err := payrollService.GetPayslip(ctx, "user-001", "period-001")
+		assert.ErrorIs(t, err, ErrPayslipNotFound)
+
+		payslip, err := payrollService.GetPayslip(ctx, "user-001", "period-002")
+		assert.NoError(t, err)
+		assert.Equal(t, "period-002", payslip.PayrollPeriodID)
+		mockPayrollRepo.AssertExpectations(t)
+	})
+
+	t.Run("GetSettlements - Only periods whose payslips are visible", func(t *testing.T) {
+		mockPayrollRepo := new(MockPayrollRepository)
+		payrollService := NewService(mockPayrollRepo, new(auth.MockEmployeeRepository))
+
+		ctx := context.Background()
+		start := time.Date(2025, 8, 5, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2025, 9, 10, 0, 0, 0, 0, time.UTC)
+		mockPayrollRepo.On("GetSettlements", ctx, "user-001", start, end).Return([]Settlement{
+			{PayrollPeriodID: "period-aug", PeriodStatus: PeriodPaid, PayslipID: "payslip-aug",
+				StartDate: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2025, 8, 31, 0, 0, 0, 0, time.UTC)},
+			{PayrollPeriodID: "period-sep", PeriodStatus: PeriodCalculated, PayslipID: "payslip-sep",
+				StartDate: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2025, 9, 30, 0, 0, 0, 0, time.UTC)},
+		}, nil).Once()
+
+		settlements, err := payrollService.GetSettlements(ctx, "user-001", start, end)
+
+		assert.NoError(t, err)
+		assert.Len(t, settlements, 1)
+		assert.Equal(t, "payslip-aug", FindSettlement(settlements, time.Date(2025, 8, 31, 0, 0, 0, 0, time.UTC)).PayslipID)
+		assert.Nil(t, FindSettlement(settlements, end), "September payslip is not approved yet")
+		mockPayrollRepo.AssertExpectations(t)
+	})
+
+	t.Run("GetSettlementsByPayslips - Only payslips that are visible", func(t *testing.T) {
+		mockPayrollRepo := new(MockPayrollRepository)
+		payrollService := NewService(mockPayrollRepo, new(auth.MockEmployeeRepository))
+
+		ctx := context.Background()
+		ids := []string{"payslip-aug", "payslip-sep"}
+		mockPayrollRepo.On("GetSettlementsByPayslips", ctx, "user-001", ids).Return([]Settlement{
+			{PayrollPeriodID: "period-aug", PeriodStatus: PeriodApproved, PayslipID: "payslip-aug"},
+			{PayrollPeriodID: "period-sep", PeriodStatus: PeriodCalculated, PayslipID: "payslip-sep"},
+		}, nil).Once()
+
+		settlements, err := payrollService.GetSettlementsByPayslips(ctx, "user-001", ids)
+
+		assert.NoError(t, err)
+		if assert.Len(t, settlements, 1) {
+			assert.Equal(t, "payslip-aug", settlements[0].PayslipID)
+		}
+		mockPayrollRepo.AssertExpectations(t)
+	})
+}
+
+func TestPayrollPeriods(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+
+	t.Run("CreatePayrollPeriod - Warns about gaps", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		payrollService := NewService(mockPayrollRepo, new(auth.MockEmployeeRepository))
+
+		ctx := context.Background()
+		start, end := day("2025-09-01"), day("2025-09-30")
+		prev := &PayrollPeriod{ID: "period-aug", StartDate: day("2025-08-01"), EndDate: day("2025-08-29")}
+		mockPayrollRepo.On("LockPayrollPeriods", ctx).Return(nil).Once()
+		mockPayrollRepo.On("GetOverlappingPeriods", ctx, start, end).Return([]PayrollPeriod{}, nil).Once()
+		mockPayrollRepo.On("GetAdjacentPeriods", ctx, start, end).Return(prev, nil, nil).Once()
+		mockPayrollRepo.On("CreatePayrollPeriod", ctx, mock.MatchedBy(func(p *PayrollPeriod) bool {
+			return p.Type == PeriodMonthly && p.Status == PeriodDraft
+		})).Return(nil).Once()
+
+		// Act
+		period, warnings, err := payrollService.CreatePayrollPeriod(ctx, PeriodMonthly, start, end, "admin-001")
+
+		// Assert: 30 dan 31 Agustus tidak tercakup periode mana pun.
+		assert.NoError(t, err)
+		assert.Equal(t, "admin-001", period.CreatedBy)
+		if assert.Len(t, warnings, 1) {
+			assert.Equal(t, WarningGapBefore, warnings[0].Code)
+			assert.Contains(t, warnings[0].Message, "2 day(s)")
+		}
+		mockPayrollRepo.AssertExpectations(t)
+	})
+
+	t.Run("CreatePayrollPeriod - Rejects overlapping periods", func(t *testing.T) {
+		mockPayrollRepo := new(MockPayrollRepository)
+		payrollService := NewService(mockPayrollRepo, new(auth.MockEmployeeRepository))
+
+		ctx := context.Background()
+		start, end := day("2025-09-15"), day("2025-10-14")
+		existing := PayrollPeriod{ID: "period-sep", StartDate: day("2025-09-01"), EndDate: day("2025-09-30")}
+		mockPayrollRepo.On("LockPayrollPeriods", ctx).Return(nil).Once()
+		mockPayrollRepo.On("GetOverlappingPeriods", ctx, start, end).Return([]PayrollPeriod{existing}, nil).Once()
+
+		_, _, err := payrollService.CreatePayrollPeriod(ctx, PeriodMonthly, start, end, "admin-001")
+
+		assert.ErrorIs(t, err, ErrPeriodOverlap)
+		assert.Contains(t, err.Error(), "period-sep")
+		mockPayrollRepo.AssertNotCalled(t, "CreatePayrollPeriod", mock.Anything, mock.Anything)
+	})
+
+	t.Run("CreatePayrollPeriod - Validates length by type", func(t *testing.T) {
+		mockPayrollRepo := new(MockPayrollRepository) // mock tidak akan dipanggil
+		payrollService := NewService(mockPayrollRepo, new(auth.MockEmployeeRepository))
+		ctx := context.Background()
+
+		_, _, err := payrollService.CreatePayrollPeriod(ctx, PeriodWeekly, day("2025-09-01"), day("2025-09-10"), "admin-001")
+		assert.ErrorIs(t, err, ErrInvalidPeriod)
+
+		_, _, err = payrollService.CreatePayrollPeriod(ctx, "biweekly", day("2025-09-01"), day("2025-09-14"), "admin-001")
+		assert.ErrorIs(t, err, ErrInvalidPeriod)
+
+		_, _, err = payrollService.CreatePayrollPeriod(ctx, PeriodOffCycle, day("2025-09-10"), day("2025-09-01"), "admin-001")
+		assert.ErrorIs(t, err, ErrInvalidPeriod)
+	})
+
+	t.Run("GeneratePayrollPeriods - Monthly calendar starting on the 26th", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		payrollService := NewService(mockPayrollRepo, new(auth.MockEmployeeRepository))
+
+		ctx := context.Background()
+		prev := &PayrollPeriod{ID: "period-2025-12", StartDate: day("2025-11-26"), EndDate: day("2025-12-25")}
+		next := &PayrollPeriod{ID: "period-2027-01", StartDate: day("2027-01-01"), EndDate: day("2027-01-31")}
+		mockPayrollRepo.On("LockPayrollPeriods", ctx).Return(nil).Once()
+		mockPayrollRepo.On("GetOverlappingPeriods", ctx, mock.Anything, mock.Anything).Return([]PayrollPeriod{}, nil).Times(12)
+		// Setiap periode melihat periode Desember 2025 dan Januari 2027 sebagai
+		// tetangga karena periode lain belum tersimpan di mock.
+		mockPayrollRepo.On("GetAdjacentPeriods", ctx, mock.Anything, mock.Anything).Return(prev, next, nil).Times(12)
+		mockPayrollRepo.On("CreatePayrollPeriod", ctx, mock.Anything).Return(nil).Times(12)
+
+		// Act
+		periods, warnings, err := payrollService.GeneratePayrollPeriods(ctx, PayCalendar{Year: 2026, Type: PeriodMonthly, StartDay: 26}, "admin-001")
+
+		// Assert
+		assert.NoError(t, err)
+		if assert.Len(t, periods, 12) {
+			assert.Equal(t, day("2025-12-26"), periods[0].StartDate)
+			assert.Equal(t, day("2026-01-25"), periods[0].EndDate)
+			assert.Equal(t, day("2026-02-26"), periods[2].StartDate)
+			assert.Equal(t, day("2026-03-25"), periods[2].EndDate)
+			assert.Equal(t, day("2026-12-25"), periods[11].EndDate)
+		}
+		// Hanya celah setelah periode terakhir (26-31 Desember 2026) yang dilaporkan.
+		if assert.Len(t, warnings, 1) {
+			assert.Equal(t, WarningGapAfter, warnings[0].Code)
+			assert.Contains(t, warnings[0].Message, "6 day(s)")
+		}
+		mockPayrollRepo.AssertExpectations(t)
+	})
+
+	t.Run("GeneratePayrollPeriods - Stops on overlap", func(t *testing.T) {
+		mockPayrollRepo := new(MockPayrollRepository)
+		payrollService := NewService(mockPayrollRepo, new(auth.MockEmployeeRepository))
+
+		ctx := context.Background()
+		existing := PayrollPeriod{ID: "period-jan", StartDate: day("2026-01-01"), EndDate: day("2026-01-31")}
+		mockPayrollRepo.On("LockPayrollPeriods", ctx).Return(nil).Once()
+		mockPayrollRepo.On("GetOverlappingPeriods", ctx, day("2026-01-01"), day("2026-01-15")).Return([]PayrollPeriod{existing}, nil).Once()
+
+		periods, _, err := payrollService.GeneratePayrollPeriods(ctx, PayCalendar{Year: 2026, Type: PeriodSemiMonthly}, "admin-001")
+
+		assert.ErrorIs(t, err, ErrPeriodOverlap)
+		assert.Nil(t, periods)
+		mockPayrollRepo.AssertNotCalled(t, "CreatePayrollPeriod", mock.Anything, mock.Anything)
+	})
+}
+
+func TestPayCalendar(t *testing.T) {
+	t.Run("Semi-monthly periods cover every day of the year", func(t *testing.T) {
+		periods, err := PayCalendar{Year: 2028, Type: PeriodSemiMonthly}.Periods()
+
+		assert.NoError(t, err)
+		assert.Len(t, periods, 24)
+		for i := 1; i < len(periods); i++ {
+			assert.Equal(t, periods[i-1][1].AddDate(0, 0, 1), periods[i][0])
+		}
+		// 2028 adalah tahun kabisat.
+		assert.Equal(t, time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC), periods[3][1])
+	})
+
+	t.Run("Semi-monthly split day must leave a 13-16 day second half", func(t *testing.T) {
+		// 16 hanya valid pada tahun kabisat: Februari 2028 = 17-29 (13 hari).
+		periods, err := PayCalendar{Year: 2028, Type: PeriodSemiMonthly, SplitDay: 16}.Periods()
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2028, time.February, 17, 0, 0, 0, 0, time.UTC), periods[3][0])
+
+		// Februari 2026 = 17-28 (12 hari).
+		_, err = PayCalendar{Year: 2026, Type: PeriodSemiMonthly, SplitDay: 16}.Periods()
+		assert.ErrorIs(t, err, ErrInvalidPeriod)
+		assert.ErrorContains(t, err, "February 2026 with a 12-day second half")
+
+		// Januari = 15-31 (17 hari).
+		_, err = PayCalendar{Year: 2026, Type: PeriodSemiMonthly, SplitDay: 14}.Periods()
+		assert.ErrorContains(t, err, "January 2026 with a 17-day second half")
+	})
+
+	t.Run("Weekly periods start on the first Monday", func(t *testing.T) {
+		periods, err := PayCalendar{Year: 2026, Type: PeriodWeekly}.Periods()
+
+		assert.NoError(t, err)
+		assert.Len(t, periods, 52)
+		assert.Equal(t, time.Date(2026, time.January, 5, 0, 0, 0, 0, time.UTC), periods[0][0])
+		assert.Equal(t, time.Date(2026, time.January, 11, 0, 0, 0, 0, time.UTC), periods[0][1])
+	})
+
+	t.Run("Invalid definitions", func(t *testing.T) {
+		for _, c := range []PayCalendar{
+			{Type: PeriodMonthly},
+			{Year: 2026, Type: PeriodMonthly, StartDay: 29},
+			{Year: 2026, Type: PeriodSemiMonthly, SplitDay: 20},
+			{Year: 2026, Type: PeriodOffCycle},
+		} {
+			_, err := c.Periods()
+			assert.ErrorIs(t, err, ErrInvalidPeriod)
+		}
+	})
+}
+
+func TestWorker(t *testing.T) {
+	cfg := WorkerConfig{PollInterval: time.Millisecond, LeaseDuration: time.Minute, MaxAttempts: 3}
+
+	t.Run("Empty queue", func(t *testing.T) {
+		mockPayrollRepo := new(MockPayrollRepository)
+		worker := NewWorker(mockPayrollRepo, NewService(mockPayrollRepo, new(auth.MockEmployeeRepository)), cfg)
+
+		ctx := context.Background()
+		mockPayrollRepo.On("ClaimNextPayrollJob", ctx, mock.Anything, mock.Anything).Return(nil, nil).Once()
+
+		processed, err := worker.RunNext(ctx)
+
+		assert.NoError(t, err)
+		assert.False(t, processed)
+	})
+
+	t.Run("Job exceeding max attempts is failed", func(t *testing.T) {
+		mockPayrollRepo := new(MockPayrollRepository)
+		worker := NewWorker(mockPayrollRepo, NewService(mockPayrollRepo, new(auth.MockEmployeeRepository)), cfg)
+
+		ctx := context.Background()
+		job := &PayrollJob{ID: "job-001", PayrollPeriodID: "period-001", Status: JobRunning, Attempts: 4}
+		mockPayrollRepo.On("ClaimNextPayrollJob", ctx, mock.Anything, mock.Anything).Return(job, nil).Once()
+		mockPayrollRepo.On("FinishPayrollJob", ctx, job.ID, JobFailed, mock.Anything).Return(nil).Once()
+
+		processed, err := worker.RunNext(ctx)
+
+		assert.NoError(t, err)
+		assert.True(t, processed)
+		mockPayrollRepo.AssertExpectations(t)
+		mockPayrollRepo.AssertNotCalled(t, "GetPayrollPeriod", mock.Anything, mock.Anything)
+	})
+}
+
+func TestProration(t *testing.T) {
+	t.Run("ParseProrationPolicy", func(t *testing.T) {
+		p, err := ParseProrationPolicy("fixed_divisor:21")
+		assert.NoError(t, err)
+		assert.Equal(t, ProrationPolicy{Method: ProrationFixedDivisor, Divisor: 21}, p)
+		assert.Equal(t, "fixed_divisor:21", p.String())
+
+		for _, invalid := range []string{"fixed_divisor", "working_days:22", "absence_deduction:x", "hourly"} {
+			_, err := ParseProrationPolicy(invalid)
+			assert.ErrorIs(t, err, ErrInvalidProration, invalid)
+		}
+	})
+
+	t.Run("PreviewPayroll - Policy per pay group applies to daily and hourly rates", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		mockEmployeeRepo := new(auth.MockEmployeeRepository)
+		payrollService := NewService(mockPayrollRepo, mockEmployeeRepo, WithProration(DefaultProrationPolicy, map[string]ProrationPolicy{
+			"staff":    {Method: ProrationCalendarDays},
+			"operator": {Method: ProrationFixedDivisor, Divisor: 21},
+			"office":   {Method: ProrationAbsenceDeduction},
+		}))
+
+		ctx := context.Background()
+		// Juni 2026: 30 hari kalender, 22 hari kerja. Semua karyawan hadir 20
+		// hari (2 hari tidak hadir) dan lembur 2 jam.
+		startDate, _ := time.Parse("2006-01-02", "2026-06-01")
+		endDate, _ := time.Parse("2006-01-02", "2026-06-30")
+		salary := money.FromMajor(6600000, money.IDR)
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-001").Return(&PayrollPeriod{ID: "period-001", StartDate: startDate, EndDate: endDate, Status: PeriodOpen}, nil).Once()
+		mockEmployeeRepo.On("GetAllEmployees", ctx).Return([]employee.Employee{
+			{ID: "user-001", BaseSalary: salary, PayGroup: "unknown"},
+			{ID: "user-002", BaseSalary: salary, PayGroup: "staff"},
+			{ID: "user-003", BaseSalary: salary, PayGroup: "operator"},
+			{ID: "user-004", BaseSalary: salary, PayGroup: "office"},
+		}, nil).Once()
+		mockPayrollRepo.On("GetAttendances", ctx, mock.Anything, startDate, endDate).Return(make([]attendance.Attendance, 20), nil).Times(4)
+		mockPayrollRepo.On("GetOvertimes", ctx, mock.Anything, endDate).Return([]overtime.Overtime{{Hours: 2}}, nil).Times(4)
+		mockPayrollRepo.On("GetReimbursements", ctx, mock.Anything, endDate).Return([]reimbursement.Reimbursement{}, nil).Times(4)
+
+		// Act
+		preview, err := payrollService.PreviewPayroll(ctx, "period-001", nil)
+
+		// Assert
+		assert.NoError(t, err)
+		if !assert.Len(t, preview.Payslips, 4) {
+			return
+		}
+		for i, want := range []struct {
+			basic, absence, overtime money.Money
+			description              string
+		}{
+			// working_days (kelompok tidak terdaftar): 6,6jt x 20/22; lembur 2 x 2 x 6,6jt/(22x8).
+			{money.FromMajor(6000000, money.IDR), money.Zero(money.IDR), money.FromMajor(150000, money.IDR), "Gaji pokok (20 dari 22 hari kerja)"},
+			// calendar_days: 6,6jt x 28/30; lembur 2 x 2 x 6,6jt/(30x8).
+			{money.FromMajor(6160000, money.IDR), money.Zero(money.IDR), money.FromMajor(110000, money.IDR), "Gaji pokok (28 dari 30 hari kalender)"},
+			// fixed_divisor:21: 6,6jt x 20/21; lembur 2 x 2 x 6,6jt/(21x8).
+			{money.FromMajor(6285714, money.IDR), money.Zero(money.IDR), money.FromMajor(157143, money.IDR), "Gaji pokok (20 hari hadir, pembagi 21)"},
+			// absence_deduction: gaji penuh dipotong 2 x 6,6jt/22.
+			{salary, money.FromMajor(600000, money.IDR), money.FromMajor(150000, money.IDR), "Gaji pokok"},
+		} {
+			payslip := preview.Payslips[i]
+			assert.Equal(t, want.description, payslip.Lines[0].Description, payslip.UserID)
+			assert.True(t, payslip.SumLines(LineEarning, CodeBasicSalary).Equal(want.basic), "%s basic: got %s", payslip.UserID, payslip.SumLines(LineEarning, CodeBasicSalary))
+			assert.True(t, payslip.SumLines(LineDeduction, CodeAbsenceDeduction).Equal(want.absence), "%s absence: got %s", payslip.UserID, payslip.SumLines(LineDeduction, CodeAbsenceDeduction))
+			assert.True(t, payslip.SumLines(LineEarning, CodeOvertime).Equal(want.overtime), "%s overtime: got %s", payslip.UserID, payslip.SumLines(LineEarning, CodeOvertime))
+		}
+	})
+
+	t.Run("TaxableIncome - Absence deduction reduces gross income", func(t *testing.T) {
+		payslip := &Payslip{BaseSalary: money.FromMajor(6600000, money.IDR)}
+		for _, line := range (ProrationPolicy{Method: ProrationAbsenceDeduction, Divisor: 22}).salaryLines(payslip.BaseSalary, fullMonth, prorationDays{Working: 22, Calendar: 30, Attended: 20}, money.DefaultRounding) {
+			payslip.AddLine(line)
+		}
+
+		assert.True(t, payslip.TaxableIncome().Equal(money.FromMajor(6000000, money.IDR)), "got %s", payslip.TaxableIncome())
+	})
+}
+
+func TestPeriodShare(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	idr := func(v int64) money.Money { return money.FromMajor(v, money.IDR) }
+
+	// Gaji 5,2jt dengan pembagi tetap 21 dan tunjangan tetap 520rb per bulan;
+	// karyawan hadir setiap hari kerja periode. Pembagi tetap adalah hari per
+	// bulan sehingga periode yang bukan bulanan memakai hari kerja periode.
+	tests := []struct {
+		name                string
+		periodType          PeriodType
+		start, end          string
+		workingDays         int
+		basic, jht, housing money.Money
+	}{
+		// 12/52 bulan: 5,2jt -> 1,2jt; JHT 2% x 5,2jt = 104rb -> 24rb.
+		{"Weekly period pays 12/52 of the month", PeriodWeekly, "2026-06-01", "2026-06-07", 5, idr(1200000), idr(24000), idr(120000)},
+		{"Semi-monthly period pays half the month", PeriodSemiMonthly, "2026-06-01", "2026-06-15", 11, idr(2600000), idr(52000), idr(260000)},
+		// Off-cycle 3 hari di bulan 30 hari: 3/30 bulan.
+		{"Off-cycle period pays its days of the month", PeriodOffCycle, "2026-06-01", "2026-06-03", 3, idr(520000), idr(10400), idr(52000)},
+	}
+
+	for _, tt := range tests {
+		t.Run("PreviewPayroll - "+tt.name, func(t *testing.T) {
+			// Arrange
+			mockPayrollRepo := new(MockPayrollRepository)
+			mockEmployeeRepo := new(auth.MockEmployeeRepository)
+			mockComponentRepo := new(MockPayComponentRepository)
+			payrollService := NewService(mockPayrollRepo, mockEmployeeRepo,
+				WithPayComponents(mockComponentRepo),
+				WithProration(ProrationPolicy{Method: ProrationFixedDivisor, Divisor: 21}, nil))
+
+			ctx := context.Background()
+			startDate, endDate := day(tt.start), day(tt.end)
+			period := &PayrollPeriod{ID: "period-001", Type: tt.periodType, StartDate: startDate, EndDate: endDate, Status: PeriodOpen}
+			mockPayrollRepo.On("GetPayrollPeriod", ctx, period.ID).Return(period, nil).Once()
+			mockEmployeeRepo.On("GetAllEmployees", ctx).Return([]employee.Employee{
+				{ID: "user-001", BaseSalary: idr(5200000)},
+			}, nil).Once()
+			mockPayrollRepo.On("GetAttendances", ctx, "user-001", startDate, endDate).Return(make([]attendance.Attendance, tt.workingDays), nil).Once()
+			mockPayrollRepo.On("GetOvertimes", ctx, "user-001", endDate).Return([]overtime.Overtime{}, nil).Once()
+			mockPayrollRepo.On("GetReimbursements", ctx, "user-001", endDate).Return([]reimbursement.Reimbursement{}, nil).Once()
+			mockComponentRepo.On("GetActiveAssignments", ctx, "user-001", startDate, endDate).Return([]paycomponent.Assignment{
+				{Component: paycomponent.Component{
+					Code: "HOUSING", Name: "Tunjangan Perumahan", Kind: paycomponent.KindEarning, Taxable: true,
+					CalculationType: paycomponent.CalculationFixed, Amount: idr(520000),
+				}},
+			}, nil).Once()
+
+			// Act
+			preview, err := payrollService.PreviewPayroll(ctx, period.ID, nil)
+
+			// Assert
+			assert.NoError(t, err)
+			if !assert.Len(t, preview.Payslips, 1) {
+				return
+			}
+			payslip := preview.Payslips[0]
+			assert.Equal(t, fmt.Sprintf("Gaji pokok (%d dari %d hari kerja)", tt.workingDays, tt.workingDays), payslip.Lines[0].Description)
+			assert.True(t, payslip.SumLines(LineEarning, CodeBasicSalary).Equal(tt.basic), "basic: got %s", payslip.SumLines(LineEarning, CodeBasicSalary))
+			assert.True(t, payslip.SumLines(LineDeduction, CodeBPJSPrefix+string(bpjs.ProgramJHT)).Equal(tt.jht), "JHT: got %s", payslip.SumLines(LineDeduction, CodeBPJSPrefix+string(bpjs.ProgramJHT)))
+			assert.True(t, payslip.SumLines(LineEarning, "HOUSING").Equal(tt.housing), "housing: got %s", payslip.SumLines(LineEarning, "HOUSING"))
+			mockComponentRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTaxPeriods(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	idr := func(v int64) money.Money { return money.FromMajor(v, money.IDR) }
+
+	tests := []struct {
+		name       string
+		periodType PeriodType
+		start, end string
+		num, den   int64 // Porsi bulan untuk TER; nol jika periode menghitung ulang pajak setahun
+		previous   int
+		months     []time.Month
+		wantMonths int
+	}{
+		{name: "Semi-monthly first half of December uses TER", periodType: PeriodSemiMonthly, start: "2026-12-01", end: "2026-12-15", num: 1, den: 2},
+		{name: "Weekly period before the last week of the year uses TER", periodType: PeriodWeekly, start: "2026-12-14", end: "2026-12-20", num: 12, den: 52},
+		{name: "Off-cycle period in December uses TER", periodType: PeriodOffCycle, start: "2026-12-28", end: "2026-12-31", num: 4, den: 31},
+		// Bergabung November: tiga payslip sebelumnya dalam dua bulan, sehingga
+		// batas biaya jabatan dihitung untuk dua bulan, bukan empat periode.
+		{
+			name: "Semi-monthly second half of December counts distinct months", periodType: PeriodSemiMonthly, start: "2026-12-16", end: "2026-12-31",
+			previous: 3, months: []time.Month{time.November, time.December}, wantMonths: 2,
+		},
+		// 28 Desember - 3 Januari berakhir di tahun berikutnya.
+		{
+			name: "Last weekly period of the year trues up", periodType: PeriodWeekly, start: "2026-12-21", end: "2026-12-27",
+			previous: 2, months: []time.Month{time.December}, wantMonths: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("PreviewPayroll - "+tt.name, func(t *testing.T) {
+			// Arrange
+			mockPayrollRepo := new(MockPayrollRepository)
+			mockEmployeeRepo := new(auth.MockEmployeeRepository)
+			mockTax := new(MockTaxService)
+			payrollService := NewService(mockPayrollRepo, mockEmployeeRepo, WithTaxService(mockTax))
+
+			ctx := context.Background()
+			startDate, endDate := day(tt.start), day(tt.end)
+			period := &PayrollPeriod{ID: "period-001", Type: tt.periodType, StartDate: startDate, EndDate: endDate, Status: PeriodOpen}
+			mockPayrollRepo.On("GetPayrollPeriod", ctx, period.ID).Return(period, nil).Once()
+			mockEmployeeRepo.On("GetAllEmployees", ctx).Return([]employee.Employee{
+				{ID: "user-001", BaseSalary: idr(5200000), TaxStatus: "TK/0"},
+			}, nil).Once()
+			mockPayrollRepo.On("GetAttendances", ctx, "user-001", startDate, endDate).Return([]attendance.Attendance{}, nil).Once()
+			mockPayrollRepo.On("GetOvertimes", ctx, "user-001", endDate).Return([]overtime.Overtime{}, nil).Once()
+			mockPayrollRepo.On("GetReimbursements", ctx, "user-001", endDate).Return([]reimbursement.Reimbursement{}, nil).Once()
+			if tt.den > 0 {
+				mockTax.On("PeriodWithholding", tax.StatusTK0, mock.Anything, tt.num, tt.den).Return(idr(0), nil).Once()
+			} else {
+				mockPayrollRepo.On("GetYearToDatePayslips", ctx, "user-001", startDate).Return(make([]Payslip, tt.previous), nil).Once()
+				mockPayrollRepo.On("GetYearToDateMonths", ctx, "user-001", startDate).Return(tt.months, nil).Once()
+				mockTax.On("DecemberWithholding", tax.StatusTK0, mock.MatchedBy(func(in tax.AnnualIncome) bool {
+					return in.Months == tt.wantMonths
+				})).Return(idr(0), nil).Once()
+			}
+
+			// Act
+			_, err := payrollService.PreviewPayroll(ctx, period.ID, nil)
+
+			// Assert
+			assert.NoError(t, err)
+			mockPayrollRepo.AssertExpectations(t)
+			mockTax.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStatutoryOvertime(t *testing.T) {
+	t.Run("ParseOvertimeMethod", func(t *testing.T) {
+		m, err := ParseOvertimeMethod("statutory")
+		assert.NoError(t, err)
+		assert.Equal(t, OvertimeStatutory, m)
+
+		_, err = ParseOvertimeMethod("triple")
+		assert.ErrorIs(t, err, ErrInvalidOvertimePolicy)
+	})
+
+	t.Run("PreviewPayroll - Tiered rates for workdays, rest days and holidays", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		mockEmployeeRepo := new(auth.MockEmployeeRepository)
+		mockHolidays := new(MockHolidayCalendar)
+		payrollService := NewService(mockPayrollRepo, mockEmployeeRepo,
+			WithOvertimeMethod(OvertimeStatutory),
+			WithHolidayCalendar(mockHolidays),
+		)
+
+		ctx := context.Background()
+		day := func(s string) time.Time {
+			d, _ := time.Parse("2006-01-02", s)
+			return d
+		}
+		startDate, endDate := day("2026-06-01"), day("2026-06-30")
+		// Gaji 17,3jt: upah sejam 1/173 = 100rb.
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-001").Return(&PayrollPeriod{ID: "period-001", StartDate: startDate, EndDate: endDate, Status: PeriodOpen}, nil).Once()
+		mockEmployeeRepo.On("GetAllEmployees", ctx).Return([]employee.Employee{
+			{ID: "user-001", BaseSalary: money.FromMajor(17300000, money.IDR)},
+		}, nil).Once()
+		mockHolidays.On("HolidaysBetween", ctx, startDate, endDate, "").Return([]holiday.Holiday{{Date: day("2026-06-03"), Name: "Libur", Type: holiday.TypeNational}}, nil).Once()
+		mockPayrollRepo.On("GetAttendances", ctx, "user-001", startDate, endDate).Return(make([]attendance.Attendance, 20), nil).Once()
+		mockPayrollRepo.On("GetOvertimes", ctx, "user-001", endDate).Return([]overtime.Overtime{
+			{Date: day("2026-06-01"), Hours: 2}, // Senin, dua pengajuan dijumlahkan: 3 jam
+			{Date: day("2026-06-01"), Hours: 1},
+			{Date: day("2026-06-02"), Hours: 1},  // Selasa
+			{Date: day("2026-06-03"), Hours: 2},  // Rabu, hari libur
+			{Date: day("2026-06-06"), Hours: 10}, // Sabtu
+		}, nil).Once()
+		mockPayrollRepo.On("GetReimbursements", ctx, "user-001", endDate).Return([]reimbursement.Reimbursement{}, nil).Once()
+
+		// Act
+		preview, err := payrollService.PreviewPayroll(ctx, "period-001", nil)
+
+		// Assert
+		assert.NoError(t, err)
+		if !assert.Len(t, preview.Payslips, 1) {
+			return
+		}
+		var got []PayslipLine
+		for _, l := range preview.Payslips[0].Lines {
+			if l.Code == CodeOvertime {
+				got = append(got, l)
+			}
+		}
+		want := []struct {
+			description string
+			quantity    float64
+			amount      int64
+		}{
+			{"Lembur hari kerja jam ke-1 (1,5x)", 2, 300000},
+			{"Lembur hari kerja jam ke-2 dst (2x)", 2, 400000},
+			{"Lembur hari libur jam ke-1 s.d. 8 (2x)", 10, 2000000},
+			{"Lembur hari libur jam ke-9 (3x)", 1, 300000},
+			{"Lembur hari libur jam ke-10 dst (4x)", 1, 400000},
+		}
+		if assert.Len(t, got, len(want)) {
+			for i, w := range want {
+				assert.Equal(t, w.description, got[i].Description)
+				assert.Equal(t, w.quantity, got[i].Quantity, w.description)
+				assert.True(t, got[i].Amount.Equal(money.FromMajor(w.amount, money.IDR)), "%s: got %s", w.description, got[i].Amount)
+			}
+		}
+		assert.True(t, preview.Payslips[0].SumLines(LineEarning, CodeOvertime).Equal(money.FromMajor(3400000, money.IDR)))
+	})
+
+	t.Run("PreviewPayroll - Overtime approved after its period uses the rate of its own date", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		mockEmployeeRepo := new(auth.MockEmployeeRepository)
+		mockHolidays := new(MockHolidayCalendar)
+		payrollService := NewService(mockPayrollRepo, mockEmployeeRepo,
+			WithOvertimeMethod(OvertimeStatutory),
+			WithHolidayCalendar(mockHolidays),
+		)
+
+		ctx := context.Background()
+		day := func(s string) time.Time {
+			d, _ := time.Parse("2006-01-02", s)
+			return d
+		}
+		startDate, endDate := day("2026-06-01"), day("2026-06-30")
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-002").Return(&PayrollPeriod{ID: "period-002", StartDate: startDate, EndDate: endDate, Status: PeriodOpen}, nil).Once()
+		mockEmployeeRepo.On("GetAllEmployees", ctx).Return([]employee.Employee{
+			{ID: "user-001", BaseSalary: money.FromMajor(17300000, money.IDR)},
+		}, nil).Once()
+		mockHolidays.On("HolidaysBetween", ctx, startDate, endDate, "").Return([]holiday.Holiday{}, nil).Once()
+		mockPayrollRepo.On("GetAttendances", ctx, "user-001", startDate, endDate).Return(make([]attendance.Attendance, 22), nil).Once()
+		// Lembur Mei yang baru disetujui setelah periode Mei dihitung.
+		mockPayrollRepo.On("GetOvertimes", ctx, "user-001", endDate).Return([]overtime.Overtime{
+			{ID: "ot-001", Date: day("2026-05-14"), Hours: 2}, // Kamis, hari libur
+			{ID: "ot-002", Date: day("2026-06-02"), Hours: 1}, // Selasa
+		}, nil).Once()
+		mockHolidays.On("HolidaysBetween", ctx, day("2026-05-14"), day("2026-05-31"), "").Return([]holiday.Holiday{{Date: day("2026-05-14"), Name: "Kenaikan", Type: holiday.TypeNational}}, nil).Once()
+		mockPayrollRepo.On("GetReimbursements", ctx, "user-001", endDate).Return([]reimbursement.Reimbursement{}, nil).Once()
+
+		// Act
+		preview, err := payrollService.PreviewPayroll(ctx, "period-002", nil)
+
+		// Assert
+		assert.NoError(t, err)
+		mockHolidays.AssertExpectations(t)
+		if !assert.Len(t, preview.Payslips, 1) {
+			return
+		}
+		payslip := preview.Payslips[0]
+		var descriptions []string
+		for _, l := range payslip.Lines {
+			if l.Code == CodeOvertime {
+				descriptions = append(descriptions, l.Description)
+			}
+		}
+		assert.Equal(t, []string{"Lembur hari kerja jam ke-1 (1,5x)", "Lembur hari libur jam ke-1 s.d. 8 (2x)"}, descriptions)
+		// 1 jam x 1,5 x 100rb + 2 jam x 2 x 100rb
+		assert.True(t, payslip.SumLines(LineEarning, CodeOvertime).Equal(money.FromMajor(550000, money.IDR)))
+		assert.Equal(t, []string{"ot-001", "ot-002"}, payslip.OvertimeIDs)
+	})
+
+	t.Run("PreviewPayroll - Roster overrides working days and overtime rates", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		mockEmployeeRepo := new(auth.MockEmployeeRepository)
+		mockRoster := new(MockRoster)
+		payrollService := NewService(mockPayrollRepo, mockEmployeeRepo,
+			WithOvertimeMethod(OvertimeStatutory),
+			WithRoster(mockRoster),
+		)
+
+		ctx := context.Background()
+		day := func(s string) time.Time {
+			d, _ := time.Parse("2006-01-02", s)
+			return d
+		}
+		// Senin-Minggu: Sabtu dan Minggu diroster shift, Rabu diroster libur.
+		startDate, endDate := day("2026-06-01"), day("2026-06-07")
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-001").Return(&PayrollPeriod{ID: "period-001", StartDate: startDate, EndDate: endDate, Status: PeriodOpen}, nil).Once()
+		mockEmployeeRepo.On("GetAllEmployees", ctx).Return([]employee.Employee{
+			{ID: "user-001", BaseSalary: money.FromMajor(17300000, money.IDR)},
+		}, nil).Once()
+		mockRoster.On("GetRoster", ctx, "user-001", startDate, endDate).Return([]shift.RosterEntry{
+			{Date: day("2026-06-03")},
+			{Date: day("2026-06-06"), ShiftID: "shift-001"},
+			{Date: day("2026-06-07"), ShiftID: "shift-001"},
+		}, nil).Once()
+		mockPayrollRepo.On("GetAttendances", ctx, "user-001", startDate, endDate).Return(make([]attendance.Attendance, 6), nil).Once()
+		mockPayrollRepo.On("GetOvertimes", ctx, "user-001", endDate).Return([]overtime.Overtime{
+			{Date: day("2026-06-03"), Hours: 1}, // Rabu, libur roster
+			{Date: day("2026-06-06"), Hours: 1}, // Sabtu, shift roster
+		}, nil).Once()
+		mockPayrollRepo.On("GetReimbursements", ctx, "user-001", endDate).Return([]reimbursement.Reimbursement{}, nil).Once()
+
+		// Act
+		preview, err := payrollService.PreviewPayroll(ctx, "period-001", nil)
+
+		// Assert
+		assert.NoError(t, err)
+		if !assert.Len(t, preview.Payslips, 1) {
+			return
+		}
+		lines := preview.Payslips[0].Lines
+		assert.Equal(t, "Gaji pokok (6 dari 6 hari kerja)", lines[0].Description)
+		var got []string
+		for _, l := range lines {
+			if l.Code == CodeOvertime {
+				got = append(got, l.Description)
+			}
+		}
+		assert.Equal(t, []string{"Lembur hari kerja jam ke-1 (1,5x)", "Lembur hari libur jam ke-1 s.d. 8 (2x)"}, got)
+		mockRoster.AssertExpectations(t)
+	})
+}
+
+func TestLeave(t *testing.T) {
+	t.Run("PreviewPayroll - Paid leave is paid and unpaid leave is deducted", func(t *testing.T) {
+		// Arrange
+		mockPayrollRepo := new(MockPayrollRepository)
+		mockEmployeeRepo := new(auth.MockEmployeeRepository)
+		mockLeaves := new(MockLeaves)
+		payrollService := NewService(mockPayrollRepo, mockEmployeeRepo, WithLeaves(mockLeaves))
+
+		ctx := context.Background()
+		day := func(s string) time.Time {
+			d, _ := time.Parse("2006-01-02", s)
+			return d
+		}
+		// Senin-Jumat: hadir Senin dan Selasa, cuti tahunan Selasa-Rabu,
+		// cuti tidak berbayar Kamis, Jumat tidak hadir.
+		startDate, endDate := day("2026-06-01"), day("2026-06-05")
+		mockPayrollRepo.On("GetPayrollPeriod", ctx, "period-001").Return(&PayrollPeriod{ID: "period-001", StartDate: startDate, EndDate: endDate, Status: PeriodOpen}, nil).Once()
+		mockEmployeeRepo.On("GetAllEmployees", ctx).Return([]employee.Employee{
+			{ID: "user-001", BaseSalary: money.FromMajor(5000000, money.IDR)},
+		}, nil).Once()
+		mockPayrollRepo.On("GetAttendances", ctx, "user-001", startDate, endDate).Return([]attendance.Attendance{
+			{Date: day("2026-06-01")}, {Date: day("2026-06-02")},
+		}, nil).Once()
+		mockLeaves.On("GetApprovedRequests", ctx, "user-001", startDate, endDate).Return([]leave.Request{
+			{StartDate: day("2026-06-02"), EndDate: day("2026-06-03"), Paid: true},
+			{StartDate: day("2026-06-04"), EndDate: day("2026-06-04"), Paid: false},
+		}, nil).Once()
+		mockPayrollRepo.On("GetOvertimes", ctx, "user-001", endDate).Return([]overtime.Overtime{}, nil).Once()
+		mockPayrollRepo.On("GetReimbursements", ctx, "user-001", endDate).Return([]reimbursement.Reimbursement{}, nil).Once()
+
+		// Act
+		preview, err := payrollService.PreviewPayroll(ctx, "period-001", nil)
+
+		// Assert
+		assert.NoError(t, err)
+		if !assert.Len(t, preview.Payslips, 1) {
+			return
+		}
+		lines := preview.Payslips[0].Lines
+		assert.Equal(t, "Gaji pokok (4 dari 5 hari kerja)", lines[0].Description)
+		assert.Equal(t, money.FromMajor(4000000, money.IDR), lines[0].Amount)
+		assert.Equal(t, CodeUnpaidLeave, lines[1].Code)
+		assert.Equal(t, LineDeduction, lines[1].Type)
+		assert.Equal(t, money.FromMajor(1000000, money.IDR), lines[1].Amount)
+		mockLeaves.AssertExpectations(t)
+	})
+
+	// Juni 2026 dengan gaji 6,6jt: setiap metode membatasi potongan cuti tidak
+	// berbayar dengan caranya sendiri.
+	base := money.FromMajor(6600000, money.IDR)
+	lines := func(p ProrationPolicy, d prorationDays) *Payslip {
+		payslip := &Payslip{BaseSalary: base}
+		for _, line := range p.salaryLines(base, fullMonth, d, money.DefaultRounding) {
+			payslip.AddLine(line)
+		}
+		return payslip
+	}
+
+	t.Run("salaryLines - Unpaid leave under working_days", func(t *testing.T) {
+		// 17 hadir + 2 cuti berbayar + 3 cuti tidak berbayar = 22 hari kerja.
+		payslip := lines(DefaultProrationPolicy, prorationDays{Working: 22, Calendar: 30, Attended: 17, PaidLeave: 2, UnpaidLeave: 3})
+
+		assert.Equal(t, "Gaji pokok (22 dari 22 hari kerja)", payslip.Lines[0].Description)
+		assert.True(t, payslip.SumLines(LineEarning, CodeBasicSalary).Equal(base))
+		// 3 x 6,6jt/22.
+		assert.True(t, payslip.SumLines(LineDeduction, CodeUnpaidLeave).Equal(money.FromMajor(900000, money.IDR)), "got %s", payslip.SumLines(LineDeduction, CodeUnpaidLeave))
+	})
+
+	t.Run("salaryLines - Unpaid leave under calendar_days", func(t *testing.T) {
+		// 2 hari kerja tidak hadir tanpa cuti: dibayar 28 dari 30 hari
+		// kalender, lalu 3 hari cuti tidak berbayar dipotong per hari kalender.
+		payslip := lines(ProrationPolicy{Method: ProrationCalendarDays}, prorationDays{Working: 22, Calendar: 30, Attended: 15, PaidLeave: 2, UnpaidLeave: 3})
+
+		assert.Equal(t, "Gaji pokok (28 dari 30 hari kalender)", payslip.Lines[0].Description)
+		assert.True(t, payslip.SumLines(LineEarning, CodeBasicSalary).Equal(money.FromMajor(6160000, money.IDR)), "got %s", payslip.SumLines(LineEarning, CodeBasicSalary))
+		// 3 x 6,6jt/30.
+		assert.True(t, payslip.SumLines(LineDeduction, CodeUnpaidLeave).Equal(money.FromMajor(660000, money.IDR)), "got %s", payslip.SumLines(LineDeduction, CodeUnpaidLeave))
+	})
+
+	t.Run("salaryLines - Unpaid leave under fixed_divisor is cut from the capped days", func(t *testing.T) {
+		// 23 hari kerja dengan pembagi 21: 20 hadir + 3 cuti tidak berbayar
+		// dibatasi 21 hari, sehingga hanya 1 hari yang dipotong dan gaji
+		// bersih tetap 20/21 seperti tanpa cuti.
+		payslip := lines(ProrationPolicy{Method: ProrationFixedDivisor, Divisor: 21}, prorationDays{Working: 23, Calendar: 31, Attended: 20, UnpaidLeave: 3})
+
+		assert.Equal(t, "Gaji pokok (21 hari hadir, pembagi 21)", payslip.Lines[0].Description)
+		assert.True(t, payslip.SumLines(LineEarning, CodeBasicSalary).Equal(base))
+		assert.Equal(t, "Potongan cuti tidak berbayar (1 hari)", payslip.Lines[1].Description)
+		assert.True(t, payslip.TaxableIncome().Equal(money.FromMajor(6285714, money.IDR)), "got %s", payslip.TaxableIncome())
+	})
+
+	t.Run("salaryLines - Unpaid leave under absence_deduction never exceeds the salary", func(t *testing.T) {
+		// Pembagi 21 pada 23 hari kerja: 19 hari tidak hadir dipotong lebih
+		// dulu, lalu cuti tidak berbayar hanya dipotong 2 dari 3 hari.
+		payslip := lines(ProrationPolicy{Method: ProrationAbsenceDeduction, Divisor: 21}, prorationDays{Working: 23, Calendar: 31, Attended: 1, UnpaidLeave: 3})
+
+		assert.Equal(t, "Potongan tidak hadir (19 hari)", payslip.Lines[1].Description)
+		assert.Equal(t, "Potongan cuti tidak berbayar (2 hari)", payslip.Lines[2].Description)
+		assert.True(t, payslip.TaxableIncome().IsZero(), "got %s", payslip.TaxableIncome())
 	})
 }
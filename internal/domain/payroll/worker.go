@@ -0,0 +1,108 @@
+package payroll
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// WorkerConfig mengatur cara Worker mengambil job dari antrean.
+type WorkerConfig struct {
+	// PollInterval adalah jeda pengecekan antrean ketika tidak ada job.
+	PollInterval time.Duration
+	// LeaseDuration adalah lama sebuah job dikunci oleh worker. Lease
+	// diperpanjang selama job diproses; jika worker mati, job diambil ulang
+	// oleh worker lain setelah lease habis.
+	LeaseDuration time.Duration
+	// MaxAttempts adalah batas pengambilan ulang job sebelum ditandai failed.
+	MaxAttempts int
+}
+
+// DefaultWorkerConfig mengembalikan konfigurasi Worker bawaan.
+func DefaultWorkerConfig() WorkerConfig {
+	return WorkerConfig{
+		PollInterval:  2 * time.Second,
+		LeaseDuration: 5 * time.Minute,
+		MaxAttempts:   3,
+	}
+}
+
+// Worker memproses PayrollJob dari antrean di database. Beberapa Worker
+// (termasuk di instance aplikasi berbeda) aman dijalankan bersamaan.
+type Worker struct {
+	repo    Repository
+	service Service
+	cfg     WorkerConfig
+}
+
+// NewWorker membuat instance baru dari Worker payroll.
+func NewWorker(repo Repository, service Service, cfg WorkerConfig) *Worker {
+	return &Worker{repo: repo, service: service, cfg: cfg}
+}
+
+// Run memproses job sampai ctx dibatalkan.
+func (w *Worker) Run(ctx context.Context) {
+	log.Println("Payroll worker started.")
+	for {
+		processed, err := w.RunNext(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Payroll worker: %v", err)
+		}
+		if processed {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			log.Println("Payroll worker stopped.")
+			return
+		case <-time.After(w.cfg.PollInterval):
+		}
+	}
+}
+
+// RunNext mengambil dan memproses satu job. Mengembalikan false jika antrean
+// kosong.
+func (w *Worker) RunNext(ctx context.Context) (bool, error) {
+	now := time.Now()
+	job, err := w.repo.ClaimNextPayrollJob(ctx, now, now.Add(w.cfg.LeaseDuration))
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	if job.Attempts > w.cfg.MaxAttempts {
+		msg := fmt.Sprintf("payroll job abandoned after %d attempts", w.cfg.MaxAttempts)
+		return true, w.repo.FinishPayrollJob(ctx, job.ID, JobFailed, msg)
+	}
+
+	stop := w.keepLease(ctx, job.ID)
+	defer stop()
+
+	if err := w.service.ProcessPayrollJob(ctx, job); err != nil {
+		return true, fmt.Errorf("payroll job %s: %w", job.ID, err)
+	}
+	log.Printf("Payroll job %s completed.", job.ID)
+	return true, nil
+}
+
+// keepLease memperpanjang lease job secara berkala sampai fungsi stop dipanggil.
+func (w *Worker) keepLease(ctx context.Context, jobID string) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(w.cfg.LeaseDuration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := w.repo.RenewPayrollJobLease(ctx, jobID, time.Now().Add(w.cfg.LeaseDuration)); err != nil {
+					log.Printf("Failed to renew lease of payroll job %s: %v", jobID, err)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,23 @@
+package periodlock
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockChecker adalah implementasi mock untuk periodlock.Checker
+type MockChecker struct {
+	mock.Mock
+}
+
+func (m *MockChecker) IsDateLocked(ctx context.Context, date time.Time) (bool, error) {
+	args := m.Called(ctx, date)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockChecker) IsDateCalculated(ctx context.Context, date time.Time) (bool, error) {
+	args := m.Called(ctx, date)
+	return args.Bool(0), args.Error(1)
+}
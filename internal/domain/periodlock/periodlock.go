@@ -0,0 +1,99 @@
+// Package periodlock menolak perubahan data yang bertanggal di dalam periode
+// payroll yang sudah ditutup, karena payroll periode tersebut tidak akan
+// dihitung ulang, dan persetujuan yang bertanggal di dalam periode yang sudah
+// dihitung.
+package periodlock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Checker melaporkan apakah sebuah tanggal berada di dalam periode payroll
+// yang sudah ditutup atau sudah dihitung. Dipenuhi oleh payroll.Repository.
+type Checker interface {
+	IsDateLocked(ctx context.Context, date time.Time) (bool, error)
+	// IsDateCalculated melaporkan apakah tanggal berada di dalam periode
+	// yang sudah melewati status open (calculated, approved, paid, atau
+	// closed).
+	IsDateCalculated(ctx context.Context, date time.Time) (bool, error)
+}
+
+// ErrLocked dikembalikan jika tanggal berada di dalam periode payroll yang
+// sudah ditutup.
+var ErrLocked = errors.New("date falls inside a closed payroll period")
+
+// ErrCalculated dikembalikan jika tanggal berada di dalam periode payroll
+// yang sudah dihitung, sehingga perubahannya tidak ikut dibayar kecuali
+// payroll periode tersebut di-reverse lebih dulu.
+var ErrCalculated = errors.New("date falls inside a payroll period that has already been calculated")
+
+// Guard menolak tanggal di dalam periode payroll yang sudah ditutup. Guard
+// tanpa Checker tidak menolak apa pun.
+type Guard struct {
+	Checker Checker
+}
+
+// Check mengembalikan ErrLocked jika date berada di dalam periode yang sudah
+// ditutup.
+func (g Guard) Check(ctx context.Context, date time.Time) error {
+	if g.Checker == nil {
+		return nil
+	}
+	locked, err := g.Checker.IsDateLocked(ctx, date)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return fmt.Errorf("%w: %s", ErrLocked, date.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// CheckRange menjalankan Check untuk setiap tanggal dari start sampai end
+// (inklusif).
+func (g Guard) CheckRange(ctx context.Context, start, end time.Time) error {
+	if g.Checker == nil {
+		return nil
+	}
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		if err := g.Check(ctx, date); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckCalculated mengembalikan ErrLocked jika date berada di dalam periode
+// yang sudah ditutup, atau ErrCalculated jika periodenya sudah dihitung
+// tetapi belum ditutup. Dipakai saat menyetujui pengajuan yang menambah
+// kehadiran atau cuti yang harus dibayar payroll.
+func (g Guard) CheckCalculated(ctx context.Context, date time.Time) error {
+	if err := g.Check(ctx, date); err != nil || g.Checker == nil {
+		return err
+	}
+	calculated, err := g.Checker.IsDateCalculated(ctx, date)
+	if err != nil {
+		return err
+	}
+	if calculated {
+		return fmt.Errorf("%w: %s", ErrCalculated, date.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// CheckCalculatedRange menjalankan CheckCalculated untuk setiap tanggal dari
+// start sampai end (inklusif).
+func (g Guard) CheckCalculatedRange(ctx context.Context, start, end time.Time) error {
+	if g.Checker == nil {
+		return nil
+	}
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		if err := g.CheckCalculated(ctx, date); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+// File: internal/domain/reimbursement/attachment.go
+package reimbursement
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
+	"github.com/google/uuid"
+)
+
+// MaxReceiptSize adalah ukuran maksimum satu berkas bukti.
+const MaxReceiptSize = 5 << 20 // 5 MB
+
+// receiptTypes memetakan tipe konten bukti yang diterima ke ekstensinya.
+var receiptTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+var (
+	// ErrUnsupportedReceipt dikembalikan untuk berkas selain JPEG, PNG, atau PDF.
+	ErrUnsupportedReceipt = errors.New("receipt must be a JPEG, PNG or PDF file")
+	// ErrReceiptTooLarge dikembalikan untuk berkas kosong atau melebihi MaxReceiptSize.
+	ErrReceiptTooLarge = errors.New("receipt must be between 1 byte and 5 MB")
+	// ErrReceiptRequired dikembalikan saat menyetujui reimbursement tanpa bukti.
+	ErrReceiptRequired = errors.New("a receipt is required to approve a reimbursement")
+	// ErrNotEditable dikembalikan jika reimbursement sudah diputuskan sehingga
+	// tidak dapat diubah lagi oleh karyawan.
+	ErrNotEditable = errors.New("reimbursement can no longer be changed")
+)
+
+// ReceiptStorage menyimpan isi berkas bukti (lihat internal/platform/storage).
+type ReceiptStorage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// WithReceiptStorage mengaktifkan unggah dan unduh berkas bukti.
+func WithReceiptStorage(store ReceiptStorage) Option {
+	return func(s *service) {
+		s.receipts = store
+	}
+}
+
+// AddAttachment menyimpan berkas bukti reimbursement milik userID yang masih
+// submitted. Tipe berkas ditentukan dari isinya, bukan dari nama atau header
+// yang dikirim klien.
+func (s *service) AddAttachment(ctx context.Context, reimbursementID, userID, fileName string, size int64, body io.Reader) (*Attachment, error) {
+	if s.receipts == nil {
+		return nil, errors.New("receipt storage is not configured")
+	}
+	if size <= 0 || size > MaxReceiptSize {
+		return nil, ErrReceiptTooLarge
+	}
+	reimbursement, err := s.repo.GetReimbursement(ctx, reimbursementID)
+	if err != nil {
+		return nil, err
+	}
+	if err := approval.CheckOwner(reimbursement.UserID, userID); err != nil {
+		return nil, err
+	}
+	if reimbursement.Status != StatusSubmitted {
+		return nil, ErrNotEditable
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(body, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	head = head[:n]
+	contentType, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	ext, ok := receiptTypes[contentType]
+	if !ok {
+		return nil, ErrUnsupportedReceipt
+	}
+
+	attachment := &Attachment{
+		ReimbursementID: reimbursement.ID,
+		FileName:        receiptFileName(fileName, ext),
+		ContentType:     contentType,
+		Size:            size,
+		StorageKey:      path.Join("reimbursements", reimbursement.ID, uuid.New().String()+ext),
+		CreatedBy:       userID,
+	}
+	if err := s.receipts.Put(ctx, attachment.StorageKey, io.MultiReader(bytes.NewReader(head), body), size, contentType); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateAttachment(ctx, attachment); err != nil {
+		if derr := s.receipts.Delete(ctx, attachment.StorageKey); derr != nil {
+			log.Printf("Failed to delete orphaned receipt %s: %v", attachment.StorageKey, derr)
+		}
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// OpenAttachment mengembalikan berkas bukti beserta isinya; pemanggil wajib
+// menutup isinya. ownerID yang terisi membatasi akses ke reimbursement milik
+// karyawan tersebut; reviewer memanggilnya dengan ownerID kosong.
+func (s *service) OpenAttachment(ctx context.Context, reimbursementID, attachmentID, ownerID string) (*Attachment, io.ReadCloser, error) {
+	if s.receipts == nil {
+		return nil, nil, errors.New("receipt storage is not configured")
+	}
+	if ownerID != "" {
+		reimbursement, err := s.repo.GetReimbursement(ctx, reimbursementID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := approval.CheckOwner(reimbursement.UserID, ownerID); err != nil {
+			return nil, nil, err
+		}
+	}
+	attachment, err := s.repo.GetAttachment(ctx, reimbursementID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := s.receipts.Open(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, content, nil
+}
+
+// receiptFileName membersihkan nama berkas dari klien untuk ditampilkan dan
+// dipakai saat diunduh.
+func receiptFileName(name, ext string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, `\`, "/")))
+	if name == "" || name == "." || name == "/" {
+		name = "receipt" + ext
+	}
+	if len(name) > 255 {
+		name = strings.ToValidUTF8(name[len(name)-255:], "")
+	}
+	return name
+}
@@ -0,0 +1,278 @@
+// File: internal/domain/reimbursement/category.go
+package reimbursement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCategoryRequired dikembalikan jika pengajuan tidak menyebut kategori.
+	ErrCategoryRequired = errors.New("reimbursement category is required")
+	// ErrUnknownCategory dikembalikan untuk kategori yang tidak ada atau
+	// sudah dinonaktifkan.
+	ErrUnknownCategory = errors.New("unknown or inactive reimbursement category")
+	// ErrLimitExceeded dikembalikan (dibungkus LimitExceededError) jika
+	// pengajuan melampaui batas kategori.
+	ErrLimitExceeded = errors.New("reimbursement limit exceeded")
+)
+
+// LimitWindow adalah jenis batas kategori.
+type LimitWindow string
+
+const (
+	LimitPerClaim LimitWindow = "per_claim"
+	LimitPerMonth LimitWindow = "per_month"
+	LimitPerYear  LimitWindow = "per_year"
+)
+
+// LimitExceededError menjelaskan batas yang terlampaui beserta sisa yang
+// masih dapat diajukan.
+type LimitExceededError struct {
+	Category  string
+	Window    LimitWindow
+	Limit     money.Money
+	Used      money.Money // Total pengajuan lain dalam bulan/tahun yang sama
+	Remaining money.Money // Tidak diisi untuk batas per pengajuan
+}
+
+func (e *LimitExceededError) Error() string {
+	if e.Window == LimitPerClaim {
+		return fmt.Sprintf("%s: %s allows at most %s per claim", ErrLimitExceeded, e.Category, e.Limit)
+	}
+	return fmt.Sprintf("%s: %s %s limit is %s, already claimed %s, remaining %s",
+		ErrLimitExceeded, e.Category, e.Window, e.Limit, e.Used, e.Remaining)
+}
+
+func (e *LimitExceededError) Is(target error) bool { return target == ErrLimitExceeded }
+
+// LimitUsage adalah pemakaian satu batas kategori. Limit dan Remaining nil
+// berarti tidak dibatasi.
+type LimitUsage struct {
+	Limit     *money.Money `json:"limit,omitempty"`
+	Used      money.Money  `json:"used"`
+	Remaining *money.Money `json:"remaining,omitempty"`
+}
+
+// Balance adalah sisa batas reimbursement karyawan untuk satu kategori pada
+// bulan dan tahun kalender tanggal yang diminta.
+type Balance struct {
+	CategoryID string       `json:"category_id"`
+	Code       string       `json:"code"`
+	Name       string       `json:"name"`
+	PerClaim   *money.Money `json:"per_claim,omitempty"`
+	Month      LimitUsage   `json:"month"`
+	Year       LimitUsage   `json:"year"`
+}
+
+// CategoryUpdate berisi field kategori yang boleh diubah. Field nil tidak
+// diubah; Limits yang diisi mengganti seluruh batas. Code tidak dapat diubah.
+type CategoryUpdate struct {
+	Name   *string          `json:"name"`
+	Active *bool            `json:"active"`
+	Limits *[]CategoryLimit `json:"limits"`
+}
+
+var categoryCodePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,31}$`)
+
+func (s *service) CreateCategory(ctx context.Context, category *Category, adminID string) error {
+	category.Code = strings.ToUpper(strings.TrimSpace(category.Code))
+	if !categoryCodePattern.MatchString(category.Code) {
+		return errors.New("category code must be 2-32 characters of A-Z, 0-9 or underscore")
+	}
+	category.Name = strings.TrimSpace(category.Name)
+	if category.Name == "" {
+		return errors.New("category name is required")
+	}
+	if err := validateLimits(category.Limits); err != nil {
+		return err
+	}
+
+	category.Active = true
+	category.CreatedBy = adminID
+	category.UpdatedBy = adminID
+	return s.repo.CreateCategory(ctx, category)
+}
+
+func (s *service) ListCategories(ctx context.Context) ([]Category, error) {
+	return s.repo.ListCategories(ctx)
+}
+
+func (s *service) UpdateCategory(ctx context.Context, id string, update CategoryUpdate, adminID string) (*Category, error) {
+	category, err := s.repo.GetCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" {
+			return nil, errors.New("category name is required")
+		}
+		category.Name = name
+	}
+	if update.Active != nil {
+		category.Active = *update.Active
+	}
+	if update.Limits != nil {
+		if err := validateLimits(*update.Limits); err != nil {
+			return nil, err
+		}
+		category.Limits = *update.Limits
+	}
+
+	category.UpdatedBy = adminID
+	if err := s.repo.UpdateCategory(ctx, category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// validateLimits memastikan batas tidak negatif dan tiap golongan hanya
+// punya satu baris batas.
+func validateLimits(limits []CategoryLimit) error {
+	seen := make(map[string]bool, len(limits))
+	for i := range limits {
+		l := &limits[i]
+		l.ID = ""
+		l.Grade = strings.TrimSpace(l.Grade)
+		if seen[l.Grade] {
+			return fmt.Errorf("duplicate limit for grade %q", l.Grade)
+		}
+		seen[l.Grade] = true
+		for _, v := range []*money.Money{l.PerClaim, l.PerMonth, l.PerYear} {
+			if v != nil && v.IsNegative() {
+				return errors.New("category limits cannot be negative")
+			}
+		}
+	}
+	return nil
+}
+
+// activeCategory mengembalikan kategori aktif dengan id tersebut.
+func (s *service) activeCategory(ctx context.Context, id string) (*Category, error) {
+	if id == "" {
+		return nil, ErrCategoryRequired
+	}
+	category, err := s.repo.GetCategory(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUnknownCategory
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !category.Active {
+		return nil, ErrUnknownCategory
+	}
+	return category, nil
+}
+
+// employeeGrade mengembalikan golongan karyawan untuk memilih batas kategori.
+func (s *service) employeeGrade(ctx context.Context, userID string) (string, error) {
+	emp, err := s.employees.GetByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return emp.Grade, nil
+}
+
+// checkLimits memastikan amount pada tanggal date tidak melampaui batas
+// per pengajuan, per bulan, dan per tahun kategori. claims adalah pengajuan
+// lain karyawan di kategori yang sama sepanjang tahun tanggal tersebut.
+func checkLimits(category *Category, limit CategoryLimit, claims []Reimbursement, date time.Time, amount money.Money) error {
+	if limit.PerClaim != nil && amount.Cmp(*limit.PerClaim) > 0 {
+		return &LimitExceededError{Category: category.Code, Window: LimitPerClaim, Limit: *limit.PerClaim}
+	}
+	month, year := usage(claims, date)
+	for _, w := range []struct {
+		window LimitWindow
+		limit  *money.Money
+		used   money.Money
+	}{{LimitPerMonth, limit.PerMonth, month}, {LimitPerYear, limit.PerYear, year}} {
+		if w.limit == nil || w.used.Add(amount).Cmp(*w.limit) <= 0 {
+			continue
+		}
+		return &LimitExceededError{Category: category.Code, Window: w.window, Limit: *w.limit, Used: w.used, Remaining: remaining(*w.limit, w.used)}
+	}
+	return nil
+}
+
+// usage menjumlahkan pengajuan pada bulan dan tahun kalender date.
+func usage(claims []Reimbursement, date time.Time) (month, year money.Money) {
+	for _, c := range claims {
+		if c.Date.Year() != date.Year() {
+			continue
+		}
+		year = year.Add(c.Amount)
+		if c.Date.Month() == date.Month() {
+			month = month.Add(c.Amount)
+		}
+	}
+	return month, year
+}
+
+func remaining(limit, used money.Money) money.Money {
+	left := limit.Sub(used)
+	if left.IsNegative() {
+		return money.Zero(left.Currency())
+	}
+	return left
+}
+
+// GetBalances mengembalikan sisa batas setiap kategori aktif bagi karyawan
+// pada bulan dan tahun tanggal date.
+func (s *service) GetBalances(ctx context.Context, userID string, date time.Time) ([]Balance, error) {
+	grade, err := s.employeeGrade(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.repo.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Date(date.Year(), time.January, 1, 0, 0, 0, 0, date.Location())
+	end := time.Date(date.Year(), time.December, 31, 0, 0, 0, 0, date.Location())
+	claims, err := s.repo.GetClaims(ctx, userID, "", start, end)
+	if err != nil {
+		return nil, err
+	}
+	byCategory := make(map[string][]Reimbursement)
+	for _, c := range claims {
+		byCategory[c.CategoryID] = append(byCategory[c.CategoryID], c)
+	}
+
+	balances := make([]Balance, 0, len(categories))
+	for i := range categories {
+		category := &categories[i]
+		if !category.Active {
+			continue
+		}
+		limit := category.LimitFor(grade)
+		month, year := usage(byCategory[category.ID], date)
+		balances = append(balances, Balance{
+			CategoryID: category.ID,
+			Code:       category.Code,
+			Name:       category.Name,
+			PerClaim:   limit.PerClaim,
+			Month:      limitUsage(limit.PerMonth, month),
+			Year:       limitUsage(limit.PerYear, year),
+		})
+	}
+	return balances, nil
+}
+
+func limitUsage(limit *money.Money, used money.Money) LimitUsage {
+	u := LimitUsage{Limit: limit, Used: used}
+	if limit != nil {
+		left := remaining(*limit, used)
+		u.Remaining = &left
+	}
+	return u
+}
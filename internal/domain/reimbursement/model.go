@@ -1,25 +1,227 @@
 package reimbursement
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// Status adalah status reimbursement. Hanya reimbursement approved yang
+// dibayar payroll; setelah masuk payslip statusnya menjadi paid.
+type Status string
+
+const (
+	StatusSubmitted Status = "submitted"
+	StatusApproved  Status = "approved"
+	StatusRejected  Status = "rejected"
+	StatusPaid      Status = "paid"
+	StatusCancelled Status = "cancelled"
+)
+
+// ErrInvalidStatusTransition dikembalikan untuk perubahan status yang tidak
+// diizinkan, mis. membayar reimbursement yang belum disetujui.
+var ErrInvalidStatusTransition = errors.New("invalid reimbursement status transition")
+
+// ParseStatus memvalidasi nama status reimbursement.
+func ParseStatus(s string) (Status, error) {
+	switch st := Status(s); st {
+	case StatusSubmitted, StatusApproved, StatusRejected, StatusPaid, StatusCancelled:
+		return st, nil
+	}
+	return "", fmt.Errorf("unknown reimbursement status %q", s)
+}
+
 type Reimbursement struct {
-	ID          string    `gorm:"primaryKey"`
-	UserID      string    `gorm:"index"`
-	Date        time.Time `gorm:"type:date"`
-	Description string
-	Amount      float64
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	CreatedBy   string    `gorm:"size:36" json:"created_by"`
-	UpdatedBy   string    `gorm:"size:36" json:"updated_by"`
+	ID           string       `json:"id" gorm:"primaryKey"`
+	UserID       string       `json:"user_id" gorm:"index"`
+	CategoryID   string       `json:"category_id,omitempty" gorm:"size:36;index"` // Kosong untuk pengajuan sebelum ada kategori
+	Date         time.Time    `json:"date" gorm:"type:date"`
+	Description  string       `json:"description"`
+	Amount       money.Money  `json:"amount"`
+	Status       Status       `json:"status" gorm:"size:16;default:'submitted';index"`
+	ReviewedBy   string       `gorm:"size:36" json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time   `json:"reviewed_at,omitempty"`
+	ReviewReason string       `json:"review_reason,omitempty"`                   // Wajib saat ditolak
+	PayslipID    string       `gorm:"size:36;index" json:"payslip_id,omitempty"` // Payslip yang membayar reimbursement ini
+	PaidAt       *time.Time   `json:"paid_at,omitempty"`
+	Attachments  []Attachment `json:"attachments,omitempty" gorm:"foreignKey:ReimbursementID"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+	CreatedBy    string       `gorm:"size:36" json:"created_by"`
+	UpdatedBy    string       `gorm:"size:36" json:"updated_by"`
 }
 
 func (r *Reimbursement) BeforeCreate(tx *gorm.DB) error {
 	r.ID = uuid.New().String()
 	return nil
 }
+
+// countedStatuses adalah status reimbursement yang mengurangi sisa batas
+// kategori. Pengajuan yang ditolak atau dibatalkan tidak dihitung.
+var countedStatuses = []Status{StatusSubmitted, StatusApproved, StatusPaid}
+
+// Category adalah jenis reimbursement yang ditetapkan admin (mis. MEDICAL,
+// TRAVEL, INTERNET, GLASSES) beserta batas nominalnya per golongan karyawan.
+type Category struct {
+	ID        string          `json:"id" gorm:"primaryKey"`
+	Code      string          `json:"code" gorm:"uniqueIndex;size:32"`
+	Name      string          `json:"name"`
+	Active    bool            `json:"active" gorm:"default:true"`
+	Limits    []CategoryLimit `json:"limits" gorm:"foreignKey:CategoryID"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	CreatedBy string          `gorm:"size:36" json:"created_by"`
+	UpdatedBy string          `gorm:"size:36" json:"updated_by"`
+}
+
+func (Category) TableName() string { return "reimbursement_categories" }
+
+func (c *Category) BeforeCreate(tx *gorm.DB) error {
+	c.ID = uuid.New().String()
+	return nil
+}
+
+// CategoryLimit adalah batas reimbursement sebuah kategori untuk satu
+// golongan. Batas per bulan dan per tahun dihitung per bulan dan tahun
+// kalender tanggal pengajuan. Batas nil berarti tidak dibatasi; batas nol
+// berarti golongan tersebut tidak berhak atas kategori ini.
+type CategoryLimit struct {
+	ID         string       `json:"id" gorm:"primaryKey"`
+	CategoryID string       `json:"category_id" gorm:"size:36;uniqueIndex:idx_reimbursement_category_limits_grade"`
+	Grade      string       `json:"grade" gorm:"size:32;uniqueIndex:idx_reimbursement_category_limits_grade"` // Kosong = default untuk golongan tanpa batas sendiri
+	PerClaim   *money.Money `json:"per_claim,omitempty"`
+	PerMonth   *money.Money `json:"per_month,omitempty"`
+	PerYear    *money.Money `json:"per_year,omitempty"`
+}
+
+func (CategoryLimit) TableName() string { return "reimbursement_category_limits" }
+
+func (l *CategoryLimit) BeforeCreate(tx *gorm.DB) error {
+	l.ID = uuid.New().String()
+	return nil
+}
+
+// LimitFor mengembalikan batas untuk golongan grade, atau batas default
+// (Grade kosong) jika golongan tersebut tidak punya batas sendiri. Tanpa
+// keduanya kategori tidak dibatasi.
+func (c *Category) LimitFor(grade string) CategoryLimit {
+	var fallback CategoryLimit
+	for _, l := range c.Limits {
+		if l.Grade == grade {
+			return l
+		}
+		if l.Grade == "" {
+			fallback = l
+		}
+	}
+	return fallback
+}
+
+// Attachment adalah berkas bukti (foto nota atau PDF) sebuah reimbursement.
+// Isinya disimpan di ReceiptStorage dengan kunci StorageKey.
+type Attachment struct {
+	ID              string    `json:"id" gorm:"primaryKey"`
+	ReimbursementID string    `json:"reimbursement_id" gorm:"index;size:36"`
+	FileName        string    `json:"file_name"`
+	ContentType     string    `json:"content_type" gorm:"size:64"`
+	Size            int64     `json:"size"`
+	StorageKey      string    `json:"-"`
+	CreatedAt       time.Time `json:"created_at"`
+	CreatedBy       string    `json:"created_by" gorm:"size:36"`
+}
+
+func (Attachment) TableName() string { return "reimbursement_attachments" }
+
+func (a *Attachment) BeforeCreate(tx *gorm.DB) error {
+	a.ID = uuid.New().String()
+	return nil
+}
+
+// StatusHistory mencatat satu perubahan status reimbursement: siapa, kapan,
+// dari dan ke status apa, serta alasannya.
+type StatusHistory struct {
+	ID              string    `json:"id" gorm:"primaryKey"`
+	ReimbursementID string    `json:"reimbursement_id" gorm:"index;size:36"`
+	FromStatus      Status    `json:"from_status,omitempty" gorm:"size:16"` // Kosong untuk pengajuan
+	ToStatus        Status    `json:"to_status" gorm:"size:16"`
+	Reason          string    `json:"reason,omitempty"`
+	PayslipID       string    `json:"payslip_id,omitempty" gorm:"size:36"`
+	ChangedBy       string    `json:"changed_by" gorm:"size:36"`
+	ChangedAt       time.Time `json:"changed_at"`
+}
+
+func (StatusHistory) TableName() string { return "reimbursement_status_histories" }
+
+func (h *StatusHistory) BeforeCreate(tx *gorm.DB) error {
+	h.ID = uuid.New().String()
+	return nil
+}
+
+// TransitionTo memindahkan reimbursement ke status next dan mengembalikan
+// catatan riwayatnya. Perubahan yang diizinkan:
+//
+//	submitted -> approved | rejected  (keputusan approver)
+//	submitted -> cancelled            (dibatalkan karyawan)
+//	approved  -> paid                 (dibayar payroll, lihat Settle)
+//	paid      -> approved             (payslip pembayarnya di-void)
+func (r *Reimbursement) TransitionTo(next Status, actorID, reason string, at time.Time) (StatusHistory, error) {
+	from := r.Status
+	switch {
+	case from == StatusSubmitted && (next == StatusApproved || next == StatusRejected):
+		r.ReviewedBy = actorID
+		r.ReviewedAt = &at
+		r.ReviewReason = reason
+	case from == StatusSubmitted && next == StatusCancelled:
+	case from == StatusApproved && next == StatusPaid:
+		r.PaidAt = &at
+	case from == StatusPaid && next == StatusApproved:
+		r.PayslipID = ""
+		r.PaidAt = nil
+	default:
+		return StatusHistory{}, fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, from, next)
+	}
+	r.Status = next
+	r.UpdatedBy = actorID
+	return StatusHistory{
+		ReimbursementID: r.ID,
+		FromStatus:      from,
+		ToStatus:        next,
+		Reason:          reason,
+		ChangedBy:       actorID,
+		ChangedAt:       at,
+	}, nil
+}
+
+// Settle menandai reimbursement approved sebagai paid oleh payslip payslipID.
+func (r *Reimbursement) Settle(payslipID, actorID string, at time.Time) (StatusHistory, error) {
+	history, err := r.TransitionTo(StatusPaid, actorID, "", at)
+	if err != nil {
+		return StatusHistory{}, err
+	}
+	r.PayslipID = payslipID
+	history.PayslipID = payslipID
+	return history, nil
+}
+
+// Revision menyimpan nilai reimbursement sebelum diubah oleh karyawan.
+type Revision struct {
+	ID              string      `json:"id" gorm:"primaryKey"`
+	ReimbursementID string      `json:"reimbursement_id" gorm:"index;size:36"`
+	CategoryID      string      `json:"category_id,omitempty" gorm:"size:36"`
+	Date            time.Time   `json:"date" gorm:"type:date"`
+	Description     string      `json:"description"`
+	Amount          money.Money `json:"amount"`
+	ChangedBy       string      `json:"changed_by" gorm:"size:36"`
+	ChangedAt       time.Time   `json:"changed_at"`
+}
+
+func (Revision) TableName() string { return "reimbursement_revisions" }
+
+func (r *Revision) BeforeCreate(tx *gorm.DB) error {
+	r.ID = uuid.New().String()
+	return nil
+}
@@ -2,12 +2,60 @@ package reimbursement
 
 import (
 	"context"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type Repository interface {
+	WithTransaction(ctx context.Context, fn func(repo Repository) error) error
+	// LockClaims mencegah pengajuan karyawan yang sama diproses paralel
+	// sampai transaksi selesai, agar batas kategori tidak terlampaui. Harus
+	// dipanggil di dalam WithTransaction.
+	LockClaims(ctx context.Context, userID string) error
+	// GetClaims mengembalikan reimbursement karyawan yang dihitung terhadap
+	// batas kategori dalam rentang tanggal. categoryID kosong berarti semua
+	// kategori.
+	GetClaims(ctx context.Context, userID, categoryID string, start, end time.Time) ([]Reimbursement, error)
+	// CreateReimbursement menyimpan pengajuan beserta riwayat status awalnya.
 	CreateReimbursement(ctx context.Context, reimbursement *Reimbursement) error
+	GetReimbursement(ctx context.Context, id string) (*Reimbursement, error)
+	ListReimbursements(ctx context.Context, filter ListFilter) ([]Reimbursement, error)
+	// CountReimbursements menghitung reimbursement sesuai filter tanpa Limit
+	// dan Offset.
+	CountReimbursements(ctx context.Context, filter ListFilter) (int64, error)
+	// UpdateReimbursementStatus menyimpan perubahan status beserta riwayatnya
+	// hanya jika status di database masih from. Nilai false berarti
+	// reimbursement sudah diubah proses lain.
+	UpdateReimbursementStatus(ctx context.Context, reimbursement *Reimbursement, from Status, history StatusHistory) (bool, error)
+	GetStatusHistory(ctx context.Context, id string) ([]StatusHistory, error)
+	// UpdateReimbursement menyimpan isi reimbursement yang diubah karyawan
+	// beserta revision berisi nilai sebelumnya, hanya jika reimbursement
+	// masih submitted. Nilai false berarti sudah diubah proses lain.
+	UpdateReimbursement(ctx context.Context, reimbursement *Reimbursement, revision Revision) (bool, error)
+	// GetRevisions mengembalikan nilai-nilai reimbursement sebelum diubah,
+	// dari yang terlama.
+	GetRevisions(ctx context.Context, id string) ([]Revision, error)
+	CreateAttachment(ctx context.Context, attachment *Attachment) error
+	GetAttachment(ctx context.Context, reimbursementID, id string) (*Attachment, error)
+
+	CreateCategory(ctx context.Context, category *Category) error
+	GetCategory(ctx context.Context, id string) (*Category, error)
+	ListCategories(ctx context.Context) ([]Category, error)
+	// UpdateCategory menyimpan perubahan kategori dan mengganti seluruh
+	// batasnya dengan category.Limits.
+	UpdateCategory(ctx context.Context, category *Category) error
+}
+
+// ListFilter membatasi daftar reimbursement. Field kosong tidak membatasi;
+// Limit 0 berarti tanpa batas jumlah.
+type ListFilter struct {
+	Status    Status
+	UserID    string
+	StartDate *time.Time
+	EndDate   *time.Time
+	Limit     int
+	Offset    int
 }
 
 type repository struct {
@@ -18,6 +66,193 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db}
 }
 
+func (r *repository) WithTransaction(ctx context.Context, fn func(repo Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&repository{tx})
+	})
+}
+
+// claimsLockKey adalah ruang kunci advisory lock pengajuan per karyawan.
+const claimsLockKey = 72010012
+
+func (r *repository) LockClaims(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Exec("SELECT pg_advisory_xact_lock(?, hashtext(?))", claimsLockKey, userID).Error
+}
+
+func (r *repository) GetClaims(ctx context.Context, userID, categoryID string, start, end time.Time) ([]Reimbursement, error) {
+	q := r.db.WithContext(ctx).Where("user_id = ? AND date >= ? AND date <= ? AND status IN ?", userID, start, end, countedStatuses)
+	if categoryID != "" {
+		q = q.Where("category_id = ?", categoryID)
+	}
+	var reimbursements []Reimbursement
+	err := q.Find(&reimbursements).Error
+	return reimbursements, err
+}
+
 func (r *repository) CreateReimbursement(ctx context.Context, reimbursement *Reimbursement) error {
-	return r.db.WithContext(ctx).Create(reimbursement).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(reimbursement).Error; err != nil {
+			return err
+		}
+		return tx.Create(&StatusHistory{
+			ReimbursementID: reimbursement.ID,
+			ToStatus:        reimbursement.Status,
+			ChangedBy:       reimbursement.CreatedBy,
+			ChangedAt:       reimbursement.CreatedAt,
+		}).Error
+	})
+}
+
+func (r *repository) GetReimbursement(ctx context.Context, id string) (*Reimbursement, error) {
+	var reimbursement Reimbursement
+	if err := r.db.WithContext(ctx).Preload("Attachments").First(&reimbursement, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &reimbursement, nil
+}
+
+func (r *repository) ListReimbursements(ctx context.Context, filter ListFilter) ([]Reimbursement, error) {
+	q := r.filtered(ctx, filter).Preload("Attachments").Order("date, created_at")
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit).Offset(filter.Offset)
+	}
+	var reimbursements []Reimbursement
+	err := q.Find(&reimbursements).Error
+	return reimbursements, err
+}
+
+func (r *repository) CountReimbursements(ctx context.Context, filter ListFilter) (int64, error) {
+	var count int64
+	err := r.filtered(ctx, filter).Model(&Reimbursement{}).Count(&count).Error
+	return count, err
+}
+
+func (r *repository) filtered(ctx context.Context, filter ListFilter) *gorm.DB {
+	q := r.db.WithContext(ctx)
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	if filter.UserID != "" {
+		q = q.Where("user_id = ?", filter.UserID)
+	}
+	if filter.StartDate != nil {
+		q = q.Where("date >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		q = q.Where("date <= ?", *filter.EndDate)
+	}
+	return q
+}
+
+func (r *repository) UpdateReimbursementStatus(ctx context.Context, reimbursement *Reimbursement, from Status, history StatusHistory) (bool, error) {
+	updated := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"status":        reimbursement.Status,
+			"reviewed_by":   reimbursement.ReviewedBy,
+			"reviewed_at":   reimbursement.ReviewedAt,
+			"review_reason": reimbursement.ReviewReason,
+			"payslip_id":    reimbursement.PayslipID,
+			"paid_at":       reimbursement.PaidAt,
+			"updated_by":    reimbursement.UpdatedBy,
+		}
+		res := tx.Model(&Reimbursement{}).Where("id = ? AND status = ?", reimbursement.ID, from).Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected != 1 {
+			return nil
+		}
+		updated = true
+		return tx.Create(&history).Error
+	})
+	return updated, err
+}
+
+func (r *repository) GetStatusHistory(ctx context.Context, id string) ([]StatusHistory, error) {
+	var history []StatusHistory
+	err := r.db.WithContext(ctx).Where("reimbursement_id = ?", id).Order("changed_at, id").Find(&history).Error
+	return history, err
+}
+
+func (r *repository) CreateAttachment(ctx context.Context, attachment *Attachment) error {
+	return r.db.WithContext(ctx).Create(attachment).Error
+}
+
+func (r *repository) GetAttachment(ctx context.Context, reimbursementID, id string) (*Attachment, error) {
+	var attachment Attachment
+	if err := r.db.WithContext(ctx).First(&attachment, "id = ? AND reimbursement_id = ?", id, reimbursementID).Error; err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (r *repository) CreateCategory(ctx context.Context, category *Category) error {
+	return r.db.WithContext(ctx).Create(category).Error
+}
+
+func (r *repository) GetCategory(ctx context.Context, id string) (*Category, error) {
+	var category Category
+	if err := r.db.WithContext(ctx).Preload("Limits").First(&category, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *repository) ListCategories(ctx context.Context) ([]Category, error) {
+	var categories []Category
+	err := r.db.WithContext(ctx).Preload("Limits").Order("code").Find(&categories).Error
+	return categories, err
+}
+
+func (r *repository) UpdateCategory(ctx context.Context, category *Category) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"name":       category.Name,
+			"active":     category.Active,
+			"updated_by": category.UpdatedBy,
+		}
+		if err := tx.Model(&Category{}).Where("id = ?", category.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("category_id = ?", category.ID).Delete(&CategoryLimit{}).Error; err != nil {
+			return err
+		}
+		for i := range category.Limits {
+			category.Limits[i].CategoryID = category.ID
+		}
+		if len(category.Limits) == 0 {
+			return nil
+		}
+		return tx.Create(&category.Limits).Error
+	})
+}
+
+func (r *repository) UpdateReimbursement(ctx context.Context, reimbursement *Reimbursement, revision Revision) (bool, error) {
+	updated := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"category_id": reimbursement.CategoryID,
+			"date":        reimbursement.Date,
+			"description": reimbursement.Description,
+			"amount":      reimbursement.Amount,
+			"updated_by":  reimbursement.UpdatedBy,
+		}
+		res := tx.Model(&Reimbursement{}).Where("id = ? AND status = ?", reimbursement.ID, StatusSubmitted).Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected != 1 {
+			return nil
+		}
+		updated = true
+		return tx.Create(&revision).Error
+	})
+	return updated, err
+}
+
+func (r *repository) GetRevisions(ctx context.Context, id string) ([]Revision, error) {
+	var revisions []Revision
+	err := r.db.WithContext(ctx).Where("reimbursement_id = ?", id).Order("changed_at").Find(&revisions).Error
+	return revisions, err
 }
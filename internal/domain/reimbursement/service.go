@@ -3,37 +3,346 @@ package reimbursement
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/periodlock"
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
 )
 
 type Service interface {
-	SubmitReimbursement(ctx context.Context, userID string, date time.Time, description string, amount float64) error
+	// SubmitReimbursement membuat pengajuan berstatus submitted pada
+	// kategori categoryID, ditolak dengan LimitExceededError jika melampaui
+	// batas kategori untuk golongan karyawan. Bukti diunggah terpisah dengan
+	// AddAttachment.
+	SubmitReimbursement(ctx context.Context, userID, categoryID string, date time.Time, description string, amount money.Money) (*Reimbursement, error)
+	// GetBalances mengembalikan sisa batas setiap kategori aktif bagi
+	// karyawan pada bulan dan tahun tanggal date.
+	GetBalances(ctx context.Context, userID string, date time.Time) ([]Balance, error)
+	ListReimbursements(ctx context.Context, filter ListFilter) ([]Reimbursement, error)
+	// ListMyReimbursements mengembalikan reimbursement milik userID beserta
+	// jumlah seluruhnya sebelum Limit dan Offset diterapkan.
+	ListMyReimbursements(ctx context.Context, userID string, filter ListFilter) ([]Reimbursement, int64, error)
+	// ApproveReimbursement dan RejectReimbursement memutuskan pengajuan yang
+	// masih submitted. Alasan wajib diisi saat menolak.
+	ApproveReimbursement(ctx context.Context, reimbursementID, approverID string) (*Reimbursement, error)
+	RejectReimbursement(ctx context.Context, reimbursementID, reason, approverID string) (*Reimbursement, error)
+	// GetStatusHistory mengembalikan riwayat perubahan status dari yang
+	// terlama.
+	GetStatusHistory(ctx context.Context, reimbursementID string) ([]StatusHistory, error)
+	// UpdateReimbursement mengubah reimbursement submitted milik userID
+	// sendiri dengan aturan yang sama seperti pengajuan baru; nilai
+	// sebelumnya disimpan sebagai Revision.
+	UpdateReimbursement(ctx context.Context, reimbursementID, userID, categoryID string, date time.Time, description string, amount money.Money) (*Reimbursement, error)
+	// CancelReimbursement membatalkan reimbursement submitted milik userID
+	// sendiri.
+	CancelReimbursement(ctx context.Context, reimbursementID, userID string) (*Reimbursement, error)
+	// GetRevisions mengembalikan riwayat perubahan isi reimbursement dari
+	// yang terlama. ownerID yang terisi membatasi akses ke reimbursement
+	// milik karyawan tersebut; reviewer memanggilnya dengan ownerID kosong.
+	GetRevisions(ctx context.Context, reimbursementID, ownerID string) ([]Revision, error)
+	// AddAttachment mengunggah bukti (JPEG, PNG, atau PDF, maksimal
+	// MaxReceiptSize) ke reimbursement milik userID yang masih submitted.
+	AddAttachment(ctx context.Context, reimbursementID, userID, fileName string, size int64, body io.Reader) (*Attachment, error)
+	OpenAttachment(ctx context.Context, reimbursementID, attachmentID, ownerID string) (*Attachment, io.ReadCloser, error)
+
+	CreateCategory(ctx context.Context, category *Category, adminID string) error
+	ListCategories(ctx context.Context) ([]Category, error)
+	UpdateCategory(ctx context.Context, id string, update CategoryUpdate, adminID string) (*Category, error)
 }
 
+var (
+	// ErrSelfReview dikembalikan jika approver memutuskan pengajuannya sendiri.
+	ErrSelfReview = errors.New("cannot review own reimbursement")
+)
+
 type service struct {
-	repo Repository
+	repo      Repository
+	employees employee.Repository
+	lock      periodlock.Guard
+	receipts  ReceiptStorage
+	now       func() time.Time
+}
+
+// Option mengubah konfigurasi opsional dari service reimbursement.
+type Option func(*service)
+
+// WithPeriodLock menolak pengajuan, perubahan, dan persetujuan reimbursement
+// pada tanggal yang periode payroll-nya sudah ditutup.
+func WithPeriodLock(lock periodlock.Checker) Option {
+	return func(s *service) {
+		s.lock = periodlock.Guard{Checker: lock}
+	}
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo}
+// WithClock mengganti sumber waktu saat ini. Default: time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(s *service) {
+		s.now = now
+	}
 }
 
-func (s *service) SubmitReimbursement(ctx context.Context, userID string, date time.Time, description string, amount float64) error {
-	if amount <= 0 {
+func NewService(repo Repository, employees employee.Repository, opts ...Option) Service {
+	s := &service{repo: repo, employees: employees, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// validate memastikan isi reimbursement dapat diajukan.
+func validate(description string, amount money.Money) error {
+	if !amount.IsPositive() {
 		return errors.New("reimbursement amount must be positive")
 	}
 	if description == "" {
 		return errors.New("reimbursement description is required")
 	}
+	return nil
+}
+
+func (s *service) SubmitReimbursement(ctx context.Context, userID, categoryID string, date time.Time, description string, amount money.Money) (*Reimbursement, error) {
+	if err := validate(description, amount); err != nil {
+		return nil, err
+	}
+
+	if err := s.lock.Check(ctx, date); err != nil {
+		return nil, err
+	}
+	category, err := s.activeCategory(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
 
 	reimbursement := &Reimbursement{
 		UserID:      userID,
+		CategoryID:  category.ID,
 		Date:        date,
 		Description: description,
 		Amount:      amount,
+		Status:      StatusSubmitted,
 		CreatedBy:   userID,
 		UpdatedBy:   userID,
 	}
 
-	return s.repo.CreateReimbursement(ctx, reimbursement)
+	err = s.withinLimits(ctx, userID, category, date, amount, "", func(repo Repository) error {
+		return repo.CreateReimbursement(ctx, reimbursement)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reimbursement, nil
+}
+
+// withinLimits menjalankan save setelah memastikan amount pada date tidak
+// melampaui batas kategori untuk golongan userID. Pemakaian dihitung ulang di
+// dalam lock agar pengajuan paralel tidak bersama-sama melampaui batas.
+// Reimbursement excludeID (yang sedang diubah) tidak dihitung.
+func (s *service) withinLimits(ctx context.Context, userID string, category *Category, date time.Time, amount money.Money, excludeID string, save func(repo Repository) error) error {
+	grade, err := s.employeeGrade(ctx, userID)
+	if err != nil {
+		return err
+	}
+	limit := category.LimitFor(grade)
+
+	return s.repo.WithTransaction(ctx, func(repo Repository) error {
+		if err := repo.LockClaims(ctx, userID); err != nil {
+			return err
+		}
+		start := time.Date(date.Year(), time.January, 1, 0, 0, 0, 0, date.Location())
+		end := time.Date(date.Year(), time.December, 31, 0, 0, 0, 0, date.Location())
+		claims, err := repo.GetClaims(ctx, userID, category.ID, start, end)
+		if err != nil {
+			return err
+		}
+		if excludeID != "" {
+			others := make([]Reimbursement, 0, len(claims))
+			for _, c := range claims {
+				if c.ID != excludeID {
+					others = append(others, c)
+				}
+			}
+			claims = others
+		}
+		if err := checkLimits(category, limit, claims, date, amount); err != nil {
+			return err
+		}
+		return save(repo)
+	})
+}
+
+// ownSubmitted mengembalikan reimbursement submitted milik userID yang
+// tanggalnya belum masuk periode payroll yang ditutup.
+func (s *service) ownSubmitted(ctx context.Context, reimbursementID, userID string) (*Reimbursement, error) {
+	reimbursement, err := s.repo.GetReimbursement(ctx, reimbursementID)
+	if err != nil {
+		return nil, err
+	}
+	if err := approval.CheckOwner(reimbursement.UserID, userID); err != nil {
+		return nil, err
+	}
+	if reimbursement.Status != StatusSubmitted {
+		return nil, ErrNotEditable
+	}
+	if err := s.lock.Check(ctx, reimbursement.Date); err != nil {
+		return nil, err
+	}
+	return reimbursement, nil
+}
+
+func (s *service) UpdateReimbursement(ctx context.Context, reimbursementID, userID, categoryID string, date time.Time, description string, amount money.Money) (*Reimbursement, error) {
+	reimbursement, err := s.ownSubmitted(ctx, reimbursementID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validate(description, amount); err != nil {
+		return nil, err
+	}
+	if err := s.lock.Check(ctx, date); err != nil {
+		return nil, err
+	}
+	category, err := s.activeCategory(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	revision := Revision{
+		ReimbursementID: reimbursement.ID,
+		CategoryID:      reimbursement.CategoryID,
+		Date:            reimbursement.Date,
+		Description:     reimbursement.Description,
+		Amount:          reimbursement.Amount,
+		ChangedBy:       userID,
+		ChangedAt:       s.now(),
+	}
+	reimbursement.CategoryID = category.ID
+	reimbursement.Date = date
+	reimbursement.Description = description
+	reimbursement.Amount = amount
+	reimbursement.UpdatedBy = userID
+
+	err = s.withinLimits(ctx, userID, category, date, amount, reimbursement.ID, func(repo Repository) error {
+		ok, err := repo.UpdateReimbursement(ctx, reimbursement, revision)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return approval.ErrModified
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reimbursement, nil
+}
+
+func (s *service) CancelReimbursement(ctx context.Context, reimbursementID, userID string) (*Reimbursement, error) {
+	reimbursement, err := s.ownSubmitted(ctx, reimbursementID, userID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := reimbursement.TransitionTo(StatusCancelled, userID, "", s.now())
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.repo.UpdateReimbursementStatus(ctx, reimbursement, StatusSubmitted, history)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, approval.ErrModified
+	}
+	return reimbursement, nil
+}
+
+func (s *service) GetRevisions(ctx context.Context, reimbursementID, ownerID string) ([]Revision, error) {
+	reimbursement, err := s.repo.GetReimbursement(ctx, reimbursementID)
+	if err != nil {
+		return nil, err
+	}
+	if ownerID != "" {
+		if err := approval.CheckOwner(reimbursement.UserID, ownerID); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.GetRevisions(ctx, reimbursementID)
+}
+
+func (s *service) ListReimbursements(ctx context.Context, filter ListFilter) ([]Reimbursement, error) {
+	return s.repo.ListReimbursements(ctx, filter)
+}
+
+func (s *service) ListMyReimbursements(ctx context.Context, userID string, filter ListFilter) ([]Reimbursement, int64, error) {
+	filter.UserID = userID
+	reimbursements, err := s.repo.ListReimbursements(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.CountReimbursements(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return reimbursements, total, nil
+}
+
+func (s *service) ApproveReimbursement(ctx context.Context, reimbursementID, approverID string) (*Reimbursement, error) {
+	return s.review(ctx, reimbursementID, StatusApproved, "", approverID)
+}
+
+func (s *service) RejectReimbursement(ctx context.Context, reimbursementID, reason, approverID string) (*Reimbursement, error) {
+	reason, err := approval.RejectReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	return s.review(ctx, reimbursementID, StatusRejected, reason, approverID)
+}
+
+// review memutuskan pengajuan submitted. Persetujuan membutuhkan bukti, dan
+// ditolak untuk tanggal di periode yang sudah ditutup karena tidak akan pernah
+// dibayar.
+func (s *service) review(ctx context.Context, reimbursementID string, next Status, reason, approverID string) (*Reimbursement, error) {
+	reimbursement, err := s.repo.GetReimbursement(ctx, reimbursementID)
+	if err != nil {
+		return nil, err
+	}
+	if reimbursement.UserID == approverID {
+		return nil, ErrSelfReview
+	}
+	// paid -> approved hanya untuk reversal payroll, bukan keputusan approver.
+	if reimbursement.Status != StatusSubmitted {
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, reimbursement.Status, next)
+	}
+	if next == StatusApproved {
+		if len(reimbursement.Attachments) == 0 {
+			return nil, ErrReceiptRequired
+		}
+		if err := s.lock.Check(ctx, reimbursement.Date); err != nil {
+			return nil, err
+		}
+	}
+
+	from := reimbursement.Status
+	history, err := reimbursement.TransitionTo(next, approverID, reason, s.now())
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.repo.UpdateReimbursementStatus(ctx, reimbursement, from, history)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, approval.ErrModified
+	}
+	return reimbursement, nil
+}
+
+func (s *service) GetStatusHistory(ctx context.Context, reimbursementID string) ([]StatusHistory, error) {
+	if _, err := s.repo.GetReimbursement(ctx, reimbursementID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetStatusHistory(ctx, reimbursementID)
 }
@@ -2,14 +2,23 @@ package reimbursement
 
 import (
 	"context"
+	"errors"
+	"io"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/approval"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/auth"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/periodlock"
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
-// MockSubmissionRepository adalah implementasi mock untuk submission.Repository
+// MockReimbursementRepository adalah implementasi mock untuk reimbursement.Repository
 type MockReimbursementRepository struct {
 	mock.Mock
 }
@@ -19,21 +28,138 @@ func (m *MockReimbursementRepository) CreateReimbursement(ctx context.Context, r
 	return args.Error(0)
 }
 
-// ... (kode yang sudah ada di service_test.go)
+func (m *MockReimbursementRepository) GetReimbursement(ctx context.Context, id string) (*Reimbursement, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Reimbursement), args.Error(1)
+}
+
+func (m *MockReimbursementRepository) ListReimbursements(ctx context.Context, filter ListFilter) ([]Reimbursement, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]Reimbursement), args.Error(1)
+}
+
+func (m *MockReimbursementRepository) CountReimbursements(ctx context.Context, filter ListFilter) (int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockReimbursementRepository) UpdateReimbursementStatus(ctx context.Context, reimbursement *Reimbursement, from Status, history StatusHistory) (bool, error) {
+	args := m.Called(ctx, reimbursement, from, history)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockReimbursementRepository) GetStatusHistory(ctx context.Context, id string) ([]StatusHistory, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]StatusHistory), args.Error(1)
+}
+
+func (m *MockReimbursementRepository) UpdateReimbursement(ctx context.Context, reimbursement *Reimbursement, revision Revision) (bool, error) {
+	args := m.Called(ctx, reimbursement, revision)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockReimbursementRepository) GetRevisions(ctx context.Context, id string) ([]Revision, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]Revision), args.Error(1)
+}
+
+func (m *MockReimbursementRepository) CreateAttachment(ctx context.Context, attachment *Attachment) error {
+	args := m.Called(ctx, attachment)
+	return args.Error(0)
+}
+
+func (m *MockReimbursementRepository) GetAttachment(ctx context.Context, reimbursementID, id string) (*Attachment, error) {
+	args := m.Called(ctx, reimbursementID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Attachment), args.Error(1)
+}
+
+func (m *MockReimbursementRepository) WithTransaction(ctx context.Context, fn func(repo Repository) error) error {
+	return fn(m)
+}
+
+func (m *MockReimbursementRepository) LockClaims(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockReimbursementRepository) GetClaims(ctx context.Context, userID, categoryID string, start, end time.Time) ([]Reimbursement, error) {
+	args := m.Called(ctx, userID, categoryID, start, end)
+	return args.Get(0).([]Reimbursement), args.Error(1)
+}
+
+func (m *MockReimbursementRepository) CreateCategory(ctx context.Context, category *Category) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockReimbursementRepository) GetCategory(ctx context.Context, id string) (*Category, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Category), args.Error(1)
+}
+
+func (m *MockReimbursementRepository) ListCategories(ctx context.Context) ([]Category, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Category), args.Error(1)
+}
+
+func (m *MockReimbursementRepository) UpdateCategory(ctx context.Context, category *Category) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+// MockReceiptStorage adalah implementasi mock untuk reimbursement.ReceiptStorage
+type MockReceiptStorage struct {
+	mock.Mock
+}
+
+func (m *MockReceiptStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	content, _ := io.ReadAll(body)
+	args := m.Called(ctx, key, string(content), size, contentType)
+	return args.Error(0)
+}
+
+func (m *MockReceiptStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(io.ReadCloser), args.Error(1)
+}
+
+func (m *MockReceiptStorage) Delete(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
 
 func TestReimbursement(t *testing.T) {
 	t.Run("SubmitReimbursement - Success", func(t *testing.T) {
 		// Arrange
 		mockRepo := new(MockReimbursementRepository)
-		reimbursementService := NewService(mockRepo)
+		mockEmpRepo := new(auth.MockEmployeeRepository)
+		reimbursementService := NewService(mockRepo, mockEmpRepo)
 		ctx := context.Background()
 		userID := "user-456"
 
+		mockRepo.On("GetCategory", ctx, "cat-travel").Return(&Category{ID: "cat-travel", Code: "TRAVEL", Active: true}, nil).Once()
+		mockEmpRepo.On("GetByID", ctx, userID).Return(&employee.Employee{ID: userID}, nil).Once()
+		mockRepo.On("LockClaims", ctx, userID).Return(nil).Once()
+		mockRepo.On("GetClaims", ctx, userID, "cat-travel", mock.Anything, mock.Anything).Return([]Reimbursement{}, nil).Once()
 		// Siapkan ekspektasi: Saat CreateReimbursement dipanggil dengan data apa pun, return nil (sukses).
-		mockRepo.On("CreateReimbursement", ctx, mock.AnythingOfType("*submission.Reimbursement")).Return(nil).Once()
+		mockRepo.On("CreateReimbursement", ctx, mock.MatchedBy(func(r *Reimbursement) bool {
+			return r.Status == StatusSubmitted && r.UserID == userID && r.CategoryID == "cat-travel"
+		})).Return(nil).Once()
 
 		// Act
-		err := reimbursementService.SubmitReimbursement(ctx, userID, time.Now(), "Biaya Transport", 75000)
+		_, err := reimbursementService.SubmitReimbursement(ctx, userID, "cat-travel", time.Now(), "Biaya Transport", money.FromMajor(75000, money.IDR))
 
 		// Assert
 		assert.NoError(t, err)
@@ -43,12 +169,12 @@ func TestReimbursement(t *testing.T) {
 	t.Run("SubmitReimbursement - Fail because amount is zero or negative", func(t *testing.T) {
 		// Arrange
 		mockRepo := new(MockReimbursementRepository) // mock tidak akan dipanggil
-		reimbursementService := NewService(mockRepo)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository))
 		ctx := context.Background()
 		userID := "user-456"
 
 		// Act
-		err := reimbursementService.SubmitReimbursement(ctx, userID, time.Now(), "Invalid Amount", -50000)
+		_, err := reimbursementService.SubmitReimbursement(ctx, userID, "cat-travel", time.Now(), "Invalid Amount", money.FromMajor(-50000, money.IDR))
 
 		// Assert
 		assert.Error(t, err)
@@ -58,15 +184,533 @@ func TestReimbursement(t *testing.T) {
 	t.Run("SubmitReimbursement - Fail because description is empty", func(t *testing.T) {
 		// Arrange
 		mockRepo := new(MockReimbursementRepository) // mock tidak akan dipanggil
-		reimbursementService := NewService(mockRepo)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository))
 		ctx := context.Background()
 		userID := "user-456"
 
 		// Act
-		err := reimbursementService.SubmitReimbursement(ctx, userID, time.Now(), "", 75000)
+		_, err := reimbursementService.SubmitReimbursement(ctx, userID, "cat-travel", time.Now(), "", money.FromMajor(75000, money.IDR))
 
 		// Assert
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "reimbursement description is required")
 	})
+
+	t.Run("SubmitReimbursement - Fail because period is closed", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository) // mock tidak akan dipanggil
+		mockLock := new(periodlock.MockChecker)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository), WithPeriodLock(mockLock))
+		ctx := context.Background()
+		date := time.Date(2025, 8, 20, 0, 0, 0, 0, time.UTC)
+
+		mockLock.On("IsDateLocked", ctx, date).Return(true, nil).Once()
+
+		_, err := reimbursementService.SubmitReimbursement(ctx, "user-456", "cat-travel", date, "Biaya Transport", money.FromMajor(75000, money.IDR))
+
+		assert.ErrorIs(t, err, periodlock.ErrLocked)
+		mockLock.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "CreateReimbursement", mock.Anything, mock.Anything)
+	})
+}
+
+func TestReimbursementApproval(t *testing.T) {
+	date := time.Date(2025, 9, 9, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2025, 9, 10, 9, 0, 0, 0, time.UTC)
+	clock := WithClock(func() time.Time { return now })
+	submitted := func() *Reimbursement {
+		return &Reimbursement{ID: "reimb-001", UserID: "user-456", Date: date, Amount: money.FromMajor(75000, money.IDR), Status: StatusSubmitted,
+			Attachments: []Attachment{{ID: "att-001", ReimbursementID: "reimb-001"}}}
+	}
+
+	t.Run("ApproveReimbursement - Records reviewer and history", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository)
+		mockLock := new(periodlock.MockChecker)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository), clock, WithPeriodLock(mockLock))
+		ctx := context.Background()
+
+		mockRepo.On("GetReimbursement", ctx, "reimb-001").Return(submitted(), nil).Once()
+		mockLock.On("IsDateLocked", ctx, date).Return(false, nil).Once()
+		mockRepo.On("UpdateReimbursementStatus", ctx, mock.MatchedBy(func(r *Reimbursement) bool {
+			return r.Status == StatusApproved && r.ReviewedBy == "approver-001" && r.UpdatedBy == "approver-001"
+		}), StatusSubmitted, StatusHistory{
+			ReimbursementID: "reimb-001",
+			FromStatus:      StatusSubmitted,
+			ToStatus:        StatusApproved,
+			ChangedBy:       "approver-001",
+			ChangedAt:       now,
+		}).Return(true, nil).Once()
+
+		item, err := reimbursementService.ApproveReimbursement(ctx, "reimb-001", "approver-001")
+
+		assert.NoError(t, err)
+		assert.Equal(t, StatusApproved, item.Status)
+		assert.Equal(t, now, *item.ReviewedAt)
+		mockRepo.AssertExpectations(t)
+		mockLock.AssertExpectations(t)
+	})
+
+	t.Run("ApproveReimbursement - Receipt is required", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository), clock)
+		ctx := context.Background()
+
+		noReceipt := submitted()
+		noReceipt.Attachments = nil
+		mockRepo.On("GetReimbursement", ctx, "reimb-001").Return(noReceipt, nil).Once()
+
+		_, err := reimbursementService.ApproveReimbursement(ctx, "reimb-001", "approver-001")
+
+		assert.ErrorIs(t, err, ErrReceiptRequired)
+		mockRepo.AssertNotCalled(t, "UpdateReimbursementStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("RejectReimbursement - Reason is required", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository) // mock tidak akan dipanggil
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository), clock)
+
+		_, err := reimbursementService.RejectReimbursement(context.Background(), "reimb-001", "  ", "approver-001")
+
+		assert.ErrorIs(t, err, approval.ErrReasonRequired)
+		mockRepo.AssertNotCalled(t, "GetReimbursement", mock.Anything, mock.Anything)
+	})
+
+	t.Run("RejectReimbursement - Records the reason", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository), clock)
+		ctx := context.Background()
+
+		mockRepo.On("GetReimbursement", ctx, "reimb-001").Return(submitted(), nil).Once()
+		mockRepo.On("UpdateReimbursementStatus", ctx, mock.MatchedBy(func(r *Reimbursement) bool {
+			return r.Status == StatusRejected && r.ReviewReason == "Nota tidak terbaca"
+		}), StatusSubmitted, mock.MatchedBy(func(h StatusHistory) bool {
+			return h.ToStatus == StatusRejected && h.Reason == "Nota tidak terbaca"
+		})).Return(true, nil).Once()
+
+		item, err := reimbursementService.RejectReimbursement(ctx, "reimb-001", "Nota tidak terbaca", "approver-001")
+
+		assert.NoError(t, err)
+		assert.Equal(t, StatusRejected, item.Status)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("ApproveReimbursement - Cannot review own claim", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository), clock)
+		ctx := context.Background()
+
+		mockRepo.On("GetReimbursement", ctx, "reimb-001").Return(submitted(), nil).Once()
+
+		_, err := reimbursementService.ApproveReimbursement(ctx, "reimb-001", "user-456")
+
+		assert.ErrorIs(t, err, ErrSelfReview)
+		mockRepo.AssertNotCalled(t, "UpdateReimbursementStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ApproveReimbursement - Paid claims cannot be reviewed again", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository), clock)
+		ctx := context.Background()
+
+		paid := submitted()
+		paid.Status = StatusPaid
+		mockRepo.On("GetReimbursement", ctx, "reimb-001").Return(paid, nil).Once()
+
+		_, err := reimbursementService.ApproveReimbursement(ctx, "reimb-001", "approver-001")
+
+		assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+	})
+
+	t.Run("ApproveReimbursement - Concurrent decision", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository), clock)
+		ctx := context.Background()
+
+		mockRepo.On("GetReimbursement", ctx, "reimb-001").Return(submitted(), nil).Once()
+		mockRepo.On("UpdateReimbursementStatus", ctx, mock.Anything, StatusSubmitted, mock.Anything).Return(false, nil).Once()
+
+		_, err := reimbursementService.ApproveReimbursement(ctx, "reimb-001", "approver-001")
+
+		assert.ErrorIs(t, err, approval.ErrModified)
+	})
+
+	t.Run("Settle - Paid claim can be returned to approved", func(t *testing.T) {
+		item := submitted()
+		item.Status = StatusApproved
+
+		history, err := item.Settle("payslip-001", "admin-001", now)
+		assert.NoError(t, err)
+		assert.Equal(t, StatusPaid, item.Status)
+		assert.Equal(t, "payslip-001", item.PayslipID)
+		assert.Equal(t, "payslip-001", history.PayslipID)
+
+		history, err = item.TransitionTo(StatusApproved, "admin-001", "payroll reversed", now)
+		assert.NoError(t, err)
+		assert.Equal(t, StatusPaid, history.FromStatus)
+		assert.Empty(t, item.PayslipID)
+		assert.Nil(t, item.PaidAt)
+	})
+}
+
+func TestReimbursementAttachments(t *testing.T) {
+	pdf := "%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj"
+	submitted := func() *Reimbursement {
+		return &Reimbursement{ID: "reimb-001", UserID: "user-456", Status: StatusSubmitted}
+	}
+
+	t.Run("AddAttachment - Stores a PDF receipt", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository)
+		mockStorage := new(MockReceiptStorage)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository), WithReceiptStorage(mockStorage))
+		ctx := context.Background()
+
+		mockRepo.On("GetReimbursement", ctx, "reimb-001").Return(submitted(), nil).Once()
+		mockStorage.On("Put", ctx, mock.MatchedBy(func(key string) bool {
+			return strings.HasPrefix(key, "reimbursements/reimb-001/") && strings.HasSuffix(key, ".pdf")
+		}), pdf, int64(len(pdf)), "application/pdf").Return(nil).Once()
+		mockRepo.On("CreateAttachment", ctx, mock.MatchedBy(func(a *Attachment) bool {
+			return a.ReimbursementID == "reimb-001" && a.FileName == "nota.pdf" && a.CreatedBy == "user-456"
+		})).Return(nil).Once()
+
+		attachment, err := reimbursementService.AddAttachment(ctx, "reimb-001", "user-456", `C:\Users\budi\nota.pdf`, int64(len(pdf)), strings.NewReader(pdf))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "application/pdf", attachment.ContentType)
+		mockRepo.AssertExpectations(t)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("AddAttachment - Content type is detected from the file", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository)
+		mockStorage := new(MockReceiptStorage)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository), WithReceiptStorage(mockStorage))
+		ctx := context.Background()
+
+		mockRepo.On("GetReimbursement", ctx, "reimb-001").Return(submitted(), nil).Once()
+		script := "<html><script>alert(1)</script></html>"
+
+		_, err := reimbursementService.AddAttachment(ctx, "reimb-001", "user-456", "nota.pdf", int64(len(script)), strings.NewReader(script))
+
+		assert.ErrorIs(t, err, ErrUnsupportedReceipt)
+		mockStorage.AssertNotCalled(t, "Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("AddAttachment - Size limit", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository) // mock tidak akan dipanggil
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository), WithReceiptStorage(new(MockReceiptStorage)))
+
+		_, err := reimbursementService.AddAttachment(context.Background(), "reimb-001", "user-456", "nota.pdf", MaxReceiptSize+1, strings.NewReader(pdf))
+		assert.ErrorIs(t, err, ErrReceiptTooLarge)
+		_, err = reimbursementService.AddAttachment(context.Background(), "reimb-001", "user-456", "nota.pdf", 0, strings.NewReader(""))
+		assert.ErrorIs(t, err, ErrReceiptTooLarge)
+	})
+
+	t.Run("AddAttachment - Only the owner of a submitted claim", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository), WithReceiptStorage(new(MockReceiptStorage)))
+		ctx := context.Background()
+
+		mockRepo.On("GetReimbursement", ctx, "reimb-001").Return(submitted(), nil).Once()
+		_, err := reimbursementService.AddAttachment(ctx, "reimb-001", "user-999", "nota.pdf", int64(len(pdf)), strings.NewReader(pdf))
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		approved := submitted()
+		approved.Status = StatusApproved
+		mockRepo.On("GetReimbursement", ctx, "reimb-001").Return(approved, nil).Once()
+		_, err = reimbursementService.AddAttachment(ctx, "reimb-001", "user-456", "nota.pdf", int64(len(pdf)), strings.NewReader(pdf))
+		assert.ErrorIs(t, err, ErrNotEditable)
+	})
+
+	t.Run("AddAttachment - Stored file is removed when saving fails", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository)
+		mockStorage := new(MockReceiptStorage)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository), WithReceiptStorage(mockStorage))
+		ctx := context.Background()
+
+		var key string
+		mockRepo.On("GetReimbursement", ctx, "reimb-001").Return(submitted(), nil).Once()
+		mockStorage.On("Put", ctx, mock.Anything, pdf, int64(len(pdf)), "application/pdf").Run(func(args mock.Arguments) {
+			key = args.String(1)
+		}).Return(nil).Once()
+		mockRepo.On("CreateAttachment", ctx, mock.Anything).Return(errors.New("db down")).Once()
+		mockStorage.On("Delete", ctx, mock.Anything).Return(nil).Once()
+
+		_, err := reimbursementService.AddAttachment(ctx, "reimb-001", "user-456", "nota.pdf", int64(len(pdf)), strings.NewReader(pdf))
+
+		assert.Error(t, err)
+		mockStorage.AssertCalled(t, "Delete", ctx, key)
+	})
+
+	t.Run("OpenAttachment - Owner and reviewers only", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository)
+		mockStorage := new(MockReceiptStorage)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository), WithReceiptStorage(mockStorage))
+		ctx := context.Background()
+		attachment := &Attachment{ID: "att-001", ReimbursementID: "reimb-001", StorageKey: "reimbursements/reimb-001/a.pdf"}
+
+		mockRepo.On("GetReimbursement", ctx, "reimb-001").Return(submitted(), nil)
+		mockRepo.On("GetAttachment", ctx, "reimb-001", "att-001").Return(attachment, nil)
+		mockStorage.On("Open", ctx, attachment.StorageKey).Return(io.NopCloser(strings.NewReader(pdf)), nil).Once()
+		mockStorage.On("Open", ctx, attachment.StorageKey).Return(io.NopCloser(strings.NewReader(pdf)), nil).Once()
+
+		_, _, err := reimbursementService.OpenAttachment(ctx, "reimb-001", "att-001", "user-999")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		for _, ownerID := range []string{"user-456", ""} {
+			got, content, err := reimbursementService.OpenAttachment(ctx, "reimb-001", "att-001", ownerID)
+			assert.NoError(t, err)
+			assert.Equal(t, attachment, got)
+			body, _ := io.ReadAll(content)
+			assert.Equal(t, pdf, string(body))
+		}
+	})
+}
+
+func TestReimbursementCategories(t *testing.T) {
+	ctx := context.Background()
+	idr := func(v int64) *money.Money {
+		m := money.FromMajor(v, money.IDR)
+		return &m
+	}
+	medical := &Category{ID: "cat-medical", Code: "MEDICAL", Name: "Medical", Active: true, Limits: []CategoryLimit{
+		{Grade: "", PerClaim: idr(1000000), PerMonth: idr(1500000), PerYear: idr(5000000)},
+		{Grade: "G5", PerClaim: idr(3000000), PerYear: idr(20000000)},
+	}}
+	date := time.Date(2025, 8, 20, 0, 0, 0, 0, time.UTC)
+	claims := []Reimbursement{
+		{CategoryID: "cat-medical", Date: time.Date(2025, 8, 5, 0, 0, 0, 0, time.UTC), Amount: money.FromMajor(1000000, money.IDR)},
+		{CategoryID: "cat-medical", Date: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), Amount: money.FromMajor(3500000, money.IDR)},
+	}
+
+	submit := func(grade string, date time.Time, amount int64) (*MockReimbursementRepository, error) {
+		mockRepo := new(MockReimbursementRepository)
+		mockEmpRepo := new(auth.MockEmployeeRepository)
+		reimbursementService := NewService(mockRepo, mockEmpRepo)
+
+		mockRepo.On("GetCategory", ctx, "cat-medical").Return(medical, nil).Once()
+		mockEmpRepo.On("GetByID", ctx, "user-456").Return(&employee.Employee{ID: "user-456", Grade: grade}, nil).Once()
+		mockRepo.On("LockClaims", ctx, "user-456").Return(nil).Once()
+		mockRepo.On("GetClaims", ctx, "user-456", "cat-medical",
+			time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)).Return(claims, nil).Maybe()
+		mockRepo.On("CreateReimbursement", ctx, mock.Anything).Return(nil).Maybe()
+
+		_, err := reimbursementService.SubmitReimbursement(ctx, "user-456", "cat-medical", date, "Kacamata", money.FromMajor(amount, money.IDR))
+		return mockRepo, err
+	}
+
+	t.Run("SubmitReimbursement - Within limits", func(t *testing.T) {
+		mockRepo, err := submit("G1", date, 400000)
+
+		assert.NoError(t, err)
+		mockRepo.AssertCalled(t, "CreateReimbursement", ctx, mock.Anything)
+	})
+
+	t.Run("SubmitReimbursement - Per claim limit", func(t *testing.T) {
+		mockRepo, err := submit("G1", date, 1200000)
+
+		var limitErr *LimitExceededError
+		assert.ErrorIs(t, err, ErrLimitExceeded)
+		assert.True(t, errors.As(err, &limitErr))
+		assert.Equal(t, LimitPerClaim, limitErr.Window)
+		mockRepo.AssertNotCalled(t, "CreateReimbursement", mock.Anything, mock.Anything)
+	})
+
+	t.Run("SubmitReimbursement - Monthly limit reports remaining balance", func(t *testing.T) {
+		mockRepo, err := submit("G1", date, 600000)
+
+		var limitErr *LimitExceededError
+		assert.True(t, errors.As(err, &limitErr))
+		assert.Equal(t, LimitPerMonth, limitErr.Window)
+		assert.Equal(t, "1000000.00", limitErr.Used.String())
+		assert.Equal(t, "500000.00", limitErr.Remaining.String())
+		assert.Contains(t, err.Error(), "remaining 500000.00")
+		mockRepo.AssertNotCalled(t, "CreateReimbursement", mock.Anything, mock.Anything)
+	})
+
+	t.Run("SubmitReimbursement - Yearly limit", func(t *testing.T) {
+		// September belum terpakai, tetapi sisa tahunan tinggal 500.000.
+		september := time.Date(2025, 9, 2, 0, 0, 0, 0, time.UTC)
+		mockRepo, err := submit("G1", september, 600000)
+
+		var limitErr *LimitExceededError
+		assert.True(t, errors.As(err, &limitErr))
+		assert.Equal(t, LimitPerYear, limitErr.Window)
+		assert.Equal(t, "4500000.00", limitErr.Used.String())
+		mockRepo.AssertNotCalled(t, "CreateReimbursement", mock.Anything, mock.Anything)
+
+		_, err = submit("G5", september, 2500000)
+		assert.NoError(t, err, "G5 has its own yearly limit")
+	})
+
+	t.Run("SubmitReimbursement - Grade specific limit", func(t *testing.T) {
+		_, err := submit("G5", date, 3500000)
+
+		var limitErr *LimitExceededError
+		assert.True(t, errors.As(err, &limitErr))
+		assert.Equal(t, LimitPerClaim, limitErr.Window)
+		assert.Equal(t, "3000000.00", limitErr.Limit.String())
+	})
+
+	t.Run("SubmitReimbursement - Category is required and must be active", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository))
+
+		_, err := reimbursementService.SubmitReimbursement(ctx, "user-456", "", date, "Kacamata", money.FromMajor(100000, money.IDR))
+		assert.ErrorIs(t, err, ErrCategoryRequired)
+
+		mockRepo.On("GetCategory", ctx, "cat-old").Return(&Category{ID: "cat-old", Active: false}, nil).Once()
+		_, err = reimbursementService.SubmitReimbursement(ctx, "user-456", "cat-old", date, "Kacamata", money.FromMajor(100000, money.IDR))
+		assert.ErrorIs(t, err, ErrUnknownCategory)
+
+		mockRepo.On("GetCategory", ctx, "cat-none").Return(nil, gorm.ErrRecordNotFound).Once()
+		_, err = reimbursementService.SubmitReimbursement(ctx, "user-456", "cat-none", date, "Kacamata", money.FromMajor(100000, money.IDR))
+		assert.ErrorIs(t, err, ErrUnknownCategory)
+	})
+
+	t.Run("GetBalances - Remaining per active category", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository)
+		mockEmpRepo := new(auth.MockEmployeeRepository)
+		reimbursementService := NewService(mockRepo, mockEmpRepo)
+
+		internet := Category{ID: "cat-internet", Code: "INTERNET", Active: true}
+		inactive := Category{ID: "cat-old", Code: "OLD", Active: false}
+		mockEmpRepo.On("GetByID", ctx, "user-456").Return(&employee.Employee{ID: "user-456", Grade: "G1"}, nil).Once()
+		mockRepo.On("ListCategories", ctx).Return([]Category{internet, inactive, *medical}, nil).Once()
+		mockRepo.On("GetClaims", ctx, "user-456", "", mock.Anything, mock.Anything).Return(claims, nil).Once()
+
+		balances, err := reimbursementService.GetBalances(ctx, "user-456", date)
+
+		assert.NoError(t, err)
+		assert.Len(t, balances, 2)
+		assert.Equal(t, "INTERNET", balances[0].Code)
+		assert.Nil(t, balances[0].Month.Remaining)
+		assert.Equal(t, "MEDICAL", balances[1].Code)
+		assert.Equal(t, "1000000.00", balances[1].PerClaim.String())
+		assert.Equal(t, "500000.00", balances[1].Month.Remaining.String())
+		assert.Equal(t, "4500000.00", balances[1].Year.Used.String())
+		assert.Equal(t, "500000.00", balances[1].Year.Remaining.String())
+	})
+
+	t.Run("CreateCategory - Validates code and limits", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository))
+
+		err := reimbursementService.CreateCategory(ctx, &Category{Code: "glasses!", Name: "Glasses"}, "admin-001")
+		assert.Error(t, err)
+
+		err = reimbursementService.CreateCategory(ctx, &Category{Code: "GLASSES", Name: "Glasses", Limits: []CategoryLimit{{PerYear: idr(-1)}}}, "admin-001")
+		assert.Error(t, err)
+
+		err = reimbursementService.CreateCategory(ctx, &Category{Code: "GLASSES", Name: "Glasses", Limits: []CategoryLimit{{Grade: "G1"}, {Grade: " G1 "}}}, "admin-001")
+		assert.ErrorContains(t, err, "duplicate limit")
+
+		mockRepo.On("CreateCategory", ctx, mock.MatchedBy(func(c *Category) bool {
+			return c.Code == "GLASSES" && c.Active && c.CreatedBy == "admin-001"
+		})).Return(nil).Once()
+		err = reimbursementService.CreateCategory(ctx, &Category{Code: " glasses ", Name: "Glasses"}, "admin-001")
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestReimbursementEdits(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 9, 15, 9, 0, 0, 0, time.UTC)
+	clock := WithClock(func() time.Time { return now })
+	date := time.Date(2025, 9, 11, 0, 0, 0, 0, time.UTC)
+	submitted := func() *Reimbursement {
+		return &Reimbursement{ID: "reimb-001", UserID: "user-456", CategoryID: "cat-travel", Date: date,
+			Description: "Taksi", Amount: money.FromMajor(150000, money.IDR), Status: StatusSubmitted}
+	}
+	travel := &Category{ID: "cat-travel", Code: "TRAVEL", Active: true, Limits: []CategoryLimit{{PerMonth: func() *money.Money {
+		m := money.FromMajor(500000, money.IDR)
+		return &m
+	}()}}}
+
+	t.Run("UpdateReimbursement - Keeps the previous values", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository)
+		mockEmpRepo := new(auth.MockEmployeeRepository)
+		reimbursementService := NewService(mockRepo, mockEmpRepo, clock)
+
+		mockRepo.On("GetReimbursement", ctx, "reimb-001").Return(submitted(), nil).Once()
+		mockRepo.On("GetCategory", ctx, "cat-travel").Return(travel, nil).Once()
+		mockEmpRepo.On("GetByID", ctx, "user-456").Return(&employee.Employee{ID: "user-456"}, nil).Once()
+		mockRepo.On("LockClaims", ctx, "user-456").Return(nil).Once()
+		// Nilai lama pengajuan ini tidak dihitung terhadap batasnya sendiri.
+		mockRepo.On("GetClaims", ctx, "user-456", "cat-travel", mock.Anything, mock.Anything).Return([]Reimbursement{*submitted()}, nil).Once()
+		mockRepo.On("UpdateReimbursement", ctx, mock.MatchedBy(func(r *Reimbursement) bool {
+			return r.Amount.String() == "450000.00" && r.Description == "Taksi bandara" && r.UpdatedBy == "user-456"
+		}), Revision{ReimbursementID: "reimb-001", CategoryID: "cat-travel", Date: date, Description: "Taksi",
+			Amount: money.FromMajor(150000, money.IDR), ChangedBy: "user-456", ChangedAt: now}).Return(true, nil).Once()
+
+		item, err := reimbursementService.UpdateReimbursement(ctx, "reimb-001", "user-456", "cat-travel", date, "Taksi bandara", money.FromMajor(450000, money.IDR))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Taksi bandara", item.Description)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("UpdateReimbursement - Only the owner's submitted claims", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository), clock)
+
+		approved := submitted()
+		approved.Status = StatusApproved
+		mockRepo.On("GetReimbursement", ctx, "reimb-001").Return(submitted(), nil).Once()
+		mockRepo.On("GetReimbursement", ctx, "reimb-002").Return(approved, nil).Once()
+
+		_, err := reimbursementService.UpdateReimbursement(ctx, "reimb-001", "user-999", "cat-travel", date, "Taksi", money.FromMajor(1, money.IDR))
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		_, err = reimbursementService.UpdateReimbursement(ctx, "reimb-002", "user-456", "cat-travel", date, "Taksi", money.FromMajor(1, money.IDR))
+		assert.ErrorIs(t, err, ErrNotEditable)
+		mockRepo.AssertNotCalled(t, "UpdateReimbursement", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("UpdateReimbursement - Cannot move into a closed period", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository)
+		mockLock := new(periodlock.MockChecker)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository), clock, WithPeriodLock(mockLock))
+		closedDate := time.Date(2025, 8, 20, 0, 0, 0, 0, time.UTC)
+
+		mockRepo.On("GetReimbursement", ctx, "reimb-001").Return(submitted(), nil).Once()
+		mockLock.On("IsDateLocked", ctx, date).Return(false, nil).Once()
+		mockLock.On("IsDateLocked", ctx, closedDate).Return(true, nil).Once()
+
+		_, err := reimbursementService.UpdateReimbursement(ctx, "reimb-001", "user-456", "cat-travel", closedDate, "Taksi", money.FromMajor(150000, money.IDR))
+
+		assert.ErrorIs(t, err, periodlock.ErrLocked)
+		mockRepo.AssertNotCalled(t, "UpdateReimbursement", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("CancelReimbursement - Recorded in the status history", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository), clock)
+
+		mockRepo.On("GetReimbursement", ctx, "reimb-001").Return(submitted(), nil).Once()
+		mockRepo.On("UpdateReimbursementStatus", ctx, mock.MatchedBy(func(r *Reimbursement) bool {
+			return r.Status == StatusCancelled && r.UpdatedBy == "user-456" && r.ReviewedBy == ""
+		}), StatusSubmitted, StatusHistory{ReimbursementID: "reimb-001", FromStatus: StatusSubmitted, ToStatus: StatusCancelled,
+			ChangedBy: "user-456", ChangedAt: now}).Return(true, nil).Once()
+
+		item, err := reimbursementService.CancelReimbursement(ctx, "reimb-001", "user-456")
+
+		assert.NoError(t, err)
+		assert.Equal(t, StatusCancelled, item.Status)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("GetRevisions - Hidden from other employees", func(t *testing.T) {
+		mockRepo := new(MockReimbursementRepository)
+		reimbursementService := NewService(mockRepo, new(auth.MockEmployeeRepository))
+
+		mockRepo.On("GetReimbursement", ctx, "reimb-001").Return(submitted(), nil)
+		mockRepo.On("GetRevisions", ctx, "reimb-001").Return([]Revision{{ReimbursementID: "reimb-001"}}, nil)
+
+		_, err := reimbursementService.GetRevisions(ctx, "reimb-001", "user-999")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		revisions, err := reimbursementService.GetRevisions(ctx, "reimb-001", "")
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 1)
+	})
 }
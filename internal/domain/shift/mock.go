@@ -0,0 +1,18 @@
+package shift
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSchedules adalah implementasi mock untuk Schedules
+type MockSchedules struct {
+	mock.Mock
+}
+
+func (m *MockSchedules) ScheduleFor(ctx context.Context, userID string, date time.Time) (Schedule, error) {
+	args := m.Called(ctx, userID, date)
+	return args.Get(0).(Schedule), args.Error(1)
+}
@@ -0,0 +1,106 @@
+package shift
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidClock dikembalikan untuk jam shift yang bukan format HH:MM.
+var ErrInvalidClock = errors.New("shift times must use the HH:MM format")
+
+// Shift adalah definisi jam kerja. StartTime dan EndTime adalah jam dinding
+// (HH:MM) pada zona waktu karyawan; shift yang EndTime-nya tidak setelah
+// StartTime berakhir keesokan harinya, mis. shift malam 22:00-06:00.
+type Shift struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	Code      string    `json:"code" gorm:"size:32;uniqueIndex"`
+	Name      string    `json:"name" gorm:"size:128"`
+	StartTime string    `json:"start_time" gorm:"size:5"`
+	EndTime   string    `json:"end_time" gorm:"size:5"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	CreatedBy string    `gorm:"size:36" json:"created_by"`
+	UpdatedBy string    `gorm:"size:36" json:"updated_by"`
+}
+
+func (s *Shift) BeforeCreate(tx *gorm.DB) error {
+	s.ID = uuid.New().String()
+	return nil
+}
+
+// CrossesMidnight melaporkan apakah shift berakhir pada hari berikutnya.
+func (s Shift) CrossesMidnight() bool {
+	return s.EndTime <= s.StartTime
+}
+
+// Window mengembalikan awal dan akhir shift yang dimulai pada tanggal
+// kalender date, di zona waktu loc.
+func (s Shift) Window(date time.Time, loc *time.Location) (start, end time.Time, err error) {
+	startClock, err := parseClock(s.StartTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	endClock, err := parseClock(s.EndTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	y, m, d := date.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	start = midnight.Add(startClock)
+	end = midnight.Add(endClock)
+	if s.CrossesMidnight() {
+		end = time.Date(y, m, d+1, 0, 0, 0, 0, loc).Add(endClock)
+	}
+	return start, end, nil
+}
+
+// parseClock mengubah "HH:MM" menjadi durasi sejak tengah malam.
+func parseClock(clock string) (time.Duration, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil || len(clock) != 5 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidClock, clock)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// RosterEntry adalah jadwal kerja karyawan pada satu tanggal. ShiftID kosong
+// berarti karyawan dijadwalkan libur pada tanggal tersebut.
+type RosterEntry struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	UserID    string    `json:"user_id" gorm:"size:36;uniqueIndex:idx_roster_user_date"`
+	Date      time.Time `json:"date" gorm:"type:date;uniqueIndex:idx_roster_user_date"`
+	ShiftID   string    `json:"shift_id,omitempty" gorm:"size:36"`
+	Shift     *Shift    `json:"shift,omitempty" gorm:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	CreatedBy string    `gorm:"size:36" json:"created_by"`
+	UpdatedBy string    `gorm:"size:36" json:"updated_by"`
+}
+
+func (RosterEntry) TableName() string {
+	return "roster_entries"
+}
+
+func (e *RosterEntry) BeforeCreate(tx *gorm.DB) error {
+	e.ID = uuid.New().String()
+	return nil
+}
+
+// Schedule adalah jadwal kerja karyawan pada satu tanggal. Tanpa roster
+// (Rostered false) berlaku pola kerja Senin-Jumat dan kalender hari libur.
+type Schedule struct {
+	Date     time.Time `json:"date"`
+	Rostered bool      `json:"rostered"`
+	Shift    *Shift    `json:"shift,omitempty"` // nil pada tanggal roster berarti libur
+}
+
+// Working melaporkan apakah karyawan dijadwalkan bekerja menurut roster.
+// Hanya bermakna jika Rostered.
+func (s Schedule) Working() bool {
+	return s.Shift != nil
+}
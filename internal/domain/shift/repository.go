@@ -0,0 +1,71 @@
+package shift
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	CreateShift(ctx context.Context, shift *Shift) error
+	GetShift(ctx context.Context, id string) (*Shift, error)
+	ListShifts(ctx context.Context) ([]Shift, error)
+	UpdateShift(ctx context.Context, shift *Shift) error
+	// SaveRoster menyimpan entries; entri yang sudah ada untuk karyawan dan
+	// tanggal yang sama ditimpa.
+	SaveRoster(ctx context.Context, entries []RosterEntry) error
+	// GetRoster mengembalikan roster karyawan dari start sampai end
+	// (inklusif), terurut menurut tanggal. Shift tidak diisi.
+	GetRoster(ctx context.Context, userID string, start, end time.Time) ([]RosterEntry, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db}
+}
+
+func (r *repository) CreateShift(ctx context.Context, shift *Shift) error {
+	return r.db.WithContext(ctx).Create(shift).Error
+}
+
+func (r *repository) GetShift(ctx context.Context, id string) (*Shift, error) {
+	var shift Shift
+	if err := r.db.WithContext(ctx).First(&shift, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+func (r *repository) ListShifts(ctx context.Context) ([]Shift, error) {
+	var shifts []Shift
+	err := r.db.WithContext(ctx).Order("code").Find(&shifts).Error
+	return shifts, err
+}
+
+func (r *repository) UpdateShift(ctx context.Context, shift *Shift) error {
+	return r.db.WithContext(ctx).Save(shift).Error
+}
+
+func (r *repository) SaveRoster(ctx context.Context, entries []RosterEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"shift_id", "updated_at", "updated_by"}),
+	}).Create(&entries).Error
+}
+
+func (r *repository) GetRoster(ctx context.Context, userID string, start, end time.Time) ([]RosterEntry, error) {
+	var entries []RosterEntry
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND date >= ? AND date <= ?", userID, start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Order("date").
+		Find(&entries).Error
+	return entries, err
+}
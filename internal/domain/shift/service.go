@@ -0,0 +1,236 @@
+package shift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/periodlock"
+)
+
+type Service interface {
+	CreateShift(ctx context.Context, shift *Shift, adminID string) error
+	ListShifts(ctx context.Context) ([]Shift, error)
+	UpdateShift(ctx context.Context, id string, update ShiftUpdate, adminID string) (*Shift, error)
+	// AssignRoster menetapkan shift karyawan per tanggal. ShiftID kosong
+	// menjadwalkan karyawan libur; jadwal yang sudah ada pada tanggal yang
+	// sama ditimpa.
+	AssignRoster(ctx context.Context, userID string, assignments []Assignment, adminID string) ([]RosterEntry, error)
+	// GetRoster mengembalikan roster karyawan dari start sampai end
+	// (inklusif) beserta shift-nya.
+	GetRoster(ctx context.Context, userID string, start, end time.Time) ([]RosterEntry, error)
+	Schedules
+}
+
+// Schedules menyediakan jadwal kerja karyawan dari roster shift. Service lain
+// yang hanya membutuhkan jadwal kerja bergantung pada interface ini, bukan
+// pada Service secara utuh.
+type Schedules interface {
+	// ScheduleFor mengembalikan jadwal kerja karyawan pada tanggal kalender
+	// date.
+	ScheduleFor(ctx context.Context, userID string, date time.Time) (Schedule, error)
+}
+
+// ShiftUpdate berisi field shift yang boleh diubah. Field nil tidak diubah.
+// Code tidak dapat diubah.
+type ShiftUpdate struct {
+	Name      *string `json:"name"`
+	StartTime *string `json:"start_time"`
+	EndTime   *string `json:"end_time"`
+	Active    *bool   `json:"active"`
+}
+
+// Assignment adalah jadwal satu tanggal pada AssignRoster.
+type Assignment struct {
+	Date    time.Time
+	ShiftID string
+}
+
+// ErrUnknownShift dikembalikan untuk shift yang tidak ada atau sudah
+// dinonaktifkan.
+var ErrUnknownShift = errors.New("unknown or inactive shift")
+
+type service struct {
+	repo      Repository
+	employees employee.Repository
+	lock      periodlock.Guard
+}
+
+// Option mengubah konfigurasi opsional dari service shift.
+type Option func(*service)
+
+// WithPeriodLock menolak perubahan roster pada tanggal di dalam periode
+// payroll yang sudah ditutup, karena hari kerja periode itu sudah dibayar.
+func WithPeriodLock(lock periodlock.Checker) Option {
+	return func(s *service) {
+		s.lock = periodlock.Guard{Checker: lock}
+	}
+}
+
+func NewService(repo Repository, employees employee.Repository, opts ...Option) Service {
+	s := &service{repo: repo, employees: employees}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+var shiftCodePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,31}$`)
+
+func (s *service) CreateShift(ctx context.Context, shift *Shift, adminID string) error {
+	shift.Code = strings.ToUpper(strings.TrimSpace(shift.Code))
+	if !shiftCodePattern.MatchString(shift.Code) {
+		return errors.New("shift code must be 2-32 characters of A-Z, 0-9 or underscore")
+	}
+	if err := validate(shift); err != nil {
+		return err
+	}
+
+	shift.Active = true
+	shift.CreatedBy = adminID
+	shift.UpdatedBy = adminID
+	return s.repo.CreateShift(ctx, shift)
+}
+
+func (s *service) ListShifts(ctx context.Context) ([]Shift, error) {
+	return s.repo.ListShifts(ctx)
+}
+
+func (s *service) UpdateShift(ctx context.Context, id string, update ShiftUpdate, adminID string) (*Shift, error) {
+	shift, err := s.repo.GetShift(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if update.Name != nil {
+		shift.Name = *update.Name
+	}
+	if update.StartTime != nil {
+		shift.StartTime = *update.StartTime
+	}
+	if update.EndTime != nil {
+		shift.EndTime = *update.EndTime
+	}
+	if update.Active != nil {
+		shift.Active = *update.Active
+	}
+	if err := validate(shift); err != nil {
+		return nil, err
+	}
+
+	shift.UpdatedBy = adminID
+	if err := s.repo.UpdateShift(ctx, shift); err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+func validate(shift *Shift) error {
+	shift.Name = strings.TrimSpace(shift.Name)
+	if shift.Name == "" {
+		return errors.New("shift name is required")
+	}
+	if _, err := parseClock(shift.StartTime); err != nil {
+		return err
+	}
+	if _, err := parseClock(shift.EndTime); err != nil {
+		return err
+	}
+	if shift.StartTime == shift.EndTime {
+		return errors.New("shift start and end times must differ")
+	}
+	return nil
+}
+
+func (s *service) AssignRoster(ctx context.Context, userID string, assignments []Assignment, adminID string) ([]RosterEntry, error) {
+	if len(assignments) == 0 {
+		return nil, errors.New("at least one roster entry is required")
+	}
+	if _, err := s.employees.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	shifts, err := s.shiftsByID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]RosterEntry, len(assignments))
+	seen := make(map[string]bool, len(assignments))
+	for i, a := range assignments {
+		day := a.Date.Format("2006-01-02")
+		if seen[day] {
+			return nil, fmt.Errorf("roster entry %d: duplicate date %s", i+1, day)
+		}
+		seen[day] = true
+
+		entry := RosterEntry{UserID: userID, Date: a.Date, ShiftID: a.ShiftID, CreatedBy: adminID, UpdatedBy: adminID}
+		if a.ShiftID != "" {
+			shift, ok := shifts[a.ShiftID]
+			if !ok || !shift.Active {
+				return nil, fmt.Errorf("roster entry %d: %w", i+1, ErrUnknownShift)
+			}
+			entry.Shift = &shift
+		}
+		if err := s.lock.Check(ctx, a.Date); err != nil {
+			return nil, fmt.Errorf("roster entry %d: %w", i+1, err)
+		}
+		entries[i] = entry
+	}
+
+	if err := s.repo.SaveRoster(ctx, entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (s *service) GetRoster(ctx context.Context, userID string, start, end time.Time) ([]RosterEntry, error) {
+	entries, err := s.repo.GetRoster(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return entries, nil
+	}
+	shifts, err := s.shiftsByID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if shift, ok := shifts[entries[i].ShiftID]; ok {
+			entries[i].Shift = &shift
+		}
+	}
+	return entries, nil
+}
+
+func (s *service) ScheduleFor(ctx context.Context, userID string, date time.Time) (Schedule, error) {
+	schedule := Schedule{Date: date}
+	entries, err := s.repo.GetRoster(ctx, userID, date, date)
+	if err != nil || len(entries) == 0 {
+		return schedule, err
+	}
+	schedule.Rostered = true
+	if entries[0].ShiftID != "" {
+		// Shift yang sudah dinonaktifkan tetap berlaku untuk roster yang ada.
+		if schedule.Shift, err = s.repo.GetShift(ctx, entries[0].ShiftID); err != nil {
+			return Schedule{}, err
+		}
+	}
+	return schedule, nil
+}
+
+// shiftsByID mengembalikan seluruh shift, termasuk yang nonaktif.
+func (s *service) shiftsByID(ctx context.Context) (map[string]Shift, error) {
+	shifts, err := s.repo.ListShifts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]Shift, len(shifts))
+	for _, shift := range shifts {
+		byID[shift.ID] = shift
+	}
+	return byID, nil
+}
@@ -0,0 +1,185 @@
+package shift
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/auth"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/periodlock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockShiftRepository adalah implementasi mock untuk shift.Repository
+type MockShiftRepository struct {
+	mock.Mock
+}
+
+func (m *MockShiftRepository) CreateShift(ctx context.Context, shift *Shift) error {
+	args := m.Called(ctx, shift)
+	return args.Error(0)
+}
+func (m *MockShiftRepository) GetShift(ctx context.Context, id string) (*Shift, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Shift), args.Error(1)
+}
+func (m *MockShiftRepository) ListShifts(ctx context.Context) ([]Shift, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Shift), args.Error(1)
+}
+func (m *MockShiftRepository) UpdateShift(ctx context.Context, shift *Shift) error {
+	args := m.Called(ctx, shift)
+	return args.Error(0)
+}
+func (m *MockShiftRepository) SaveRoster(ctx context.Context, entries []RosterEntry) error {
+	args := m.Called(ctx, entries)
+	return args.Error(0)
+}
+func (m *MockShiftRepository) GetRoster(ctx context.Context, userID string, start, end time.Time) ([]RosterEntry, error) {
+	args := m.Called(ctx, userID, start, end)
+	return args.Get(0).([]RosterEntry), args.Error(1)
+}
+
+func day(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+func TestShiftWindow(t *testing.T) {
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+
+	t.Run("Day shift ends on the same date", func(t *testing.T) {
+		start, end, err := Shift{StartTime: "08:00", EndTime: "17:00"}.Window(day("2025-09-10"), jakarta)
+
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 9, 10, 8, 0, 0, 0, jakarta), start)
+		assert.Equal(t, time.Date(2025, 9, 10, 17, 0, 0, 0, jakarta), end)
+	})
+
+	t.Run("Night shift ends on the next date", func(t *testing.T) {
+		night := Shift{StartTime: "22:00", EndTime: "06:00"}
+		start, end, err := night.Window(day("2025-09-30"), jakarta)
+
+		assert.NoError(t, err)
+		assert.True(t, night.CrossesMidnight())
+		assert.Equal(t, time.Date(2025, 9, 30, 22, 0, 0, 0, jakarta), start)
+		assert.Equal(t, time.Date(2025, 10, 1, 6, 0, 0, 0, jakarta), end)
+	})
+
+	t.Run("Rejects malformed clocks", func(t *testing.T) {
+		_, _, err := Shift{StartTime: "8:00", EndTime: "17:00"}.Window(day("2025-09-10"), jakarta)
+
+		assert.ErrorIs(t, err, ErrInvalidClock)
+	})
+}
+
+func TestShiftService(t *testing.T) {
+	ctx := context.Background()
+	morning := Shift{ID: "shift-001", Code: "MORNING", Name: "Pagi", StartTime: "08:00", EndTime: "17:00", Active: true}
+	retired := Shift{ID: "shift-002", Code: "RETIRED", Name: "Lama", StartTime: "09:00", EndTime: "18:00", Active: false}
+
+	t.Run("CreateShift - Validates code and times", func(t *testing.T) {
+		mockRepo := new(MockShiftRepository) // mock tidak akan dipanggil
+		shiftService := NewService(mockRepo, new(auth.MockEmployeeRepository))
+
+		err := shiftService.CreateShift(ctx, &Shift{Code: "x", Name: "Pagi", StartTime: "08:00", EndTime: "17:00"}, "admin-001")
+		assert.Error(t, err)
+
+		err = shiftService.CreateShift(ctx, &Shift{Code: "MORNING", Name: "Pagi", StartTime: "08:00", EndTime: "25:00"}, "admin-001")
+		assert.ErrorIs(t, err, ErrInvalidClock)
+
+		err = shiftService.CreateShift(ctx, &Shift{Code: "MORNING", Name: "Pagi", StartTime: "08:00", EndTime: "08:00"}, "admin-001")
+		assert.EqualError(t, err, "shift start and end times must differ")
+		mockRepo.AssertNotCalled(t, "CreateShift", mock.Anything, mock.Anything)
+	})
+
+	t.Run("AssignRoster - Saves shifts and days off", func(t *testing.T) {
+		// Arrange
+		mockRepo := new(MockShiftRepository)
+		mockEmployees := new(auth.MockEmployeeRepository)
+		mockLock := new(periodlock.MockChecker)
+		shiftService := NewService(mockRepo, mockEmployees, WithPeriodLock(mockLock))
+
+		mockEmployees.On("GetByID", ctx, "user-123").Return(&employee.Employee{ID: "user-123"}, nil).Once()
+		mockRepo.On("ListShifts", ctx).Return([]Shift{morning, retired}, nil).Once()
+		mockLock.On("IsDateLocked", ctx, mock.Anything).Return(false, nil).Twice()
+		mockRepo.On("SaveRoster", ctx, mock.MatchedBy(func(es []RosterEntry) bool {
+			return len(es) == 2 && es[0].ShiftID == "shift-001" && es[0].Shift.Code == "MORNING" &&
+				es[1].ShiftID == "" && es[1].Shift == nil && es[1].UserID == "user-123" && es[1].CreatedBy == "admin-001"
+		})).Return(nil).Once()
+
+		// Act
+		entries, err := shiftService.AssignRoster(ctx, "user-123", []Assignment{
+			{Date: day("2025-09-13"), ShiftID: "shift-001"},
+			{Date: day("2025-09-15")},
+		}, "admin-001")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, entries, 2)
+		mockRepo.AssertExpectations(t)
+		mockLock.AssertExpectations(t)
+	})
+
+	t.Run("AssignRoster - Rejects invalid entries", func(t *testing.T) {
+		mockRepo := new(MockShiftRepository)
+		mockEmployees := new(auth.MockEmployeeRepository)
+		mockLock := new(periodlock.MockChecker)
+		shiftService := NewService(mockRepo, mockEmployees, WithPeriodLock(mockLock))
+
+		mockEmployees.On("GetByID", ctx, "user-123").Return(&employee.Employee{ID: "user-123"}, nil)
+		mockRepo.On("ListShifts", ctx).Return([]Shift{morning, retired}, nil)
+		mockLock.On("IsDateLocked", ctx, day("2025-08-29")).Return(true, nil)
+		mockLock.On("IsDateLocked", ctx, mock.Anything).Return(false, nil)
+
+		_, err := shiftService.AssignRoster(ctx, "user-123", []Assignment{{Date: day("2025-09-13"), ShiftID: "shift-002"}}, "admin-001")
+		assert.ErrorIs(t, err, ErrUnknownShift)
+
+		_, err = shiftService.AssignRoster(ctx, "user-123", []Assignment{{Date: day("2025-09-13"), ShiftID: "missing"}}, "admin-001")
+		assert.ErrorIs(t, err, ErrUnknownShift)
+
+		_, err = shiftService.AssignRoster(ctx, "user-123", []Assignment{
+			{Date: day("2025-09-13"), ShiftID: "shift-001"},
+			{Date: day("2025-09-13")},
+		}, "admin-001")
+		assert.EqualError(t, err, "roster entry 2: duplicate date 2025-09-13")
+
+		_, err = shiftService.AssignRoster(ctx, "user-123", []Assignment{{Date: day("2025-08-29"), ShiftID: "shift-001"}}, "admin-001")
+		assert.ErrorIs(t, err, periodlock.ErrLocked)
+		mockRepo.AssertNotCalled(t, "SaveRoster", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ScheduleFor - Distinguishes unrostered, shift and day off", func(t *testing.T) {
+		mockRepo := new(MockShiftRepository)
+		shiftService := NewService(mockRepo, new(auth.MockEmployeeRepository))
+
+		mockRepo.On("GetRoster", ctx, "user-123", day("2025-09-12"), day("2025-09-12")).Return([]RosterEntry{}, nil).Once()
+		mockRepo.On("GetRoster", ctx, "user-123", day("2025-09-13"), day("2025-09-13")).
+			Return([]RosterEntry{{UserID: "user-123", Date: day("2025-09-13"), ShiftID: "shift-002"}}, nil).Once()
+		mockRepo.On("GetRoster", ctx, "user-123", day("2025-09-15"), day("2025-09-15")).
+			Return([]RosterEntry{{UserID: "user-123", Date: day("2025-09-15")}}, nil).Once()
+		// Shift nonaktif tetap berlaku untuk roster yang sudah ada.
+		mockRepo.On("GetShift", ctx, "shift-002").Return(&retired, nil).Once()
+
+		schedule, err := shiftService.ScheduleFor(ctx, "user-123", day("2025-09-12"))
+		assert.NoError(t, err)
+		assert.False(t, schedule.Rostered)
+
+		schedule, err = shiftService.ScheduleFor(ctx, "user-123", day("2025-09-13"))
+		assert.NoError(t, err)
+		assert.True(t, schedule.Rostered)
+		assert.True(t, schedule.Working())
+		assert.Equal(t, "RETIRED", schedule.Shift.Code)
+
+		schedule, err = shiftService.ScheduleFor(ctx, "user-123", day("2025-09-15"))
+		assert.NoError(t, err)
+		assert.True(t, schedule.Rostered)
+		assert.False(t, schedule.Working())
+		mockRepo.AssertExpectations(t)
+	})
+}
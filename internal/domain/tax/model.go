@@ -0,0 +1,80 @@
+package tax
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
+)
+
+// Status adalah status PTKP (Penghasilan Tidak Kena Pajak) karyawan,
+// mis. "TK/0" (tidak kawin, tanpa tanggungan) atau "K/2".
+type Status string
+
+const (
+	StatusTK0 Status = "TK/0"
+	StatusTK1 Status = "TK/1"
+	StatusTK2 Status = "TK/2"
+	StatusTK3 Status = "TK/3"
+	StatusK0  Status = "K/0"
+	StatusK1  Status = "K/1"
+	StatusK2  Status = "K/2"
+	StatusK3  Status = "K/3"
+)
+
+// DefaultStatus dipakai untuk karyawan yang belum mengisi status PTKP.
+const DefaultStatus = StatusTK0
+
+// ParseStatus memvalidasi status PTKP. String kosong dianggap DefaultStatus.
+func ParseStatus(s string) (Status, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return DefaultStatus, nil
+	}
+	st := Status(s)
+	if _, ok := ptkp[st]; !ok {
+		return "", fmt.Errorf("unknown PTKP status %q", s)
+	}
+	return st, nil
+}
+
+// PTKP mengembalikan nilai PTKP setahun untuk status ini.
+func (s Status) PTKP() money.Money {
+	return money.FromMajor(ptkp[s], money.IDR)
+}
+
+// Category mengembalikan kategori TER (A, B, atau C) sesuai PP 58/2023.
+func (s Status) Category() Category {
+	switch s {
+	case StatusTK2, StatusTK3, StatusK1, StatusK2:
+		return CategoryB
+	case StatusK3:
+		return CategoryC
+	}
+	return CategoryA
+}
+
+// Category adalah kategori Tarif Efektif Rata-rata bulanan.
+type Category string
+
+const (
+	CategoryA Category = "A"
+	CategoryB Category = "B"
+	CategoryC Category = "C"
+)
+
+// AnnualIncome adalah data setahun yang dibutuhkan untuk perhitungan ulang
+// PPh 21 di masa pajak terakhir (Desember).
+type AnnualIncome struct {
+	// Gross adalah penghasilan bruto kena pajak Januari s.d. Desember.
+	Gross money.Money
+	// PensionContributions adalah iuran JHT/JP yang dibayar karyawan
+	// (pengurang penghasilan bruto).
+	PensionContributions money.Money
+	// WithheldToDate adalah PPh 21 yang sudah dipotong pada masa
+	// sebelumnya di tahun yang sama.
+	WithheldToDate money.Money
+	// Months adalah jumlah bulan bekerja dalam tahun pajak (1-12),
+	// dipakai untuk batas maksimum biaya jabatan.
+	Months int
+}
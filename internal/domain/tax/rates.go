@@ -0,0 +1,85 @@
+package tax
+
+import "math"
+
+// ptkp adalah nilai PTKP setahun dalam Rupiah (PMK 101/PMK.010/2016).
+var ptkp = map[Status]int64{
+	StatusTK0: 54000000,
+	StatusTK1: 58500000,
+	StatusTK2: 63000000,
+	StatusTK3: 67500000,
+	StatusK0:  58500000,
+	StatusK1:  63000000,
+	StatusK2:  67500000,
+	StatusK3:  72000000,
+}
+
+// bracket adalah satu lapisan tarif: berlaku untuk penghasilan sampai dengan
+// upTo Rupiah, dengan tarif dalam basis poin (1% = 100).
+type bracket struct {
+	upTo    int64
+	rateBps int64
+}
+
+const unlimited = math.MaxInt64
+
+// terRates adalah tabel Tarif Efektif Rata-rata bulanan (lampiran PP 58/2023),
+// diterapkan pada penghasilan bruto sebulan.
+var terRates = map[Category][]bracket{
+	CategoryA: {
+		{5400000, 0}, {5650000, 25}, {5950000, 50}, {6300000, 75},
+		{6750000, 100}, {7500000, 125}, {8550000, 150}, {9650000, 175},
+		{10050000, 200}, {10350000, 225}, {10700000, 250}, {11050000, 300},
+		{11600000, 350}, {12500000, 400}, {13750000, 500}, {15100000, 600},
+		{16950000, 700}, {19750000, 800}, {24150000, 900}, {26450000, 1000},
+		{28000000, 1100}, {30050000, 1200}, {32400000, 1300}, {35400000, 1400},
+		{39100000, 1500}, {43850000, 1600}, {47800000, 1700}, {51400000, 1800},
+		{56300000, 1900}, {62200000, 2000}, {68600000, 2100}, {77500000, 2200},
+		{89000000, 2300}, {103000000, 2400}, {125000000, 2500}, {157000000, 2600},
+		{206000000, 2700}, {337000000, 2800}, {454000000, 2900}, {550000000, 3000},
+		{695000000, 3100}, {910000000, 3200}, {1400000000, 3300}, {unlimited, 3400},
+	},
+	CategoryB: {
+		{6200000, 0}, {6500000, 25}, {6850000, 50}, {7300000, 75},
+		{9200000, 100}, {10750000, 150}, {11250000, 200}, {11600000, 250},
+		{12600000, 300}, {13600000, 400}, {14950000, 500}, {16400000, 600},
+		{18450000, 700}, {21850000, 800}, {26000000, 900}, {27700000, 1000},
+		{29350000, 1100}, {31450000, 1200}, {33950000, 1300}, {37100000, 1400},
+		{41100000, 1500}, {45800000, 1600}, {49500000, 1700}, {53800000, 1800},
+		{58500000, 1900}, {64000000, 2000}, {71000000, 2100}, {80000000, 2200},
+		{93000000, 2300}, {109000000, 2400}, {129000000, 2500}, {163000000, 2600},
+		{211000000, 2700}, {374000000, 2800}, {459000000, 2900}, {555000000, 3000},
+		{704000000, 3100}, {957000000, 3200}, {1405000000, 3300}, {unlimited, 3400},
+	},
+	CategoryC: {
+		{6600000, 0}, {6950000, 25}, {7350000, 50}, {7800000, 75},
+		{8850000, 100}, {9800000, 125}, {10950000, 150}, {11200000, 175},
+		{12050000, 200}, {12950000, 300}, {14150000, 400}, {15550000, 500},
+		{17050000, 600}, {19500000, 700}, {22700000, 800}, {26600000, 900},
+		{28100000, 1000}, {30100000, 1100}, {32600000, 1200}, {35400000, 1300},
+		{38900000, 1400}, {43000000, 1500}, {47400000, 1600}, {51200000, 1700},
+		{55800000, 1800}, {60400000, 1900}, {66700000, 2000}, {74500000, 2100},
+		{83200000, 2200}, {95600000, 2300}, {110000000, 2400}, {134000000, 2500},
+		{169000000, 2600}, {221000000, 2700}, {390000000, 2800}, {463000000, 2900},
+		{561000000, 3000}, {709000000, 3100}, {965000000, 3200}, {1419000000, 3300},
+		{unlimited, 3400},
+	},
+}
+
+// progressiveRates adalah tarif Pasal 17 ayat (1) huruf a UU HPP, diterapkan
+// secara berlapis pada Penghasilan Kena Pajak setahun.
+var progressiveRates = []bracket{
+	{60000000, 500},
+	{250000000, 1500},
+	{500000000, 2500},
+	{5000000000, 3000},
+	{unlimited, 3500},
+}
+
+const (
+	// occupationalCostBps adalah biaya jabatan: 5% dari penghasilan bruto.
+	occupationalCostBps = 500
+	// occupationalCostMonthlyCap adalah batas biaya jabatan per bulan
+	// (Rp6.000.000 setahun).
+	occupationalCostMonthlyCap = 500000
+)
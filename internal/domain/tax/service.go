@@ -0,0 +1,119 @@
+// Package tax menghitung pemotongan PPh 21 karyawan tetap: tarif efektif
+// rata-rata (TER) bulanan untuk masa Januari s.d. November dan perhitungan
+// ulang setahun dengan tarif Pasal 17 pada masa Desember.
+package tax
+
+import (
+	"fmt"
+
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
+)
+
+// Service mendefinisikan kontrak perhitungan PPh 21.
+type Service interface {
+	// MonthlyWithholding menghitung PPh 21 masa (Jan-Nov) dengan tarif TER
+	// dari penghasilan bruto sebulan.
+	MonthlyWithholding(status Status, gross money.Money) (money.Money, error)
+	// PeriodWithholding menghitung PPh 21 masa untuk periode num/den bulan,
+	// mis. 1/2 untuk periode semi-bulanan. Lapisan TER dipilih dari
+	// penghasilan sebulan (gross x den/num) dan tarifnya dikenakan pada gross.
+	PeriodWithholding(status Status, gross money.Money, num, den int64) (money.Money, error)
+	// AnnualTax menghitung PPh 21 terutang setahun dengan tarif Pasal 17.
+	AnnualTax(status Status, in AnnualIncome) (money.Money, error)
+	// DecemberWithholding menghitung PPh 21 masa Desember, yaitu PPh 21
+	// setahun dikurangi yang sudah dipotong. Hasil negatif berarti lebih
+	// potong yang harus dikembalikan ke karyawan.
+	DecemberWithholding(status Status, in AnnualIncome) (money.Money, error)
+}
+
+type service struct{}
+
+// NewService membuat instance baru dari service pajak.
+func NewService() Service {
+	return &service{}
+}
+
+// taxRounding: PPh 21 dibulatkan ke bawah ke Rupiah penuh.
+var taxRounding = money.Rounding{Mode: money.RoundDown, Scale: 0}
+
+// pkpRounding: PKP dibulatkan ke bawah ke ribuan penuh.
+var pkpRounding = money.Rounding{Mode: money.RoundDown, Scale: -3}
+
+func (s *service) MonthlyWithholding(status Status, gross money.Money) (money.Money, error) {
+	return s.PeriodWithholding(status, gross, 1, 1)
+}
+
+func (s *service) PeriodWithholding(status Status, gross money.Money, num, den int64) (money.Money, error) {
+	if err := validate(status); err != nil {
+		return money.Money{}, err
+	}
+	if !gross.IsPositive() {
+		return money.Zero(gross.Currency()), nil
+	}
+
+	rate := terRates[status.Category()][0].rateBps
+	for _, b := range terRates[status.Category()] {
+		rate = b.rateBps
+		if b.upTo == unlimited || gross.Mul(den).Cmp(money.FromMajor(b.upTo, gross.Currency()).Mul(num)) <= 0 {
+			break
+		}
+	}
+	return gross.MulDiv(rate, 10000, taxRounding), nil
+}
+
+func (s *service) AnnualTax(status Status, in AnnualIncome) (money.Money, error) {
+	if err := validate(status); err != nil {
+		return money.Money{}, err
+	}
+	cur := in.Gross.Currency()
+
+	months := in.Months
+	if months <= 0 || months > 12 {
+		months = 12
+	}
+
+	// Biaya jabatan: 5% bruto, maksimal Rp500.000 per bulan bekerja.
+	occupationalCost := in.Gross.MulDiv(occupationalCostBps, 10000, taxRounding)
+	if limit := money.FromMajor(occupationalCostMonthlyCap*int64(months), cur); occupationalCost.Cmp(limit) > 0 {
+		occupationalCost = limit
+	}
+
+	net := in.Gross.Sub(occupationalCost).Sub(in.PensionContributions)
+	taxable := net.Sub(status.PTKP()).Round(pkpRounding)
+	if !taxable.IsPositive() {
+		return money.Zero(cur), nil
+	}
+
+	total := money.Zero(cur)
+	lower := money.Zero(cur)
+	for _, b := range progressiveRates {
+		layer := taxable.Sub(lower)
+		if b.upTo != unlimited {
+			upper := money.FromMajor(b.upTo, cur)
+			if width := upper.Sub(lower); layer.Cmp(width) > 0 {
+				layer = width
+			}
+			lower = upper
+		}
+		if !layer.IsPositive() {
+			break
+		}
+		total = total.Add(layer.MulDiv(b.rateBps, 10000, taxRounding))
+	}
+	return total, nil
+}
+
+func (s *service) DecemberWithholding(status Status, in AnnualIncome) (money.Money, error) {
+	annual, err := s.AnnualTax(status, in)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return annual.Sub(in.WithheldToDate), nil
+}
+
+func validate(status Status) error {
+	if _, ok := ptkp[status]; !ok {
+		return fmt.Errorf("unknown PTKP status %q", status)
+	}
+	return nil
+}
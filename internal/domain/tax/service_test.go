@@ -0,0 +1,169 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func idr(v int64) money.Money { return money.FromMajor(v, money.IDR) }
+
+func TestMonthlyWithholding(t *testing.T) {
+	svc := NewService()
+
+	tests := []struct {
+		name   string
+		status Status
+		gross  money.Money
+		want   money.Money
+	}{
+		{"A batas atas tarif 0%", StatusTK0, idr(5400000), idr(0)},
+		{"A satu rupiah di atas batas 0%", StatusTK0, idr(5400001), idr(13500)},
+		{"A TK/1 6,5 juta tarif 1%", StatusTK1, idr(6500000), idr(65000)},
+		{"A TK/0 10 juta tarif 2%", StatusTK0, idr(10000000), idr(200000)},
+		{"A K/0 20 juta tarif 9%", StatusK0, idr(20000000), idr(1800000)},
+		{"B K/1 10 juta tarif 1,5%", StatusK1, idr(10000000), idr(150000)},
+		{"B TK/2 6,2 juta tarif 0%", StatusTK2, idr(6200000), idr(0)},
+		{"C K/3 10 juta tarif 1,5%", StatusK3, idr(10000000), idr(150000)},
+		{"C K/3 di atas 1,419 miliar tarif 34%", StatusK3, idr(1500000000), idr(510000000)},
+		{"penghasilan nol", StatusTK0, idr(0), idr(0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.MonthlyWithholding(tt.status, tt.gross)
+			assert.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+		})
+	}
+}
+
+func TestPeriodWithholding(t *testing.T) {
+	svc := NewService()
+
+	tests := []struct {
+		name     string
+		gross    money.Money
+		num, den int64
+		want     money.Money
+	}{
+		// Sebulan 10jt: lapisan 2%, bukan 0% untuk 5jt.
+		{"Semi-bulanan 5 juta", idr(5000000), 1, 2, idr(100000)},
+		// Sebulan 2,4jt x 52/12 = 10,4jt: lapisan 2,5%.
+		{"Mingguan 2,4 juta", idr(2400000), 12, 52, idr(60000)},
+		{"Bulanan sama dengan MonthlyWithholding", idr(10000000), 1, 1, idr(200000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.PeriodWithholding(StatusTK0, tt.gross, tt.num, tt.den)
+			assert.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+		})
+	}
+}
+
+func TestAnnualTax(t *testing.T) {
+	svc := NewService()
+
+	tests := []struct {
+		name   string
+		status Status
+		in     AnnualIncome
+		want   money.Money
+	}{
+		{
+			// Bruto 120jt - biaya jabatan 6jt - PTKP 54jt = PKP 60jt x 5%
+			name:   "TK/0 gaji 10 juta sebulan",
+			status: StatusTK0,
+			in:     AnnualIncome{Gross: idr(120000000), Months: 12},
+			want:   idr(3000000),
+		},
+		{
+			// Bruto 120jt - 6jt - iuran pensiun 3,6jt - PTKP 63jt = PKP 47,4jt x 5%
+			name:   "K/1 dengan iuran JHT dan JP",
+			status: StatusK1,
+			in:     AnnualIncome{Gross: idr(120000000), PensionContributions: idr(3600000), Months: 12},
+			want:   idr(2370000),
+		},
+		{
+			// PKP 300jt: 60jt x 5% + 190jt x 15% + 50jt x 25%
+			name:   "TK/0 melewati tiga lapisan tarif",
+			status: StatusTK0,
+			in:     AnnualIncome{Gross: idr(360000000), Months: 12},
+			want:   idr(44000000),
+		},
+		{
+			// Biaya jabatan dibatasi 6 x 500rb untuk 6 bulan bekerja.
+			name:   "TK/0 bekerja enam bulan",
+			status: StatusTK0,
+			in:     AnnualIncome{Gross: idr(120000000), Months: 6},
+			want:   idr(3450000),
+		},
+		{
+			// Neto 54.001.999 - PTKP 54jt = 1.999, dibulatkan menjadi 1.000.
+			name:   "PKP dibulatkan ke bawah ke ribuan",
+			status: StatusTK0,
+			in:     AnnualIncome{Gross: idr(56844209), Months: 12},
+			want:   idr(50),
+		},
+		{
+			name:   "penghasilan di bawah PTKP",
+			status: StatusK3,
+			in:     AnnualIncome{Gross: idr(60000000), Months: 12},
+			want:   idr(0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.AnnualTax(tt.status, tt.in)
+			assert.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+		})
+	}
+}
+
+func TestDecemberWithholding(t *testing.T) {
+	svc := NewService()
+
+	t.Run("Kurang potong dilunasi di Desember", func(t *testing.T) {
+		// Jan-Nov: 11 x 200rb (TER A 2%); setahun terutang 3jt.
+		got, err := svc.DecemberWithholding(StatusTK0, AnnualIncome{
+			Gross:          idr(120000000),
+			WithheldToDate: idr(2200000),
+			Months:         12,
+		})
+		assert.NoError(t, err)
+		assert.True(t, idr(800000).Equal(got), "got %s", got)
+	})
+
+	t.Run("Lebih potong dikembalikan", func(t *testing.T) {
+		got, err := svc.DecemberWithholding(StatusTK0, AnnualIncome{
+			Gross:          idr(120000000),
+			WithheldToDate: idr(3500000),
+			Months:         12,
+		})
+		assert.NoError(t, err)
+		assert.True(t, idr(-500000).Equal(got), "got %s", got)
+	})
+
+	t.Run("Status PTKP tidak dikenal", func(t *testing.T) {
+		_, err := svc.DecemberWithholding(Status("X/9"), AnnualIncome{Gross: idr(1)})
+		assert.Error(t, err)
+	})
+}
+
+func TestParseStatus(t *testing.T) {
+	st, err := ParseStatus("")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusTK0, st)
+
+	st, err = ParseStatus("k/2")
+	assert.NoError(t, err)
+	assert.Equal(t, CategoryB, st.Category())
+
+	_, err = ParseStatus("K/4")
+	assert.Error(t, err)
+}
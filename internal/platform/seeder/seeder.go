@@ -7,10 +7,25 @@ import (
 
 	"github.com/brianvoe/gofakeit/v6"
 	"github.com/dzakaeryan20/dealls-hris/internal/domain/employee"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/reimbursement"
+	"github.com/dzakaeryan20/dealls-hris/internal/domain/tax"
+	"github.com/dzakaeryan20/dealls-hris/pkg/money"
 	"gorm.io/gorm"
 )
 
 func Run(db *gorm.DB) error {
+	// Approver dan manager ditambahkan terpisah agar database yang sudah
+	// di-seed sebelumnya juga mendapatkannya.
+	if err := seedApprover(db); err != nil {
+		return err
+	}
+	if err := seedManager(db); err != nil {
+		return err
+	}
+	if err := seedReimbursementCategories(db); err != nil {
+		return err
+	}
+
 	// Check if admin user already exists
 	var count int64
 	db.Model(&employee.Employee{}).Where("username = ?", "admin").Count(&count)
@@ -23,7 +38,7 @@ func Run(db *gorm.DB) error {
 	employeeRepo := employee.NewRepository(db)
 
 	// Create Admin
-	admin, err := employee.NewUser("admin", "password123", "admin", 0)
+	admin, err := employee.NewUser("admin", "password123", "admin", money.Zero(money.IDR))
 	if err != nil {
 		return fmt.Errorf("failed to create admin user model: %w", err)
 	}
@@ -35,13 +50,14 @@ func Run(db *gorm.DB) error {
 	// Create Employees
 	for i := 1; i <= 100; i++ {
 		username := fmt.Sprintf("employee%d", i)
-		salary := gofakeit.Float64Range(4000000, 10000000) // Gaji bulanan dalam Rupiah
+		salary := money.FromMajor(int64(gofakeit.Number(4000, 10000))*1000, money.IDR) // Gaji bulanan dalam Rupiah
 
 		employee, err := employee.NewUser(username, "password123", "employee", salary)
 		if err != nil {
 			log.Printf("failed to create model for %s: %v", username, err)
 			continue
 		}
+		employee.TaxStatus = string(seedTaxStatuses[i%len(seedTaxStatuses)])
 		if err := employeeRepo.Create(ctx, employee); err != nil {
 			log.Printf("failed to save employee %s: %v", username, err)
 			continue
@@ -49,5 +65,100 @@ func Run(db *gorm.DB) error {
 	}
 	log.Println("100 employee users created.")
 
+	// Manager dibuat sebelum karyawan ada; tetapkan bawahannya sekarang.
+	return assignManagerReports(db)
+}
+
+// seedTaxStatuses adalah status PTKP karyawan seed, bergiliran agar ketiga
+// kategori TER terwakili.
+var seedTaxStatuses = []tax.Status{tax.StatusTK0, tax.StatusK0, tax.StatusK1, tax.StatusTK1, tax.StatusK2, tax.StatusK3}
+
+// seedApprover membuat user approver yang menyetujui hasil payroll.
+func seedApprover(db *gorm.DB) error {
+	var count int64
+	db.Model(&employee.Employee{}).Where("username = ?", "approver").Count(&count)
+	if count > 0 {
+		return nil
+	}
+
+	approver, err := employee.NewUser("approver", "password123", "approver", money.Zero(money.IDR))
+	if err != nil {
+		return fmt.Errorf("failed to create approver user model: %w", err)
+	}
+	if err := employee.NewRepository(db).Create(context.Background(), approver); err != nil {
+		return fmt.Errorf("failed to save approver user: %w", err)
+	}
+	log.Println("Approver user created.")
+	return nil
+}
+
+// seedManager membuat user manager yang menyetujui lembur bawahannya, yaitu
+// employee1 s.d. employee10.
+func seedManager(db *gorm.DB) error {
+	var count int64
+	db.Model(&employee.Employee{}).Where("username = ?", "manager").Count(&count)
+	if count > 0 {
+		return nil
+	}
+
+	manager, err := employee.NewUser("manager", "password123", "manager", money.Zero(money.IDR))
+	if err != nil {
+		return fmt.Errorf("failed to create manager user model: %w", err)
+	}
+	if err := employee.NewRepository(db).Create(context.Background(), manager); err != nil {
+		return fmt.Errorf("failed to save manager user: %w", err)
+	}
+	log.Println("Manager user created.")
+	return assignManagerReports(db)
+}
+
+// assignManagerReports menjadikan employee1 s.d. employee10 yang belum
+// memiliki atasan sebagai bawahan user manager.
+func assignManagerReports(db *gorm.DB) error {
+	var manager employee.Employee
+	if err := db.Where("username = ?", "manager").First(&manager).Error; err != nil {
+		return fmt.Errorf("failed to find manager user: %w", err)
+	}
+	usernames := make([]string, 0, 10)
+	for i := 1; i <= 10; i++ {
+		usernames = append(usernames, fmt.Sprintf("employee%d", i))
+	}
+	err := db.Model(&employee.Employee{}).
+		Where("username IN ? AND (manager_id = '' OR manager_id IS NULL)", usernames).
+		Update("manager_id", manager.ID).Error
+	if err != nil {
+		return fmt.Errorf("failed to assign manager reports: %w", err)
+	}
+	return nil
+}
+
+// seedReimbursementCategories membuat kategori reimbursement bawaan beserta
+// batas default-nya (berlaku untuk semua golongan). Kategori yang kodenya
+// sudah ada tidak diubah.
+func seedReimbursementCategories(db *gorm.DB) error {
+	idr := func(v int64) *money.Money {
+		m := money.FromMajor(v, money.IDR)
+		return &m
+	}
+	categories := []reimbursement.Category{
+		{Code: "MEDICAL", Name: "Medical", Limits: []reimbursement.CategoryLimit{{PerClaim: idr(2000000), PerYear: idr(10000000)}}},
+		{Code: "TRAVEL", Name: "Travel", Limits: []reimbursement.CategoryLimit{{PerClaim: idr(1500000), PerMonth: idr(3000000)}}},
+		{Code: "INTERNET", Name: "Internet", Limits: []reimbursement.CategoryLimit{{PerMonth: idr(300000)}}},
+		{Code: "GLASSES", Name: "Glasses", Limits: []reimbursement.CategoryLimit{{PerYear: idr(1000000)}}},
+	}
+
+	repo := reimbursement.NewRepository(db)
+	for i := range categories {
+		var count int64
+		db.Model(&reimbursement.Category{}).Where("code = ?", categories[i].Code).Count(&count)
+		if count > 0 {
+			continue
+		}
+		categories[i].Active = true
+		if err := repo.CreateCategory(context.Background(), &categories[i]); err != nil {
+			return fmt.Errorf("failed to save reimbursement category %s: %w", categories[i].Code, err)
+		}
+		log.Printf("Reimbursement category %s created.", categories[i].Code)
+	}
 	return nil
 }
@@ -0,0 +1,22 @@
+// Package storage menyediakan penyimpanan berkas (mis. bukti reimbursement)
+// di filesystem lokal atau object storage yang kompatibel dengan S3.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound dikembalikan jika objek dengan kunci tersebut tidak ada.
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage menyimpan berkas berdasarkan kunci berbentuk path relatif, mis.
+// "reimbursements/<id>/<berkas>.pdf".
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Open mengembalikan isi objek; pemanggil wajib menutupnya.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete tidak mengembalikan error jika objek sudah tidak ada.
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,303 @@
+// Package money menyediakan tipe nilai uang fixed-point (satuan minor dalam
+// int64 beserta mata uangnya) agar perhitungan payroll tidak mengalami drift
+// pembulatan seperti pada float64.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Currency adalah kode mata uang ISO 4217.
+type Currency string
+
+// IDR adalah Rupiah, mata uang default aplikasi.
+const IDR Currency = "IDR"
+
+// DefaultCurrency dipakai untuk nilai yang dibaca dari database, karena kolom
+// NUMERIC hanya menyimpan angka tanpa kode mata uang.
+const DefaultCurrency = IDR
+
+// exponents adalah jumlah digit satuan minor per mata uang (IDR: sen).
+var exponents = map[Currency]int{
+	IDR: 2,
+}
+
+// Exponent mengembalikan jumlah digit desimal satuan minor mata uang.
+func (c Currency) Exponent() int {
+	if e, ok := exponents[c]; ok {
+		return e
+	}
+	return 2
+}
+
+func (c Currency) orDefault() Currency {
+	if c == "" {
+		return DefaultCurrency
+	}
+	return c
+}
+
+// Money adalah jumlah uang dalam satuan minor (mis. sen untuk IDR).
+// Zero value adalah nol dalam DefaultCurrency.
+type Money struct {
+	minor    int64
+	currency Currency
+}
+
+// New membuat Money dari jumlah satuan minor.
+func New(minor int64, c Currency) Money {
+	return Money{minor: minor, currency: c.orDefault()}
+}
+
+// FromMajor membuat Money dari jumlah satuan mayor (mis. Rupiah utuh).
+func FromMajor(major int64, c Currency) Money {
+	c = c.orDefault()
+	return Money{minor: major * pow10(c.Exponent()), currency: c}
+}
+
+// Zero mengembalikan nilai nol untuk mata uang c.
+func Zero(c Currency) Money {
+	return Money{currency: c.orDefault()}
+}
+
+// Parse membaca representasi desimal seperti "150000" atau "-1250.50".
+// Digit pecahan melebihi exponent mata uang akan ditolak, bukan dibulatkan.
+func Parse(s string, c Currency) (Money, error) {
+	c = c.orDefault()
+	exp := c.Exponent()
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Money{}, errors.New("money: empty amount")
+	}
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return Money{}, fmt.Errorf("money: invalid amount %q", s)
+	}
+	if len(fracPart) > exp {
+		return Money{}, fmt.Errorf("money: amount %q has more than %d decimal places", s, exp)
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+	digits := intPart + fracPart + strings.Repeat("0", exp-len(fracPart))
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Money{}, fmt.Errorf("money: invalid amount %q", s)
+		}
+	}
+
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("money: amount %q out of range", s)
+	}
+	if neg {
+		minor = -minor
+	}
+	return Money{minor: minor, currency: c}, nil
+}
+
+// MustParse seperti Parse tetapi panic jika input tidak valid.
+// Ditujukan untuk konstanta dan tes.
+func MustParse(s string, c Currency) Money {
+	m, err := Parse(s, c)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Minor mengembalikan jumlah dalam satuan minor.
+func (m Money) Minor() int64 { return m.minor }
+
+// Currency mengembalikan mata uang dari nilai ini.
+func (m Money) Currency() Currency { return m.currency.orDefault() }
+
+func (m Money) IsZero() bool     { return m.minor == 0 }
+func (m Money) IsPositive() bool { return m.minor > 0 }
+func (m Money) IsNegative() bool { return m.minor < 0 }
+
+// Add menjumlahkan dua nilai dengan mata uang yang sama.
+func (m Money) Add(o Money) Money {
+	c := m.mustMatch(o)
+	return Money{minor: m.minor + o.minor, currency: c}
+}
+
+// Sub mengurangkan o dari m.
+func (m Money) Sub(o Money) Money {
+	c := m.mustMatch(o)
+	return Money{minor: m.minor - o.minor, currency: c}
+}
+
+// Neg mengembalikan nilai negatif dari m.
+func (m Money) Neg() Money {
+	return Money{minor: -m.minor, currency: m.Currency()}
+}
+
+// Mul mengalikan m dengan bilangan bulat n.
+func (m Money) Mul(n int64) Money {
+	return Money{minor: m.minor * n, currency: m.Currency()}
+}
+
+// MulDiv menghitung m × num / den secara eksak lalu membulatkan hasilnya
+// sekali saja sesuai aturan r. Ini adalah satu-satunya titik pembulatan untuk
+// operasi perkalian/pembagian, sehingga hasil dapat direkonsiliasi.
+func (m Money) MulDiv(num, den int64, r Rounding) Money {
+	if den == 0 {
+		panic("money: division by zero")
+	}
+	n := new(big.Int).Mul(big.NewInt(m.minor), big.NewInt(num))
+	return Money{minor: r.quantize(n, big.NewInt(den), m.Currency()), currency: m.Currency()}
+}
+
+// Round membulatkan m ke skala yang ditentukan r.
+func (m Money) Round(r Rounding) Money {
+	return Money{minor: r.quantize(big.NewInt(m.minor), big.NewInt(1), m.Currency()), currency: m.Currency()}
+}
+
+// Cmp mengembalikan -1, 0, atau +1 jika m lebih kecil, sama, atau lebih besar dari o.
+func (m Money) Cmp(o Money) int {
+	m.mustMatch(o)
+	switch {
+	case m.minor < o.minor:
+		return -1
+	case m.minor > o.minor:
+		return 1
+	}
+	return 0
+}
+
+// Equal melaporkan apakah m dan o memiliki jumlah dan mata uang yang sama.
+func (m Money) Equal(o Money) bool {
+	return m.minor == o.minor && m.Currency() == o.Currency()
+}
+
+// Sum menjumlahkan seluruh nilai; hasil kosong adalah nol DefaultCurrency.
+func Sum(ms ...Money) Money {
+	var total Money
+	for i, m := range ms {
+		if i == 0 {
+			total = m
+			continue
+		}
+		total = total.Add(m)
+	}
+	return Money{minor: total.minor, currency: total.Currency()}
+}
+
+// String mengembalikan representasi desimal lengkap, mis. "4550000.00".
+func (m Money) String() string {
+	exp := m.Currency().Exponent()
+	unit := pow10(exp)
+	sign := ""
+	minor := m.minor
+	if minor < 0 {
+		sign = "-"
+	}
+	abs := new(big.Int).Abs(big.NewInt(minor))
+	q, r := new(big.Int).QuoRem(abs, big.NewInt(unit), new(big.Int))
+	if exp == 0 {
+		return sign + q.String()
+	}
+	return fmt.Sprintf("%s%s.%0*d", sign, q.String(), exp, r.Int64())
+}
+
+// decimal mengembalikan representasi desimal tanpa nol di belakang koma,
+// mis. "4550000" atau "1250.5".
+func (m Money) decimal() string {
+	s := m.String()
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(s, "0")
+		s = strings.TrimSuffix(s, ".")
+	}
+	return s
+}
+
+// MarshalJSON menulis nilai sebagai angka JSON eksak (tanpa melalui float).
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.decimal()), nil
+}
+
+// UnmarshalJSON menerima angka JSON maupun string desimal.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		*m = Money{}
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	parsed, err := Parse(s, DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value menyimpan nilai sebagai NUMERIC desimal.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan membaca kolom NUMERIC ke dalam Money dengan DefaultCurrency.
+func (m *Money) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*m = Money{}
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		*m = FromMajor(v, DefaultCurrency)
+		return nil
+	case float64:
+		s = strconv.FormatFloat(v, 'f', DefaultCurrency.Exponent(), 64)
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+	parsed, err := Parse(s, DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// GormDataType menentukan tipe kolom database untuk Money.
+func (Money) GormDataType() string {
+	return "numeric(20,2)"
+}
+
+func (m Money) mustMatch(o Money) Currency {
+	if m.Currency() != o.Currency() {
+		panic(fmt.Sprintf("money: currency mismatch %s vs %s", m.Currency(), o.Currency()))
+	}
+	return m.Currency()
+}
+
+func pow10(n int) int64 {
+	v := int64(1)
+	for i := 0; i < n; i++ {
+		v *= 10
+	}
+	return v
+}
@@ -0,0 +1,95 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		minor   int64
+		wantErr bool
+	}{
+		{"150000", 15000000, false},
+		{"1250.5", 125050, false},
+		{"-1250.50", -125050, false},
+		{".75", 75, false},
+		{"1.234", 0, true},
+		{"12a", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			m, err := Parse(tt.in, IDR)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.minor, m.Minor())
+		})
+	}
+}
+
+func TestMulDiv(t *testing.T) {
+	base := FromMajor(5000000, IDR)
+
+	tests := []struct {
+		name     string
+		num, den int64
+		rounding Rounding
+		want     string
+	}{
+		{"exact", 4, 5, Rounding{RoundHalfUp, 0}, "4000000.00"},
+		{"half up to rupiah", 1, 3, Rounding{RoundHalfUp, 0}, "1666667.00"},
+		{"down to rupiah", 1, 3, Rounding{RoundDown, 0}, "1666666.00"},
+		{"half up to sen", 1, 3, Rounding{RoundHalfUp, 2}, "1666666.67"},
+		{"down to thousands", 1, 3, Rounding{RoundDown, -3}, "1666000.00"},
+		{"up to thousands", 1, 3, Rounding{RoundUp, -3}, "1667000.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, base.MulDiv(tt.num, tt.den, tt.rounding).String())
+		})
+	}
+}
+
+func TestRoundHalfEven(t *testing.T) {
+	assert.Equal(t, "2.00", MustParse("2.50", IDR).Round(Rounding{RoundHalfEven, 0}).String())
+	assert.Equal(t, "4.00", MustParse("3.50", IDR).Round(Rounding{RoundHalfEven, 0}).String())
+	assert.Equal(t, "-3.00", MustParse("-2.50", IDR).Round(Rounding{RoundHalfUp, 0}).String())
+}
+
+func TestSumReconciles(t *testing.T) {
+	// Tiga bagian dari 100.000 yang dibulatkan per bagian harus tetap
+	// dijumlahkan secara eksak, tanpa drift float.
+	total := FromMajor(100000, IDR)
+	part := total.MulDiv(1, 3, DefaultRounding)
+	assert.Equal(t, "99999.00", Sum(part, part, part).String())
+}
+
+func TestJSON(t *testing.T) {
+	var payload struct {
+		Amount Money `json:"amount"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": 150000.25}`), &payload))
+	assert.Equal(t, int64(15000025), payload.Amount.Minor())
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": "75000"}`), &payload))
+	assert.Equal(t, int64(7500000), payload.Amount.Minor())
+
+	out, err := json.Marshal(payload)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount": 75000}`, string(out))
+}
+
+func TestScan(t *testing.T) {
+	var m Money
+	assert.NoError(t, m.Scan([]byte("4550000.00")))
+	assert.True(t, m.Equal(FromMajor(4550000, IDR)))
+}
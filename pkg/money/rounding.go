@@ -0,0 +1,80 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// RoundingMode menentukan arah pembulatan saat hasil tidak tepat pada skala.
+type RoundingMode string
+
+const (
+	// RoundHalfUp membulatkan .5 menjauhi nol (pembulatan "biasa").
+	RoundHalfUp RoundingMode = "half_up"
+	// RoundHalfEven membulatkan .5 ke angka genap terdekat (banker's rounding).
+	RoundHalfEven RoundingMode = "half_even"
+	// RoundDown memotong ke arah nol.
+	RoundDown RoundingMode = "down"
+	// RoundUp membulatkan menjauhi nol.
+	RoundUp RoundingMode = "up"
+)
+
+// ParseRoundingMode memvalidasi nama mode pembulatan dari konfigurasi.
+func ParseRoundingMode(s string) (RoundingMode, error) {
+	switch m := RoundingMode(s); m {
+	case RoundHalfUp, RoundHalfEven, RoundDown, RoundUp:
+		return m, nil
+	}
+	return "", fmt.Errorf("money: unknown rounding mode %q", s)
+}
+
+// Rounding adalah aturan pembulatan: mode beserta jumlah digit desimal yang
+// dipertahankan. Scale 0 berarti Rupiah utuh, Scale 2 berarti sen, dan Scale
+// negatif membulatkan ke puluhan/ratusan/ribuan (mis. -3 untuk ribuan).
+type Rounding struct {
+	Mode  RoundingMode
+	Scale int
+}
+
+// DefaultRounding membulatkan half-up ke Rupiah utuh.
+var DefaultRounding = Rounding{Mode: RoundHalfUp, Scale: 0}
+
+// quantize menghitung num/den (dalam satuan minor) lalu membulatkannya ke
+// kelipatan unit skala sesuai mode. Hasilnya dalam satuan minor.
+func (r Rounding) quantize(num, den *big.Int, c Currency) int64 {
+	scale := r.Scale
+	if exp := c.Exponent(); scale > exp {
+		scale = exp
+	}
+	unit := big.NewInt(pow10(c.Exponent() - scale))
+
+	d := new(big.Int).Mul(den, unit)
+	q, rem := new(big.Int).QuoRem(num, d, new(big.Int))
+	if rem.Sign() != 0 {
+		sign := int64(num.Sign() * d.Sign())
+		twice := new(big.Int).Abs(rem)
+		twice.Lsh(twice, 1)
+		half := twice.Cmp(new(big.Int).Abs(d))
+
+		bump := false
+		switch r.Mode {
+		case RoundUp:
+			bump = true
+		case RoundDown:
+			bump = false
+		case RoundHalfEven:
+			bump = half > 0 || (half == 0 && q.Bit(0) == 1)
+		default: // RoundHalfUp
+			bump = half >= 0
+		}
+		if bump {
+			q.Add(q, big.NewInt(sign))
+		}
+	}
+
+	q.Mul(q, unit)
+	if !q.IsInt64() {
+		panic("money: overflow")
+	}
+	return q.Int64()
+}